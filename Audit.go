@@ -0,0 +1,86 @@
+package GoPlug
+
+import (
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader"
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/audit"
+)
+
+// SetAudit - Record plugin loads, unloads and hook calls to a hash-chained audit log, (see audit.Config).
+func (m *PluginManager) SetAudit(config audit.Config) Return.Error {
+	for range Only.Once {
+		var l *audit.Log
+		l, m.Error = audit.Open(config)
+		if m.Error.IsError() {
+			break
+		}
+
+		m.stopAudit()
+		audit.SetLog(l)
+		m.auditLog = l
+		m.Audit = l.Config
+	}
+
+	return m.Error
+}
+
+// stopAudit - Stop recording to the audit log from SetAudit(), if set.
+func (m *PluginManager) stopAudit() {
+	if m.auditLog == nil {
+		return
+	}
+
+	if audit.GetLog() == m.auditLog {
+		audit.SetLog(nil)
+	}
+	_ = m.auditLog.Close()
+	m.auditLog = nil
+}
+
+// auditLoad - Record a plugin load, with the SHA-256 of its file, to the audit log.
+func (m *PluginManager) auditLoad(pluginPath utils.FilePath, item *GoPlugLoader.PluginItem, err Return.Error) {
+	if !audit.IsEnabled() {
+		return
+	}
+
+	entry := audit.Entry{
+		Event: audit.EventLoad,
+		File:  pluginPath.GetPath(),
+	}
+	entry.Sha256, _ = pluginPath.Sha256()
+
+	if item != nil && item.Pluggable != nil {
+		identity := item.Pluggable.GetIdentity()
+		entry.Plugin = identity.Name
+		entry.Version = identity.Version
+		entry.Loader = GoPlugLoader.NativeLoaderName
+		if item.IsRpcPlugin() {
+			entry.Loader = GoPlugLoader.RpcLoaderName
+		}
+	}
+
+	if err.IsError() {
+		entry.Error = utils.Redact(err.Error())
+	}
+	audit.Record(entry)
+}
+
+// auditUnload - Record a plugin unload to the audit log.
+func (m *PluginManager) auditUnload(pluginPath utils.FilePath, name string, err Return.Error) {
+	if !audit.IsEnabled() {
+		return
+	}
+
+	entry := audit.Entry{
+		Event:  audit.EventUnload,
+		Plugin: name,
+		File:   pluginPath.GetPath(),
+	}
+	if err.IsError() {
+		entry.Error = utils.Redact(err.Error())
+	}
+	audit.Record(entry)
+}
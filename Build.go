@@ -1,15 +1,25 @@
 package GoPlug
 
 import (
-	"io"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/MickMake/GoUnify/Only"
 
 	"github.com/MickMake/GoPlug/GoPlugLoader"
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
 	"github.com/MickMake/GoPlug/utils"
 	"github.com/MickMake/GoPlug/utils/Return"
 )
@@ -17,293 +27,549 @@ import (
 // BuildPlugins implements the interface method
 // ---------------------------------------------------------------------------------------------------- //
 func (m *PluginManager) BuildPlugins() Return.Error {
+	return m.BuildPluginsContext(context.Background())
+}
+
+// BuildPluginsContext implements the interface method
+// ---------------------------------------------------------------------------------------------------- //
+func (m *PluginManager) BuildPluginsContext(ctx context.Context) Return.Error {
 	for range Only.Once {
-		loader := m.Loaders.GetLoader(GoPlugLoader.NativeLoaderName)
-		if loader == nil {
-			break
-		}
+		m.BuildReport = NewBuildReport()
+		//goland:noinspection GoDeferInLoop
+		defer m.BuildReport.Finish()
 
-		// scan plugin base dir
-		m.Error = loader.PluginScanByExtension(GoPlugLoader.NativePluginExtensions...)
+		var targets []*BuildTarget
+		targets, m.Error = m.buildTargets()
 		if m.Error.IsError() {
 			break
 		}
+		workers := m.BuildOptions.GetWorkers()
+		log.Printf("[INFO]: Found %d possible plugin sources, building with %d workers", len(targets), workers)
 
-		paths := loader.GetFiles()
-		log.Printf("[INFO]: Found %d possible plugins", paths.Length())
-
-		if !paths.AnyPaths() {
-			// No plugin files found
-			break
+		// Builds run in parallel, results are kept in target order.
+		results := make([][]*BuildResult, len(targets))
+		sem := make(chan struct{}, workers)
+		var wg sync.WaitGroup
+		for index, target := range targets {
+			wg.Add(1)
+			go func(index int, target *BuildTarget) {
+				defer wg.Done()
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+				}
+				results[index] = m.buildTarget(ctx, target)
+			}(index, target)
 		}
+		wg.Wait()
 
-		for _, pPaths := range paths {
-			pPaths.Dir.ShortenPaths()
-			pPaths.Dir.SetAltPath(loader.GetDir(), "[PluginDir]")
-
-			log.Printf("[INFO]: Plugin(%s): Analyzing plugin files", pPaths.Dir.String())
-			fSo := utils.FilePath{}
-			fGo := utils.FilePath{}
-
-			for _, pName := range pPaths.Get() {
-				if pName.HasExtension("go") {
-					fGo = pName
-					continue
-				}
-				if pName.HasExtension("so") {
-					fSo = pName
-					continue
+		// Loaders are not safe for concurrent use, so verify sequentially.
+		for _, r := range results {
+			for _, result := range r {
+				if result.Status == BuildStatusBuilt {
+					result.Error = m.verifyPlugin(result.Type, result.Output)
 				}
+				m.BuildReport.Add(result)
 			}
-			fGo.ShortenPaths()
-			fGo.SetAltPath(loader.GetDir(), "[PluginDir]")
-			fSo.ShortenPaths()
-			fSo.SetAltPath(loader.GetDir(), "[PluginDir]")
-
-			if fGo.GetMod().IsZero() {
-				// We don't have a *.go file, so nothing to build.
-				log.Printf("[INFO]: Plugin(%s): No GoLang src code found for '%s'", pPaths.Dir.String(), fSo.GetBase())
-				continue
-			}
+		}
 
-			if fSo.GetMod().IsZero() {
-				// If *.go file is more recent than the *.so file.
-				log.Printf("[INFO]: Plugin(%s): Plugin file '%s' needs building - *.so file missing", pPaths.Dir.String(), fGo.GetBase())
-				m.Error = m.buildPlugin(fGo)
-				if m.Error.IsError() {
-					continue
-				}
+		// Pick up any new files.
+		m.Error = m.Scan()
+		if m.Error.IsError() {
+			break
+		}
 
-				fSo = fGo.ChangeExtension("so")
-				m.Error = m.LoadPlugin(fSo)
-				if m.Error.IsError() {
-					continue
-				}
+		if m.BuildReport.IsError() {
+			m.Error.SetError("%d/%d plugin builds failed",
+				m.BuildReport.Count(BuildStatusFailed), len(m.BuildReport.Results))
+			break
+		}
+	}
 
-				m.Error = m.UnloadPlugin(fSo)
-				if m.Error.IsError() {
-					continue
-				}
+	return m.Error
+}
 
-				continue
-			}
+// SetBuildOptions - Set the number of parallel builds and the per build timeout.
+func (m *PluginManager) SetBuildOptions(options BuildOptions) Return.Error {
+	for range Only.Once {
+		if options.Workers < 0 {
+			m.Error.SetError("invalid number of build workers: %d", options.Workers)
+			break
+		}
+		if options.Timeout < 0 {
+			m.Error.SetError("invalid build timeout: %s", options.Timeout)
+			break
+		}
+		m.BuildOptions = options
+	}
 
-			if fGo.GetMod().After(fSo.GetMod()) {
-				// If *.go file is more recent than the *.so file.
-				log.Printf("[INFO]: Plugin(%s): Plugin needs re-building - %s (%s) is newer than %s (%s)",
-					pPaths.Dir.String(),
-					fGo.GetBase(),
-					fGo.GetMod().Format("2006-01-02 15:04:05"),
-					fSo.GetBase(),
-					fSo.GetMod().Format("2006-01-02 15:04:05"),
-				)
-				m.Error = m.buildPlugin(fGo)
-				if m.Error.IsError() {
-					continue
-				}
+	return m.Error
+}
 
-				m.Error = m.LoadPlugin(fSo)
-				if m.Error.IsError() {
-					continue
-				}
+// GetBuildReport - Return the report of the last BuildPlugins() run.
+func (m *PluginManager) GetBuildReport() *BuildReport {
+	return m.BuildReport
+}
 
-				m.Error = m.UnloadPlugin(fSo)
-				if m.Error.IsError() {
-					continue
-				}
+// BuildPlugin implements the interface method
+// ---------------------------------------------------------------------------------------------------- //
+func (m *PluginManager) BuildPlugin(pluginPath utils.FilePath) Return.Error {
+	for range Only.Once {
+		var target *BuildTarget
+		target, m.Error = m.NewBuildTarget(pluginPath.GetDir())
+		if m.Error.IsError() {
+			break
+		}
 
-				continue
+		for _, result := range m.buildTarget(context.Background(), target) {
+			if result.Status == BuildStatusBuilt {
+				result.Error = m.verifyPlugin(result.Type, result.Output)
+			}
+			if result.Error.IsError() {
+				m.Error = result.Error
 			}
-
-			log.Printf("[INFO]: Plugin(%s): Plugin file '%s' is OK", pPaths.Dir.String(), fGo.GetBase())
 		}
-
-		log.Printf("[INFO]: %d plugins loaded", m.Loaders.StoreSize())
 	}
 
 	return m.Error
 }
 
-// BuildPlugin implements the interface method
+// RebuildPlugin - Force a rebuild of the native plugin from the plugin source dir, ignoring the build cache.
+// Used to rebuild native plugins that are incompatible with master. The plugin is not loaded.
 // ---------------------------------------------------------------------------------------------------- //
-func (m *PluginManager) BuildPlugin(pluginPath utils.FilePath) Return.Error {
+func (m *PluginManager) RebuildPlugin(pluginPath utils.FilePath) Return.Error {
+	var err Return.Error
+
 	for range Only.Once {
-		loader := m.Loaders.GetLoader(GoPlugLoader.NativeLoaderName)
-		if loader == nil {
+		var target *BuildTarget
+		target, err = m.NewBuildTarget(pluginPath.GetDir())
+		if err.IsError() {
 			break
 		}
+		target.Types = Plugin.NativePluginType
+		target.Force = true
+
+		// Build with the settings master was built with, or the rebuilt plugin won't match either.
+		gcflags := GoPlugLoader.GetHostSetting("-gcflags")
+		target.Settings.GcFlags = &gcflags
+		target.Settings.TrimPath = GoPlugLoader.GetHostSetting("-trimpath") == "true"
+		target.Settings.Tags = nil
+		if tags := GoPlugLoader.GetHostSetting("-tags"); tags != "" {
+			target.Settings.Tags = strings.Split(tags, ",")
+		}
 
-		m.Error = m.buildPlugin(pluginPath)
-		if m.Error.IsError() {
-			break
+		for _, result := range m.buildTarget(context.Background(), target) {
+			if result.Error.IsError() {
+				err = result.Error
+			}
 		}
 	}
 
-	return m.Error
+	return err
 }
 
 //
-// buildPlugin
+// BuildOptions - How BuildPlugins() runs the builds.
 // ---------------------------------------------------------------------------------------------------- //
-func (m *PluginManager) buildPlugin(goFile utils.FilePath) Return.Error {
+type BuildOptions struct {
+	Workers int           `json:"workers"` // Maximum number of parallel builds, (0 = runtime.NumCPU()).
+	Timeout time.Duration `json:"timeout"` // Kill a single build after this long, (0 = no timeout).
+}
+
+// GetWorkers - Return the number of parallel builds to run.
+func (o BuildOptions) GetWorkers() int {
+	if o.Workers <= 0 {
+		return runtime.NumCPU()
+	}
+	return o.Workers
+}
+
+//
+// BuildTarget - A plugin source dir, with its build settings.
+// ---------------------------------------------------------------------------------------------------- //
+type BuildTarget struct {
+	Name     string
+	Dir      string
+	ModDir   string
+	Types    Plugin.Types
+	Settings Plugin.BuildSettings
+	Force    bool   // Ignore the build cache.
+	OutDir   string // Dir the built files are written to, (defaults to Dir).
+}
+
+// NewBuildTarget - Create a BuildTarget from a plugin source dir, using its manifest if present.
+func (m *PluginManager) NewBuildTarget(dir string) (*BuildTarget, Return.Error) {
+	var target *BuildTarget
+	var err Return.Error
+
 	for range Only.Once {
-		loader := m.Loaders.GetLoader(GoPlugLoader.NativeLoaderName)
-		if loader == nil {
+		dir, _ = filepath.Abs(dir)
+		var manifest *Plugin.Manifest
+		manifest, err = Plugin.LoadManifest(dir)
+		if err.IsError() {
 			break
 		}
 
-		m.Error = goFile.FileExists()
-		if m.Error.IsError() {
+		target = &BuildTarget{
+			Name:     filepath.Base(dir),
+			Dir:      dir,
+			ModDir:   dir,
+			Types:    m.Config.PluginTypes,
+			Settings: manifest.Build,
+		}
+
+		if target.Settings.Types != nil {
+			target.Types = *target.Settings.Types
+		}
+
+		if target.Settings.ModDir != "" {
+			target.ModDir = filepath.Clean(filepath.Join(dir, target.Settings.ModDir))
+		}
+
+		if !utils.IsFile(filepath.Join(target.ModDir, "go.mod")) {
+			err.SetError("no go.mod found in '%s'", target.ModDir)
 			break
 		}
+	}
 
-		goFile.ShortenPaths()
-		base := goFile.SetAltPath(loader.GetDir(), "[PluginDir]")
-		log.Printf("[INFO]: Plugin(%s): Building", base)
+	return target, err
+}
+
+// Output - Return the path of the built file for the given loader type.
+func (t *BuildTarget) Output(loaderType string) string {
+	name := t.Settings.Output
+	if name == "" {
+		name = t.Name
+	}
+	dir := t.Dir
+	if t.OutDir != "" {
+		dir = t.OutDir
+	}
+	name = filepath.Join(dir, name)
 
-		soFile := goFile.ChangeExtension("so")
+	switch {
+	case loaderType == GoPlugLoader.NativeLoaderName:
+		name += GoPlugLoader.NativePluginExtensions[0]
+	case runtime.GOOS == "windows":
+		name += ".exe"
+	}
 
-		options := ExecOptions{
-			Dir:     goFile.GetDir(),
-			Command: "go",
-			Args: []string{
-				"build",
-				"-buildmode=plugin",
-				"-o", soFile.GetBase(),
-				"-gcflags", "all=-N -l",
-				goFile.GetBase(),
-			},
+	return name
+}
+
+// Hash - Return a content hash of all build inputs for the given loader type.
+// Covers the Go source files, go.mod, go.sum, the build settings and the Go toolchain version.
+func (t *BuildTarget) Hash(loaderType string) (string, Return.Error) {
+	var ret string
+	var err Return.Error
+
+	for range Only.Once {
+		var files []string
+		e := filepath.WalkDir(t.Dir, func(path string, d fs.DirEntry, e error) error {
+			if e != nil {
+				return e
+			}
+			if d.IsDir() {
+				if path != t.Dir && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if e != nil {
+			err.SetError(e)
+			break
 		}
-		e := options.Exec()
-		m.Error.SetError(e)
-		if m.Error.IsError() {
-			log.Printf("[ERROR]: Plugin(%s): Build failed: %s", base, m.Error.String())
+
+		for _, name := range []string{"go.mod", "go.sum", "go.work", "go.work.sum"} {
+			name = filepath.Join(t.ModDir, name)
+			if utils.IsFile(name) {
+				files = append(files, name)
+			}
+		}
+		sort.Strings(files)
+
+		h := sha256.New()
+		settings, _ := json.Marshal(t.Settings)
+		_, _ = fmt.Fprintf(h, "%s\n%s\n%s\n", runtime.Version(), loaderType, settings)
+
+		for _, name := range files {
+			var file utils.FilePath
+			file, err = utils.NewFile(name)
+			if err.IsError() {
+				break
+			}
+
+			var sum string
+			sum, err = file.Sha256()
+			if err.IsError() {
+				break
+			}
+
+			rel, _ := filepath.Rel(t.Dir, name)
+			_, _ = fmt.Fprintf(h, "%s %s\n", sum, rel)
+		}
+		if err.IsError() {
 			break
 		}
 
-		log.Printf("[INFO]: Plugin(%s): Build OK", base)
+		ret = hex.EncodeToString(h.Sum(nil))
 	}
 
-	return m.Error
+	return ret, err
+}
+
+// BuildCacheFileName - Per plugin dir file that holds the content hash of the last successful builds.
+const BuildCacheFileName = ".buildcache.json"
+
+func (t *BuildTarget) readCache() map[string]string {
+	cache := make(map[string]string)
+	data, err := utils.ReadFile(filepath.Join(t.Dir, BuildCacheFileName))
+	if err.IsError() {
+		return cache
+	}
+	_ = json.Unmarshal(data, &cache)
+	return cache
+}
+
+func (t *BuildTarget) writeCache(cache map[string]string) Return.Error {
+	data, e := json.MarshalIndent(cache, "", "\t")
+	if e != nil {
+		return Return.NewError(e)
+	}
+	return utils.WriteFile(filepath.Join(t.Dir, BuildCacheFileName), data)
+}
+
+// Command - Return the build command for the given loader type.
+func (t *BuildTarget) Command(loaderType string) ExecOptions {
+	args := []string{"build"}
+	if loaderType == GoPlugLoader.NativeLoaderName {
+		args = append(args, "-buildmode=plugin")
+	}
+	args = append(args, "-o", t.Output(loaderType))
+	if gcflags := t.Settings.GetGcFlags(); gcflags != "" {
+		args = append(args, "-gcflags", gcflags)
+	}
+	if t.Settings.TrimPath {
+		args = append(args, "-trimpath")
+	}
+	if len(t.Settings.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(t.Settings.Tags, ","))
+	}
+	if t.Settings.LdFlags != "" {
+		args = append(args, "-ldflags", t.Settings.LdFlags)
+	}
+
+	pkg, _ := filepath.Rel(t.ModDir, t.Dir)
+	args = append(args, "./"+filepath.ToSlash(pkg))
+
+	env := append([]string{}, t.Settings.Env...)
+	switch {
+	case loaderType == GoPlugLoader.NativeLoaderName:
+		// -buildmode=plugin always requires cgo.
+		env = append(env, "CGO_ENABLED=1")
+	case t.Settings.Cgo != nil && *t.Settings.Cgo:
+		env = append(env, "CGO_ENABLED=1")
+	case t.Settings.Cgo != nil:
+		env = append(env, "CGO_ENABLED=0")
+	}
+
+	return ExecOptions{
+		Dir:     t.ModDir,
+		Command: "go",
+		Args:    args,
+		Env:     env,
+	}
 }
 
 //
-// CheckPlugin
+// buildTargets - Find all plugin source dirs within the plugin dir.
 // ---------------------------------------------------------------------------------------------------- //
-func (m *PluginManager) CheckPlugin(pluginPath utils.FilePath) (*GoPlugLoader.PluginItem, Return.Error) {
-	var plug *GoPlugLoader.PluginItem
+func (m *PluginManager) buildTargets() ([]*BuildTarget, Return.Error) {
+	var targets []*BuildTarget
+	var err Return.Error
 
 	for range Only.Once {
-		m.Error = pluginPath.FileExists()
-		if m.Error.IsError() {
+		base := m.Loaders.GetDir()
+		entries, e := os.ReadDir(base)
+		if e != nil {
+			err.SetError(e)
 			break
 		}
 
-		plug, m.Error = m.Loaders.StoreGet(pluginPath.GetBase())
-		if m.Error.IsError() {
-			// bm.Error.SetError("plugin with name '%s' is not existing", name.GetBase())
+		// Matched as the loaders match plugin files, (see filepath.Match).
+		if _, e = filepath.Match(m.FileGlob, ""); e != nil {
+			err.SetError("invalid plugin glob '%s': %s", m.FileGlob, e)
 			break
 		}
 
-		log.Printf("[INFO]: Plugin '%s' loaded with version '%s'\n", plug.Pluggable.GetName(), plug.Pluggable.GetVersion())
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			if ok, _ := filepath.Match(m.FileGlob, entry.Name()); !ok {
+				continue
+			}
+
+			dir := filepath.Join(base, entry.Name())
+			if files, _ := filepath.Glob(filepath.Join(dir, "*.go")); len(files) == 0 {
+				// We don't have a *.go file, so nothing to build.
+				continue
+			}
+
+			var target *BuildTarget
+			target, err = m.NewBuildTarget(dir)
+			if err.IsError() {
+				log.Printf("[WARN]: Plugin(%s): %s", entry.Name(), err.String())
+				err = Return.Ok
+				continue
+			}
+			targets = append(targets, target)
+		}
 	}
 
-	return plug, m.Error
+	return targets, err
 }
 
 //
-// ExecOptions
+// buildTarget - Build each plugin type of a plugin source dir, skipping up-to-date outputs.
+// Build output is captured into the result, and passed to a logger named after the plugin.
 // ---------------------------------------------------------------------------------------------------- //
-type ExecOptions struct {
-	Dir     string
-	Command string
-	Args    []string
-}
+func (m *PluginManager) buildTarget(ctx context.Context, target *BuildTarget) []*BuildResult {
+	var results []*BuildResult
+
+	logger := m.Logger.Named(target.Name)
+	output := utils.NewLineWriter(func(line string) {
+		logger.Info("%s", line)
+	})
+
+	cache := target.readCache()
+	for _, types := range Plugin.OrderedPluginTypes {
+		loaderType := GoPlugLoader.NativeLoaderName
+		if types.IsRpc() {
+			loaderType = GoPlugLoader.RpcLoaderName
+			if !target.Types.Rpc {
+				continue
+			}
+		} else if !target.Types.Native {
+			continue
+		}
 
-//
-// Exec
-// ---------------------------------------------------------------------------------------------------- //
-func (e *ExecOptions) Exec() error {
-	var err error
+		result := &BuildResult{
+			Name:   target.Name,
+			Dir:    target.Dir,
+			Type:   loaderType,
+			Output: target.Output(loaderType),
+		}
+		results = append(results, result)
+		started := time.Now()
 
-	for range Only.Once {
-		if e.Dir != "" {
-			err = os.Chdir(e.Dir)
-			if err != nil {
-				log.Printf("Chdir to %s FAILED\n", e.Dir)
-				break
-			}
+		if e := ctx.Err(); e != nil {
+			result.Error.SetError("build cancelled: %s", e)
+			result.Status = BuildStatusFailed
+			continue
 		}
 
-		cmd := exec.Command(e.Command, e.Args...)
-		log.Printf("Exec START: %s\n", cmd.String())
+		result.Hash, result.Error = target.Hash(loaderType)
+		if result.Error.IsError() {
+			result.Status = BuildStatusFailed
+			continue
+		}
 
-		var stdout, stderr []byte
-		var errStdout, errStderr error
-		stdoutIn, _ := cmd.StdoutPipe()
-		stderrIn, _ := cmd.StderrPipe()
-		err = cmd.Start()
-		if err != nil {
-			log.Printf("cmd.Start() failed with '%s'\n", err)
-			break
+		if !target.Force && cache[loaderType] == result.Hash && utils.IsFile(result.Output) {
+			log.Printf("[INFO]: Plugin(%s): %s plugin is up-to-date", target.Name, loaderType)
+			result.Status = BuildStatusUpToDate
+			continue
 		}
 
-		// cmd.Wait() should be called only after we finish reading
-		// from stdoutIn and stderrIn.
-		// wg ensures that we finish
-		var wg sync.WaitGroup
-		wg.Add(1)
-		go func() {
-			stdout, errStdout = copyAndCapture(os.Stdout, stdoutIn)
-			wg.Done()
-		}()
-		stderr, errStderr = copyAndCapture(os.Stderr, stderrIn)
-		wg.Wait()
+		log.Printf("[INFO]: Plugin(%s): Building %s plugin", target.Name, loaderType)
+		options := target.Command(loaderType)
+		options.Timeout = m.BuildOptions.Timeout
+		options.Output = output
+		result.Command = options.String()
+		out, e := options.ExecContext(ctx)
+		output.Flush()
+		result.Log = string(out)
+		result.Duration = time.Since(started)
+		if e != nil {
+			result.Error.SetError(e)
+			result.Status = BuildStatusFailed
+			log.Printf("[ERROR]: Plugin(%s): Build failed: %s", target.Name, e)
+			continue
+		}
+
+		result.Status = BuildStatusBuilt
+		cache[loaderType] = result.Hash
+		log.Printf("[INFO]: Plugin(%s): Build OK", target.Name)
+	}
+
+	err := target.writeCache(cache)
+	if err.IsError() {
+		log.Printf("[WARN]: Plugin(%s): %s", target.Name, err.String())
+	}
+
+	return results
+}
+
+//
+// verifyPlugin - Load, then unload, a freshly built plugin. Failures are reported as warnings.
+// ---------------------------------------------------------------------------------------------------- //
+func (m *PluginManager) verifyPlugin(loaderType string, output string) Return.Error {
+	var err Return.Error
 
-		err = cmd.Wait()
-		if err != nil {
-			log.Printf("Exec Error: %s\n", err)
+	for range Only.Once {
+		loader := m.Loaders.GetLoader(loaderType)
+		if loader == nil {
 			break
 		}
 
-		if errStdout != nil || errStderr != nil {
-			log.Printf("failed to capture stdout or stderr\n")
+		var pluginPath utils.FilePath
+		pluginPath, err = utils.NewFile(output)
+		if err.IsError() {
 			break
 		}
 
-		// outStr, errStr := string(stdout), string(stderr)
-		if len(stdout) > 0 {
-			log.Printf("stdout:\n%s\n", string(stdout))
-		}
-		if len(stderr) > 0 {
-			log.Printf("stderr:\n%s\n", string(stderr))
+		_, err = loader.PluginLoad(pluginPath)
+		if err.IsError() {
+			err.SetWarning("built, but failed to load: %v", err.GetError())
+			break
 		}
 
-		// log.Printf("Exec STOP: %s\n", cmd.String())
+		err = loader.PluginUnload(pluginPath)
+		if err.IsError() {
+			err.SetWarning("built, but failed to unload: %v", err.GetError())
+			break
+		}
 	}
 
 	return err
 }
 
-func copyAndCapture(w io.Writer, r io.Reader) ([]byte, error) {
-	var out []byte
-	buf := make([]byte, 1024, 1024)
-	for {
-		n, err := r.Read(buf[:])
-		if n > 0 {
-			d := buf[:n]
-			out = append(out, d...)
-			_, err := w.Write(d)
-			if err != nil {
-				return out, err
-			}
+//
+// CheckPlugin
+// ---------------------------------------------------------------------------------------------------- //
+func (m *PluginManager) CheckPlugin(pluginPath utils.FilePath) (*GoPlugLoader.PluginItem, Return.Error) {
+	var plug *GoPlugLoader.PluginItem
+
+	for range Only.Once {
+		m.Error = pluginPath.FileExists()
+		if m.Error.IsError() {
+			break
 		}
-		if err != nil {
-			// Read returns io.EOF at the end of file, which is not an error for us
-			if err == io.EOF {
-				err = nil
-			}
-			return out, err
+
+		plug, m.Error = m.Loaders.StoreGet(pluginPath.GetBase())
+		if m.Error.IsError() {
+			// bm.Error.SetError("plugin with name '%s' is not existing", name.GetBase())
+			break
 		}
+
+		log.Printf("[INFO]: Plugin '%s' loaded with version '%s'\n", plug.Pluggable.GetName(), plug.Pluggable.GetVersion())
 	}
+
+	return plug, m.Error
 }
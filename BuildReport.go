@@ -0,0 +1,101 @@
+package GoPlug
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+const (
+	BuildStatusBuilt    = "built"
+	BuildStatusUpToDate = "up-to-date"
+	BuildStatusFailed   = "failed"
+)
+
+//
+// BuildReport - The results of a BuildPlugins() run.
+// ---------------------------------------------------------------------------------------------------- //
+type BuildReport struct {
+	Started  time.Time      `json:"started"`
+	Duration time.Duration  `json:"duration"`
+	Results  []*BuildResult `json:"results"`
+}
+
+// NewBuildReport - Create a new instance of this structure.
+func NewBuildReport() *BuildReport {
+	return &BuildReport{
+		Started: time.Now(),
+		Results: make([]*BuildResult, 0),
+	}
+}
+
+// Add - Add a build result to the report.
+func (r *BuildReport) Add(result *BuildResult) {
+	r.Results = append(r.Results, result)
+}
+
+// Finish - Mark the report as complete.
+func (r *BuildReport) Finish() {
+	r.Duration = time.Since(r.Started)
+}
+
+// Count - Return the number of results with the given status.
+func (r *BuildReport) Count(status string) int {
+	var count int
+	for _, result := range r.Results {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// IsError - Returns true if any build failed.
+func (r *BuildReport) IsError() bool {
+	return r.Count(BuildStatusFailed) > 0
+}
+
+// String - Stringer interface.
+func (r BuildReport) String() string {
+	var ret string
+	ret += fmt.Sprintf("# Build report: %d built, %d up-to-date, %d failed (%s)\n",
+		r.Count(BuildStatusBuilt), r.Count(BuildStatusUpToDate), r.Count(BuildStatusFailed), r.Duration.Round(time.Millisecond))
+	for _, result := range r.Results {
+		ret += result.String()
+	}
+	return ret
+}
+
+// Print - Print the report.
+func (r *BuildReport) Print() {
+	fmt.Print(r.String())
+}
+
+//
+// BuildResult - The result of building a single plugin, for a single loader type.
+// ---------------------------------------------------------------------------------------------------- //
+type BuildResult struct {
+	Name     string        `json:"name"`     // Plugin source dir name.
+	Dir      string        `json:"dir"`      // Plugin source dir.
+	Type     string        `json:"type"`     // Loader type, (native or rpc).
+	Output   string        `json:"output"`   // Built file.
+	Hash     string        `json:"hash"`     // Content hash of the build inputs.
+	Status   string        `json:"status"`   // One of the BuildStatus constants.
+	Command  string        `json:"command"`  // The build command executed.
+	Duration time.Duration `json:"duration"` //
+	Log      string        `json:"log"`      // Captured build output.
+	Error    Return.Error  `json:"-"`        // Build or verification error.
+}
+
+// String - Stringer interface.
+func (r BuildResult) String() string {
+	ret := fmt.Sprintf("%-10s %-6s %-24s %s (%s)\n", r.Status, r.Type, r.Name, r.Output, r.Duration.Round(time.Millisecond))
+	if r.Error.IsError() {
+		ret += fmt.Sprintf("\t%v\n", r.Error.GetError())
+	}
+	if r.Error.IsWarning() {
+		ret += fmt.Sprintf("\t%v\n", r.Error.GetWarning())
+	}
+	return ret
+}
@@ -0,0 +1,207 @@
+package GoPlug
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader"
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+)
+
+type BuildSuite struct {
+	suite.Suite
+	dir     string
+	manager *PluginManager
+}
+
+// SetupTest - A manager of an empty plugin dir.
+func (s *BuildSuite) SetupTest() {
+	var e error
+	s.dir, e = filepath.EvalSymlinks(s.T().TempDir())
+	s.Require().NoError(e)
+
+	// The manager log file is created in the working dir.
+	wd, e := os.Getwd()
+	s.Require().NoError(e)
+	s.Require().NoError(os.Chdir(s.T().TempDir()))
+	defer func() {
+		s.Require().NoError(os.Chdir(wd))
+	}()
+
+	identity := Plugin.Identity{
+		Name:        "build",
+		Version:     "0.0.0",
+		PluginTypes: Plugin.AllPluginTypes,
+		Callbacks:   Plugin.NewCallbacks(),
+	}
+	manager, err := NewPluginManager(&identity)
+	s.Require().False(err.IsError(), err.String())
+	s.manager = manager.(*PluginManager)
+
+	err = s.manager.SetDir(s.dir)
+	s.Require().False(err.IsError(), err.String())
+}
+
+// source - Create a plugin source dir, with the given files.
+func (s *BuildSuite) source(name string, files map[string]string) string {
+	dir := filepath.Join(s.dir, name)
+	s.Require().NoError(os.MkdirAll(dir, 0o755))
+	for file, data := range files {
+		s.Require().NoError(os.WriteFile(filepath.Join(dir, file), []byte(data), 0o644))
+	}
+	return dir
+}
+
+func (s *BuildSuite) TestBuildTargets() {
+	plugin := map[string]string{
+		"go.mod":  "module example.com/plugin\n\ngo 1.21\n",
+		"main.go": "package main\n\nfunc main() {}\n",
+	}
+	s.source("goplug-a", plugin)
+	s.source("goplug", plugin)
+	s.source("other", plugin)
+	s.source("goplug-b", map[string]string{"README": "no go files"})
+	s.Require().NoError(os.WriteFile(filepath.Join(s.dir, "goplug-c.go"), []byte("package main\n"), 0o644))
+
+	tests := []struct {
+		glob string
+		want []string
+		err  string
+	}{
+		{glob: "goplug-*", want: []string{"goplug-a"}},
+		{glob: "*", want: []string{"goplug", "goplug-a", "other"}},
+		{glob: "*.go"},
+		{glob: "goplug-?", want: []string{"goplug-a"}},
+		{glob: "goplug-[", err: "invalid plugin glob 'goplug-['"},
+	}
+
+	for _, test := range tests {
+		s.Run(test.glob, func() {
+			err := s.manager.SetFileGlob(test.glob)
+			s.Require().False(err.IsError(), err.String())
+
+			targets, err := s.manager.buildTargets()
+			if test.err != "" {
+				s.True(err.IsError())
+				s.Contains(err.Error(), test.err)
+				return
+			}
+			s.Require().False(err.IsError(), err.String())
+
+			var names []string
+			for _, target := range targets {
+				names = append(names, target.Name)
+			}
+			s.Equal(test.want, names)
+		})
+	}
+}
+
+// build - Build an RPC plugin target, returning the status of the build.
+func (s *BuildSuite) build(ctx context.Context, target *BuildTarget) *BuildResult {
+	results := s.manager.buildTarget(ctx, target)
+	s.Require().Len(results, 1)
+	return results[0]
+}
+
+func (s *BuildSuite) TestBuildCache() {
+	if testing.Short() {
+		s.T().Skip("builds a plugin")
+	}
+
+	dir := s.source("goplug-a", map[string]string{
+		"go.mod":  "module example.com/plugin\n\ngo 1.21\n",
+		"main.go": "package main\n\nfunc main() {}\n",
+	})
+	target, err := s.manager.NewBuildTarget(dir)
+	s.Require().False(err.IsError(), err.String())
+	target.Types = Plugin.RpcPluginType
+	ctx := context.Background()
+
+	result := s.build(ctx, target)
+	s.Require().False(result.Error.IsError(), result.Error.String()+result.Log)
+	s.Equal(BuildStatusBuilt, result.Status, "no cache")
+	s.FileExists(result.Output)
+	s.Equal(map[string]string{GoPlugLoader.RpcLoaderName: result.Hash}, target.readCache())
+	hash := result.Hash
+
+	tests := []struct {
+		name   string
+		change func()
+		status string
+		same   bool // Built again from the same inputs.
+	}{
+		{name: "unchanged", status: BuildStatusUpToDate},
+		{name: "test file", change: func() {
+			s.source("goplug-a", map[string]string{"main_test.go": "package main\n"})
+		}, status: BuildStatusUpToDate},
+		{name: "hidden dir", change: func() {
+			s.source("goplug-a/.hidden", map[string]string{"x.go": "package x\n"})
+		}, status: BuildStatusUpToDate},
+		{name: "source", change: func() {
+			s.source("goplug-a", map[string]string{"main.go": "package main\n\nfunc main() { println() }\n"})
+		}, status: BuildStatusBuilt},
+		{name: "new file", change: func() {
+			s.source("goplug-a", map[string]string{"other.go": "package main\n"})
+		}, status: BuildStatusBuilt},
+		{name: "go.mod", change: func() {
+			s.source("goplug-a", map[string]string{"go.mod": "module example.com/plugin\n\ngo 1.20\n"})
+		}, status: BuildStatusBuilt},
+		{name: "settings", change: func() {
+			target.Settings.Tags = []string{"other"}
+		}, status: BuildStatusBuilt},
+		{name: "output removed", change: func() {
+			s.Require().NoError(os.Remove(target.Output(GoPlugLoader.RpcLoaderName)))
+		}, status: BuildStatusBuilt, same: true},
+		{name: "forced", change: func() {
+			target.Force = true
+		}, status: BuildStatusBuilt, same: true},
+		{name: "failed", change: func() {
+			target.Force = false
+			s.source("goplug-a", map[string]string{"other.go": "package main\n\nbroken\n"})
+		}, status: BuildStatusFailed},
+		{name: "fixed", change: func() {
+			s.source("goplug-a", map[string]string{"other.go": "package main\n"})
+		}, status: BuildStatusUpToDate},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			if test.change != nil {
+				test.change()
+			}
+			cached := target.readCache()[GoPlugLoader.RpcLoaderName]
+
+			result := s.build(ctx, target)
+			s.Equal(test.status, result.Status, result.Log)
+			switch test.status {
+			case BuildStatusBuilt:
+				s.Equal(test.same, hash == result.Hash, "the hash covers the inputs")
+				s.NotEmpty(result.Command)
+				s.Equal(result.Hash, target.readCache()[GoPlugLoader.RpcLoaderName])
+			case BuildStatusUpToDate:
+				s.Equal(cached, result.Hash)
+				s.Empty(result.Command, "nothing was run")
+			case BuildStatusFailed:
+				s.True(result.Error.IsError())
+				s.Equal(cached, target.readCache()[GoPlugLoader.RpcLoaderName], "a failed build isn't cached")
+			}
+			hash = result.Hash
+		})
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	target.Force = true
+	result = s.build(ctx, target)
+	s.Equal(BuildStatusFailed, result.Status)
+	s.Contains(result.Error.Error(), "build cancelled")
+}
+
+func TestBuildSuite(t *testing.T) {
+	suite.Run(t, new(BuildSuite))
+}
@@ -0,0 +1,264 @@
+package GoPlug
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MickMake/GoUnify/Only"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader"
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// SetConfigDir - Set the dir plugin config files are loaded from, (defaults to 'config' within the plugin dir).
+func (m *PluginManager) SetConfigDir(dir string) Return.Error {
+	for range Only.Once {
+		if dir == "" {
+			m.ConfigDir = ""
+			break
+		}
+
+		var fp utils.FilePath
+		fp, m.Error = utils.NewDir(dir)
+		if m.Error.IsError() {
+			break
+		}
+		m.ConfigDir = fp.GetPath()
+	}
+
+	return m.Error
+}
+
+// GetConfigDir - The dir plugin config files are loaded from.
+func (m *PluginManager) GetConfigDir() string {
+	if m.ConfigDir == "" {
+		return filepath.Join(m.Loaders.GetDir(), utils.ConfigDirName)
+	}
+	return m.ConfigDir
+}
+
+// LoadPluginConfig - Load the config of a plugin from <config dir>/<name>.{json,yaml,toml},
+// override it with GOPLUG_<NAME>_<KEY> environment variables, resolve 'secret:<name>' values, then validate it against Identity.Config.
+// A missing config file is not an error, defaults are used instead.
+func (m *PluginManager) LoadPluginConfig(identity Plugin.Identity) (Plugin.Config, Return.Error) {
+	var config Plugin.Config
+	var err Return.Error
+
+	for range Only.Once {
+		v := viper.New()
+		v.SetConfigName(identity.Name)
+		v.AddConfigPath(m.GetConfigDir())
+		e := v.ReadInConfig()
+		if e != nil {
+			if _, ok := e.(viper.ConfigFileNotFoundError); !ok {
+				err.SetError("plugin '%s' config: %s", identity.Name, e)
+				break
+			}
+		}
+		values := v.AllSettings()
+
+		prefix := Plugin.ConfigEnvName(identity.Name, "")
+		for _, env := range os.Environ() {
+			key, value, _ := strings.Cut(env, "=")
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+
+			name := strings.ToLower(strings.TrimPrefix(key, prefix))
+			for _, k := range identity.Config.Keys() {
+				if Plugin.ConfigEnvName(identity.Name, k) == key {
+					name = k
+					break
+				}
+			}
+			// The file may hold the same option with different case.
+			for k := range values {
+				if strings.EqualFold(k, name) {
+					delete(values, k)
+				}
+			}
+			values[name] = value
+		}
+
+		err = m.resolveSecrets(values)
+		if err.IsError() {
+			err.SetError("plugin '%s' config: %s", identity.Name, err.GetError())
+			break
+		}
+
+		config, err = identity.Config.Apply(values)
+		if err.IsError() {
+			file := v.ConfigFileUsed()
+			if file == "" {
+				file = "environment"
+			}
+			err.SetError("plugin '%s' config, (%s): %s", identity.Name, file, err.GetError())
+			err.SetCode(Return.ConfigInvalid)
+			break
+		}
+	}
+
+	return config, err
+}
+
+// GetPluginConfig - The config a loaded plugin was given.
+func (m *PluginManager) GetPluginConfig(name string) (Plugin.Config, Return.Error) {
+	var config Plugin.Config
+	var err Return.Error
+
+	for range Only.Once {
+		var item *GoPlugLoader.PluginItem
+		item, err = m.getPluginByIdentity(name)
+		if err.IsError() {
+			break
+		}
+
+		config = item.GetConfig()
+	}
+
+	return config, err
+}
+
+// ReloadConfig - Reload the config of all loaded plugins. Plugins whose config changed are given it, then notified with it.
+// A plugin keeps its current config if the new one doesn't validate.
+func (m *PluginManager) ReloadConfig() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		for _, item := range m.GetPlugins() {
+			e := m.reloadPluginConfig(item)
+			if e.IsError() {
+				err.AddError("%s", e.GetError())
+			}
+		}
+	}
+
+	return err
+}
+
+// WatchConfig - Reload plugin config when files within the config dir change. Stopped by Dispose().
+func (m *PluginManager) WatchConfig() Return.Error {
+	for range Only.Once {
+		m.configLock.Lock()
+		defer m.configLock.Unlock()
+
+		if m.configWatch != nil {
+			break
+		}
+
+		watcher, e := fsnotify.NewWatcher()
+		if e != nil {
+			m.Error.SetError("config watcher: %s", e)
+			break
+		}
+
+		e = watcher.Add(m.GetConfigDir())
+		if e != nil {
+			//goland:noinspection GoUnhandledErrorResult
+			watcher.Close()
+			m.Error.SetError("config watcher: %s", e)
+			break
+		}
+		m.configWatch = watcher
+
+		go m.watchConfig(watcher)
+		log.Printf("[INFO]: Watching plugin config in '%s'", m.GetConfigDir())
+	}
+
+	return m.Error
+}
+
+// watchConfig - Reload the config of loaded plugins whose config file changed.
+func (m *PluginManager) watchConfig(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+
+			name := strings.TrimSuffix(filepath.Base(event.Name), filepath.Ext(event.Name))
+			for _, item := range m.GetPlugins() {
+				if item.Pluggable.GetIdentity().Name != name {
+					continue
+				}
+				err := m.reloadPluginConfig(item)
+				if err.IsError() {
+					log.Printf("[WARN]: Plugin(%s): %s", name, err.GetError())
+				}
+			}
+
+		case e, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[WARN]: config watcher: %s", e)
+		}
+	}
+}
+
+// stopConfigWatch - Stop WatchConfig(), if running.
+func (m *PluginManager) stopConfigWatch() {
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+
+	if m.configWatch != nil {
+		//goland:noinspection GoUnhandledErrorResult
+		m.configWatch.Close()
+		m.configWatch = nil
+	}
+}
+
+// reloadPluginConfig - Reload the config of a loaded plugin, and notify it if changed.
+func (m *PluginManager) reloadPluginConfig(item *GoPlugLoader.PluginItem) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		identity := item.Pluggable.GetIdentity()
+
+		var config Plugin.Config
+		config, err = m.LoadPluginConfig(identity)
+		if err.IsError() {
+			break
+		}
+
+		if config.Equal(item.GetConfig()) {
+			break
+		}
+
+		err = item.SetConfig(config)
+		if err.IsError() {
+			break
+		}
+
+		err = item.Notify(config)
+		if err.IsError() {
+			err.SetError("plugin '%s' config notify: %s", identity.Name, err.GetError())
+			break
+		}
+		err = Return.Ok
+
+		log.Printf("[INFO]: Plugin(%s): config changed", identity.Name)
+	}
+
+	return err
+}
+
+// getPluginByIdentity - Find a loaded plugin by its identity name.
+func (m *PluginManager) getPluginByIdentity(name string) (*GoPlugLoader.PluginItem, Return.Error) {
+	for _, item := range m.GetPlugins() {
+		if item.Pluggable.GetIdentity().Name == name {
+			return item, Return.Ok
+		}
+	}
+	return nil, Return.NewCodeError(Return.PluginNotFound, "plugin '%s' is not loaded", name)
+}
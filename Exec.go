@@ -0,0 +1,101 @@
+package GoPlug
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/MickMake/GoUnify/Only"
+)
+
+//
+// ExecOptions - An external command, run within Dir without changing the working dir of this process.
+// ---------------------------------------------------------------------------------------------------- //
+type ExecOptions struct {
+	Dir     string        // Working dir of the command.
+	Command string        //
+	Args    []string      //
+	Env     []string      // Added to os.Environ(), ("KEY=value").
+	Timeout time.Duration // Kill the command after this long, (0 = no timeout).
+	Output  io.Writer     // Optionally receives the combined stdout/stderr as it is produced.
+}
+
+// String - Stringer interface.
+func (e ExecOptions) String() string {
+	return strings.TrimSpace(strings.Join(e.Env, " ") + " " + e.Command + " " + strings.Join(e.Args, " "))
+}
+
+//
+// Exec - Run the command, discarding captured output.
+// ---------------------------------------------------------------------------------------------------- //
+func (e *ExecOptions) Exec() error {
+	_, err := e.ExecContext(context.Background())
+	return err
+}
+
+//
+// ExecContext - Run the command, returning the combined stdout/stderr.
+// The command, and any children, are killed when ctx is done or the timeout expires.
+// ---------------------------------------------------------------------------------------------------- //
+func (e *ExecOptions) ExecContext(ctx context.Context) ([]byte, error) {
+	var out bytes.Buffer
+	var err error
+
+	for range Only.Once {
+		if e.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+			//goland:noinspection GoDeferInLoop
+			defer cancel()
+		}
+
+		err = ctx.Err()
+		if err != nil {
+			break
+		}
+
+		cmd := exec.Command(e.Command, e.Args...)
+		cmd.Dir = e.Dir
+		if len(e.Env) > 0 {
+			cmd.Env = append(os.Environ(), e.Env...)
+		}
+
+		// Using the same writer for both means exec only runs one copying goroutine.
+		var w io.Writer = &out
+		if e.Output != nil {
+			w = io.MultiWriter(&out, e.Output)
+		}
+		cmd.Stdout = w
+		cmd.Stderr = w
+		setProcessGroup(cmd)
+
+		err = cmd.Start()
+		if err != nil {
+			break
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			done <- cmd.Wait()
+		}()
+
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+			<-done
+			if ctx.Err() == context.DeadlineExceeded {
+				err = fmt.Errorf("timed out after %s: %w", e.Timeout, ctx.Err())
+				break
+			}
+			err = fmt.Errorf("cancelled: %w", ctx.Err())
+		}
+	}
+
+	return out.Bytes(), err
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package GoPlug
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup - Process groups are not supported on this platform.
+func setProcessGroup(_ *exec.Cmd) {
+}
+
+// killProcessGroup - Kill the command.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	_ = cmd.Process.Kill()
+}
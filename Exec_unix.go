@@ -0,0 +1,21 @@
+//go:build unix
+
+package GoPlug
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup - Start the command in its own process group, so children can be killed with it.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup - Kill the command and its children.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,20 @@
+package GoPlugLoader
+
+import (
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// Sentinel errors of the loader. Match a Return.Error against them with errors.Is(err, GoPlugLoader.ErrPluginNotFound),
+// rather than by its message.
+//goland:noinspection GoUnusedGlobalVariable
+var (
+	ErrPluginNotFound     error = Return.PluginNotFound
+	ErrPluginRefused      error = Return.PluginRefused
+	ErrPluginIncompatible error = Return.PluginIncompatible
+	ErrHandshakeFailed    error = Return.HandshakeFailed
+	ErrHookNotFound       error = Return.HookNotFound
+	ErrHookArgMismatch    error = Return.HookArgMismatch
+	ErrCapabilityDenied   error = Return.CapabilityDenied
+	ErrConfigInvalid      error = Return.ConfigInvalid
+	ErrSecretNotFound     error = Return.SecretNotFound
+)
@@ -43,13 +43,53 @@ func (l *Loader) SetPluginTypes(pluginTypes Plugin.Types) Return.Error {
 	return Return.Ok
 }
 
+func (l *Loader) SetPreLoadValidator(validator Plugin.Validator) Return.Error {
+	err := l.Native.SetPreLoadValidator(validator)
+	if err.IsError() {
+		return err
+	}
+	return l.Rpc.SetPreLoadValidator(validator)
+}
+
+func (l *Loader) SetHostFactory(factory Plugin.HostFactory) Return.Error {
+	err := l.Native.SetHostFactory(factory)
+	if err.IsError() {
+		return err
+	}
+	return l.Rpc.SetHostFactory(factory)
+}
+
+func (l *Loader) SetConfigFactory(factory Plugin.ConfigFactory) Return.Error {
+	err := l.Native.SetConfigFactory(factory)
+	if err.IsError() {
+		return err
+	}
+	return l.Rpc.SetConfigFactory(factory)
+}
+
+func (l *Loader) SetValuesFactory(factory Plugin.ValuesFactory) Return.Error {
+	err := l.Native.SetValuesFactory(factory)
+	if err.IsError() {
+		return err
+	}
+	return l.Rpc.SetValuesFactory(factory)
+}
+
+func (l *Loader) SetLogLevels(levels utils.LogLevels) Return.Error {
+	err := l.Native.SetLogLevels(levels)
+	if err.IsError() {
+		return err
+	}
+	return l.Rpc.SetLogLevels(levels)
+}
+
 func (l *Loader) GetLoader(force string) LoaderInterface {
 	if force == NativeLoaderName {
 		return l.Native.GetLoader(NativeLoaderName)
 	}
 
 	if force == RpcLoaderName {
-		return l.Native.GetLoader(RpcLoaderName)
+		return l.Rpc.GetLoader(RpcLoaderName)
 	}
 
 	ret := l.Native.GetLoader("")
@@ -209,48 +249,50 @@ func (l *Loader) PluginLoad(path utils.FilePath) (PluginItem, Return.Error) {
 	var item PluginItem
 
 	for range Only.Once {
-		if l.PluginTypes.Native {
-			item, l.Error = l.Native.PluginLoad(path)
-			if l.Error.IsError() {
-				break
-			}
+		loader := l.loaderFor(path)
+		if loader == nil {
+			l.Error.SetError("no loader available for plugin '%s'", path.String())
 			break
 		}
 
-		if l.PluginTypes.Rpc {
-			item, l.Error = l.Rpc.PluginLoad(path)
-			if l.Error.IsError() {
-				break
-			}
-			break
-		}
+		item, l.Error = loader.PluginLoad(path)
 	}
 
+	l.Error.SetContext(path.GetName(), "")
 	return item, l.Error
 }
 
 func (l *Loader) PluginUnload(path utils.FilePath) Return.Error {
 	for range Only.Once {
-		if l.PluginTypes.Native {
-			l.Error = l.Native.PluginUnload(path)
-			if l.Error.IsError() {
-				break
-			}
+		loader := l.loaderFor(path)
+		if loader == nil {
+			l.Error.SetError("no loader available for plugin '%s'", path.String())
 			break
 		}
 
-		if l.PluginTypes.Rpc {
-			l.Error = l.Rpc.PluginUnload(path)
-			if l.Error.IsError() {
-				break
-			}
-			break
-		}
+		l.Error = loader.PluginUnload(path)
 	}
 
 	return l.Error
 }
 
+// loaderFor - Select the child loader that handles a plugin file.
+// When both plugin types are enabled, the file extension decides.
+func (l *Loader) loaderFor(path utils.FilePath) LoaderInterface {
+	switch {
+	case l.PluginTypes.Native && l.PluginTypes.Rpc:
+		if path.HasExtension(NativePluginExtensions...) {
+			return l.Native
+		}
+		return l.Rpc
+	case l.PluginTypes.Native:
+		return l.Native
+	case l.PluginTypes.Rpc:
+		return l.Rpc
+	}
+	return nil
+}
+
 func (l *Loader) PluginRegister() (PluginItems, Return.Error) {
 	var items PluginItems
 
@@ -318,19 +360,13 @@ func (l *Loader) PluginParse(path utils.FilePath) (*Plugin.Identity, Return.Erro
 	var identity *Plugin.Identity
 
 	for range Only.Once {
-		if l.PluginTypes.Native {
-			identity, l.Error = l.Native.PluginParse(path)
-			if l.Error.IsError() {
-				break
-			}
+		loader := l.loaderFor(path)
+		if loader == nil {
+			l.Error.SetError("no loader available for plugin '%s'", path.GetPath())
+			break
 		}
 
-		if l.PluginTypes.Rpc {
-			identity, l.Error = l.Rpc.PluginParse(path)
-			if l.Error.IsError() {
-				break
-			}
-		}
+		identity, l.Error = loader.PluginParse(path)
 	}
 
 	return identity, l.Error
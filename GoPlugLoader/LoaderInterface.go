@@ -1,9 +1,17 @@
 package GoPlugLoader
 
 import (
+	"log"
+	"time"
+
+	"github.com/MickMake/GoUnify/Only"
+	"github.com/hashicorp/go-hclog"
+
 	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
 	"github.com/MickMake/GoPlug/utils"
 	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/metrics"
+	"github.com/MickMake/GoPlug/utils/store"
 )
 
 //
@@ -42,6 +50,22 @@ type LoaderInterface interface {
 	PluginParse(path utils.FilePath) (*Plugin.Identity, Return.Error)
 
 	SetPluginTypes(pluginTypes Plugin.Types) Return.Error
+
+	// SetPreLoadValidator - validator run against each plugin file before it is opened or executed.
+	SetPreLoadValidator(validator Plugin.Validator) Return.Error
+
+	// SetHostFactory - Creates the host services given to each plugin once it's loaded, (see Plugin.HostInterface).
+	SetHostFactory(factory Plugin.HostFactory) Return.Error
+
+	// SetConfigFactory - Loads the config given to each plugin once it's loaded, before it's initialised.
+	SetConfigFactory(factory Plugin.ConfigFactory) Return.Error
+
+	// SetValuesFactory - Opens the durable storage of each plugin's values once it's loaded, before it's initialised.
+	SetValuesFactory(factory Plugin.ValuesFactory) Return.Error
+
+	// SetLogLevels - Log level of each plugin once it's loaded, before it's initialised, (see utils.LogLevels).
+	SetLogLevels(levels utils.LogLevels) Return.Error
+
 	GetLoader(force string) LoaderInterface
 	GetLoaderType() string
 	IsLoaderType(loaderType string) bool
@@ -74,4 +98,128 @@ type ChildLoader struct {
 	logfile *utils.FilePath
 	store   PluginStore
 	Error   Return.Error
+
+	toolchain ToolchainCheck    // Native loader only.
+	security  RpcSecurityConfig // RPC loader only.
+	limits    RpcLimitsConfig   // RPC loader only.
+	faults    *PluginFaults     // RPC loader only.
+	output    RpcOutputConfig   // RPC loader only.
+	preLoad   Plugin.Validator  // Run before plugin.Open() or exec.
+	host      Plugin.HostFactory
+	config    Plugin.ConfigFactory
+	values    Plugin.ValuesFactory
+	levels    utils.LogLevels
+	grants    Plugin.Grants // RPC loader only.
+}
+
+// preLoadCheck - Run the pre-load validator, if any, against a plugin file.
+// Returns the SHA-256 the file was verified with, if a validator checked it, (signature, lockfile).
+func preLoadCheck(validator Plugin.Validator, pluginPath utils.FilePath) (string, Return.Error) {
+	var sum string
+	var err Return.Error
+
+	for range Only.Once {
+		if validator == nil {
+			break
+		}
+
+		var ret any
+		ret, err = validator.Validate(pluginPath)
+		if verified, ok := ret.(*Plugin.VerifiedFile); ok {
+			sum = verified.Sha256
+		}
+		if err.IsError() {
+			err.SetError("refusing to load plugin '%s': %s", pluginPath.GetPath(), err.GetError())
+			err.SetCode(Return.PluginRefused)
+			break
+		}
+
+		if err.IsWarning() {
+			log.Printf("[WARN]: Plugin(%s): %s", pluginPath.GetName(), err.GetWarning())
+			err = Return.Ok
+		}
+	}
+
+	return sum, err
+}
+
+// setHost - Give a loaded plugin its host services, if the loader has a host factory.
+func setHost(factory Plugin.HostFactory, item PluginItem) Return.Error {
+	if factory == nil {
+		return Return.Ok
+	}
+	return item.Pluggable.SetHost(factory(item.Pluggable.GetIdentity()))
+}
+
+// setValues - Restore a loaded plugin's values, and store them from now on, if the loader has a values factory.
+func setValues(factory Plugin.ValuesFactory, item PluginItem) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if factory == nil {
+			break
+		}
+
+		var backend store.ValueBackend
+		backend, err = factory(item.Pluggable.GetIdentity())
+		if err.IsError() || backend == nil {
+			break
+		}
+
+		err = item.Pluggable.RefValues().SetBackend(backend)
+	}
+
+	return err
+}
+
+// setLogLevel - Set a loaded plugin's log level, if one is set for it.
+func setLogLevel(levels utils.LogLevels, item PluginItem) Return.Error {
+	level := levels.Get(item.Pluggable.GetName())
+	if level == hclog.NoLevel {
+		return Return.Ok
+	}
+	return item.Pluggable.SetLogLevel(level)
+}
+
+// observeLoad - Record a plugin load that started at start, (see metrics.PluginLoads).
+func observeLoad(loaderType string, start time.Time, err Return.Error) {
+	if !metrics.IsEnabled() {
+		return
+	}
+
+	result := metrics.ResultOk
+	if err.IsError() {
+		result = metrics.ResultError
+	}
+	metrics.Inc(metrics.PluginLoads, metrics.Labels{"loader": loaderType, "result": result})
+	metrics.ObserveSince(metrics.PluginLoadSeconds, start, metrics.Labels{"loader": loaderType})
+}
+
+// closeValues - Close the storage of a plugin's values, if any.
+func closeValues(item *PluginItem) {
+	err := item.Pluggable.RefValues().Close()
+	if err.IsError() {
+		log.Printf("[WARN]: Plugin(%s): values: %s", item.Pluggable.GetName(), err.GetError())
+	}
+}
+
+// setConfig - Give a loaded plugin its config, if the loader has a config factory.
+func setConfig(factory Plugin.ConfigFactory, item PluginItem) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if factory == nil {
+			break
+		}
+
+		var config Plugin.Config
+		config, err = factory(item.Pluggable.GetIdentity())
+		if err.IsError() {
+			break
+		}
+
+		err = item.Pluggable.SetConfig(config)
+	}
+
+	return err
 }
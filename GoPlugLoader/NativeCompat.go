@@ -0,0 +1,238 @@
+package GoPlugLoader
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+const (
+	ModuleStatusMismatch   = "mismatch"   // Both sides have a version, and they differ.
+	ModuleStatusUnverified = "unverified" // At least one side is a local replace, so can't be compared.
+)
+
+// ToolchainSettings - Build settings that must match between master and a native plugin.
+var ToolchainSettings = []string{
+	"-compiler",
+	"-race",
+	"-msan",
+	"-asan",
+	"-trimpath",
+	"-gcflags",
+	"GOOS",
+	"GOARCH",
+	"GOAMD64",
+	"GOARM",
+	"GOARM64",
+	"GO386",
+	"GOMIPS",
+	"GOMIPS64",
+	"GOPPC64",
+	"GORISCV64",
+	"GOEXPERIMENT",
+}
+
+//
+// ToolchainCheck - NativeLoader options for checking a native plugin before plugin.Open().
+// ---------------------------------------------------------------------------------------------------- //
+type ToolchainCheck struct {
+	// Check each native plugin before loading.
+	Enabled bool
+
+	// Optionally called when a plugin is incompatible, (typically rebuilding it).
+	// The plugin is validated, (see SetPreLoadValidator), and checked again after a Rebuild that returns without error.
+	Rebuild func(pluginPath utils.FilePath, report *ToolchainReport) Return.Error
+}
+
+//
+// ToolchainReport - Differences between the build info of master and a native plugin.
+// ---------------------------------------------------------------------------------------------------- //
+type ToolchainReport struct {
+	Plugin        string            `json:"plugin"`
+	GoVersion     string            `json:"go_version"`
+	HostGoVersion string            `json:"host_go_version"`
+	Modules       []ModuleDiff      `json:"modules"`
+	Settings      []SettingDiff     `json:"settings"`
+	Shared        int               `json:"shared"` // Number of modules used by both master and plugin.
+	info          *debug.BuildInfo  //
+	host          *debug.BuildInfo  //
+	settings      map[string]string //
+}
+
+// ModuleDiff - A module used by both master and plugin, with different versions.
+type ModuleDiff struct {
+	Path   string `json:"path"`
+	Plugin string `json:"plugin"`
+	Host   string `json:"host"`
+	Status string `json:"status"`
+}
+
+// SettingDiff - A build setting that differs between master and plugin.
+type SettingDiff struct {
+	Key    string `json:"key"`
+	Plugin string `json:"plugin"`
+	Host   string `json:"host"`
+}
+
+// CheckToolchain - Compare the build info of a native plugin file with that of the running binary.
+func CheckToolchain(path string) (*ToolchainReport, Return.Error) {
+	var report *ToolchainReport
+	var err Return.Error
+
+	for range Only.Once {
+		info, e := buildinfo.ReadFile(path)
+		if e != nil {
+			err.SetError("can't read build info from '%s': %s", path, e)
+			break
+		}
+
+		host, ok := debug.ReadBuildInfo()
+		if !ok {
+			err.SetError("can't read build info of master binary")
+			break
+		}
+
+		report = CompareBuildInfo(host, info)
+		report.Plugin = path
+	}
+
+	return report, err
+}
+
+// CompareBuildInfo - Compare the build info of master with that of a native plugin.
+func CompareBuildInfo(host *debug.BuildInfo, info *debug.BuildInfo) *ToolchainReport {
+	report := ToolchainReport{
+		GoVersion:     info.GoVersion,
+		HostGoVersion: host.GoVersion,
+		info:          info,
+		host:          host,
+	}
+
+	hostModules := make(map[string]*debug.Module)
+	hostModules[host.Main.Path] = &host.Main
+	for _, dep := range host.Deps {
+		hostModules[dep.Path] = dep
+	}
+
+	for _, dep := range info.Deps {
+		hm, ok := hostModules[dep.Path]
+		if !ok {
+			continue
+		}
+		report.Shared++
+
+		hv, hLocal := moduleVersion(hm)
+		pv, pLocal := moduleVersion(dep)
+		switch {
+		case hLocal || pLocal:
+			report.Modules = append(report.Modules, ModuleDiff{Path: dep.Path, Plugin: pv, Host: hv, Status: ModuleStatusUnverified})
+		case hv != pv:
+			report.Modules = append(report.Modules, ModuleDiff{Path: dep.Path, Plugin: pv, Host: hv, Status: ModuleStatusMismatch})
+		}
+	}
+	sort.Slice(report.Modules, func(i, j int) bool {
+		return report.Modules[i].Path < report.Modules[j].Path
+	})
+
+	hostSettings := buildSettings(host)
+	pluginSettings := buildSettings(info)
+	for _, key := range ToolchainSettings {
+		if hostSettings[key] != pluginSettings[key] {
+			report.Settings = append(report.Settings, SettingDiff{Key: key, Plugin: pluginSettings[key], Host: hostSettings[key]})
+		}
+	}
+
+	return &report
+}
+
+// IsCompatible - Returns true if nothing prevents the plugin from loading.
+// Unverified modules are reported, but don't make a plugin incompatible.
+func (r *ToolchainReport) IsCompatible() bool {
+	if r.GoVersion != r.HostGoVersion {
+		return false
+	}
+	if len(r.Settings) > 0 {
+		return false
+	}
+	for _, module := range r.Modules {
+		if module.Status == ModuleStatusMismatch {
+			return false
+		}
+	}
+	return true
+}
+
+// IsToolchainMismatch - Returns true if the Go version or build settings differ, (fixable with a rebuild).
+func (r *ToolchainReport) IsToolchainMismatch() bool {
+	return (r.GoVersion != r.HostGoVersion) || (len(r.Settings) > 0)
+}
+
+// String - Stringer interface.
+func (r ToolchainReport) String() string {
+	var ret string
+
+	status := "compatible"
+	if !r.IsCompatible() {
+		status = "INCOMPATIBLE"
+	}
+	ret += fmt.Sprintf("# Toolchain check: %s - %s (%d shared modules)\n", r.Plugin, status, r.Shared)
+
+	if r.GoVersion != r.HostGoVersion {
+		ret += fmt.Sprintf("%-10s %-40s plugin: %-24s master: %s\n", "go", "version", r.GoVersion, r.HostGoVersion)
+	}
+	for _, setting := range r.Settings {
+		ret += fmt.Sprintf("%-10s %-40s plugin: %-24s master: %s\n", "setting", setting.Key, quoteEmpty(setting.Plugin), quoteEmpty(setting.Host))
+	}
+	for _, module := range r.Modules {
+		ret += fmt.Sprintf("%-10s %-40s plugin: %-24s master: %s\n", module.Status, module.Path, module.Plugin, module.Host)
+	}
+
+	return ret
+}
+
+// moduleVersion - The effective version of a module, and whether it's replaced by a local dir.
+func moduleVersion(module *debug.Module) (string, bool) {
+	if module.Replace == nil {
+		if module.Version == "" || module.Version == "(devel)" {
+			return "(devel)", true
+		}
+		return module.Version, false
+	}
+
+	if module.Replace.Version == "" || module.Replace.Version == "(devel)" {
+		// Replaced by a local dir.
+		return "=> " + module.Replace.Path, true
+	}
+	return module.Replace.Path + " " + module.Replace.Version, false
+}
+
+// GetHostSetting - A build setting of the running binary, (eg: "-gcflags", "-tags", "-trimpath"), or "" if not set.
+func GetHostSetting(key string) string {
+	host, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return buildSettings(host)[key]
+}
+
+func buildSettings(info *debug.BuildInfo) map[string]string {
+	ret := make(map[string]string)
+	for _, setting := range info.Settings {
+		ret[setting.Key] = setting.Value
+	}
+	return ret
+}
+
+func quoteEmpty(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return `""`
+	}
+	return value
+}
@@ -0,0 +1,154 @@
+//go:build (linux || darwin) && cgo
+
+package GoPlugLoader
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+type NativeCompatSuite struct {
+	suite.Suite
+	dir          string
+	incompatible string // Built with -trimpath, which master isn't.
+	compatible   string // Built as master is, (when tested without -race or -cover).
+}
+
+// SetupSuite - Build the plain test plugin, (see testdata/symbols), with and without -trimpath.
+func (s *NativeCompatSuite) SetupSuite() {
+	if testing.Short() {
+		s.T().Skip("builds plugins")
+	}
+
+	s.dir = s.T().TempDir()
+	s.incompatible = s.build("incompatible", "-trimpath")
+	s.compatible = s.build("compatible")
+}
+
+func (s *NativeCompatSuite) build(name string, flags ...string) string {
+	path := filepath.Join(s.dir, name+".so")
+	args := append([]string{"build", "-buildmode=plugin", "-o", path}, flags...)
+	cmd := exec.Command(filepath.Join(runtime.GOROOT(), "bin", "go"), append(args, "./testdata/symbols/plain")...)
+	cmd.Env = append(os.Environ(), "GOPROXY=off")
+	out, e := cmd.CombinedOutput()
+	s.Require().NoError(e, string(out))
+	return path
+}
+
+// copy - Copy a built plugin to path, (as a rebuild would write it).
+func (s *NativeCompatSuite) copy(from string, path string) {
+	data, e := os.ReadFile(from)
+	s.Require().NoError(e)
+	s.Require().NoError(os.WriteFile(path, data, 0o600))
+}
+
+// validatorFunc - A Validator counting its calls.
+type validatorFunc struct {
+	calls    int
+	validate func(params ...any) (any, Return.Error)
+}
+
+func (v *validatorFunc) Validate(params ...any) (any, Return.Error) {
+	v.calls++
+	return v.validate(params...)
+}
+
+func (s *NativeCompatSuite) TestCheckToolchain() {
+	report, err := CheckToolchain(s.compatible)
+	s.Require().False(err.IsError(), err.String())
+	if !report.IsCompatible() {
+		s.T().Skip("the test binary was built with other flags, (eg: -race or -cover)")
+	}
+
+	report, err = CheckToolchain(s.incompatible)
+	s.Require().False(err.IsError(), err.String())
+	s.False(report.IsCompatible())
+	s.True(report.IsToolchainMismatch())
+	s.Equal([]SettingDiff{{Key: "-trimpath", Plugin: "true"}}, report.Settings)
+
+	tests := []struct {
+		name     string
+		disabled bool
+		rebuild  string // Copied over the plugin by Rebuild, ("" for no Rebuild, "fail" to fail).
+		locked   bool   // Lock the original file, and refuse any other.
+		error    string
+		code     Return.Code
+		rebuilds int
+		validate int
+	}{
+		{name: "disabled", disabled: true},
+		{name: "no rebuild", error: "is incompatible with master", code: Return.PluginIncompatible},
+		{name: "rebuilt", rebuild: s.compatible, rebuilds: 1, validate: 1},
+		{name: "still incompatible", rebuild: s.incompatible, error: "is incompatible with master", code: Return.PluginIncompatible, rebuilds: 1, validate: 1},
+		{name: "rebuild failed", rebuild: "fail", error: "rebuild of incompatible plugin", code: Return.PluginIncompatible, rebuilds: 1},
+		{name: "rebuilt file validated", rebuild: s.compatible, locked: true, error: "refusing to load plugin", code: Return.PluginRefused, rebuilds: 1, validate: 1},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			dir := s.T().TempDir()
+			path := filepath.Join(dir, "goplug-plain.so")
+			s.copy(s.incompatible, path)
+			pluginPath, err := utils.NewFile(path)
+			s.Require().False(err.IsError(), err.String())
+
+			validator := &validatorFunc{validate: func(params ...any) (any, Return.Error) {
+				return params[0], Return.Ok
+			}}
+			if test.locked {
+				lock := Plugin.NewLock()
+				entry, err := Plugin.NewLockEntry(dir, pluginPath, NativeLoaderName, nil)
+				s.Require().False(err.IsError(), err.String())
+				lock.Add(entry)
+				locked := &Plugin.LockValidator{Lock: lock, BaseDir: dir, Refuse: true}
+				validator.validate = locked.Validate
+			}
+
+			var rebuilds int
+			check := ToolchainCheck{Enabled: !test.disabled}
+			if test.rebuild != "" {
+				check.Rebuild = func(rebuild utils.FilePath, report *ToolchainReport) Return.Error {
+					rebuilds++
+					s.Equal(path, rebuild.GetPath())
+					s.Equal(path, report.Plugin)
+					if test.rebuild == "fail" {
+						return Return.NewError("build failed")
+					}
+					s.copy(test.rebuild, path)
+					return Return.Ok
+				}
+			}
+
+			loader := NewNativeLoader(nil, nil, nil).(*NativeLoader)
+			err = loader.SetToolchainCheck(check)
+			s.Require().False(err.IsError(), err.String())
+			err = loader.SetPreLoadValidator(validator)
+			s.Require().False(err.IsError(), err.String())
+
+			err = loader.CheckToolchain(pluginPath)
+			if test.error == "" {
+				s.False(err.IsError(), err.String())
+			} else {
+				s.True(err.IsError())
+				s.Contains(err.Error(), test.error)
+				s.True(errors.Is(err, test.code), "code %s", err.GetCode())
+			}
+			s.Equal(test.rebuilds, rebuilds)
+			s.Equal(test.validate, validator.calls, "the rebuilt file is validated")
+		})
+	}
+}
+
+func TestNativeCompatSuite(t *testing.T) {
+	suite.Run(t, new(NativeCompatSuite))
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
 	"github.com/MickMake/GoPlug/utils"
 	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/trace"
 )
 
 var (
@@ -63,6 +64,42 @@ func (l *NativeLoader) IsLoaderType(loaderType string) bool {
 	return false
 }
 
+// SetToolchainCheck - Check the build info of native plugins against master, before plugin.Open().
+func (l *NativeLoader) SetToolchainCheck(check ToolchainCheck) Return.Error {
+	l.toolchain = check
+	return Return.Ok
+}
+
+// SetPreLoadValidator - Validate each plugin file before plugin.Open().
+func (l *NativeLoader) SetPreLoadValidator(validator Plugin.Validator) Return.Error {
+	l.preLoad = validator
+	return Return.Ok
+}
+
+// SetHostFactory - Give each plugin its host services once it's loaded, before it's initialised.
+func (l *NativeLoader) SetHostFactory(factory Plugin.HostFactory) Return.Error {
+	l.host = factory
+	return Return.Ok
+}
+
+// SetConfigFactory - Give each plugin its config once it's loaded, before it's initialised.
+func (l *NativeLoader) SetConfigFactory(factory Plugin.ConfigFactory) Return.Error {
+	l.config = factory
+	return Return.Ok
+}
+
+// SetValuesFactory - Restore each plugin's values once it's loaded, before it's initialised.
+func (l *NativeLoader) SetValuesFactory(factory Plugin.ValuesFactory) Return.Error {
+	l.values = factory
+	return Return.Ok
+}
+
+// SetLogLevels - Set each plugin's log level once it's loaded, before it's initialised.
+func (l *NativeLoader) SetLogLevels(levels utils.LogLevels) Return.Error {
+	l.levels = levels
+	return Return.Ok
+}
+
 func (l *NativeLoader) SetPrefix(prefix string) Return.Error {
 	l.prefix = prefix
 	return l.Error
@@ -164,9 +201,21 @@ func (l *NativeLoader) PluginUnregister() Return.Error {
 }
 
 func (l *NativeLoader) PluginLoad(pluginPath utils.FilePath) (PluginItem, Return.Error) {
+	start := time.Now()
+	span := trace.Start("load", trace.SpanContext{}, "loader", NativeLoaderName, "file", pluginPath.GetName())
 	var item PluginItem
 
 	for range Only.Once {
+		_, l.Error = preLoadCheck(l.preLoad, pluginPath)
+		if l.Error.IsError() {
+			break
+		}
+
+		l.Error = l.CheckToolchain(pluginPath)
+		if l.Error.IsError() {
+			break
+		}
+
 		id := strings.TrimPrefix(pluginPath.GetName(), l.prefix)
 		item.Pluggable = NewNativePlugin()
 		l.Error = item.Pluggable.PluginLoad(id, pluginPath)
@@ -174,20 +223,105 @@ func (l *NativeLoader) PluginLoad(pluginPath utils.FilePath) (PluginItem, Return
 			break
 		}
 
+		l.Error = CheckManifest(pluginPath, NativeLoaderName, item.Pluggable.GetIdentity())
+		if l.Error.IsError() {
+			_ = item.Pluggable.PluginUnload()
+			break
+		}
+
+		l.Error = setHost(l.host, item)
+		if l.Error.IsError() {
+			_ = item.Pluggable.PluginUnload()
+			break
+		}
+
+		l.Error = setConfig(l.config, item)
+		if l.Error.IsError() {
+			_ = item.Pluggable.PluginUnload()
+			break
+		}
+
+		l.Error = setValues(l.values, item)
+		if l.Error.IsError() {
+			_ = item.Pluggable.PluginUnload()
+			break
+		}
+
+		l.Error = setLogLevel(l.levels, item)
+		if l.Error.IsError() {
+			closeValues(&item)
+			_ = item.Pluggable.PluginUnload()
+			break
+		}
+
+		initSpan := trace.Start("initialise", span.Context, "plugin", item.Pluggable.GetName())
 		l.Error = l.PluginInit(item)
+		initSpan.End(l.Error)
 		if l.Error.IsError() {
+			closeValues(&item)
+			_ = item.Pluggable.PluginUnload()
 			break
 		}
 
 		l.Error = l.StorePut(&item, true)
 	}
 
+	observeLoad(NativeLoaderName, start, l.Error)
+	span.End(l.Error)
 	return item, l.Error
 }
 
-func (l *NativeLoader) PluginUnload(path utils.FilePath) Return.Error {
+// CheckToolchain - Refuse native plugins built with a toolchain or dependencies that differ from master.
+// plugin.Open() would otherwise fail with "plugin was built with a different version of package ...".
+func (l *NativeLoader) CheckToolchain(pluginPath utils.FilePath) Return.Error {
 	var err Return.Error
 
+	for range Only.Once {
+		if !l.toolchain.Enabled {
+			break
+		}
+
+		var report *ToolchainReport
+		report, err = CheckToolchain(pluginPath.GetPath())
+		if err.IsError() {
+			break
+		}
+		if report.IsCompatible() {
+			break
+		}
+
+		if l.toolchain.Rebuild != nil {
+			log.Printf("[WARN]: Plugin(%s): Incompatible with master, rebuilding", pluginPath.GetName())
+			err = l.toolchain.Rebuild(pluginPath, report)
+			if err.IsError() {
+				err.SetError("rebuild of incompatible plugin '%s' failed: %s\n%s", pluginPath.GetPath(), err.GetError(), report.String())
+				err.SetCode(Return.PluginIncompatible)
+				break
+			}
+
+			// The rebuilt file hasn't been validated, (signature, lockfile).
+			_, err = preLoadCheck(l.preLoad, pluginPath)
+			if err.IsError() {
+				break
+			}
+
+			report, err = CheckToolchain(pluginPath.GetPath())
+			if err.IsError() {
+				break
+			}
+			if report.IsCompatible() {
+				break
+			}
+		}
+
+		err.SetError("plugin '%s' is incompatible with master\n%s", pluginPath.GetPath(), report.String())
+		err.SetCode(Return.PluginIncompatible)
+	}
+
+	return err
+}
+
+func (l *NativeLoader) PluginUnload(path utils.FilePath) Return.Error {
 	for range Only.Once {
 		var plug *PluginItem
 		plug, l.Error = l.StoreGet(path.GetPath())
@@ -201,6 +335,12 @@ func (l *NativeLoader) PluginUnload(path utils.FilePath) Return.Error {
 			break
 		}
 
+		l.Error = plug.PluginUnload()
+		if l.Error.IsError() {
+			break
+		}
+		closeValues(plug)
+
 		_, l.Error = l.store.StoreRemove(path.GetPath())
 		if l.Error.IsError() {
 			l.Error.SetError("[INFO]: Plugin(%s): Unload FAILED", path.String())
@@ -208,7 +348,7 @@ func (l *NativeLoader) PluginUnload(path utils.FilePath) Return.Error {
 		}
 	}
 
-	return err
+	return l.Error
 }
 
 func (l *NativeLoader) PluginInit(items ...PluginItem) Return.Error {
@@ -227,7 +367,7 @@ func (l *NativeLoader) PluginInit(items ...PluginItem) Return.Error {
 
 			itemData.SetValue("slave-init-timestamp", time.Now())
 
-			l.Error = item.Initialise()
+			l.Error = item.Initialise(item.GetConfig())
 			if l.Error.IsError() {
 				itemData.SetValue("slave-init", l.Error)
 				break
@@ -240,9 +380,20 @@ func (l *NativeLoader) PluginInit(items ...PluginItem) Return.Error {
 	return l.Error
 }
 
+// PluginParse - Read the plugin identity from the manifest next to the plugin file, without loading it.
 func (l *NativeLoader) PluginParse(path utils.FilePath) (*Plugin.Identity, Return.Error) {
-	l.Error.SetError("Parse() not implemented yet in PluginLoader: %s", path)
-	return nil, l.Error
+	var identity *Plugin.Identity
+
+	for range Only.Once {
+		var manifest *Plugin.Manifest
+		manifest, l.Error = ParseManifest(path, NativeLoaderName)
+		if l.Error.IsError() {
+			break
+		}
+		identity = &manifest.Identity
+	}
+
+	return identity, l.Error
 }
 
 //
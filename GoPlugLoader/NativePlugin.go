@@ -6,9 +6,7 @@ import (
 	"log"
 	"os"
 	sysPlugin "plugin"
-	"regexp"
 	"sort"
-	"strings"
 	"time"
 
 	"github.com/MickMake/GoUnify/Only"
@@ -272,11 +270,7 @@ func (p *NativePlugin) PluginLoad(id string, pluginPath utils.FilePath) Return.E
 		p.SetStructName(*identity)
 		log.Printf("[%s]: Name:%s Path: %s\n",
 			p.Common.Id, p.Common.Filename.GetName(), p.Common.Filename.String())
-
-		p.Error = p.Callback(Plugin.CallbackInitialise, p)
-		if p.Error.IsError() {
-			break
-		}
+		// Initialise is called by the loader, once host services and config are set.
 	}
 
 	return p.Error
@@ -358,34 +352,38 @@ func (ns *NativeService) ListExported() []string {
 }
 
 // Scan - Scans all exported symbols and finds type.
+// Symbols are listed by the plugin's GoPluginSymbols registry if it has one,
+// otherwise they are read from the dynamic symbol table of the plugin file.
 func (ns *NativeService) Scan() Return.Error {
 	var err Return.Error
 
 	for range Only.Once {
-		// Find exported symbols.
-		re := regexp.MustCompile(`map\[(.*)]`)
-		str := fmt.Sprintf("%v", ns.Object)
-		sa := re.FindStringSubmatch(str)
-		if len(sa) < 2 {
+		if ns.Object == nil {
+			err.SetError("Native plugin file not loaded")
 			break
 		}
-		sa2 := strings.Split(sa[1], " ")
-		ns.Symbols = make(map[string]string)
-		for _, symbol := range sa2 {
-			sa3 := strings.Split(symbol, ":")
-			if len(sa3) < 2 {
-				continue
-			}
-			ns.Symbols[sa3[0]] = "Unknown"
+
+		var symbols Plugin.Symbols
+		symbols, err = ns.registrySymbols()
+		if err.IsError() {
+			break
 		}
 
-		// Find types of exported symbols.
-		for symbol := range ns.Symbols {
-			var sym any
-			sym, err = ns.Lookup(symbol)
+		if symbols == nil {
+			symbols, err = ExportedSymbols(ns.pluginPath.GetPath())
 			if err.IsError() {
 				break
 			}
+		}
+
+		// Find types of exported symbols.
+		ns.Symbols = make(map[string]string)
+		for _, symbol := range symbols {
+			sym, e := ns.Object.Lookup(symbol)
+			if e != nil {
+				log.Printf("[WARN]: Plugin(%s): symbol '%s' not found: %s", ns.pluginPath.GetName(), symbol, e)
+				continue
+			}
 			ns.Symbols[symbol] = utils.GetTypeName(sym)
 		}
 	}
@@ -393,6 +391,42 @@ func (ns *NativeService) Scan() Return.Error {
 	return err
 }
 
+// registrySymbols - Symbols listed by the plugin's GoPluginSymbols, (nil if not exported).
+func (ns *NativeService) registrySymbols() (Plugin.Symbols, Return.Error) {
+	var ret Plugin.Symbols
+	var err Return.Error
+
+	for range Only.Once {
+		sym, e := ns.Object.Lookup(Plugin.GoPluginSymbols)
+		if e != nil {
+			break
+		}
+
+		switch v := sym.(type) {
+		case *Plugin.Symbols:
+			ret = *v
+		case *[]string:
+			ret = *v
+		case func() Plugin.Symbols:
+			ret = v()
+		case func() []string:
+			ret = v()
+		default:
+			err.SetError("Symbol '%s' had type '%s', was expecting 'Plugin.Symbols'",
+				Plugin.GoPluginSymbols, utils.GetTypeName(sym))
+		}
+		if err.IsError() {
+			break
+		}
+
+		if !ret.Has(Plugin.GoPluginSymbols) {
+			ret = append(Plugin.Symbols{Plugin.GoPluginSymbols}, ret...)
+		}
+	}
+
+	return ret, err
+}
+
 // String - Stringer.
 func (ns *NativeService) String() string {
 	var ret string
@@ -470,32 +504,37 @@ func (ns *NativeService) IsType(name string) bool {
 }
 
 // GetIdentity - Gets the Identity symbol using several symbol names.
-// Will scan exported symbols if none specified.
+// Will try GoPluginIdentity, then any other exported *Plugin.Identity, if none specified.
 func (ns *NativeService) GetIdentity(lookups ...string) (*Plugin.Identity, Return.Error) {
 	var identity *Plugin.Identity
 	var err Return.Error
 
 	for range Only.Once {
+		var f *Plugin.Identity
+		find := utils.GetTypeName(f)
+
 		if len(lookups) == 0 {
 			lookups = []string{Plugin.GoPluginIdentity}
-			lookups = append(lookups, ns.ListExported()...)
+			for _, symbol := range ns.ListExported() {
+				if (symbol != Plugin.GoPluginIdentity) && (ns.Symbols[symbol] == find) {
+					lookups = append(lookups, symbol)
+				}
+			}
 		}
 
-		var f *Plugin.Identity
-		find := utils.GetTypeName(f)
 		for _, lookup := range lookups {
 			var sym any
 			sym, err = ns.LookupType(lookup, find) // "*Plugin.Identity")
 			if err.IsError() {
-				err.SetError("%s is not globally defined: %s", Plugin.GoPluginIdentity, err)
-				break
+				continue
 			}
 
 			var ok bool
 			identity, ok = sym.(*Plugin.Identity)
 			if !ok {
 				err.SetError("Symbol '%s' had type '%s', was expecting '*NativePlugin.Identity'", lookup, utils.GetTypeName(sym))
-				break
+				identity = nil
+				continue
 			}
 
 			err = identity.IsValid()
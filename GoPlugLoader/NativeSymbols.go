@@ -0,0 +1,130 @@
+package GoPlugLoader
+
+import (
+	"debug/buildinfo"
+	"debug/elf"
+	"debug/macho"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// ExportedSymbols - List the exported globals and functions of the main package of a native plugin file.
+// Reads the dynamic symbol table, without opening the plugin.
+func ExportedSymbols(path string) ([]string, Return.Error) {
+	var ret []string
+	var err Return.Error
+
+	for range Only.Once {
+		// Symbols of the main package are named after its package path, (or "main" with older toolchains),
+		// or "plugin/unnamed-<hash>" when built from a list of files rather than a package.
+		prefixes := []string{"main."}
+		if info, e := buildinfo.ReadFile(path); e == nil && info.Path != "" {
+			prefixes = append(prefixes, info.Path+".")
+		}
+
+		var names []string
+		names, err = symbolNames(path)
+		if err.IsError() {
+			break
+		}
+
+		found := make(map[string]bool)
+		for _, name := range names {
+			matches := prefixes
+			if unnamed := unnamedPrefix(name); unnamed != "" {
+				matches = []string{unnamed}
+			}
+
+			for _, prefix := range matches {
+				if !strings.HasPrefix(name, prefix) {
+					continue
+				}
+
+				symbol := strings.TrimPrefix(name, prefix)
+				if strings.ContainsAny(symbol, ".()*") {
+					// Methods, closures and init functions.
+					continue
+				}
+				if !token.IsExported(symbol) {
+					continue
+				}
+				found[symbol] = true
+			}
+		}
+
+		for symbol := range found {
+			ret = append(ret, symbol)
+		}
+		sort.Strings(ret)
+	}
+
+	return ret, err
+}
+
+// unnamedPrefix - The "plugin/unnamed-<hash>." prefix of a symbol name, if any.
+func unnamedPrefix(name string) string {
+	const unnamed = "plugin/unnamed-"
+	if !strings.HasPrefix(name, unnamed) {
+		return ""
+	}
+	i := strings.Index(name[len(unnamed):], ".")
+	if i < 0 {
+		return ""
+	}
+	return name[:len(unnamed)+i+1]
+}
+
+// symbolNames - Defined symbols of an ELF or Mach-O shared object.
+func symbolNames(path string) ([]string, Return.Error) {
+	var ret []string
+	var err Return.Error
+
+	for range Only.Once {
+		if f, e := elf.Open(path); e == nil {
+			//goland:noinspection GoDeferInLoop
+			defer f.Close()
+
+			syms, e := f.DynamicSymbols()
+			if e != nil {
+				err.SetError("can't read dynamic symbols from '%s': %s", path, e)
+				break
+			}
+			for _, sym := range syms {
+				if sym.Section == elf.SHN_UNDEF {
+					continue
+				}
+				if elf.ST_BIND(sym.Info) != elf.STB_GLOBAL {
+					continue
+				}
+				ret = append(ret, sym.Name)
+			}
+			break
+		}
+
+		if f, e := macho.Open(path); e == nil {
+			//goland:noinspection GoDeferInLoop
+			defer f.Close()
+
+			if f.Symtab == nil {
+				err.SetError("no symbol table in '%s'", path)
+				break
+			}
+			for _, sym := range f.Symtab.Syms {
+				if sym.Sect == 0 {
+					continue
+				}
+				ret = append(ret, strings.TrimPrefix(sym.Name, "_"))
+			}
+			break
+		}
+
+		err.SetError("'%s' is not an ELF or Mach-O shared object", path)
+	}
+
+	return ret, err
+}
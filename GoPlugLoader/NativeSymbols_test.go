@@ -0,0 +1,100 @@
+//go:build (linux || darwin) && cgo
+
+package GoPlugLoader
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils"
+)
+
+type NativeSymbolsSuite struct {
+	suite.Suite
+	dir string
+}
+
+// SetupSuite - Build the test plugins, (see testdata/symbols).
+func (s *NativeSymbolsSuite) SetupSuite() {
+	if testing.Short() {
+		s.T().Skip("builds plugins")
+	}
+	s.dir = s.T().TempDir()
+}
+
+// build - Build a native plugin from a package, or a list of files, (named "plugin/unnamed-<hash>").
+func (s *NativeSymbolsSuite) build(name string, source string) string {
+	path := filepath.Join(s.dir, name+".so")
+	cmd := exec.Command(filepath.Join(runtime.GOROOT(), "bin", "go"), "build", "-buildmode=plugin", "-o", path, source)
+	cmd.Env = append(os.Environ(), "GOPROXY=off")
+	out, e := cmd.CombinedOutput()
+	s.Require().NoError(e, string(out))
+	return path
+}
+
+func (s *NativeSymbolsSuite) TestExportedSymbols() {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{name: "package", source: "./testdata/symbols/plain", want: []string{"Func", "Value"}},
+		{name: "files", source: "./testdata/symbols/plain/main.go", want: []string{"Func", "Value"}},
+		{name: "registry", source: "./testdata/symbols/registry/main.go", want: []string{"GoPluginSymbols", "Listed", "Unlisted"}},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			symbols, err := ExportedSymbols(s.build(test.name, test.source))
+			s.Require().False(err.IsError(), err.String())
+			s.Equal(test.want, symbols)
+		})
+	}
+
+	_, err := ExportedSymbols("NativeSymbols.go")
+	s.True(err.IsError())
+	s.Contains(err.Error(), "is not an ELF or Mach-O shared object")
+}
+
+func (s *NativeSymbolsSuite) TestScan() {
+	tests := []struct {
+		name   string
+		source string
+		want   map[string]string
+	}{
+		{name: "scan plain", source: "./testdata/symbols/plain/main.go", want: map[string]string{
+			"Func":  utils.GetTypeName(func() string { return "" }),
+			"Value": utils.GetTypeName(new(string)),
+		}},
+		{name: "scan registry", source: "./testdata/symbols/registry/main.go", want: map[string]string{
+			"GoPluginSymbols": utils.GetTypeName(new(Plugin.Symbols)),
+			"Listed":          utils.GetTypeName(new(string)),
+		}},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			path, err := utils.NewFile(s.build(test.name, test.source))
+			s.Require().False(err.IsError(), err.String())
+
+			ns := NewNativeService()
+			err = ns.Open(path)
+			if strings.Contains(err.Error(), "plugin was built with a different version of package") {
+				s.T().Skip("the test binary was built with other flags, (eg: -race or -cover)")
+			}
+			s.Require().False(err.IsError(), err.String())
+			s.Equal(test.want, ns.Symbols)
+		})
+	}
+}
+
+func TestNativeSymbolsSuite(t *testing.T) {
+	suite.Run(t, new(NativeSymbolsSuite))
+}
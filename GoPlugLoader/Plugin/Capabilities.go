@@ -0,0 +1,264 @@
+package Plugin
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+//goland:noinspection GoUnusedConst
+const (
+	CapabilityAll         = "*"            // Everything, for trusted plugins. Only valid as a grant.
+	CapabilityNetwork     = "network"      // HTTP requests through the host, (RPC plugins without it run in a network namespace on Linux).
+	CapabilityFsRead      = "fs:read"      // fs:read:<path> - Read files within path, through the host.
+	CapabilityFsWrite     = "fs:write"     // fs:write:<path> - Read and write files within path, through the host.
+	CapabilityHostHooks   = "host-hooks"   // host-hooks:<name> - Call a hook master offers, (see PluginManager.SetHostHook()).
+	CapabilityValuesRead  = "values:read"  // Read from master's value store.
+	CapabilityValuesWrite = "values:write" // Read from, and write to, master's value store.
+	CapabilityPlugins     = "plugins"      // plugins:<name> - Call the hooks of another plugin.
+
+	// PermissionDenied - Prefix of the error returned when a capability is missing.
+	PermissionDenied = "permission denied"
+)
+
+// capabilityArgs - Capabilities that take an argument, (kind:<arg>).
+var capabilityArgs = []string{CapabilityFsRead, CapabilityFsWrite, CapabilityHostHooks, CapabilityPlugins}
+
+//
+// Capabilities - Capabilities a plugin declares it needs, or master grants to it.
+// ---------------------------------------------------------------------------------------------------- //
+// A trailing ":*" matches any argument, (host-hooks:*), and fs paths match anything within them.
+//
+// Capabilities are checked when a plugin calls master through its HostInterface: host hooks, values,
+// other plugins, HTTP requests, (network), and files, (fs:read, fs:write). A native plugin runs within
+// master's process, so nothing stops it using the os and net packages directly, only load trusted code
+// as a native plugin. An RPC plugin not granted network has no network on Linux, (see RpcLoader.SetGrants).
+type Capabilities []string
+
+// IsValid - Check each capability is known, and has an argument if it needs one.
+func (c Capabilities) IsValid() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		for _, capability := range c {
+			e := IsCapabilityValid(capability)
+			if e.IsError() {
+				err.AddError("%s", e.GetError())
+			}
+		}
+	}
+
+	return err
+}
+
+// Has - Returns true if any of the capabilities cover the required one.
+func (c Capabilities) Has(required string) bool {
+	for _, capability := range c {
+		if CapabilityMatches(capability, required) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCapabilityValid - Check a single capability.
+func IsCapabilityValid(capability string) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		switch capability {
+		case CapabilityAll, CapabilityNetwork, CapabilityValuesRead, CapabilityValuesWrite:
+			break
+		default:
+			kind, arg := SplitCapability(capability)
+			var ok bool
+			for _, k := range capabilityArgs {
+				if kind == k {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				err.SetError("unknown capability '%s'", capability)
+				break
+			}
+			if arg == "" {
+				err.SetError("capability '%s' needs an argument, (%s:<arg>)", capability, kind)
+				break
+			}
+		}
+	}
+
+	return err
+}
+
+// SplitCapability - Split a capability into its kind and argument, (fs:read:/data => fs:read, /data).
+func SplitCapability(capability string) (string, string) {
+	for _, kind := range capabilityArgs {
+		if strings.HasPrefix(capability, kind+":") {
+			return kind, strings.TrimPrefix(capability, kind+":")
+		}
+	}
+	return capability, ""
+}
+
+// CapabilityMatches - Returns true if capability covers required.
+func CapabilityMatches(capability string, required string) bool {
+	if capability == CapabilityAll || capability == required {
+		return true
+	}
+	if capability == CapabilityValuesWrite && required == CapabilityValuesRead {
+		return true
+	}
+
+	kind, arg := SplitCapability(capability)
+	reqKind, reqArg := SplitCapability(required)
+	if arg == "" || reqArg == "" {
+		return false
+	}
+
+	switch {
+	case kind == CapabilityFsWrite && (reqKind == CapabilityFsRead || reqKind == CapabilityFsWrite):
+		return arg == "*" || pathWithin(arg, reqArg)
+	case kind != reqKind:
+		return false
+	case arg == "*":
+		return true
+	case kind == CapabilityFsRead:
+		return pathWithin(arg, reqArg)
+	}
+	return false
+}
+
+// pathWithin - Returns true if path is dir, or within it.
+func pathWithin(dir string, path string) bool {
+	rel, e := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if e != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+//
+// Grants - Capabilities granted to plugins by master, keyed by plugin name. The "*" key applies to all plugins.
+// ---------------------------------------------------------------------------------------------------- //
+// A nil Grants doesn't enforce anything, all plugins are trusted.
+type Grants map[string]Capabilities
+
+// NewGrants - Create a new instance of this structure.
+func NewGrants() Grants {
+	return make(Grants)
+}
+
+// ParseGrants - Parse 'plugin=capability' settings, as used on the command line.
+func ParseGrants(settings ...string) (Grants, Return.Error) {
+	ret := NewGrants()
+	var err Return.Error
+
+	for range Only.Once {
+		for _, setting := range settings {
+			name, capability, ok := strings.Cut(setting, "=")
+			if !ok || name == "" || capability == "" {
+				err.SetError("invalid grant '%s', expecting 'plugin=capability'", setting)
+				break
+			}
+			ret.Add(name, capability)
+		}
+		if err.IsError() {
+			break
+		}
+
+		err = ret.IsValid()
+	}
+
+	return ret, err
+}
+
+// IsValid - Check all granted capabilities.
+func (g Grants) IsValid() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		for _, name := range g.Names() {
+			e := g[name].IsValid()
+			if e.IsError() {
+				err.AddError("plugin '%s': %s", name, e.GetError())
+			}
+		}
+	}
+
+	return err
+}
+
+// Add - Grant capabilities to a plugin.
+func (g Grants) Add(name string, capabilities ...string) {
+	g[name] = append(g[name], capabilities...)
+}
+
+// Get - Capabilities granted to a plugin, including those granted to all plugins.
+func (g Grants) Get(name string) Capabilities {
+	var ret Capabilities
+	ret = append(ret, g[CapabilityAll]...)
+	ret = append(ret, g[name]...)
+	return ret
+}
+
+// Names - Sorted plugin names.
+func (g Grants) Names() []string {
+	var ret []string
+	for name := range g {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// IsEnforced - Returns true if grants are checked.
+func (g Grants) IsEnforced() bool {
+	return g != nil
+}
+
+// Check - Returns a permission error, unless the plugin both declares and is granted the capability.
+func (g Grants) Check(identity Identity, capability string) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if !g.IsEnforced() {
+			break
+		}
+
+		if !identity.Capabilities.Has(capability) {
+			err.SetError("%s: plugin '%s' does not declare capability '%s'", PermissionDenied, identity.Name, capability)
+			err.SetCode(Return.CapabilityDenied)
+			break
+		}
+
+		if !g.Get(identity.Name).Has(capability) {
+			err.SetError("%s: plugin '%s' is not granted capability '%s'", PermissionDenied, identity.Name, capability)
+			err.SetCode(Return.CapabilityDenied)
+			break
+		}
+	}
+
+	return err
+}
+
+// Missing - Declared capabilities of a plugin that are not granted.
+func (g Grants) Missing(identity Identity) Capabilities {
+	var ret Capabilities
+	if !g.IsEnforced() {
+		return ret
+	}
+
+	granted := g.Get(identity.Name)
+	for _, capability := range identity.Capabilities {
+		if !granted.Has(capability) {
+			ret = append(ret, capability)
+		}
+	}
+	return ret
+}
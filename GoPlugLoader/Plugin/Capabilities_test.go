@@ -0,0 +1,93 @@
+package Plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+type CapabilitiesSuite struct {
+	suite.Suite
+}
+
+func (s *CapabilitiesSuite) TestMatches() {
+	tests := []struct {
+		capability string
+		required   string
+		want       bool
+	}{
+		{CapabilityAll, CapabilityNetwork, true},
+		{CapabilityNetwork, CapabilityNetwork, true},
+		{CapabilityNetwork, CapabilityValuesWrite, false},
+		{CapabilityValuesWrite, CapabilityValuesRead, true},
+		{CapabilityValuesRead, CapabilityValuesWrite, false},
+		{"host-hooks:*", "host-hooks:Log", true},
+		{"host-hooks:Log", "host-hooks:Exec", false},
+		{"plugins:a", "host-hooks:a", false},
+		{"fs:read:/data", "fs:read:/data/a/b", true},
+		{"fs:read:/data", "fs:read:/data", true},
+		{"fs:read:/data", "fs:read:/data/../etc/passwd", false},
+		{"fs:read:/data", "fs:read:/database", false},
+		{"fs:read:/data", "fs:write:/data/a", false},
+		{"fs:write:/data", "fs:read:/data/a", true},
+		{"fs:write:/data", "fs:write:/data/a", true},
+	}
+
+	for _, test := range tests {
+		s.Equal(test.want, CapabilityMatches(test.capability, test.required), "%s covers %s", test.capability, test.required)
+	}
+}
+
+func (s *CapabilitiesSuite) TestCheck() {
+	identity := Identity{Name: "a", Capabilities: Capabilities{CapabilityNetwork, "fs:read:/data"}}
+
+	tests := []struct {
+		name       string
+		grants     Grants
+		capability string
+		denied     bool
+	}{
+		{name: "not enforced", grants: nil, capability: CapabilityValuesWrite},
+		{name: "declared and granted", grants: Grants{"a": {CapabilityNetwork}}, capability: CapabilityNetwork},
+		{name: "granted to all", grants: Grants{CapabilityAll: {CapabilityNetwork}}, capability: CapabilityNetwork},
+		{name: "granted within path", grants: Grants{"a": {"fs:read:/"}}, capability: "fs:read:/data/x"},
+		{name: "not granted", grants: Grants{"b": {CapabilityNetwork}}, capability: CapabilityNetwork, denied: true},
+		{name: "not declared", grants: Grants{"a": {CapabilityAll}}, capability: CapabilityValuesWrite, denied: true},
+		{name: "outside declared path", grants: Grants{"a": {CapabilityAll}}, capability: "fs:read:/etc/passwd", denied: true},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			err := test.grants.Check(identity, test.capability)
+			s.Equal(test.denied, err.IsError(), err.String())
+			if test.denied {
+				s.Equal(Return.CapabilityDenied, err.GetCode())
+				s.Contains(err.Error(), PermissionDenied)
+			}
+		})
+	}
+}
+
+func (s *CapabilitiesSuite) TestParseGrants() {
+	tests := []struct {
+		settings []string
+		invalid  bool
+	}{
+		{settings: []string{"a=network", "*=values:read"}},
+		{settings: []string{"a=fs:read:/data", "a=host-hooks:*"}},
+		{settings: []string{"a"}, invalid: true},
+		{settings: []string{"a=fs:read"}, invalid: true},
+		{settings: []string{"a=teleport"}, invalid: true},
+	}
+
+	for _, test := range tests {
+		_, err := ParseGrants(test.settings...)
+		s.Equal(test.invalid, err.IsError(), "%v: %s", test.settings, err)
+	}
+}
+
+func TestCapabilitiesSuite(t *testing.T) {
+	suite.Run(t, new(CapabilitiesSuite))
+}
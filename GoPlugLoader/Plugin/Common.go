@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/MickMake/GoUnify/Only"
+	"github.com/hashicorp/go-hclog"
 
 	"github.com/MickMake/GoPlug/utils"
 	"github.com/MickMake/GoPlug/utils/Return"
@@ -46,6 +47,8 @@ type CommonInterface interface {
 	GetLogger() *utils.Logger
 	// SetLogFile - Set an alternative file location for this plugin's logfiles.
 	SetLogFile(filename string) Return.Error
+	// SetLogLevel - Change the level of this plugin's logger, while running.
+	SetLogLevel(level hclog.Level) Return.Error
 
 	// ---------------------------------------- //
 	// Identity based methods
@@ -281,6 +284,17 @@ func (p *Common) SetLogFile(filename string) Return.Error {
 	return p.Error
 }
 
+// SetLogLevel - Change the level of this plugin's logger, while running.
+func (p *Common) SetLogLevel(level hclog.Level) Return.Error {
+	var err Return.Error
+	if p == nil || p.Logger == nil {
+		err.SetError("logger is not defined")
+		return err
+	}
+	p.Logger.SetLevel(level)
+	return err
+}
+
 // ---------------------------------------------------------------------------------------------------- //
 // Identity based methods
 //
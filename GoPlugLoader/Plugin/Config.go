@@ -0,0 +1,142 @@
+package Plugin
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Cast"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// ConfigEnvPrefix - Prefix of environment variables overriding plugin config, (GOPLUG_<PLUGIN>_<KEY>).
+const ConfigEnvPrefix = "GOPLUG"
+
+//
+// Config - Config values handed to a plugin, keyed by option name. See ConfigSchema.
+// ---------------------------------------------------------------------------------------------------- //
+// Passed as the first arg of the Initialise and Notify callbacks, and available from ctx.GetConfig().
+type Config map[string]any
+
+// ConfigFactory - Loads the config of a plugin, (see PluginManager.LoadPluginConfig).
+type ConfigFactory func(identity Identity) (Config, Return.Error)
+
+// Get - Get an option's value, nil if not set.
+func (c Config) Get(key string) any {
+	return c[key]
+}
+
+// Has - Returns true if an option is set.
+func (c Config) Has(key string) bool {
+	_, ok := c[key]
+	return ok
+}
+
+// GetString - Get an option as a string. Secrets are revealed.
+func (c Config) GetString(key string) string {
+	if secret, ok := c[key].(utils.Secret); ok {
+		return secret.Reveal()
+	}
+	return Cast.ToString(c[key])
+}
+
+// GetSecret - Get an option as a secret.
+func (c Config) GetSecret(key string) utils.Secret {
+	if secret, ok := c[key].(utils.Secret); ok {
+		return secret
+	}
+	return utils.NewSecret(Cast.ToString(c[key]))
+}
+
+func (c Config) GetBool(key string) bool {
+	return Cast.ToBool(c[key])
+}
+
+func (c Config) GetInt(key string) int {
+	return Cast.ToInt(c[key])
+}
+
+func (c Config) GetFloat(key string) float64 {
+	return Cast.ToFloat64(c[key])
+}
+
+func (c Config) GetDuration(key string) time.Duration {
+	return Cast.ToDuration(c[key])
+}
+
+func (c Config) GetStrings(key string) []string {
+	return Cast.ToStringSlice(c[key])
+}
+
+// RegisterSecrets - Redact the secrets within the config from output, (see utils.RegisterSecret).
+func (c Config) RegisterSecrets() {
+	for _, value := range c {
+		if secret, ok := value.(utils.Secret); ok {
+			utils.RegisterSecret(secret.Reveal())
+		}
+	}
+}
+
+// Keys - Sorted option names.
+func (c Config) Keys() []string {
+	var ret []string
+	for key := range c {
+		ret = append(ret, key)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Equal - Returns true if both configs hold the same values.
+func (c Config) Equal(other Config) bool {
+	if len(c) != len(other) {
+		return false
+	}
+	if len(c) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(c, other)
+}
+
+// String - Stringer interface.
+func (c Config) String() string {
+	var ret string
+	for _, key := range c.Keys() {
+		ret += fmt.Sprintf("\t%s\t%v\n", key, c[key])
+	}
+	return ret
+}
+
+// ConfigEnvName - Environment variable overriding a plugin config option, (GOPLUG_<PLUGIN>_<KEY>).
+func ConfigEnvName(plugin string, key string) string {
+	return ConfigEnvPrefix + "_" + configEnvPart(plugin) + "_" + configEnvPart(key)
+}
+
+var configEnvRe = regexp.MustCompile(`[^A-Z0-9]+`)
+
+func configEnvPart(s string) string {
+	return strings.Trim(configEnvRe.ReplaceAllString(strings.ToUpper(s), "_"), "_")
+}
+
+// configRef - Shared between copies of PluginData, so hooks see the config set by master.
+type configRef struct {
+	config Config
+	lock   sync.RWMutex
+}
+
+func (r *configRef) get() Config {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.config
+}
+
+func (r *configRef) set(config Config) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.config = config
+}
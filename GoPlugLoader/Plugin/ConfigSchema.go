@@ -0,0 +1,228 @@
+package Plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Cast"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+//goland:noinspection GoUnusedConst
+const (
+	ConfigTypeString   = "string"
+	ConfigTypeBool     = "bool"
+	ConfigTypeInt      = "int"
+	ConfigTypeFloat    = "float"
+	ConfigTypeDuration = "duration"
+	ConfigTypeStrings  = "[]string"
+	ConfigTypeSecret   = "secret" // A utils.Secret, never printed or saved. Usually a 'secret:<name>' reference.
+)
+
+// ConfigTypes - Valid ConfigOption types.
+var ConfigTypes = []string{
+	ConfigTypeString,
+	ConfigTypeBool,
+	ConfigTypeInt,
+	ConfigTypeFloat,
+	ConfigTypeDuration,
+	ConfigTypeStrings,
+	ConfigTypeSecret,
+}
+
+//
+// ConfigSchema - Config options a plugin accepts, keyed by option name.
+// ---------------------------------------------------------------------------------------------------- //
+type ConfigSchema map[string]ConfigOption
+
+// ConfigOption - A single config option.
+type ConfigOption struct {
+	Type        string `json:"type"`                  // One of ConfigTypes, (defaults to "string").
+	Default     any    `json:"default,omitempty"`     //
+	Required    bool   `json:"required,omitempty"`    //
+	Description string `json:"description,omitempty"` //
+}
+
+// IsValid - Check option types.
+func (s ConfigSchema) IsValid() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		for _, key := range s.Keys() {
+			option := s[key]
+			var ok bool
+			for _, t := range ConfigTypes {
+				if option.GetType() == t {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				err.AddError("config option '%s' has unknown type '%s'", key, option.Type)
+			}
+		}
+	}
+
+	return err
+}
+
+// Apply - Convert values to the option types, fill in defaults, then check for required and unknown options.
+// Keys are matched case-insensitively, (viper lower cases them). Without a schema, values are passed through as is.
+func (s ConfigSchema) Apply(values map[string]any) (Config, Return.Error) {
+	ret := make(Config)
+	var err Return.Error
+
+	for range Only.Once {
+		if len(s) == 0 {
+			for key, value := range values {
+				ret[key] = value
+			}
+			break
+		}
+
+		var keys []string
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		given := make(map[string]bool)
+		for _, key := range keys {
+			value := values[key]
+			name, ok := s.Lookup(key)
+			if !ok {
+				err.AddError("unknown config option '%s'", key)
+				continue
+			}
+			given[name] = true
+
+			v, e := s[name].Convert(value)
+			if e != nil {
+				err.AddError("config option '%s': %s", name, e)
+				continue
+			}
+			ret[name] = v
+		}
+
+		for _, key := range s.Keys() {
+			option := s[key]
+			if given[key] {
+				continue
+			}
+
+			if option.Default != nil {
+				v, e := option.Convert(option.Default)
+				if e != nil {
+					err.AddError("config option '%s' default: %s", key, e)
+					continue
+				}
+				ret[key] = v
+				continue
+			}
+
+			if option.Required {
+				err.AddError("config option '%s' is required", key)
+			}
+		}
+	}
+
+	return ret, err
+}
+
+// Lookup - Find an option name, ignoring case.
+func (s ConfigSchema) Lookup(key string) (string, bool) {
+	if _, ok := s[key]; ok {
+		return key, true
+	}
+	for name := range s {
+		if strings.EqualFold(name, key) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Keys - Sorted option names.
+func (s ConfigSchema) Keys() []string {
+	var ret []string
+	for key := range s {
+		ret = append(ret, key)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// String - Stringer interface.
+func (s ConfigSchema) String() string {
+	var ret string
+	for _, key := range s.Keys() {
+		option := s[key]
+		ret += fmt.Sprintf("\t%s\t%s", key, option.GetType())
+		if option.Required {
+			ret += " (required)"
+		}
+		if option.Default != nil {
+			if option.GetType() == ConfigTypeSecret {
+				ret += fmt.Sprintf(" [%s]", utils.Redacted)
+			} else {
+				ret += fmt.Sprintf(" [%v]", option.Default)
+			}
+		}
+		if option.Description != "" {
+			ret += " - " + option.Description
+		}
+		ret += "\n"
+	}
+	return ret
+}
+
+// GetType - Option type, (defaults to "string").
+func (o ConfigOption) GetType() string {
+	if o.Type == "" {
+		return ConfigTypeString
+	}
+	return o.Type
+}
+
+// Convert - Convert a value to the option type. Strings, (from env vars), are parsed, lists are comma separated.
+// A secret given to a string option stays a secret.
+func (o ConfigOption) Convert(value any) (any, error) {
+	if secret, ok := value.(utils.Secret); ok {
+		switch o.GetType() {
+		case ConfigTypeString, ConfigTypeSecret:
+			return secret, nil
+		}
+		value = secret.Reveal()
+	}
+
+	switch o.GetType() {
+	case ConfigTypeSecret:
+		str, err := Cast.ToStringE(value)
+		return utils.NewSecret(str), err
+	case ConfigTypeBool:
+		return Cast.ToBoolE(value)
+	case ConfigTypeInt:
+		return Cast.ToIntE(value)
+	case ConfigTypeFloat:
+		return Cast.ToFloat64E(value)
+	case ConfigTypeDuration:
+		return Cast.ToDurationE(value)
+	case ConfigTypeStrings:
+		if str, ok := value.(string); ok {
+			var ret []string
+			for _, v := range strings.Split(str, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					ret = append(ret, v)
+				}
+			}
+			return ret, nil
+		}
+		return Cast.ToStringSliceE(value)
+	default:
+		return Cast.ToStringE(value)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/MickMake/GoPlug/utils/Return"
 	"github.com/MickMake/GoPlug/utils/store"
+	"github.com/MickMake/GoPlug/utils/trace"
 )
 
 //
@@ -38,6 +39,8 @@ type DynamicDataInterface interface {
 	// 'name' has to exist.
 	// 'args' also have to match, both into quantity and type.
 	CallHook(name string, args ...any) (HookResponse, Return.Error)
+	// CallHookTrace - As CallHook(), within the trace of parent, (see HookStruct.Trace).
+	CallHookTrace(parent trace.SpanContext, name string, args ...any) (HookResponse, Return.Error)
 
 	RefValues() *store.ValueStruct
 	ValueExists(key string) bool
@@ -53,6 +56,9 @@ type DynamicDataInterface interface {
 	HookStore
 }
 
+// ValuesFactory - Opens the durable storage of a plugin's values, nil keeps them in memory only.
+type ValuesFactory func(identity Identity) (store.ValueBackend, Return.Error)
+
 //goland:noinspection GoUnusedExportedFunction
 func CreateDynamicData(plug PluginData) DynamicDataInterface {
 	return NewDynamicData(plug)
@@ -168,6 +174,9 @@ func (d *DynamicData) SetHook(name string, function HookFunction, args ...any) R
 func (d *DynamicData) CallHook(name string, args ...any) (HookResponse, Return.Error) {
 	return d.Hooks.CallHook(name, args...)
 }
+func (d *DynamicData) CallHookTrace(parent trace.SpanContext, name string, args ...any) (HookResponse, Return.Error) {
+	return d.Hooks.CallHookTrace(parent, name, args...)
+}
 
 // ---------------------------------------------------------------------------------------------------- //
 
@@ -4,12 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/MickMake/GoUnify/Only"
 
 	"github.com/MickMake/GoPlug/utils"
 	"github.com/MickMake/GoPlug/utils/Cast"
 	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/audit"
+	"github.com/MickMake/GoPlug/utils/store"
+	"github.com/MickMake/GoPlug/utils/trace"
 )
 
 // ---------------------------------------------------------------------------------------------------- //
@@ -73,10 +77,11 @@ func NewHookStore() HookStore {
 // HookStruct
 // ---------------------------------------------------------------------------------------------------- //
 type HookStruct struct {
-	Identity string       `json:"identity,omitempty"`
-	Hooks    HookMap      `json:"-"`
-	Master   bool         `json:"master,omitempty"`
-	Error    Return.Error `json:"-"`
+	Identity string            `json:"identity,omitempty"`
+	Hooks    HookMap           `json:"-"`
+	Master   bool              `json:"master,omitempty"`
+	Trace    trace.SpanContext `json:"-"` // Span of the hook call, set on the HookStruct given to a HookFunction.
+	Error    Return.Error      `json:"-"`
 	plugin   PluginDataInterface
 }
 
@@ -115,6 +120,37 @@ func (h *HookStruct) GetHookIdentity() string {
 	return h.Identity
 }
 
+// Host - Services master offers to the plugin these hooks belong to.
+// Within a hook call, hooks called through it are part of the same trace.
+func (h *HookStruct) Host() HostInterface {
+	if h.plugin == nil {
+		return noHost{}
+	}
+	return HostWithTrace(h.plugin.Host(), h.Trace)
+}
+
+// Traceparent - The W3C traceparent of the hook call, or empty outside a hook call.
+func (h *HookStruct) Traceparent() string {
+	return h.Trace.Traceparent()
+}
+
+// GetConfig - Config master loaded for the plugin these hooks belong to.
+func (h *HookStruct) GetConfig() Config {
+	if h.plugin == nil {
+		return Config{}
+	}
+	return h.plugin.GetConfig()
+}
+
+// Values - Values of the plugin these hooks belong to. For RPC plugins, these are shared with master.
+func (h *HookStruct) Values() *store.ValueStruct {
+	if h.plugin == nil {
+		ret := store.NewValueStruct()
+		return &ret
+	}
+	return h.plugin.RefValues()
+}
+
 // HookExists - Check if a key exists.
 func (h *HookStruct) HookExists(name string) bool {
 	hook, _ := h.Hooks.Get(name)
@@ -192,6 +228,13 @@ func (h *HookStruct) SetHook(name string, function HookFunction, args ...any) Re
 }
 
 func (h *HookStruct) CallHook(name string, args ...any) (HookResponse, Return.Error) {
+	return h.CallHookTrace(trace.SpanContext{}, name, args...)
+}
+
+// CallHookTrace - As CallHook(), within the trace of parent, (a new trace if parent is invalid).
+func (h *HookStruct) CallHookTrace(parent trace.SpanContext, name string, args ...any) (HookResponse, Return.Error) {
+	start := time.Now()
+	span := trace.Start("hook "+name, parent, "plugin", h.Identity, "hook", name)
 	h.Error = Return.Ok
 	var resp HookResponse
 	for range Only.Once {
@@ -200,6 +243,7 @@ func (h *HookStruct) CallHook(name string, args ...any) (HookResponse, Return.Er
 		hook := h.GetHook(name)
 		if hook == nil {
 			h.Error.SetError("hook '%s' not found", name)
+			h.Error.SetCode(Return.HookNotFound)
 			break
 		}
 
@@ -208,8 +252,20 @@ func (h *HookStruct) CallHook(name string, args ...any) (HookResponse, Return.Er
 			break
 		}
 
-		resp, h.Error = hook.function(*h, args...)
+		if hook.function == nil {
+			// Hooks received from an RPC plugin process, (eg: once it's unloaded), can only be called within it.
+			h.Error.SetError("hook '%s' has no function, (is the plugin loaded?)", name)
+			break
+		}
+
+		call := *h
+		call.Trace = span.Context
+		resp, h.Error = hook.function(call, args...)
 	}
+	h.Error.SetContext(h.Identity, name)
+	ObserveHook(h.Identity, name, start, h.Error)
+	audit.Hook(h.Identity, name, args, resp.Value, h.Error)
+	span.End(h.Error)
 	return resp, h.Error
 }
 
@@ -314,8 +370,9 @@ func (h Hook) String() string {
 // HookCallArgs
 // ---------------------------------------------------------------------------------------------------- //
 type HookCallArgs struct {
-	Name string `json:"name,omitempty"`
-	Args []any  `json:"args,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Args        []any  `json:"args,omitempty"`
+	Traceparent string `json:"traceparent,omitempty"` // W3C trace context of the caller.
 }
 
 //
@@ -348,16 +405,19 @@ func (a *HookArgs) Validate(args ...any) Return.Error {
 		cargs := len(*a)
 		if nargs > cargs {
 			err.SetError("too many args, should be %d", cargs)
+			err.SetCode(Return.HookArgMismatch)
 			break
 		}
 		if nargs < cargs {
 			err.SetError("not enough args, should be %d", cargs)
+			err.SetCode(Return.HookArgMismatch)
 			break
 		}
 		for index, arg := range args {
 			targ := utils.GetTypeName(arg)
 			if targ != string((*a)[index]) {
 				err.SetError("args at position %d should be of type %s, not %s", index, (*a)[index], targ)
+				err.SetCode(Return.HookArgMismatch)
 				break
 			}
 		}
@@ -365,6 +425,73 @@ func (a *HookArgs) Validate(args ...any) Return.Error {
 	return err
 }
 
+// Parse - Convert string args, (EG: from the command line), into the types declared by this HookArgs.
+func (a *HookArgs) Parse(args ...string) ([]any, Return.Error) {
+	var ret []any
+	var err Return.Error
+	for range Only.Once {
+		nargs := len(args)
+		cargs := len(*a)
+		if nargs > cargs {
+			err.SetError("too many args, should be %d (%s)", cargs, a)
+			err.SetCode(Return.HookArgMismatch)
+			break
+		}
+		if nargs < cargs {
+			err.SetError("not enough args, should be %d (%s)", cargs, a)
+			err.SetCode(Return.HookArgMismatch)
+			break
+		}
+		for index, arg := range args {
+			var value any
+			var e error
+			switch (*a)[index].String() {
+			case "string":
+				value = arg
+			case "bool":
+				value, e = Cast.ToBoolE(arg)
+			case "int":
+				value, e = Cast.ToIntE(arg)
+			case "int8":
+				value, e = Cast.ToInt8E(arg)
+			case "int16":
+				value, e = Cast.ToInt16E(arg)
+			case "int32":
+				value, e = Cast.ToInt32E(arg)
+			case "int64":
+				value, e = Cast.ToInt64E(arg)
+			case "uint":
+				value, e = Cast.ToUintE(arg)
+			case "uint8":
+				value, e = Cast.ToUint8E(arg)
+			case "uint16":
+				value, e = Cast.ToUint16E(arg)
+			case "uint32":
+				value, e = Cast.ToUint32E(arg)
+			case "uint64":
+				value, e = Cast.ToUint64E(arg)
+			case "float32":
+				value, e = Cast.ToFloat32E(arg)
+			case "float64":
+				value, e = Cast.ToFloat64E(arg)
+			case "[]uint8":
+				value = []byte(arg)
+			case "nil":
+				value = nil
+			default:
+				e = fmt.Errorf("unsupported type %s", (*a)[index])
+			}
+			if e != nil {
+				err.SetError("args at position %d should be of type %s: %s", index, (*a)[index], e)
+				err.SetCode(Return.HookArgMismatch)
+				break
+			}
+			ret = append(ret, value)
+		}
+	}
+	return ret, err
+}
+
 func (a *HookArgs) Count() int {
 	return len(*a)
 }
@@ -414,7 +541,7 @@ func (r HookResponse) String() string {
 	if r.Value == nil {
 		return ""
 	}
-	return fmt.Sprintf("%v", r.Value)
+	return utils.Redact(fmt.Sprintf("%v", r.Value))
 }
 
 func (r *HookResponse) Print() {
@@ -0,0 +1,118 @@
+package Plugin
+
+import (
+	"sync"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/trace"
+)
+
+//
+// HostInterface - Services master offers to a loaded plugin.
+// ---------------------------------------------------------------------------------------------------- //
+// Each call is checked against the capabilities the plugin declares, and master grants, (see Grants).
+// Plugins reach it from hooks with hook.Host(), and from callbacks with ctx.Host().
+type HostInterface interface {
+	// CallHostHook - Call a hook master offers, (needs host-hooks:<name>).
+	CallHostHook(name string, args ...any) (HookResponse, Return.Error)
+
+	// CallPluginHook - Call a hook of another loaded plugin, (needs plugins:<plugin>).
+	CallPluginHook(plugin string, name string, args ...any) (HookResponse, Return.Error)
+
+	// GetHostValue - Read a value from master's value store, (needs values:read).
+	GetHostValue(key string) (any, Return.Error)
+
+	// SetHostValue - Write a value to master's value store, (needs values:write).
+	SetHostValue(key string, value any) Return.Error
+
+	// HttpGet - GET a URL, returning the body, (needs network).
+	HttpGet(url string, headers map[string]string) ([]byte, Return.Error)
+
+	// ReadFile - Read a file, (needs fs:read:<path>). The path must be absolute, symlinks are resolved before checking.
+	ReadFile(path string) ([]byte, Return.Error)
+
+	// WriteFile - Write a file, (needs fs:write:<path>). The path must be absolute, symlinks are resolved before checking.
+	WriteFile(path string, data []byte) Return.Error
+
+	// CheckCapability - Returns a permission error if the plugin may not use a capability.
+	CheckCapability(capability string) Return.Error
+}
+
+// HostFactory - Creates the HostInterface for a loaded plugin.
+type HostFactory func(identity Identity) HostInterface
+
+// TracedHost - A HostInterface that can carry a trace, so hooks it calls are part of the caller's trace.
+type TracedHost interface {
+	// WithTrace - A copy of the host, calling hooks within the trace of parent.
+	WithTrace(parent trace.SpanContext) HostInterface
+}
+
+// HostWithTrace - host, calling hooks within the trace of parent, if it's a TracedHost and parent is valid.
+func HostWithTrace(host HostInterface, parent trace.SpanContext) HostInterface {
+	if !parent.IsValid() {
+		return host
+	}
+	if traced, ok := host.(TracedHost); ok {
+		return traced.WithTrace(parent)
+	}
+	return host
+}
+
+// hostRef - Shared between copies of PluginData, so hooks see a host set after loading.
+type hostRef struct {
+	host HostInterface
+	lock sync.RWMutex
+}
+
+func (r *hostRef) get() HostInterface {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.host
+}
+
+func (r *hostRef) set(host HostInterface) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.host = host
+}
+
+//
+// noHost - HostInterface used when the plugin isn't loaded by master.
+// ---------------------------------------------------------------------------------------------------- //
+type noHost struct{}
+
+func (noHost) error() Return.Error {
+	return Return.NewError("no host, plugin is not loaded by master")
+}
+
+func (h noHost) CallHostHook(_ string, _ ...any) (HookResponse, Return.Error) {
+	return HookResponse{}, h.error()
+}
+
+func (h noHost) CallPluginHook(_ string, _ string, _ ...any) (HookResponse, Return.Error) {
+	return HookResponse{}, h.error()
+}
+
+func (h noHost) GetHostValue(_ string) (any, Return.Error) {
+	return nil, h.error()
+}
+
+func (h noHost) SetHostValue(_ string, _ any) Return.Error {
+	return h.error()
+}
+
+func (h noHost) HttpGet(_ string, _ map[string]string) ([]byte, Return.Error) {
+	return nil, h.error()
+}
+
+func (h noHost) ReadFile(_ string) ([]byte, Return.Error) {
+	return nil, h.error()
+}
+
+func (h noHost) WriteFile(_ string, _ []byte) Return.Error {
+	return h.error()
+}
+
+func (h noHost) CheckCapability(_ string) Return.Error {
+	return h.error()
+}
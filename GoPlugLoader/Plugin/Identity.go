@@ -2,6 +2,7 @@ package Plugin
 
 import (
 	"fmt"
+	"path"
 	"reflect"
 	"regexp"
 	"runtime"
@@ -9,7 +10,9 @@ import (
 
 	"github.com/MickMake/GoUnify/Only"
 
+	"github.com/MickMake/GoPlug/utils"
 	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/trace"
 )
 
 // ---------------------------------------------------------------------------------------------------- //
@@ -19,6 +22,7 @@ const (
 	PackageName             = "GoPlugin"
 	GoPluginItem            = "GoPluginItem"
 	GoPluginIdentity        = "GoPluginIdentity"
+	GoPluginSymbols         = "GoPluginSymbols"
 	GoPluginNativeInterface = "GoPluginNativeInterface"
 	GoPluginRpcInterface    = "GoPluginRpcInterface"
 	HandshakeKey            = "GoPlug"
@@ -78,6 +82,12 @@ type Identity struct {
 	// The HTTP service should be served by the plugin
 	HTTPServices *HTTPServices `json:"HTTPServices,omitempty"`
 
+	// Capabilities the plugin needs, which master must grant, (network, fs:read:<path>, host-hooks:<name>, ...) - OPTIONAL
+	Capabilities Capabilities `json:"capabilities,omitempty"`
+
+	// Config options the plugin accepts, loaded by master from config/<plugin>.{json,yaml,toml} - OPTIONAL
+	Config ConfigSchema `json:"config,omitempty"`
+
 	// Callbacks - interact with the plugin.
 	Callbacks Callbacks `json:"callbacks"`
 }
@@ -95,6 +105,8 @@ func NewIdentity() *Identity {
 		Repository:   "",
 		Source:       nil,
 		HTTPServices: nil,
+		Capabilities: nil,
+		Config:       nil,
 		Callbacks:    NewCallbacks(),
 	}
 }
@@ -108,8 +120,12 @@ func (i Identity) String() string {
 	ret += fmt.Sprintf("\tIcon:\t%s\n", i.Icon)
 	ret += fmt.Sprintf("\tRepository:\t%s\n", i.Repository)
 	ret += fmt.Sprintf("\tSource:\t%s\n", i.Source)
+	ret += fmt.Sprintf("\tCapabilities:\t%s\n", strings.Join(i.Capabilities, ", "))
+	if len(i.Config) > 0 {
+		ret += fmt.Sprintf("\tConfig:\n%s", i.Config)
+	}
 	ret += fmt.Sprintf("\tCallbacks:\t%v\n", i.Callbacks)
-	return ret
+	return utils.Redact(ret)
 }
 
 func (i *Identity) Print() {
@@ -144,6 +160,9 @@ func (i *Identity) IsValid() Return.Error {
 		if len(i.Maintainers) == 0 {
 			err.AddError("plugin config Maintainers is not defined")
 		}
+		if e := i.Config.IsValid(); e.IsError() {
+			err.AddError("%s", e.GetError())
+		}
 	}
 	return err
 }
@@ -169,8 +188,25 @@ func (i *Identity) GetKey(key string) string {
 	return value
 }
 
+// Match - Returns true if the value of an identity key matches a glob pattern, (see path.Match).
+func (i *Identity) Match(key string, pattern string) bool {
+	if (strings.ToLower(key) == "source") && (i.Source == nil) {
+		return pattern == "" || pattern == "*"
+	}
+	ok, _ := path.Match(pattern, i.GetKey(key))
+	return ok
+}
+
 func (i *Identity) Callback(callback string, ctx PluginDataInterface, args ...any) Return.Error {
 	callback = strings.ToLower(callback)
+	span := trace.Start("callback "+callback, trace.SpanContext{}, "plugin", i.Name, "callback", callback)
+	err := i.callback(callback, ctx, args...)
+	ObserveCallback(i.Name, callback, err)
+	span.End(err)
+	return err
+}
+
+func (i *Identity) callback(callback string, ctx PluginDataInterface, args ...any) Return.Error {
 	switch callback {
 	case CallbackInitialise:
 		if i.Callbacks.Initialise == nil {
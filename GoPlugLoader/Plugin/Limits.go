@@ -0,0 +1,196 @@
+package Plugin
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+//goland:noinspection GoUnusedConst
+const (
+	NamespaceUser  = "user"
+	NamespacePid   = "pid"
+	NamespaceIpc   = "ipc"
+	NamespaceUts   = "uts"
+	NamespaceNet   = "net"
+	NamespaceMount = "mount"
+)
+
+// Namespaces - Valid Limits.Namespaces.
+var Namespaces = []string{
+	NamespaceUser,
+	NamespacePid,
+	NamespaceIpc,
+	NamespaceUts,
+	NamespaceNet,
+	NamespaceMount,
+}
+
+//
+// Limits - Resource limits and sandboxing for an RPC plugin process.
+// ---------------------------------------------------------------------------------------------------- //
+// Declared in the plugin manifest, or set on the manager, (which takes precedence).
+// A zero value means no limits, and the process inherits master's environment.
+type Limits struct {
+	// Memory limit, (RLIMIT_DATA). GOMEMLIMIT is also set, so the Go runtime collects garbage before reaching it.
+	// RLIMIT_AS isn't used, as the Go runtime reserves far more address space than it uses.
+	MemoryMB uint64 `json:"memory_mb,omitempty"`
+
+	// CPU time limit, (RLIMIT_CPU). The process receives SIGXCPU when exceeded.
+	CPUSeconds uint64 `json:"cpu_seconds,omitempty"`
+
+	// Open file descriptor limit, (RLIMIT_NOFILE).
+	OpenFiles uint64 `json:"open_files,omitempty"`
+
+	// Don't inherit master's environment. Only the variables in Env, and those go-plugin needs, are passed.
+	RestrictEnv bool `json:"restrict_env,omitempty"`
+
+	// Variables to pass, either "NAME", (copied from master), or "NAME=value".
+	Env []string `json:"env,omitempty"`
+
+	// Working dir, relative to the plugin dir, which it can't escape. Defaults to the plugin dir.
+	Dir string `json:"dir,omitempty"`
+
+	// Linux namespaces to run the plugin in, (see Namespaces). A user namespace is added when not running as root.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Linux: start the plugin with a seccomp filter denying privileged syscalls, (mount, ptrace, kexec, modules, ...).
+	Seccomp bool `json:"seccomp,omitempty"`
+}
+
+// ParseLimits - Parse limits from 'name=value' settings, as used on the command line.
+// memory=<MB>, cpu=<seconds>, files=<count>, env=<NAME[=value]>, dir=<dir>, namespaces=<ns:ns...>, restrict-env, seccomp.
+func ParseLimits(settings ...string) (Limits, Return.Error) {
+	var ret Limits
+	var err Return.Error
+
+	for range Only.Once {
+		for _, setting := range settings {
+			name, value, _ := strings.Cut(setting, "=")
+			name = strings.TrimSpace(name)
+
+			var e error
+			switch name {
+			case "memory":
+				ret.MemoryMB, e = strconv.ParseUint(value, 10, 64)
+			case "cpu":
+				ret.CPUSeconds, e = strconv.ParseUint(value, 10, 64)
+			case "files":
+				ret.OpenFiles, e = strconv.ParseUint(value, 10, 64)
+			case "env":
+				ret.Env = append(ret.Env, value)
+			case "dir":
+				ret.Dir = value
+			case "namespaces":
+				ret.Namespaces = append(ret.Namespaces, strings.Split(value, ":")...)
+			case "restrict-env":
+				ret.RestrictEnv = true
+			case "seccomp":
+				ret.Seccomp = true
+			default:
+				err.SetError("unknown limit '%s'", name)
+			}
+			if e != nil {
+				err.SetError("limit '%s': %s", name, e)
+			}
+			if err.IsError() {
+				break
+			}
+		}
+		if err.IsError() {
+			break
+		}
+
+		err = ret.IsValid()
+	}
+
+	return ret, err
+}
+
+// IsEmpty - Returns true if no limits are set.
+func (l *Limits) IsEmpty() bool {
+	return l.MemoryMB == 0 && l.CPUSeconds == 0 && l.OpenFiles == 0 &&
+		!l.RestrictEnv && len(l.Env) == 0 && l.Dir == "" && len(l.Namespaces) == 0 && !l.Seccomp
+}
+
+// IsValid - Check the limits.
+func (l *Limits) IsValid() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		for _, ns := range l.Namespaces {
+			var ok bool
+			for _, n := range Namespaces {
+				if ns == n {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				err.AddError("unknown namespace '%s', try one of %s", ns, strings.Join(Namespaces, ", "))
+			}
+		}
+
+		if l.Dir != "" {
+			if _, e := l.GetDir("/plugin"); e.IsError() {
+				err.AddError("%s", e.GetError())
+			}
+		}
+
+		for _, env := range l.Env {
+			if strings.TrimSpace(env) == "" || strings.HasPrefix(env, "=") {
+				err.AddError("invalid env '%s'", env)
+			}
+		}
+	}
+
+	return err
+}
+
+// GetDir - The working dir within pluginDir.
+func (l *Limits) GetDir(pluginDir string) (string, Return.Error) {
+	var err Return.Error
+
+	dir := filepath.Join(pluginDir, l.Dir)
+	rel, e := filepath.Rel(pluginDir, dir)
+	if e != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		err.SetError("dir '%s' is outside the plugin dir", l.Dir)
+		return "", err
+	}
+	return dir, err
+}
+
+// String - Stringer interface.
+func (l Limits) String() string {
+	var ret []string
+	if l.MemoryMB > 0 {
+		ret = append(ret, fmt.Sprintf("memory=%dMB", l.MemoryMB))
+	}
+	if l.CPUSeconds > 0 {
+		ret = append(ret, fmt.Sprintf("cpu=%ds", l.CPUSeconds))
+	}
+	if l.OpenFiles > 0 {
+		ret = append(ret, fmt.Sprintf("files=%d", l.OpenFiles))
+	}
+	if l.RestrictEnv {
+		ret = append(ret, fmt.Sprintf("env=%d", len(l.Env)))
+	}
+	if l.Dir != "" {
+		ret = append(ret, "dir="+l.Dir)
+	}
+	if len(l.Namespaces) > 0 {
+		ret = append(ret, "namespaces="+strings.Join(l.Namespaces, ","))
+	}
+	if l.Seccomp {
+		ret = append(ret, "seccomp")
+	}
+	if len(ret) == 0 {
+		return "none"
+	}
+	return strings.Join(ret, " ")
+}
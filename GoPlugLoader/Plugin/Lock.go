@@ -0,0 +1,302 @@
+package Plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+const (
+	LockVersion = 1
+
+	LockStatusMissing = "missing" // In the lockfile, but not found in the plugin dir.
+	LockStatusExtra   = "extra"   // Found in the plugin dir, but not in the lockfile.
+	LockStatusChanged = "changed" // SHA-256 or version differs from the lockfile.
+)
+
+//
+// Lock - Pins the plugin files within a plugin dir, (goplug.lock).
+// ---------------------------------------------------------------------------------------------------- //
+type Lock struct {
+	LockVersion int         `json:"lock_version"`
+	Generated   time.Time   `json:"generated"`
+	Plugins     []LockEntry `json:"plugins"`
+}
+
+// LockEntry - A single pinned plugin file.
+type LockEntry struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Loader  string `json:"loader"`
+	File    string `json:"file"` // Relative to the plugin dir, with '/' separators.
+	Sha256  string `json:"sha256"`
+}
+
+// LockDiff - A difference between the lockfile and the plugin dir.
+type LockDiff struct {
+	Status string     `json:"status"`
+	File   string     `json:"file"`
+	Locked *LockEntry `json:"locked,omitempty"`
+	Found  *LockEntry `json:"found,omitempty"`
+}
+
+// NewLock - Create a new instance of this structure.
+func NewLock() *Lock {
+	return &Lock{
+		LockVersion: LockVersion,
+		Generated:   time.Now(),
+		Plugins:     make([]LockEntry, 0),
+	}
+}
+
+// NewLockEntry - Pin a plugin file. The identity is optional.
+func NewLockEntry(baseDir string, pluginPath utils.FilePath, loaderType string, identity *Identity) (LockEntry, Return.Error) {
+	var ret LockEntry
+	var err Return.Error
+
+	for range Only.Once {
+		ret.Loader = loaderType
+		ret.File = LockFile(baseDir, pluginPath)
+		if identity != nil {
+			ret.Name = identity.Name
+			ret.Version = identity.Version
+		}
+
+		ret.Sha256, err = pluginPath.Sha256()
+	}
+
+	return ret, err
+}
+
+// LockFile - The lockfile key of a plugin file, (relative to the plugin dir, with '/' separators).
+func LockFile(baseDir string, pluginPath utils.FilePath) string {
+	file := pluginPath.GetPath()
+	if rel, e := filepath.Rel(baseDir, file); e == nil {
+		file = rel
+	}
+	return filepath.ToSlash(file)
+}
+
+// LoadLock - Read a lockfile.
+func LoadLock(file string) (*Lock, Return.Error) {
+	var ret Lock
+	var err Return.Error
+
+	for range Only.Once {
+		var data []byte
+		data, err = utils.ReadFile(file)
+		if err.IsError() {
+			break
+		}
+
+		e := json.Unmarshal(data, &ret)
+		if e != nil {
+			err.SetError("lockfile %s: %s", file, e)
+			break
+		}
+
+		if ret.LockVersion != LockVersion {
+			err.SetError("lockfile %s: unsupported lock_version %d", file, ret.LockVersion)
+			break
+		}
+	}
+
+	return &ret, err
+}
+
+// Save - Write the lockfile, replacing any existing file atomically.
+func (l *Lock) Save(file string) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		l.Sort()
+		data, e := json.MarshalIndent(l, "", "\t")
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		data = append(data, '\n')
+
+		tmp := file + ".tmp"
+		err = utils.WriteFile(tmp, data)
+		if err.IsError() {
+			break
+		}
+
+		e = os.Rename(tmp, file)
+		if e != nil {
+			_ = os.Remove(tmp)
+			err.SetError(e)
+			break
+		}
+	}
+
+	return err
+}
+
+// Add - Add, or replace, a lock entry.
+func (l *Lock) Add(entry LockEntry) {
+	for index := range l.Plugins {
+		if l.Plugins[index].File == entry.File {
+			l.Plugins[index] = entry
+			return
+		}
+	}
+	l.Plugins = append(l.Plugins, entry)
+}
+
+// Get - Find the lock entry for a plugin file key, (see LockFile).
+func (l *Lock) Get(file string) *LockEntry {
+	for index := range l.Plugins {
+		if l.Plugins[index].File == file {
+			return &l.Plugins[index]
+		}
+	}
+	return nil
+}
+
+// Sort - Sort entries by file.
+func (l *Lock) Sort() {
+	sort.Slice(l.Plugins, func(i, j int) bool {
+		return l.Plugins[i].File < l.Plugins[j].File
+	})
+}
+
+// Check - Compare a single plugin file against the lockfile.
+// An empty found.Version, (identity not known), isn't compared.
+func (l *Lock) Check(found LockEntry) *LockDiff {
+	locked := l.Get(found.File)
+	switch {
+	case locked == nil:
+		return &LockDiff{Status: LockStatusExtra, File: found.File, Found: &found}
+	case locked.Sha256 != found.Sha256:
+		return &LockDiff{Status: LockStatusChanged, File: found.File, Locked: locked, Found: &found}
+	case (found.Version != "") && (locked.Version != found.Version):
+		return &LockDiff{Status: LockStatusChanged, File: found.File, Locked: locked, Found: &found}
+	}
+	return nil
+}
+
+// Compare - Compare all plugin files found against the lockfile.
+func (l *Lock) Compare(found []LockEntry) LockDiffs {
+	var ret LockDiffs
+
+	seen := make(map[string]bool)
+	for _, entry := range found {
+		seen[entry.File] = true
+		if diff := l.Check(entry); diff != nil {
+			ret = append(ret, *diff)
+		}
+	}
+
+	for index := range l.Plugins {
+		if !seen[l.Plugins[index].File] {
+			ret = append(ret, LockDiff{Status: LockStatusMissing, File: l.Plugins[index].File, Locked: &l.Plugins[index]})
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].File < ret[j].File
+	})
+	return ret
+}
+
+// String - Stringer interface.
+func (d LockDiff) String() string {
+	switch d.Status {
+	case LockStatusMissing:
+		return fmt.Sprintf("plugin '%s' (%s %s) is in the lockfile, but missing", d.File, d.Locked.Name, d.Locked.Version)
+	case LockStatusExtra:
+		return fmt.Sprintf("plugin '%s' is not in the lockfile", d.File)
+	case LockStatusChanged:
+		if d.Locked.Sha256 != d.Found.Sha256 {
+			return fmt.Sprintf("plugin '%s' has changed, sha256 %s does not match lockfile %s", d.File, d.Found.Sha256, d.Locked.Sha256)
+		}
+		return fmt.Sprintf("plugin '%s' has changed, version '%s' does not match lockfile '%s'", d.File, d.Found.Version, d.Locked.Version)
+	}
+	return fmt.Sprintf("plugin '%s': %s", d.File, d.Status)
+}
+
+//
+// LockDiffs - All differences between the lockfile and the plugin dir.
+// ---------------------------------------------------------------------------------------------------- //
+type LockDiffs []LockDiff
+
+// String - Stringer interface.
+func (d LockDiffs) String() string {
+	var ret string
+	for _, diff := range d {
+		ret += fmt.Sprintf("%-8s\t%s\n", diff.Status, diff)
+	}
+	return ret
+}
+
+//
+// LockValidator - Reports, (or refuses), plugin files that are not in the lockfile, or have changed.
+// ---------------------------------------------------------------------------------------------------- //
+type LockValidator struct {
+	Lock    *Lock
+	BaseDir string // The plugin dir, lockfile entries are relative to this.
+	Refuse  bool   // Return an error, rather than a warning.
+}
+
+// Validate is the implementation of Validator interface.
+// Expects a utils.FilePath of the plugin file within params.
+// Returns a *VerifiedFile with the locked SHA-256 if the file matches, otherwise params[0] as is.
+func (lv *LockValidator) Validate(params ...any) (any, Return.Error) {
+	var ret any
+	var err Return.Error
+
+	for range Only.Once {
+		if len(params) == 0 {
+			err.SetError("plugin file is required")
+			break
+		}
+		ret = params[0]
+
+		file := pluginFileParam(params...)
+		if file == "" {
+			err.SetError("plugin file is required")
+			break
+		}
+
+		if lv.Lock == nil {
+			err.SetError("no lockfile")
+			break
+		}
+
+		var pluginPath utils.FilePath
+		pluginPath, err = utils.NewFile(file)
+		if err.IsError() {
+			break
+		}
+
+		var found LockEntry
+		found, err = NewLockEntry(lv.BaseDir, pluginPath, "", nil)
+		if err.IsError() {
+			break
+		}
+
+		diff := lv.Lock.Check(found)
+		if diff == nil {
+			ret, err = verifiedFile(file, lv.Lock.Get(found.File).Sha256, params...)
+			break
+		}
+
+		if lv.Refuse {
+			err.SetError(diff.String())
+			break
+		}
+		err.SetWarning(diff.String())
+	}
+
+	return ret, err
+}
@@ -0,0 +1,139 @@
+package Plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/utils"
+)
+
+type LockSuite struct {
+	suite.Suite
+	dir  string
+	lock *Lock
+}
+
+// SetupTest - A plugin dir with two plugin files, both in a saved, then loaded, lockfile.
+func (s *LockSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+	lock := NewLock()
+	for _, name := range []string{"a.so", "b.so"} {
+		file := s.write(name, name)
+		entry, err := NewLockEntry(s.dir, file, "native", &Identity{Name: name, Version: "1.0.0"})
+		s.Require().False(err.IsError(), err.String())
+		lock.Add(entry)
+	}
+
+	err := lock.Save(filepath.Join(s.dir, utils.LockFileName))
+	s.Require().False(err.IsError(), err.String())
+	s.lock, err = LoadLock(filepath.Join(s.dir, utils.LockFileName))
+	s.Require().False(err.IsError(), err.String())
+	s.Require().Len(s.lock.Plugins, 2)
+}
+
+func (s *LockSuite) write(name string, data string) utils.FilePath {
+	file := filepath.Join(s.dir, name)
+	s.Require().NoError(os.WriteFile(file, []byte(data), 0o600))
+	ret, err := utils.NewFile(file)
+	s.Require().False(err.IsError(), err.String())
+	return ret
+}
+
+func (s *LockSuite) TestValidate() {
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		refuse  bool
+		error   bool
+		warning bool
+	}{
+		{name: "unchanged", file: "a.so", data: "a.so"},
+		{name: "hash mismatch", file: "a.so", data: "changed", warning: true},
+		{name: "hash mismatch refused", file: "a.so", data: "changed", refuse: true, error: true},
+		{name: "not in lockfile", file: "c.so", data: "c.so", warning: true},
+		{name: "not in lockfile refused", file: "c.so", data: "c.so", refuse: true, error: true},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.SetupTest()
+			file := s.write(test.file, test.data)
+
+			validator := LockValidator{Lock: s.lock, BaseDir: s.dir, Refuse: test.refuse}
+			ret, err := validator.Validate(file)
+			s.Equal(test.error, err.IsError(), err.String())
+			s.Equal(test.warning, err.IsWarning(), err.String())
+			if !test.error && !test.warning {
+				sum, _ := file.Sha256()
+				s.Equal(&VerifiedFile{Path: file.GetPath(), Sha256: sum}, ret)
+			}
+		})
+	}
+}
+
+func (s *LockSuite) TestChanged() {
+	file := s.write("a.so", "a.so")
+	validator := LockValidator{Lock: s.lock, BaseDir: s.dir, Refuse: true}
+	_, err := validator.Validate(&VerifiedFile{Path: file.GetPath(), Sha256: "signed digest"}, file)
+	s.True(err.IsError(), "a file verified with a different SHA-256 earlier in the chain should be refused")
+}
+
+func (s *LockSuite) TestCompare() {
+	tests := []struct {
+		name    string
+		found   map[string]string
+		version string
+		want    map[string]string
+	}{
+		{
+			name:  "unchanged",
+			found: map[string]string{"a.so": "a.so", "b.so": "b.so"},
+			want:  map[string]string{},
+		},
+		{
+			name:  "hash mismatch",
+			found: map[string]string{"a.so": "changed", "b.so": "b.so"},
+			want:  map[string]string{"a.so": LockStatusChanged},
+		},
+		{
+			name:    "version mismatch",
+			found:   map[string]string{"a.so": "a.so", "b.so": "b.so"},
+			version: "2.0.0",
+			want:    map[string]string{"a.so": LockStatusChanged, "b.so": LockStatusChanged},
+		},
+		{
+			name:  "missing and extra",
+			found: map[string]string{"a.so": "a.so", "c.so": "c.so"},
+			want:  map[string]string{"b.so": LockStatusMissing, "c.so": LockStatusExtra},
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			var found []LockEntry
+			for name, data := range test.found {
+				var identity *Identity
+				if test.version != "" {
+					identity = &Identity{Name: name, Version: test.version}
+				}
+				entry, err := NewLockEntry(s.dir, s.write(name, data), "native", identity)
+				s.Require().False(err.IsError(), err.String())
+				found = append(found, entry)
+			}
+
+			got := make(map[string]string)
+			for _, diff := range s.lock.Compare(found) {
+				got[diff.File] = diff.Status
+			}
+			s.Equal(test.want, got)
+		})
+	}
+}
+
+func TestLockSuite(t *testing.T) {
+	suite.Run(t, new(LockSuite))
+}
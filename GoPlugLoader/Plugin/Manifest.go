@@ -0,0 +1,333 @@
+package Plugin
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/MickMake/GoUnify/Only"
+	"gopkg.in/yaml.v3"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+const (
+	ManifestLoaderNative = "native"
+	ManifestLoaderRpc    = "rpc"
+)
+
+// ManifestFileNames - Manifest filenames looked for within a plugin dir, in order.
+var ManifestFileNames = []string{utils.PluginJSONFileName, utils.PluginYAMLFileName, "plugin.yml"}
+
+//
+// Manifest - Plugin metadata file, (plugin.json or plugin.yaml), placed within each plugin dir.
+// ---------------------------------------------------------------------------------------------------- //
+// The Identity fields sit at the top level of the manifest, so a plugin.json with only an Identity is still valid.
+type Manifest struct {
+	Identity
+
+	// Loader type of the entry file, ("native" or "rpc"). Empty allows either.
+	Loader string `json:"loader,omitempty"`
+
+	// Plugin file, relative to the plugin dir. Empty matches any plugin file within the dir.
+	Entry string `json:"entry,omitempty"`
+
+	// Build settings used by PluginManager.BuildPlugins().
+	Build BuildSettings `json:"build"`
+
+	// Resource limits and sandboxing for the RPC plugin process.
+	Limits *Limits `json:"limits,omitempty"`
+
+	// Base64 ed25519 signatures of plugin files within the dir, keyed by filename. See SignFile().
+	Signatures map[string]string `json:"signatures,omitempty"`
+
+	// Where the manifest was loaded from.
+	file  utils.FilePath
+	found bool
+}
+
+// LoadManifest - Load the manifest from a plugin dir.
+// A missing manifest is not an error, an empty manifest is returned instead.
+func LoadManifest(dir string) (*Manifest, Return.Error) {
+	var ret Manifest
+	var err Return.Error
+
+	for range Only.Once {
+		for _, name := range ManifestFileNames {
+			ret.file, err = utils.NewFile(filepath.Join(dir, name))
+			if err.IsError() {
+				break
+			}
+			if utils.IsFile(ret.file.GetPath()) {
+				ret.found = true
+				break
+			}
+		}
+		if err.IsError() {
+			break
+		}
+
+		if !ret.found {
+			err = Return.Ok
+			break
+		}
+
+		var data []byte
+		data, err = utils.ReadFile(ret.file.GetPath())
+		if err.IsError() {
+			break
+		}
+
+		err = ret.Parse(data, filepath.Ext(ret.file.GetPath()))
+		if err.IsError() {
+			break
+		}
+
+		err = ret.IsManifestValid()
+	}
+
+	return &ret, err
+}
+
+// Parse - Parse JSON or YAML manifest data, (depending on the file extension).
+func (m *Manifest) Parse(data []byte, ext string) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		switch strings.ToLower(ext) {
+		case ".yaml", ".yml":
+			// YAML is converted to JSON, so the json struct tags apply to both.
+			var raw any
+			e := yaml.Unmarshal(data, &raw)
+			if e != nil {
+				err.SetError("manifest %s: %s", m.file.GetPath(), e)
+				break
+			}
+
+			data, e = json.Marshal(raw)
+			if e != nil {
+				err.SetError("manifest %s: %s", m.file.GetPath(), e)
+				break
+			}
+		}
+		if err.IsError() {
+			break
+		}
+
+		e := json.Unmarshal(data, m)
+		if e != nil {
+			err.SetError("manifest %s: %s", m.file.GetPath(), e)
+			break
+		}
+	}
+
+	return err
+}
+
+// IsManifestValid - Check the manifest specific fields.
+func (m *Manifest) IsManifestValid() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		switch m.Loader {
+		case "", ManifestLoaderNative, ManifestLoaderRpc:
+		default:
+			err.SetError("manifest %s: unknown loader '%s', try '%s' or '%s'",
+				m.file.GetPath(), m.Loader, ManifestLoaderNative, ManifestLoaderRpc)
+		}
+		if err.IsError() {
+			break
+		}
+
+		err = m.Config.IsValid()
+		if err.IsError() {
+			err.SetError("manifest %s: %s", m.file.GetPath(), err.GetError())
+			break
+		}
+
+		err = m.Capabilities.IsValid()
+		if err.IsError() {
+			err.SetError("manifest %s: capabilities: %s", m.file.GetPath(), err.GetError())
+			break
+		}
+		if m.Capabilities.Has(CapabilityAll) {
+			err.SetError("manifest %s: capabilities: '%s' can only be granted, not declared", m.file.GetPath(), CapabilityAll)
+			break
+		}
+
+		if m.Limits != nil {
+			err = m.Limits.IsValid()
+			if err.IsError() {
+				err.SetError("manifest %s: limits: %s", m.file.GetPath(), err.GetError())
+				break
+			}
+		}
+	}
+
+	return err
+}
+
+// Exists - Returns true if a manifest file was found.
+func (m *Manifest) Exists() bool {
+	return m.found
+}
+
+// GetFile - Where the manifest was loaded from.
+func (m *Manifest) GetFile() *utils.FilePath {
+	return &m.file
+}
+
+// IsLoader - Returns true if the manifest allows the given loader type.
+func (m *Manifest) IsLoader(loaderType string) bool {
+	return (m.Loader == "") || (m.Loader == loaderType)
+}
+
+// IsEntry - Returns true if the manifest describes the given plugin file.
+// The entry may be given with, or without, the file extension.
+func (m *Manifest) IsEntry(pluginPath utils.FilePath) bool {
+	if m.Entry == "" {
+		return true
+	}
+
+	entry := filepath.Base(m.Entry)
+	if entry == pluginPath.GetBase() {
+		return true
+	}
+	if filepath.Ext(entry) == "" && entry == pluginPath.GetName() {
+		return true
+	}
+	return false
+}
+
+// Check - Cross-check the identity reported by a loaded plugin against the manifest.
+func (m *Manifest) Check(identity Identity) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if !m.found {
+			break
+		}
+
+		if (m.Name != "") && (m.Name != identity.Name) {
+			err.AddError("name '%s' does not match manifest '%s'", identity.Name, m.Name)
+		}
+		if (m.Version != "") && (m.Version != identity.Version) {
+			err.AddError("version '%s' does not match manifest '%s'", identity.Version, m.Version)
+		}
+		// The manifest is what gets reviewed, so the plugin can't ask for more than it declares.
+		for _, capability := range identity.Capabilities {
+			if !m.Capabilities.Has(capability) {
+				err.AddError("capability '%s' is not declared in manifest", capability)
+			}
+		}
+		if err.IsError() {
+			err.SetError("plugin does not match manifest %s: %s", m.file.GetPath(), err.GetError())
+			break
+		}
+	}
+
+	return err
+}
+
+// SetSignature - Add a plugin file signature to the manifest file.
+// Only the signatures field is changed, other fields are written back as found, (YAML comments are not kept).
+func (m *Manifest) SetSignature(fileName string, signature string) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if !m.found {
+			err.SetError("no manifest file")
+			break
+		}
+
+		var data []byte
+		data, err = utils.ReadFile(m.file.GetPath())
+		if err.IsError() {
+			break
+		}
+
+		isYaml := strings.ToLower(filepath.Ext(m.file.GetPath())) != ".json"
+		raw := make(map[string]any)
+		var e error
+		if isYaml {
+			e = yaml.Unmarshal(data, &raw)
+		} else {
+			e = json.Unmarshal(data, &raw)
+		}
+		if e != nil {
+			err.SetError("manifest %s: %s", m.file.GetPath(), e)
+			break
+		}
+
+		signatures := make(map[string]any)
+		if s, ok := raw["signatures"].(map[string]any); ok {
+			signatures = s
+		}
+		signatures[fileName] = signature
+		raw["signatures"] = signatures
+
+		if isYaml {
+			data, e = yaml.Marshal(raw)
+		} else {
+			data, e = json.MarshalIndent(raw, "", "\t")
+			data = append(data, '\n')
+		}
+		if e != nil {
+			err.SetError("manifest %s: %s", m.file.GetPath(), e)
+			break
+		}
+
+		err = utils.WriteFile(m.file.GetPath(), data)
+		if err.IsError() {
+			break
+		}
+
+		if m.Signatures == nil {
+			m.Signatures = make(map[string]string)
+		}
+		m.Signatures[fileName] = signature
+	}
+
+	return err
+}
+
+//
+// BuildSettings - Per-plugin build settings.
+// ---------------------------------------------------------------------------------------------------- //
+type BuildSettings struct {
+	// Plugin types to build, (defaults to the types configured on the manager).
+	Types *Types `json:"types,omitempty"`
+
+	// Build tags passed to 'go build -tags'.
+	Tags []string `json:"tags,omitempty"`
+
+	// Flags passed to 'go build -ldflags'.
+	LdFlags string `json:"ldflags,omitempty"`
+
+	// Flags passed to 'go build -gcflags', (defaults to "all=-N -l").
+	GcFlags *string `json:"gcflags,omitempty"`
+
+	// Build with 'go build -trimpath'.
+	TrimPath bool `json:"trimpath,omitempty"`
+
+	// Set CGO_ENABLED. Native plugins always need cgo.
+	Cgo *bool `json:"cgo,omitempty"`
+
+	// Extra environment variables, ("KEY=value").
+	Env []string `json:"env,omitempty"`
+
+	// Output filename, (without extension), relative to the plugin dir. Defaults to the plugin dir name.
+	Output string `json:"output,omitempty"`
+
+	// Directory containing go.mod, relative to the plugin dir. Defaults to the plugin dir.
+	ModDir string `json:"mod_dir,omitempty"`
+}
+
+// GetGcFlags - Return the gcflags to build with.
+func (b *BuildSettings) GetGcFlags() string {
+	if b.GcFlags == nil {
+		return "all=-N -l"
+	}
+	return *b.GcFlags
+}
@@ -0,0 +1,173 @@
+package Plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/utils"
+)
+
+type ManifestSuite struct {
+	suite.Suite
+	dir string
+}
+
+func (s *ManifestSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+}
+
+func (s *ManifestSuite) write(name string, data string) {
+	s.Require().NoError(os.WriteFile(filepath.Join(s.dir, name), []byte(data), 0o600))
+}
+
+func (s *ManifestSuite) file(name string) utils.FilePath {
+	ret, err := utils.NewFile(filepath.Join(s.dir, name))
+	s.Require().False(err.IsError(), err.String())
+	return ret
+}
+
+func (s *ManifestSuite) TestLoadManifest() {
+	tests := []struct {
+		name  string
+		file  string
+		data  string
+		want  Manifest
+		error string
+	}{
+		{name: "json", file: utils.PluginJSONFileName,
+			data: `{"name": "a", "version": "1.0.0", "loader": "rpc", "entry": "goplug-a", "capabilities": ["network"], "build": {"tags": ["x"]}}`,
+			want: Manifest{Identity: Identity{Name: "a", Version: "1.0.0", Capabilities: Capabilities{CapabilityNetwork}},
+				Loader: ManifestLoaderRpc, Entry: "goplug-a", Build: BuildSettings{Tags: []string{"x"}}}},
+		{name: "yaml", file: utils.PluginYAMLFileName,
+			data: "name: a\nversion: 1.0.0\nloader: native\nconfig:\n  port:\n    type: int\n    default: 80\n",
+			want: Manifest{Identity: Identity{Name: "a", Version: "1.0.0", Config: ConfigSchema{"port": {Type: ConfigTypeInt, Default: float64(80)}}},
+				Loader: ManifestLoaderNative}},
+		{name: "yml", file: "plugin.yml", data: "name: a\n", want: Manifest{Identity: Identity{Name: "a"}}},
+		{name: "bad json", file: utils.PluginJSONFileName, data: `{"name": `, error: "unexpected end of JSON input"},
+		{name: "bad yaml", file: utils.PluginYAMLFileName, data: "name: [", error: "yaml:"},
+		{name: "unknown loader", file: utils.PluginJSONFileName, data: `{"loader": "wasm"}`, error: "unknown loader 'wasm'"},
+		{name: "unknown config type", file: utils.PluginJSONFileName, data: `{"config": {"port": {"type": "uint"}}}`, error: "unknown type 'uint'"},
+		{name: "unknown capability", file: utils.PluginJSONFileName, data: `{"capabilities": ["root"]}`, error: "capabilities:"},
+		{name: "all capabilities", file: utils.PluginJSONFileName, data: `{"capabilities": ["*"]}`, error: "can only be granted, not declared"},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.SetupTest()
+			s.write(test.file, test.data)
+
+			manifest, err := LoadManifest(s.dir)
+			if test.error != "" {
+				s.True(err.IsError())
+				s.Contains(err.Error(), test.error)
+				return
+			}
+			s.Require().False(err.IsError(), err.String())
+			s.True(manifest.Exists())
+			s.Equal(filepath.Join(s.dir, test.file), manifest.GetFile().GetPath())
+
+			test.want.file = *manifest.GetFile()
+			test.want.found = true
+			s.Equal(&test.want, manifest)
+		})
+	}
+
+	s.SetupTest()
+	manifest, err := LoadManifest(s.dir)
+	s.False(err.IsError(), "a missing manifest is not an error")
+	s.False(manifest.Exists())
+
+	s.write(utils.PluginYAMLFileName, "name: yaml\n")
+	s.write(utils.PluginJSONFileName, `{"name": "json"}`)
+	manifest, err = LoadManifest(s.dir)
+	s.Require().False(err.IsError(), err.String())
+	s.Equal("json", manifest.Name, "plugin.json is found first")
+}
+
+func (s *ManifestSuite) TestEntry() {
+	manifest := Manifest{Loader: ManifestLoaderRpc}
+	s.True(manifest.IsEntry(s.file("goplug-a")), "any file")
+	s.True(manifest.IsLoader(ManifestLoaderRpc))
+	s.False(manifest.IsLoader(ManifestLoaderNative))
+
+	manifest = Manifest{Entry: "goplug-a"}
+	s.True(manifest.IsLoader(ManifestLoaderNative), "any loader")
+	s.True(manifest.IsEntry(s.file("goplug-a")))
+	s.True(manifest.IsEntry(s.file("goplug-a.so")), "without the extension")
+	s.False(manifest.IsEntry(s.file("goplug-b")))
+
+	manifest = Manifest{Entry: "bin/goplug-a.so"}
+	s.True(manifest.IsEntry(s.file("goplug-a.so")))
+	s.False(manifest.IsEntry(s.file("goplug-a")))
+}
+
+func (s *ManifestSuite) TestCheck() {
+	s.write(utils.PluginJSONFileName, `{"name": "a", "version": "1.0.0", "capabilities": ["network", "fs:read:/tmp"]}`)
+	manifest, err := LoadManifest(s.dir)
+	s.Require().False(err.IsError(), err.String())
+
+	tests := []struct {
+		name     string
+		identity Identity
+		errors   []string
+	}{
+		{name: "match", identity: Identity{Name: "a", Version: "1.0.0", Capabilities: Capabilities{CapabilityNetwork}}},
+		{name: "name", identity: Identity{Name: "b", Version: "1.0.0"}, errors: []string{"name 'b' does not match manifest 'a'"}},
+		{name: "version", identity: Identity{Name: "a", Version: "1.1.0"}, errors: []string{"version '1.1.0' does not match manifest '1.0.0'"}},
+		{name: "capability", identity: Identity{Name: "a", Version: "1.0.0", Capabilities: Capabilities{"fs:read:/etc"}},
+			errors: []string{"capability 'fs:read:/etc' is not declared in manifest"}},
+		{name: "all", identity: Identity{Name: "b", Version: "2.0.0", Capabilities: Capabilities{CapabilityValuesWrite}},
+			errors: []string{"name 'b'", "version '2.0.0'", "capability 'values:write'"}},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			err := manifest.Check(test.identity)
+			if len(test.errors) == 0 {
+				s.False(err.IsError(), err.String())
+				return
+			}
+			s.True(err.IsError())
+			s.Contains(err.Error(), "plugin does not match manifest "+manifest.GetFile().GetPath())
+			for _, e := range test.errors {
+				s.Contains(err.Error(), e)
+			}
+		})
+	}
+
+	manifest = &Manifest{Identity: Identity{Name: "a"}}
+	err = manifest.Check(Identity{Name: "b"})
+	s.False(err.IsError(), "no manifest file")
+}
+
+func (s *ManifestSuite) TestSetSignature() {
+	for _, name := range []string{utils.PluginJSONFileName, utils.PluginYAMLFileName} {
+		s.Run(name, func() {
+			s.SetupTest()
+			s.write(name, `{"name": "a", "loader": "rpc", "signatures": {"goplug-b": "b"}}`)
+			manifest, err := LoadManifest(s.dir)
+			s.Require().False(err.IsError(), err.String())
+
+			err = manifest.SetSignature("goplug-a", "a")
+			s.Require().False(err.IsError(), err.String())
+			s.Equal(map[string]string{"goplug-a": "a", "goplug-b": "b"}, manifest.Signatures)
+
+			manifest, err = LoadManifest(s.dir)
+			s.Require().False(err.IsError(), err.String())
+			s.Equal("a", manifest.Name, "other fields are kept")
+			s.Equal(ManifestLoaderRpc, manifest.Loader)
+			s.Equal(map[string]string{"goplug-a": "a", "goplug-b": "b"}, manifest.Signatures)
+		})
+	}
+
+	err := (&Manifest{}).SetSignature("goplug-a", "a")
+	s.True(err.IsError())
+	s.Contains(err.Error(), "no manifest file")
+}
+
+func TestManifestSuite(t *testing.T) {
+	suite.Run(t, new(ManifestSuite))
+}
@@ -0,0 +1,39 @@
+package Plugin
+
+import (
+	"strings"
+	"time"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/metrics"
+)
+
+// ObserveHook - Record a hook call that started at start, (see metrics.HookCalls).
+func ObserveHook(plugin string, hook string, start time.Time, err Return.Error) {
+	if !metrics.IsEnabled() {
+		return
+	}
+
+	labels := metrics.Labels{"plugin": plugin, "hook": hook}
+	metrics.Inc(metrics.HookCalls, labels)
+	metrics.ObserveSince(metrics.HookSeconds, start, labels)
+	if err.IsError() {
+		metrics.Inc(metrics.HookErrors, metrics.Labels{"plugin": plugin, "hook": hook, "code": string(err.GetCode())})
+	}
+}
+
+// ObserveCallback - Record the result of a callback, (see metrics.Callbacks).
+func ObserveCallback(plugin string, callback string, err Return.Error) {
+	if !metrics.IsEnabled() {
+		return
+	}
+
+	result := metrics.ResultOk
+	switch {
+	case err.IsError():
+		result = metrics.ResultError
+	case err.IsWarning():
+		result = metrics.ResultWarning
+	}
+	metrics.Inc(metrics.Callbacks, metrics.Labels{"plugin": plugin, "callback": strings.ToLower(callback), "result": result})
+}
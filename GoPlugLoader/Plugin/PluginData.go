@@ -9,11 +9,13 @@ import (
 	"time"
 
 	"github.com/MickMake/GoUnify/Only"
+	"github.com/hashicorp/go-hclog"
 	goplugin "github.com/hashicorp/go-plugin"
 
 	"github.com/MickMake/GoPlug/utils"
 	"github.com/MickMake/GoPlug/utils/Return"
 	"github.com/MickMake/GoPlug/utils/store"
+	"github.com/MickMake/GoPlug/utils/trace"
 )
 
 //
@@ -50,6 +52,16 @@ type PluginDataInterface interface {
 	// SaveIdentity - Saves the config.PluginIdentity struct as a JSON file.
 	SaveIdentity() Return.Error
 
+	// SetHost - Set the services master offers to this plugin. Called by the loader.
+	SetHost(host HostInterface) Return.Error
+	// Host - Services master offers to this plugin, (host hooks, values, other plugins).
+	Host() HostInterface
+
+	// SetConfig - Set the config loaded by master. Called by the loader, before Initialise.
+	SetConfig(config Config) Return.Error
+	// GetConfig - Config loaded by master, validated against Identity.Config.
+	GetConfig() Config
+
 	CommonInterface
 	store.PluginServiceInterface
 	DynamicDataInterface
@@ -69,6 +81,8 @@ type PluginData struct {
 	Services store.PluginServiceStruct `json:"services"`
 	Dynamic  DynamicData               `json:"dynamic"`
 	Error    Return.Error              `json:"error"`
+	host     *hostRef
+	config   *configRef
 }
 
 func NewPlugin() *PluginData {
@@ -78,6 +92,8 @@ func NewPlugin() *PluginData {
 		Services: store.NewPluginServiceStruct(),
 		Dynamic:  *NewDynamicData(PluginData{}),
 		Error:    Return.New(),
+		host:     &hostRef{},
+		config:   &configRef{},
 	}
 	ret.Dynamic.SetHookPlugin(&ret)
 	return &ret
@@ -189,6 +205,45 @@ func (p *PluginData) SaveIdentity() Return.Error {
 	return p.Error
 }
 
+// SetHost - Set the services master offers to this plugin. Copies of this structure share the host.
+func (p *PluginData) SetHost(host HostInterface) Return.Error {
+	if p.host == nil {
+		p.host = &hostRef{}
+	}
+	p.host.set(host)
+	return Return.Ok
+}
+
+// Host - Services master offers to this plugin. Calls fail until the plugin has been loaded by master.
+func (p *PluginData) Host() HostInterface {
+	if p.host != nil {
+		if host := p.host.get(); host != nil {
+			return host
+		}
+	}
+	return noHost{}
+}
+
+// SetConfig - Set the config loaded by master. Copies of this structure share the config.
+func (p *PluginData) SetConfig(config Config) Return.Error {
+	if p.config == nil {
+		p.config = &configRef{}
+	}
+	config.RegisterSecrets()
+	p.config.set(config)
+	return Return.Ok
+}
+
+// GetConfig - Config loaded by master. Empty until the plugin has been loaded by master.
+func (p *PluginData) GetConfig() Config {
+	if p.config != nil {
+		if config := p.config.get(); config != nil {
+			return config
+		}
+	}
+	return Config{}
+}
+
 //
 // ---------------------------------------------------------------------------------------------------- //
 // Mirror methods of Plugin.CommonInterface interface structure
@@ -226,6 +281,9 @@ func (p *PluginData) GetLogger() *utils.Logger {
 func (p *PluginData) SetLogFile(filename string) Return.Error {
 	return p.Common.SetLogFile(filename)
 }
+func (p *PluginData) SetLogLevel(level hclog.Level) Return.Error {
+	return p.Common.SetLogLevel(level)
+}
 func (p *PluginData) SetPluginType(name Types) Return.Error {
 	p.Dynamic.Identity.SetPluginType(name)
 	return p.Common.SetPluginType(name)
@@ -381,6 +439,9 @@ func (p *PluginData) SetHook(name string, function HookFunction, args ...any) Re
 func (p *PluginData) CallHook(name string, args ...any) (HookResponse, Return.Error) {
 	return p.Dynamic.CallHook(name, args...)
 }
+func (p *PluginData) CallHookTrace(parent trace.SpanContext, name string, args ...any) (HookResponse, Return.Error) {
+	return p.Dynamic.CallHookTrace(parent, name, args...)
+}
 func (p *PluginData) ValueExists(key string) bool {
 	return p.Dynamic.ValueExists(key)
 }
@@ -454,5 +515,5 @@ func StructToString(name string, ref any) string {
 	if err != nil {
 		return "ERROR: " + err.Error()
 	}
-	return fmt.Sprintf("#### JSON[%s] ####\n%s\n", name, data)
+	return fmt.Sprintf("#### JSON[%s] ####\n%s\n", name, utils.RedactBytes(data))
 }
@@ -0,0 +1,529 @@
+package Plugin
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/MickMake/GoUnify/Only"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+const (
+	SecretRefPrefix = "secret:"        // Config values of the form 'secret:<name>' are resolved by the manager's providers.
+	SecretEnvPrefix = "GOPLUG_SECRET_" // EnvSecrets default prefix.
+	SecretPassEnv   = "GOPLUG_SECRETS_PASSPHRASE"
+
+	SecretsFileVersion = 1
+)
+
+//
+// SecretsProvider - A source of named secrets.
+// ---------------------------------------------------------------------------------------------------- //
+type SecretsProvider interface {
+	Name() string
+	// GetSecret - Returns false if the provider doesn't hold the secret.
+	GetSecret(name string) (utils.Secret, bool, Return.Error)
+}
+
+// SecretsStore - A provider secrets can be written to.
+type SecretsStore interface {
+	SecretsProvider
+	Set(name string, value utils.Secret) Return.Error
+	Delete(name string) Return.Error
+	Names() ([]string, Return.Error)
+}
+
+// Secrets - Providers, tried in order.
+type Secrets []SecretsProvider
+
+// Get - Get a secret from the first provider that holds it.
+func (s Secrets) Get(name string) (utils.Secret, Return.Error) {
+	var ret utils.Secret
+	var err Return.Error
+
+	for range Only.Once {
+		var ok bool
+		for _, provider := range s {
+			ret, ok, err = provider.GetSecret(name)
+			if err.IsError() {
+				err.SetError("secret '%s', (%s): %s", name, provider.Name(), err.GetError())
+				break
+			}
+			if ok {
+				break
+			}
+		}
+		if err.IsError() || ok {
+			break
+		}
+
+		err.SetError("secret '%s' not found", name)
+		err.SetCode(Return.SecretNotFound)
+	}
+
+	return ret, err
+}
+
+// IsSecretRef - Returns the secret name, if the value is a 'secret:<name>' reference.
+func IsSecretRef(value any) (string, bool) {
+	str, ok := value.(string)
+	if !ok || !strings.HasPrefix(str, SecretRefPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(str, SecretRefPrefix), true
+}
+
+//
+// EnvSecrets - Secrets from environment variables, (GOPLUG_SECRET_<NAME>).
+// ---------------------------------------------------------------------------------------------------- //
+type EnvSecrets struct {
+	Prefix string
+}
+
+// NewEnvSecrets - Create a new instance of this structure. An empty prefix defaults to SecretEnvPrefix.
+func NewEnvSecrets(prefix string) *EnvSecrets {
+	if prefix == "" {
+		prefix = SecretEnvPrefix
+	}
+	return &EnvSecrets{Prefix: prefix}
+}
+
+func (s *EnvSecrets) Name() string {
+	return "env"
+}
+
+func (s *EnvSecrets) GetSecret(name string) (utils.Secret, bool, Return.Error) {
+	value, ok := os.LookupEnv(s.Prefix + configEnvPart(name))
+	if !ok {
+		return "", false, Return.Ok
+	}
+	return utils.NewSecret(value), true, Return.Ok
+}
+
+//
+// KeyringSecrets - Secrets held as files within a dir, one per secret, readable only by the owner.
+// ---------------------------------------------------------------------------------------------------- //
+type KeyringSecrets struct {
+	Dir string
+}
+
+// NewKeyringSecrets - Create a new instance of this structure.
+func NewKeyringSecrets(dir string) (*KeyringSecrets, Return.Error) {
+	var ret KeyringSecrets
+	var err Return.Error
+
+	for range Only.Once {
+		var fp utils.FilePath
+		fp, err = utils.NewDir(dir)
+		if err.IsError() {
+			break
+		}
+		ret.Dir = fp.GetPath()
+	}
+
+	return &ret, err
+}
+
+func (s *KeyringSecrets) Name() string {
+	return "keyring"
+}
+
+func (s *KeyringSecrets) GetSecret(name string) (utils.Secret, bool, Return.Error) {
+	var ret utils.Secret
+	var ok bool
+	var err Return.Error
+
+	for range Only.Once {
+		file, e := s.file(name)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		fi, e := os.Stat(file)
+		if os.IsNotExist(e) {
+			break
+		}
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		if fi.Mode().Perm()&0o077 != 0 {
+			err.SetError("'%s' is readable by others, (mode %s)", file, fi.Mode().Perm())
+			break
+		}
+
+		data, e := os.ReadFile(file)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		ret = utils.NewSecret(strings.TrimRight(string(data), "\r\n"))
+		ok = true
+	}
+
+	return ret, ok, err
+}
+
+// Set - Write a secret, readable only by the owner.
+func (s *KeyringSecrets) Set(name string, value utils.Secret) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		file, e := s.file(name)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		err = writeSecretFile(file, []byte(value.Reveal()))
+	}
+
+	return err
+}
+
+// Delete - Remove a secret.
+func (s *KeyringSecrets) Delete(name string) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		file, e := s.file(name)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		e = os.Remove(file)
+		if e != nil && !os.IsNotExist(e) {
+			err.SetError(e)
+		}
+	}
+
+	return err
+}
+
+// Names - Sorted secret names.
+func (s *KeyringSecrets) Names() ([]string, Return.Error) {
+	var ret []string
+	var err Return.Error
+
+	for range Only.Once {
+		entries, e := os.ReadDir(s.Dir)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && !strings.HasSuffix(entry.Name(), ".tmp") {
+				ret = append(ret, entry.Name())
+			}
+		}
+		sort.Strings(ret)
+	}
+
+	return ret, err
+}
+
+// file - The file holding a secret. Names can't escape the dir.
+func (s *KeyringSecrets) file(name string) (string, error) {
+	if !isSecretName(name) {
+		return "", fmt.Errorf("invalid secret name '%s'", name)
+	}
+	return filepath.Join(s.Dir, name), nil
+}
+
+//
+// EncryptedFileSecrets - Secrets held within a single file, encrypted with AES-256-GCM.
+// ---------------------------------------------------------------------------------------------------- //
+// The key is derived from a passphrase with scrypt. The file is read on first use.
+type EncryptedFileSecrets struct {
+	File       string
+	passphrase utils.Secret
+	values     map[string]string
+	lock       sync.Mutex
+}
+
+// encryptedFile - The file format.
+type encryptedFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// NewEncryptedFileSecrets - Create a new instance of this structure. The file need not exist yet.
+func NewEncryptedFileSecrets(file string, passphrase utils.Secret) (*EncryptedFileSecrets, Return.Error) {
+	var ret EncryptedFileSecrets
+	var err Return.Error
+
+	for range Only.Once {
+		if passphrase.IsEmpty() {
+			err.SetError("secrets file '%s': no passphrase", file)
+			break
+		}
+
+		var e error
+		ret.File, e = filepath.Abs(file)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		ret.passphrase = passphrase
+	}
+
+	return &ret, err
+}
+
+func (s *EncryptedFileSecrets) Name() string {
+	return "file"
+}
+
+func (s *EncryptedFileSecrets) GetSecret(name string) (utils.Secret, bool, Return.Error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err := s.read()
+	if err.IsError() {
+		return "", false, err
+	}
+
+	value, ok := s.values[name]
+	if !ok {
+		return "", false, Return.Ok
+	}
+	return utils.NewSecret(value), true, Return.Ok
+}
+
+// Set - Add, or replace, a secret and rewrite the file.
+func (s *EncryptedFileSecrets) Set(name string, value utils.Secret) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if !isSecretName(name) {
+			err.SetError("invalid secret name '%s'", name)
+			break
+		}
+
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		err = s.read()
+		if err.IsError() {
+			break
+		}
+
+		s.values[name] = value.Reveal()
+		err = s.write()
+	}
+
+	return err
+}
+
+// Delete - Remove a secret and rewrite the file.
+func (s *EncryptedFileSecrets) Delete(name string) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		err = s.read()
+		if err.IsError() {
+			break
+		}
+
+		if _, ok := s.values[name]; !ok {
+			break
+		}
+		delete(s.values, name)
+		err = s.write()
+	}
+
+	return err
+}
+
+// Names - Sorted secret names.
+func (s *EncryptedFileSecrets) Names() ([]string, Return.Error) {
+	var ret []string
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err := s.read()
+	if err.IsError() {
+		return ret, err
+	}
+
+	for name := range s.values {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret, err
+}
+
+// read - Decrypt the file, once. A missing file holds no secrets.
+func (s *EncryptedFileSecrets) read() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if s.values != nil {
+			break
+		}
+
+		data, e := os.ReadFile(s.File)
+		if os.IsNotExist(e) {
+			s.values = make(map[string]string)
+			break
+		}
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		var ef encryptedFile
+		e = json.Unmarshal(data, &ef)
+		if e != nil {
+			err.SetError("secrets file '%s': %s", s.File, e)
+			break
+		}
+		if ef.Version != SecretsFileVersion {
+			err.SetError("secrets file '%s': unsupported version %d", s.File, ef.Version)
+			break
+		}
+
+		gcm, e := s.cipher(ef.Salt)
+		if e != nil {
+			err.SetError("secrets file '%s': %s", s.File, e)
+			break
+		}
+
+		data, e = gcm.Open(nil, ef.Nonce, ef.Data, nil)
+		if e != nil {
+			err.SetError("secrets file '%s': wrong passphrase, or the file is corrupt", s.File)
+			break
+		}
+
+		values := make(map[string]string)
+		e = json.Unmarshal(data, &values)
+		if e != nil {
+			err.SetError("secrets file '%s': %s", s.File, e)
+			break
+		}
+		for _, value := range values {
+			utils.RegisterSecret(value)
+		}
+		s.values = values
+	}
+
+	return err
+}
+
+// write - Encrypt the secrets with a new salt and nonce, replacing the file atomically.
+func (s *EncryptedFileSecrets) write() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		ef := encryptedFile{
+			Version: SecretsFileVersion,
+			Salt:    make([]byte, 16),
+		}
+		_, e := rand.Read(ef.Salt)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		gcm, e := s.cipher(ef.Salt)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		ef.Nonce = make([]byte, gcm.NonceSize())
+		_, e = rand.Read(ef.Nonce)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		data, e := json.Marshal(s.values)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		ef.Data = gcm.Seal(nil, ef.Nonce, data, nil)
+
+		data, e = json.MarshalIndent(ef, "", "\t")
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		err = writeSecretFile(s.File, append(data, '\n'))
+	}
+
+	return err
+}
+
+// cipher - AES-256-GCM, keyed from the passphrase and salt.
+func (s *EncryptedFileSecrets) cipher(salt []byte) (cipher.AEAD, error) {
+	key, e := scrypt.Key([]byte(s.passphrase.Reveal()), salt, 32768, 8, 1, 32)
+	if e != nil {
+		return nil, e
+	}
+
+	block, e := aes.NewCipher(key)
+	if e != nil {
+		return nil, e
+	}
+	return cipher.NewGCM(block)
+}
+
+// writeSecretFile - Write a file readable only by the owner, replacing any existing file atomically.
+func writeSecretFile(file string, data []byte) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		tmp := file + ".tmp"
+		e := os.WriteFile(tmp, data, 0o600)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		e = os.Rename(tmp, file)
+		if e != nil {
+			_ = os.Remove(tmp)
+			err.SetError(e)
+			break
+		}
+	}
+
+	return err
+}
+
+// isSecretName - Names may hold letters, digits, '.', '-' and '_', but not start with '.'.
+func isSecretName(name string) bool {
+	if name == "" || strings.HasPrefix(name, ".") {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package Plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+type SecretsSuite struct {
+	suite.Suite
+	file    string
+	secrets *EncryptedFileSecrets
+}
+
+// SetupTest - An encrypted secrets file holding a single secret.
+func (s *SecretsSuite) SetupTest() {
+	s.file = filepath.Join(s.T().TempDir(), "secrets.json")
+
+	var err Return.Error
+	s.secrets, err = NewEncryptedFileSecrets(s.file, utils.Secret("passphrase"))
+	s.Require().False(err.IsError(), err.String())
+	err = s.secrets.Set("api-key", utils.Secret("s3cr3t-value"))
+	s.Require().False(err.IsError(), err.String())
+}
+
+// open - The secrets file as another process would see it.
+func (s *SecretsSuite) open(passphrase string) *EncryptedFileSecrets {
+	ret, err := NewEncryptedFileSecrets(s.file, utils.Secret(passphrase))
+	s.Require().False(err.IsError(), err.String())
+	return ret
+}
+
+func (s *SecretsSuite) TestEncrypted() {
+	data, e := os.ReadFile(s.file)
+	s.Require().NoError(e)
+	s.NotContains(string(data), "s3cr3t-value")
+	s.NotContains(string(data), "api-key", "names are encrypted too")
+
+	fi, e := os.Stat(s.file)
+	s.Require().NoError(e)
+	s.Equal(os.FileMode(0o600), fi.Mode().Perm())
+}
+
+func (s *SecretsSuite) TestRead() {
+	tests := []struct {
+		name       string
+		passphrase string
+		tamper     func()
+		secret     string
+		want       string
+		found      bool
+		error      string
+	}{
+		{name: "found", passphrase: "passphrase", secret: "api-key", want: "s3cr3t-value", found: true},
+		{name: "not found", passphrase: "passphrase", secret: "other"},
+		{name: "wrong passphrase", passphrase: "guess", secret: "api-key", error: "wrong passphrase"},
+		{
+			name:       "corrupt",
+			passphrase: "passphrase",
+			tamper: func() {
+				data, e := os.ReadFile(s.file)
+				s.Require().NoError(e)
+				// Flip a character of the ciphertext, (base64 within the JSON).
+				i := strings.Index(string(data), `"data": "`) + len(`"data": "`) + 4
+				if data[i] == 'A' {
+					data[i] = 'B'
+				} else {
+					data[i] = 'A'
+				}
+				s.Require().NoError(os.WriteFile(s.file, data, 0o600))
+			},
+			secret: "api-key",
+			error:  "wrong passphrase, or the file is corrupt",
+		},
+		{
+			name:       "unsupported version",
+			passphrase: "passphrase",
+			tamper: func() {
+				data, e := os.ReadFile(s.file)
+				s.Require().NoError(e)
+				data = []byte(strings.Replace(string(data), fmt.Sprintf(`"version": %d`, SecretsFileVersion), `"version": 99`, 1))
+				s.Require().NoError(os.WriteFile(s.file, data, 0o600))
+			},
+			secret: "api-key",
+			error:  "unsupported version",
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.SetupTest()
+			if test.tamper != nil {
+				test.tamper()
+			}
+
+			value, found, err := s.open(test.passphrase).GetSecret(test.secret)
+			if test.error != "" {
+				s.True(err.IsError())
+				s.Contains(err.Error(), test.error)
+				return
+			}
+			s.False(err.IsError(), err.String())
+			s.Equal(test.found, found)
+			s.Equal(test.want, value.Reveal())
+		})
+	}
+}
+
+func (s *SecretsSuite) TestSetDelete() {
+	err := s.secrets.Set("db.password", utils.Secret("another-secret"))
+	s.Require().False(err.IsError(), err.String())
+	err = s.secrets.Set(".hidden", utils.Secret("x"))
+	s.True(err.IsError(), "names starting with '.' are invalid")
+
+	names, err := s.open("passphrase").Names()
+	s.Require().False(err.IsError(), err.String())
+	s.Equal([]string{"api-key", "db.password"}, names)
+
+	err = s.secrets.Delete("api-key")
+	s.Require().False(err.IsError(), err.String())
+	names, err = s.open("passphrase").Names()
+	s.Require().False(err.IsError(), err.String())
+	s.Equal([]string{"db.password"}, names)
+}
+
+func (s *SecretsSuite) TestRedacted() {
+	value, _, err := s.open("passphrase").GetSecret("api-key")
+	s.Require().False(err.IsError(), err.String())
+	s.Equal(utils.Redacted, value.String())
+	s.Equal(utils.Redacted, fmt.Sprintf("%v", value))
+	s.NotContains(utils.Redact("key is s3cr3t-value"), "s3cr3t-value")
+
+	// Secrets JSON escapes, (see utils.RegisterSecret).
+	utils.NewSecret(`p&ss<"w0rd">`)
+	dump := StructToString("config", map[string]any{"password": `p&ss<"w0rd">`})
+	s.NotContains(dump, `p\u0026ss`)
+	s.Contains(dump, utils.Redacted)
+}
+
+func (s *SecretsSuite) TestProviders() {
+	s.T().Setenv(SecretEnvPrefix+"API_KEY", "from-env")
+	tests := []struct {
+		name      string
+		providers Secrets
+		want      string
+		notFound  bool
+	}{
+		{name: "env first", providers: Secrets{NewEnvSecrets(""), s.secrets}, want: "from-env"},
+		{name: "file first", providers: Secrets{s.secrets, NewEnvSecrets("")}, want: "s3cr3t-value"},
+		{name: "none", providers: Secrets{NewEnvSecrets("OTHER_")}, notFound: true},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			value, err := test.providers.Get("api-key")
+			if test.notFound {
+				s.Equal(Return.SecretNotFound, err.GetCode())
+				return
+			}
+			s.False(err.IsError(), err.String())
+			s.Equal(test.want, value.Reveal())
+		})
+	}
+}
+
+func TestSecretsSuite(t *testing.T) {
+	suite.Run(t, new(SecretsSuite))
+}
@@ -0,0 +1,433 @@
+package Plugin
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+const (
+	SignatureFileExtension  = ".sig"
+	PrivateKeyFileExtension = ".key"
+	PublicKeyFileExtension  = ".pub"
+)
+
+//
+// TrustedKeys - ed25519 public keys that plugins may be signed with, keyed by name.
+// ---------------------------------------------------------------------------------------------------- //
+type TrustedKeys map[string]ed25519.PublicKey
+
+// NewTrustedKeys - Create a new instance of this structure.
+func NewTrustedKeys() TrustedKeys {
+	return make(TrustedKeys)
+}
+
+// LoadTrustedKeys - Load public keys from one or more files.
+// Each line is "<name> <base64 key>", or just "<base64 key>". Blank lines and '#' comments are ignored.
+// The '.pub' files written by GenerateKey() can be used as is, or concatenated.
+func LoadTrustedKeys(files ...string) (TrustedKeys, Return.Error) {
+	keys := NewTrustedKeys()
+	var err Return.Error
+
+	for range Only.Once {
+		for _, file := range files {
+			var data []byte
+			data, err = utils.ReadFile(file)
+			if err.IsError() {
+				break
+			}
+
+			err = keys.Parse(data, strings.TrimSuffix(filepath.Base(file), PublicKeyFileExtension))
+			if err.IsError() {
+				err.SetError("trusted keys %s: %s", file, err.GetError())
+				break
+			}
+		}
+	}
+
+	return keys, err
+}
+
+// Parse - Add public keys from "<name> <base64 key>" lines. Unnamed keys are named after defaultName.
+func (k TrustedKeys) Parse(data []byte, defaultName string) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		var line int
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+
+			name := defaultName
+			fields := strings.Fields(text)
+			switch len(fields) {
+			case 1:
+				if _, ok := k[name]; ok {
+					name = fmt.Sprintf("%s-%d", defaultName, line)
+				}
+			case 2:
+				name = fields[0]
+			default:
+				err.SetError("line %d: expecting '<name> <base64 key>'", line)
+			}
+			if err.IsError() {
+				break
+			}
+
+			var key ed25519.PublicKey
+			key, err = ParsePublicKey(fields[len(fields)-1])
+			if err.IsError() {
+				err.SetError("line %d: %s", line, err.GetError())
+				break
+			}
+			k[name] = key
+		}
+	}
+
+	return err
+}
+
+// Add - Add a single public key.
+func (k TrustedKeys) Add(name string, key ed25519.PublicKey) {
+	k[name] = key
+}
+
+// Names - Sorted key names.
+func (k TrustedKeys) Names() []string {
+	var ret []string
+	for name := range k {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Verify - Returns the name of the key that signed the digest.
+func (k TrustedKeys) Verify(digest []byte, signature []byte) (string, bool) {
+	for _, name := range k.Names() {
+		if ed25519.Verify(k[name], digest, signature) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// ParsePublicKey - Decode a base64 ed25519 public key.
+func ParsePublicKey(value string) (ed25519.PublicKey, Return.Error) {
+	var err Return.Error
+	data, e := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if e != nil {
+		err.SetError("invalid public key: %s", e)
+		return nil, err
+	}
+	if len(data) != ed25519.PublicKeySize {
+		err.SetError("invalid public key: expecting %d bytes, got %d", ed25519.PublicKeySize, len(data))
+		return nil, err
+	}
+	return data, err
+}
+
+// LoadPrivateKey - Load a base64 ed25519 private key, (or seed), from a file.
+func LoadPrivateKey(file string) (ed25519.PrivateKey, Return.Error) {
+	var key ed25519.PrivateKey
+	var err Return.Error
+
+	for range Only.Once {
+		var data []byte
+		data, err = utils.ReadFile(file)
+		if err.IsError() {
+			break
+		}
+
+		var value string
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "#") {
+				value = line
+				break
+			}
+		}
+
+		raw, e := base64.StdEncoding.DecodeString(value)
+		if e != nil {
+			err.SetError("private key %s: %s", file, e)
+			break
+		}
+
+		switch len(raw) {
+		case ed25519.SeedSize:
+			key = ed25519.NewKeyFromSeed(raw)
+		case ed25519.PrivateKeySize:
+			key = raw
+		default:
+			err.SetError("private key %s: expecting %d bytes, got %d", file, ed25519.SeedSize, len(raw))
+		}
+	}
+
+	return key, err
+}
+
+// GenerateKey - Create a new key pair, written to <dir>/<name>.key and <dir>/<name>.pub.
+// Returns the filenames written.
+func GenerateKey(dir string, name string, force bool) ([]string, Return.Error) {
+	var files []string
+	var err Return.Error
+
+	for range Only.Once {
+		public, private, e := ed25519.GenerateKey(rand.Reader)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		keyFile := filepath.Join(dir, name+PrivateKeyFileExtension)
+		pubFile := filepath.Join(dir, name+PublicKeyFileExtension)
+		if !force {
+			for _, file := range []string{keyFile, pubFile} {
+				if utils.IsFile(file) {
+					err.SetError("file '%s' already exists", file)
+					break
+				}
+			}
+			if err.IsError() {
+				break
+			}
+		}
+
+		data := fmt.Sprintf("# GoPlug ed25519 private key '%s' - keep secret\n%s\n",
+			name, base64.StdEncoding.EncodeToString(private.Seed()))
+		e = os.WriteFile(keyFile, []byte(data), 0600)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		files = append(files, keyFile)
+
+		data = fmt.Sprintf("%s %s\n", name, base64.StdEncoding.EncodeToString(public))
+		e = os.WriteFile(pubFile, []byte(data), 0644)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		files = append(files, pubFile)
+	}
+
+	return files, err
+}
+
+// FileDigest - SHA-256 of a file, which is what gets signed.
+func FileDigest(file string) ([]byte, Return.Error) {
+	var err Return.Error
+
+	f, e := os.Open(file)
+	if e != nil {
+		err.SetError(e)
+		return nil, err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer f.Close()
+
+	h := sha256.New()
+	_, e = io.Copy(h, f)
+	if e != nil {
+		err.SetError(e)
+		return nil, err
+	}
+	return h.Sum(nil), err
+}
+
+// SignFile - Sign the SHA-256 of a plugin file.
+// Writes a detached <file>.sig, or, with embed, adds the signature to the plugin manifest.
+// Returns the file written.
+func SignFile(file string, key ed25519.PrivateKey, embed bool) (string, Return.Error) {
+	var written string
+	var err Return.Error
+
+	for range Only.Once {
+		var digest []byte
+		digest, err = FileDigest(file)
+		if err.IsError() {
+			break
+		}
+		signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, digest))
+
+		if !embed {
+			written = file + SignatureFileExtension
+			err = utils.WriteFile(written, []byte(signature+"\n"))
+			break
+		}
+
+		var manifest *Manifest
+		manifest, err = LoadManifest(filepath.Dir(file))
+		if err.IsError() {
+			break
+		}
+		if !manifest.Exists() {
+			err.SetError("no manifest found in '%s' to embed the signature in", filepath.Dir(file))
+			break
+		}
+
+		err = manifest.SetSignature(filepath.Base(file), signature)
+		if err.IsError() {
+			break
+		}
+		written = manifest.GetFile().GetPath()
+	}
+
+	return written, err
+}
+
+// VerifySignature - Verify the signature of a plugin file against the trusted keys.
+// The signature is read from a detached <file>.sig, or from the plugin manifest.
+// Returns the name of the key that signed the file.
+func VerifySignature(file string, keys TrustedKeys) (string, Return.Error) {
+	signer, _, err := verifySignature(file, keys)
+	return signer, err
+}
+
+// verifySignature - Returns the name of the key that signed the file, and the digest it signed.
+func verifySignature(file string, keys TrustedKeys) (string, []byte, Return.Error) {
+	var signer string
+	var digest []byte
+	var err Return.Error
+
+	for range Only.Once {
+		var signature string
+		sigFile := file + SignatureFileExtension
+		if utils.IsFile(sigFile) {
+			var data []byte
+			data, err = utils.ReadFile(sigFile)
+			if err.IsError() {
+				break
+			}
+			signature = strings.TrimSpace(string(data))
+		} else {
+			var manifest *Manifest
+			manifest, err = LoadManifest(filepath.Dir(file))
+			if err.IsError() {
+				break
+			}
+			signature = manifest.Signatures[filepath.Base(file)]
+		}
+
+		if signature == "" {
+			err.SetError("plugin '%s' is not signed", file)
+			break
+		}
+
+		raw, e := base64.StdEncoding.DecodeString(signature)
+		if e != nil || len(raw) != ed25519.SignatureSize {
+			err.SetError("plugin '%s' has an invalid signature", file)
+			break
+		}
+
+		digest, err = FileDigest(file)
+		if err.IsError() {
+			break
+		}
+
+		var ok bool
+		signer, ok = keys.Verify(digest, raw)
+		if !ok {
+			err.SetError("plugin '%s' signature does not match any trusted key, (modified, or signed with an untrusted key)", file)
+			break
+		}
+	}
+
+	return signer, digest, err
+}
+
+//
+// SignatureValidator - Refuses plugin files that are not signed by a trusted key.
+// ---------------------------------------------------------------------------------------------------- //
+type SignatureValidator struct {
+	Keys TrustedKeys
+}
+
+// Validate is the implementation of Validator interface.
+// Expects a utils.FilePath, (or path string), of the plugin file within params. Returns a *VerifiedFile with the signed SHA-256.
+func (sv *SignatureValidator) Validate(params ...any) (any, Return.Error) {
+	var ret any
+	var err Return.Error
+
+	for range Only.Once {
+		if len(params) == 0 {
+			err.SetError("plugin file is required")
+			break
+		}
+		ret = params[0]
+
+		file := pluginFileParam(params...)
+		if file == "" {
+			err.SetError("plugin file is required")
+			break
+		}
+
+		if len(sv.Keys) == 0 {
+			err.SetError("no trusted keys, refusing plugin '%s'", file)
+			break
+		}
+
+		var digest []byte
+		_, digest, err = verifySignature(file, sv.Keys)
+		if err.IsError() {
+			break
+		}
+		ret, err = verifiedFile(file, hex.EncodeToString(digest), params...)
+	}
+
+	return ret, err
+}
+
+// pluginFileParam - Find the plugin file within validator params.
+func pluginFileParam(params ...any) string {
+	for _, param := range params {
+		switch v := param.(type) {
+		case string:
+			return v
+		case utils.FilePath:
+			return v.GetPath()
+		case *utils.FilePath:
+			if v != nil {
+				return v.GetPath()
+			}
+		case *VerifiedFile:
+			if v != nil {
+				return v.Path
+			}
+		}
+	}
+	return ""
+}
+
+// verifiedFile - The result of a validator that verified the SHA-256 of file.
+// Fails if an earlier validator in the chain verified it with a different SHA-256, (the file changed in between).
+func verifiedFile(file string, sum string, params ...any) (*VerifiedFile, Return.Error) {
+	var err Return.Error
+	for _, param := range params {
+		if v, ok := param.(*VerifiedFile); ok && v != nil && v.Sha256 != sum {
+			err.SetError("plugin '%s' changed while being validated", file)
+			return nil, err
+		}
+	}
+	return &VerifiedFile{Path: file, Sha256: sum}, err
+}
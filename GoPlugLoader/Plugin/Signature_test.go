@@ -0,0 +1,132 @@
+package Plugin
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SignatureSuite struct {
+	suite.Suite
+	dir     string
+	file    string
+	key     ed25519.PrivateKey
+	trusted TrustedKeys
+}
+
+// SetupTest - A plugin file, signed with a detached signature by a trusted key.
+func (s *SignatureSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+	files, err := GenerateKey(s.dir, "test", false)
+	s.Require().False(err.IsError(), err.String())
+	s.Require().Len(files, 2)
+
+	s.key, err = LoadPrivateKey(filepath.Join(s.dir, "test"+PrivateKeyFileExtension))
+	s.Require().False(err.IsError(), err.String())
+	s.trusted, err = LoadTrustedKeys(filepath.Join(s.dir, "test"+PublicKeyFileExtension))
+	s.Require().False(err.IsError(), err.String())
+
+	s.file = filepath.Join(s.dir, "plugin.so")
+	s.Require().NoError(os.WriteFile(s.file, []byte("plugin"), 0o600))
+	_, err = SignFile(s.file, s.key, false)
+	s.Require().False(err.IsError(), err.String())
+}
+
+func (s *SignatureSuite) TestValidate() {
+	_, other, e := ed25519.GenerateKey(rand.Reader)
+	s.Require().NoError(e)
+
+	tests := []struct {
+		name   string
+		tamper func()
+		keys   func() TrustedKeys
+		refuse bool
+	}{
+		{
+			name: "signed",
+		},
+		{
+			name: "file modified",
+			tamper: func() {
+				s.Require().NoError(os.WriteFile(s.file, []byte("plugin!"), 0o600))
+			},
+			refuse: true,
+		},
+		{
+			name: "signature modified",
+			tamper: func() {
+				sig := ed25519.Sign(s.key, []byte("something else"))
+				s.Require().NoError(os.WriteFile(s.file+SignatureFileExtension, []byte(base64.StdEncoding.EncodeToString(sig)), 0o600))
+			},
+			refuse: true,
+		},
+		{
+			name: "signature invalid",
+			tamper: func() {
+				s.Require().NoError(os.WriteFile(s.file+SignatureFileExtension, []byte("not base64!"), 0o600))
+			},
+			refuse: true,
+		},
+		{
+			name: "signature missing",
+			tamper: func() {
+				s.Require().NoError(os.Remove(s.file + SignatureFileExtension))
+			},
+			refuse: true,
+		},
+		{
+			name: "untrusted key",
+			tamper: func() {
+				_, err := SignFile(s.file, other, false)
+				s.Require().False(err.IsError(), err.String())
+			},
+			refuse: true,
+		},
+		{
+			name: "no trusted keys",
+			keys: func() TrustedKeys {
+				return NewTrustedKeys()
+			},
+			refuse: true,
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.SetupTest()
+			if test.tamper != nil {
+				test.tamper()
+			}
+			keys := s.trusted
+			if test.keys != nil {
+				keys = test.keys()
+			}
+
+			validator := SignatureValidator{Keys: keys}
+			_, err := validator.Validate(s.file)
+			s.Equal(test.refuse, err.IsError(), err.String())
+		})
+	}
+}
+
+func (s *SignatureSuite) TestVerifySigner() {
+	signer, err := VerifySignature(s.file, s.trusted)
+	s.Require().False(err.IsError(), err.String())
+	s.Equal("test", signer)
+
+	validator := SignatureValidator{Keys: s.trusted}
+	ret, err := validator.Validate(s.file)
+	s.Require().False(err.IsError(), err.String())
+	digest, _ := FileDigest(s.file)
+	s.Equal(&VerifiedFile{Path: s.file, Sha256: hex.EncodeToString(digest)}, ret)
+}
+
+func TestSignatureSuite(t *testing.T) {
+	suite.Run(t, new(SignatureSuite))
+}
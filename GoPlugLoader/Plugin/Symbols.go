@@ -0,0 +1,25 @@
+package Plugin
+
+//
+// Symbols - Registry of the symbols exported by a native plugin.
+// ---------------------------------------------------------------------------------------------------- //
+// A native plugin may export this as GoPluginSymbols, either as a global:
+//
+//	var GoPluginSymbols = Plugin.Symbols{"GoPluginIdentity", "MyNativePlugin"}
+//
+// or as a function, (useful when generated):
+//
+//	func GoPluginSymbols() Plugin.Symbols { return Plugin.Symbols{"GoPluginIdentity", "MyNativePlugin"} }
+//
+// Without it, exported symbols are found from the plugin's dynamic symbol table.
+type Symbols []string
+
+// Has - Returns true if the symbol is listed.
+func (s Symbols) Has(symbol string) bool {
+	for _, name := range s {
+		if name == symbol {
+			return true
+		}
+	}
+	return false
+}
@@ -1,10 +1,9 @@
 package Plugin
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 
 	"github.com/Masterminds/semver"
 	"github.com/MickMake/GoUnify/Only"
@@ -23,6 +22,16 @@ type Validator interface {
 	Validate(params ...any) (any, Return.Error)
 }
 
+//
+// VerifiedFile - Returned by validators that verify the SHA-256 of a plugin file, (signature, lockfile).
+// ---------------------------------------------------------------------------------------------------- //
+// The RPC loader pins the checksum go-plugin verifies just before exec to Sha256, so the binary
+// executed is the one that was validated.
+type VerifiedFile struct {
+	Path   string // The plugin file.
+	Sha256 string // Hex encoded.
+}
+
 // ---------------------------------------------------------------------------------------------------- //
 
 // BaseValidatorChain build a validation pipeline with 'JSONFileValidator' and 'IdentityValidator'.
@@ -54,6 +63,7 @@ func NewBaseValidatorChain(validators ...Validator) Validator {
 func (bvc *BaseValidatorChain) Validate(params ...any) (any, Return.Error) {
 	var ret any
 	var err Return.Error
+	var warning Return.Error
 
 	for range Only.Once {
 		ret = nil
@@ -81,6 +91,15 @@ func (bvc *BaseValidatorChain) Validate(params ...any) (any, Return.Error) {
 			if err.IsError() {
 				break
 			}
+
+			// Keep warnings from earlier validators.
+			if err.IsWarning() {
+				warning.AddWarning(err.GetWarning())
+			}
+		}
+
+		if err.IsNotError() && warning.IsWarning() {
+			err = warning
 		}
 	}
 
@@ -89,7 +108,7 @@ func (bvc *BaseValidatorChain) Validate(params ...any) (any, Return.Error) {
 
 // ---------------------------------------------------------------------------------------------------- //
 
-// JSONFileValidator validates the existence of plugin.json.
+// JSONFileValidator validates the existence of plugin.json, (or plugin.yaml).
 type JSONFileValidator struct{}
 
 // Validate is the implementation of Validator interface
@@ -117,37 +136,28 @@ func (jfv *JSONFileValidator) Validate(params ...any) (any, Return.Error) {
 			break
 		}
 
-		pluginJSONFile := filepath.Join(pluginDirPath, utils.PluginJSONFileName)
-		_, err = utils.FileExists(pluginJSONFile)
+		// Load plugin.json, or plugin.yaml
+		var manifest *Manifest
+		manifest, err = LoadManifest(pluginDirPath)
 		if err.IsError() {
-			err.AddError("%s is not found under plugin dir %s", utils.PluginJSONFileName, pluginDirPath)
 			break
 		}
-
-		data, e := os.ReadFile(pluginJSONFile)
-		if e != nil {
-			err.SetError(e)
-			break
-		}
-
-		// Load plugin.json
-		identity := &Identity{}
-		e = json.Unmarshal(data, identity)
-		if e != nil {
-			err.SetError(e)
+		if !manifest.Exists() {
+			err.SetError("%s is not found under plugin dir %s", strings.Join(ManifestFileNames, " or "), pluginDirPath)
 			break
 		}
+		identity := &manifest.Identity
 
 		// Plugin dir name should be equal with the name of the plugin
-		var fi os.FileInfo
-		fi, e = os.Stat(pluginDirPath)
+		fi, e := os.Stat(pluginDirPath)
 		if e != nil {
 			err.SetError(e)
 			// Actually, should not come here
 			break
 		}
 
-		if fi.Name() != identity.Name {
+		// Allow for a filename prefix, (goplug-<name>).
+		if (fi.Name() != identity.Name) && !strings.HasSuffix(fi.Name(), "-"+identity.Name) {
 			err.SetError("Name conflicts: expect %s but got %s in the metadata json file", fi.Name(), identity.Name)
 			break
 		}
@@ -5,12 +5,14 @@ import (
 	sysPlugin "plugin"
 
 	"github.com/MickMake/GoUnify/Only"
+	"github.com/hashicorp/go-hclog"
 	goplugin "github.com/hashicorp/go-plugin"
 
 	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
 	"github.com/MickMake/GoPlug/utils"
 	"github.com/MickMake/GoPlug/utils/Return"
 	"github.com/MickMake/GoPlug/utils/store"
+	"github.com/MickMake/GoPlug/utils/trace"
 )
 
 //
@@ -171,6 +173,18 @@ func (p *PluginItem) IdentifyString() string {
 func (p *PluginItem) SaveIdentity() Return.Error {
 	return p.Pluggable.SaveIdentity()
 }
+func (p *PluginItem) SetHost(host Plugin.HostInterface) Return.Error {
+	return p.Pluggable.SetHost(host)
+}
+func (p *PluginItem) Host() Plugin.HostInterface {
+	return p.Pluggable.Host()
+}
+func (p *PluginItem) SetConfig(config Plugin.Config) Return.Error {
+	return p.Pluggable.SetConfig(config)
+}
+func (p *PluginItem) GetConfig() Plugin.Config {
+	return p.Pluggable.GetConfig()
+}
 func (p *PluginItem) String() string {
 	return p.Pluggable.String()
 }
@@ -267,6 +281,9 @@ func (p *PluginItem) GetLogger() *utils.Logger {
 func (p *PluginItem) SetLogFile(filename string) Return.Error {
 	return p.Pluggable.SetLogFile(filename)
 }
+func (p *PluginItem) SetLogLevel(level hclog.Level) Return.Error {
+	return p.Pluggable.SetLogLevel(level)
+}
 func (p *PluginItem) SetPluginType(name Plugin.Types) Return.Error {
 	return p.Pluggable.SetPluginType(name)
 }
@@ -397,6 +414,9 @@ func (p *PluginItem) SetHook(name string, function Plugin.HookFunction, args ...
 func (p *PluginItem) CallHook(name string, args ...any) (Plugin.HookResponse, Return.Error) {
 	return p.Pluggable.CallHook(name, args...)
 }
+func (p *PluginItem) CallHookTrace(parent trace.SpanContext, name string, args ...any) (Plugin.HookResponse, Return.Error) {
+	return p.Pluggable.CallHookTrace(parent, name, args...)
+}
 func (p *PluginItem) ValueExists(key string) bool {
 	return p.Pluggable.ValueExists(key)
 }
@@ -0,0 +1,68 @@
+package GoPlugLoader
+
+import (
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// ParseManifest - Read the manifest describing a plugin file, without loading the plugin.
+func ParseManifest(pluginPath utils.FilePath, loaderType string) (*Plugin.Manifest, Return.Error) {
+	var manifest *Plugin.Manifest
+	var err Return.Error
+
+	for range Only.Once {
+		manifest, err = Plugin.LoadManifest(pluginPath.GetDir())
+		if err.IsError() {
+			break
+		}
+
+		if !manifest.Exists() {
+			err.SetError("no manifest found for plugin '%s'", pluginPath.GetPath())
+			break
+		}
+
+		if !manifest.IsEntry(pluginPath) {
+			err.SetError("manifest %s describes entry '%s', not '%s'",
+				manifest.GetFile().GetPath(), manifest.Entry, pluginPath.GetBase())
+			break
+		}
+
+		if !manifest.IsLoader(loaderType) {
+			err.SetError("manifest %s is for a %s plugin, not %s",
+				manifest.GetFile().GetPath(), manifest.Loader, loaderType)
+			break
+		}
+	}
+
+	return manifest, err
+}
+
+// CheckManifest - Cross-check the identity reported by a loaded plugin against its manifest, if it has one.
+func CheckManifest(pluginPath utils.FilePath, loaderType string, identity Plugin.Identity) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		var manifest *Plugin.Manifest
+		manifest, err = Plugin.LoadManifest(pluginPath.GetDir())
+		if err.IsError() {
+			break
+		}
+
+		if !manifest.Exists() || !manifest.IsEntry(pluginPath) {
+			break
+		}
+
+		if !manifest.IsLoader(loaderType) {
+			err.SetError("manifest %s is for a %s plugin, not %s",
+				manifest.GetFile().GetPath(), manifest.Loader, loaderType)
+			break
+		}
+
+		err = manifest.Check(identity)
+	}
+
+	return err
+}
@@ -189,6 +189,7 @@ func (ps *PluginStoreStruct) StoreGet(name string) (*PluginItem, Return.Error) {
 
 		if item == nil {
 			ps.Error.SetError("plugin %s is not loaded", name)
+			ps.Error.SetCode(Return.PluginNotFound)
 			break
 		}
 	}
@@ -228,6 +229,7 @@ func (ps *PluginStoreStruct) StoreRemove(name string) (*PluginItem, Return.Error
 		item, ok = ps.hash[name]
 		if !ok {
 			ps.Error.SetError("plugin %s is not loaded", name)
+			ps.Error.SetCode(Return.PluginNotFound)
 			break
 		}
 
@@ -0,0 +1,194 @@
+package GoPlugLoader
+
+import (
+	"net/rpc"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/trace"
+)
+
+// ---------------------------------------------------------------------------------------------------- //
+// Master's host services, served back to an RPC plugin process over the go-plugin MuxBroker.
+
+//
+// HostCallArgs - Arguments of a host, or inter-plugin, hook call.
+// ---------------------------------------------------------------------------------------------------- //
+type HostCallArgs struct {
+	Plugin      string `json:"plugin,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Args        []any  `json:"args,omitempty"`
+	Traceparent string `json:"traceparent,omitempty"` // W3C trace context of the hook calling.
+}
+
+//
+// HostValueArgs - Arguments of a host value write.
+// ---------------------------------------------------------------------------------------------------- //
+type HostValueArgs struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+//
+// HostHttpArgs - Arguments of a host HTTP request.
+// ---------------------------------------------------------------------------------------------------- //
+type HostHttpArgs struct {
+	Url     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+//
+// HostFileArgs - Arguments of a host file write.
+// ---------------------------------------------------------------------------------------------------- //
+type HostFileArgs struct {
+	Path string `json:"path"`
+	Data []byte `json:"data"`
+}
+
+//
+// RpcHostServer - Master side, serves Plugin.HostInterface to the plugin process.
+// ---------------------------------------------------------------------------------------------------- //
+type RpcHostServer struct {
+	Host Plugin.HostInterface
+}
+
+func (s *RpcHostServer) CallHostHook(args HostCallArgs, resp *Plugin.HookResponse) error {
+	var err Return.Error
+	*resp, err = s.withTrace(args).CallHostHook(args.Name, args.Args...)
+	return err.GetRemoteError()
+}
+
+func (s *RpcHostServer) CallPluginHook(args HostCallArgs, resp *Plugin.HookResponse) error {
+	var err Return.Error
+	*resp, err = s.withTrace(args).CallPluginHook(args.Plugin, args.Name, args.Args...)
+	return err.GetRemoteError()
+}
+
+// withTrace - The host, calling hooks within the trace of the plugin's hook, if any.
+func (s *RpcHostServer) withTrace(args HostCallArgs) Plugin.HostInterface {
+	parent, _ := trace.ParseTraceparent(args.Traceparent)
+	return Plugin.HostWithTrace(s.Host, parent)
+}
+
+func (s *RpcHostServer) GetHostValue(key string, resp *any) error {
+	var err Return.Error
+	*resp, err = s.Host.GetHostValue(key)
+	return err.GetRemoteError()
+}
+
+func (s *RpcHostServer) SetHostValue(args HostValueArgs, _ *any) error {
+	err := s.Host.SetHostValue(args.Key, args.Value)
+	return err.GetRemoteError()
+}
+
+func (s *RpcHostServer) HttpGet(args HostHttpArgs, resp *[]byte) error {
+	var err Return.Error
+	*resp, err = s.Host.HttpGet(args.Url, args.Headers)
+	return err.GetRemoteError()
+}
+
+func (s *RpcHostServer) ReadFile(path string, resp *[]byte) error {
+	var err Return.Error
+	*resp, err = s.Host.ReadFile(path)
+	return err.GetRemoteError()
+}
+
+func (s *RpcHostServer) WriteFile(args HostFileArgs, _ *any) error {
+	err := s.Host.WriteFile(args.Path, args.Data)
+	return err.GetRemoteError()
+}
+
+func (s *RpcHostServer) CheckCapability(capability string, _ *any) error {
+	err := s.Host.CheckCapability(capability)
+	return err.GetRemoteError()
+}
+
+//
+// RpcHostClient - Plugin side, implements Plugin.HostInterface by calling master.
+// ---------------------------------------------------------------------------------------------------- //
+type RpcHostClient struct {
+	Client *rpc.Client
+	parent trace.SpanContext
+}
+
+// WithTrace - Implements Plugin.TracedHost.
+func (c *RpcHostClient) WithTrace(parent trace.SpanContext) Plugin.HostInterface {
+	return &RpcHostClient{Client: c.Client, parent: parent}
+}
+
+func (c *RpcHostClient) CallHostHook(name string, args ...any) (Plugin.HookResponse, Return.Error) {
+	var err Return.Error
+	var resp Plugin.HookResponse
+	e := c.Client.Call("Plugin.CallHostHook", &HostCallArgs{Name: name, Args: args, Traceparent: c.parent.Traceparent()}, &resp)
+	if e != nil {
+		err = Return.NewRemoteError(e)
+	}
+	return resp, err
+}
+
+func (c *RpcHostClient) CallPluginHook(plugin string, name string, args ...any) (Plugin.HookResponse, Return.Error) {
+	var err Return.Error
+	var resp Plugin.HookResponse
+	e := c.Client.Call("Plugin.CallPluginHook", &HostCallArgs{Plugin: plugin, Name: name, Args: args, Traceparent: c.parent.Traceparent()}, &resp)
+	if e != nil {
+		err = Return.NewRemoteError(e)
+	}
+	return resp, err
+}
+
+func (c *RpcHostClient) GetHostValue(key string) (any, Return.Error) {
+	var err Return.Error
+	var resp any
+	e := c.Client.Call("Plugin.GetHostValue", key, &resp)
+	if e != nil {
+		err = Return.NewRemoteError(e)
+	}
+	return resp, err
+}
+
+func (c *RpcHostClient) SetHostValue(key string, value any) Return.Error {
+	var err Return.Error
+	e := c.Client.Call("Plugin.SetHostValue", &HostValueArgs{Key: key, Value: value}, new(any))
+	if e != nil {
+		err = Return.NewRemoteError(e)
+	}
+	return err
+}
+
+func (c *RpcHostClient) HttpGet(url string, headers map[string]string) ([]byte, Return.Error) {
+	var err Return.Error
+	var resp []byte
+	e := c.Client.Call("Plugin.HttpGet", &HostHttpArgs{Url: url, Headers: headers}, &resp)
+	if e != nil {
+		err = Return.NewRemoteError(e)
+	}
+	return resp, err
+}
+
+func (c *RpcHostClient) ReadFile(path string) ([]byte, Return.Error) {
+	var err Return.Error
+	var resp []byte
+	e := c.Client.Call("Plugin.ReadFile", path, &resp)
+	if e != nil {
+		err = Return.NewRemoteError(e)
+	}
+	return resp, err
+}
+
+func (c *RpcHostClient) WriteFile(path string, data []byte) Return.Error {
+	var err Return.Error
+	e := c.Client.Call("Plugin.WriteFile", &HostFileArgs{Path: path, Data: data}, new(any))
+	if e != nil {
+		err = Return.NewRemoteError(e)
+	}
+	return err
+}
+
+func (c *RpcHostClient) CheckCapability(capability string) Return.Error {
+	var err Return.Error
+	e := c.Client.Call("Plugin.CheckCapability", capability, new(any))
+	if e != nil {
+		err = Return.NewRemoteError(e)
+	}
+	return err
+}
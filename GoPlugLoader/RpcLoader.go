@@ -3,6 +3,7 @@ package GoPlugLoader
 import (
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
 	"github.com/MickMake/GoPlug/utils"
 	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/trace"
 )
 
 //
@@ -32,6 +34,7 @@ func NewRpcLoader(dir *utils.FilePath, file *utils.FilePath, cfg *Plugin.Identit
 		Files:   nil,
 		logger:  logger,
 		store:   NewPluginStore(),
+		faults:  NewPluginFaults(),
 		Error:   err,
 	}
 }
@@ -66,6 +69,124 @@ func (l *RpcLoader) IsLoaderType(loaderType string) bool {
 	return false
 }
 
+// SetPreLoadValidator - Validate each plugin file before it is executed.
+func (l *RpcLoader) SetPreLoadValidator(validator Plugin.Validator) Return.Error {
+	l.preLoad = validator
+	return Return.Ok
+}
+
+// SetRpcSecurity - Set how the RPC channel to each plugin is secured.
+func (l *RpcLoader) SetRpcSecurity(config RpcSecurityConfig) Return.Error {
+	for range Only.Once {
+		l.Error = config.Default.IsValid()
+		if l.Error.IsError() {
+			break
+		}
+
+		for name, security := range config.Plugins {
+			l.Error = security.IsValid()
+			if l.Error.IsError() {
+				l.Error.SetError("plugin '%s': %s", name, l.Error.GetError())
+				break
+			}
+		}
+		if l.Error.IsError() {
+			break
+		}
+
+		l.security = config
+	}
+
+	return l.Error
+}
+
+// SetRpcLimits - Set the resource limits and sandboxing applied to each plugin process.
+func (l *RpcLoader) SetRpcLimits(config RpcLimitsConfig) Return.Error {
+	for range Only.Once {
+		l.Error = config.Default.IsValid()
+		if l.Error.IsError() {
+			break
+		}
+
+		for name, limits := range config.Plugins {
+			l.Error = limits.IsValid()
+			if l.Error.IsError() {
+				l.Error.SetError("plugin '%s': %s", name, l.Error.GetError())
+				break
+			}
+		}
+		if l.Error.IsError() {
+			break
+		}
+
+		l.limits = config
+	}
+
+	return l.Error
+}
+
+// SetRpcOutput - Set where the stdout and stderr of each plugin process go.
+func (l *RpcLoader) SetRpcOutput(config RpcOutputConfig) Return.Error {
+	for range Only.Once {
+		l.Error = config.IsValid()
+		if l.Error.IsError() {
+			break
+		}
+
+		l.output = config
+	}
+
+	return l.Error
+}
+
+// SetGrants - Capabilities granted to plugins. Plugins not granted network run within a network namespace, (Linux only).
+func (l *RpcLoader) SetGrants(grants Plugin.Grants) Return.Error {
+	for range Only.Once {
+		l.Error = grants.IsValid()
+		if l.Error.IsError() {
+			break
+		}
+
+		l.grants = grants
+	}
+
+	return l.Error
+}
+
+// GetPluginFaults - Plugin processes that exited while loaded.
+func (l *RpcLoader) GetPluginFaults() []PluginFault {
+	return l.faults.Get()
+}
+
+// OnPluginFault - Call handler when a plugin process exits while loaded.
+func (l *RpcLoader) OnPluginFault(handler func(fault PluginFault)) {
+	l.faults.OnFault(handler)
+}
+
+// SetHostFactory - Give each plugin its host services once it's loaded, before it's initialised.
+func (l *RpcLoader) SetHostFactory(factory Plugin.HostFactory) Return.Error {
+	l.host = factory
+	return Return.Ok
+}
+
+// SetConfigFactory - Give each plugin its config once it's loaded, before it's initialised.
+func (l *RpcLoader) SetConfigFactory(factory Plugin.ConfigFactory) Return.Error {
+	l.config = factory
+	return Return.Ok
+}
+
+// SetValuesFactory - Restore each plugin's values once it's loaded, before it's initialised.
+func (l *RpcLoader) SetValuesFactory(factory Plugin.ValuesFactory) Return.Error {
+	l.values = factory
+	return Return.Ok
+}
+
+// SetLogLevels - Set each plugin's log level once it's loaded, before it's initialised.
+func (l *RpcLoader) SetLogLevels(levels utils.LogLevels) Return.Error {
+	l.levels = levels
+	return Return.Ok
+}
+
 func (l *RpcLoader) SetPrefix(prefix string) Return.Error {
 	l.prefix = prefix
 	return l.Error
@@ -126,6 +247,7 @@ func (l *RpcLoader) PluginScan(glob string) Return.Error {
 			break
 		}
 		l.Files.RemoveExtensions(NativePluginExtensions...)
+		l.Files.RemoveExtensions(Plugin.SignatureFileExtension, filepath.Ext(utils.LockFileName))
 	}
 	return l.Error
 }
@@ -166,30 +288,96 @@ func (l *RpcLoader) PluginUnregister() Return.Error {
 }
 
 func (l *RpcLoader) PluginLoad(pluginPath utils.FilePath) (PluginItem, Return.Error) {
+	start := time.Now()
+	span := trace.Start("load", trace.SpanContext{}, "loader", RpcLoaderName, "file", pluginPath.GetName())
 	var item PluginItem
 
 	for range Only.Once {
+		var sum string
+		sum, l.Error = preLoadCheck(l.preLoad, pluginPath)
+		if l.Error.IsError() {
+			break
+		}
+
 		id := strings.TrimPrefix(pluginPath.GetName(), l.prefix)
-		item.Pluggable = NewRpcPlugin()
+		plug := NewRpcPlugin()
+		plug.RpcService.Security = l.security.Get(id)
+		plug.RpcService.Security.Sha256 = sum
+
+		// Limits set on the manager take precedence over those in the manifest.
+		var manifestLimits *Plugin.Limits
+		if manifest, err := ParseManifest(pluginPath, RpcLoaderName); err.IsNotError() {
+			manifestLimits = manifest.Limits
+		}
+		limits := l.limits.Get(id, manifestLimits)
+		if l.grants.IsEnforced() && !l.grants.Get(id).Has(Plugin.CapabilityNetwork) {
+			if sandboxNamespaces {
+				limits.Namespaces = append(append([]string{}, limits.Namespaces...), Plugin.NamespaceNet)
+			} else {
+				log.Printf("[WARN]: Plugin(%s): network access can't be restricted on this platform", id)
+			}
+		}
+		plug.RpcService.Output = NewRpcOutput(id, l.output)
+		if !limits.IsEmpty() {
+			plug.RpcService.Sandbox = NewRpcSandbox(id, pluginPath, limits)
+			plug.RpcService.Sandbox.OnFault = l.faults.Report
+			plug.RpcService.Sandbox.Output = plug.RpcService.Output
+		}
+		item.Pluggable = plug
 
 		l.Error = item.Pluggable.PluginLoad(id, pluginPath)
 		if l.Error.IsError() {
 			break
 		}
 
+		l.Error = CheckManifest(pluginPath, RpcLoaderName, item.Pluggable.GetIdentity())
+		if l.Error.IsError() {
+			_ = item.Pluggable.PluginUnload()
+			break
+		}
+
+		l.Error = setHost(l.host, item)
+		if l.Error.IsError() {
+			_ = item.Pluggable.PluginUnload()
+			break
+		}
+
+		l.Error = setConfig(l.config, item)
+		if l.Error.IsError() {
+			_ = item.Pluggable.PluginUnload()
+			break
+		}
+
+		l.Error = setValues(l.values, item)
+		if l.Error.IsError() {
+			_ = item.Pluggable.PluginUnload()
+			break
+		}
+
+		l.Error = setLogLevel(l.levels, item)
+		if l.Error.IsError() {
+			closeValues(&item)
+			_ = item.Pluggable.PluginUnload()
+			break
+		}
+
+		initSpan := trace.Start("initialise", span.Context, "plugin", item.Pluggable.GetName())
 		l.Error = l.PluginInit(item)
+		initSpan.End(l.Error)
 		if l.Error.IsError() {
+			closeValues(&item)
+			_ = item.Pluggable.PluginUnload()
 			break
 		}
 
 		l.Error = l.StorePut(&item, true)
 	}
 
+	observeLoad(RpcLoaderName, start, l.Error)
+	span.End(l.Error)
 	return item, l.Error
 }
 func (l *RpcLoader) PluginUnload(path utils.FilePath) Return.Error {
-	var err Return.Error
-
 	for range Only.Once {
 		var plug *PluginItem
 		plug, l.Error = l.StoreGet(path.GetPath())
@@ -203,6 +391,12 @@ func (l *RpcLoader) PluginUnload(path utils.FilePath) Return.Error {
 			break
 		}
 
+		l.Error = plug.PluginUnload()
+		if l.Error.IsError() {
+			break
+		}
+		closeValues(plug)
+
 		_, l.Error = l.store.StoreRemove(path.GetPath())
 		if l.Error.IsError() {
 			l.Error.SetError("[INFO]: Plugin(%s): Unload FAILED", path.String())
@@ -211,7 +405,7 @@ func (l *RpcLoader) PluginUnload(path utils.FilePath) Return.Error {
 		}
 	}
 
-	return err
+	return l.Error
 }
 
 func (l *RpcLoader) PluginInit(items ...PluginItem) Return.Error {
@@ -230,7 +424,7 @@ func (l *RpcLoader) PluginInit(items ...PluginItem) Return.Error {
 
 			itemData.SetValue("slave-init-timestamp", time.Now())
 
-			l.Error = item.Initialise()
+			l.Error = item.Initialise(item.GetConfig())
 			if l.Error.IsError() {
 				itemData.SetValue("slave-init", l.Error)
 				break
@@ -242,9 +436,21 @@ func (l *RpcLoader) PluginInit(items ...PluginItem) Return.Error {
 
 	return l.Error
 }
+
+// PluginParse - Read the plugin identity from the manifest next to the plugin file, without loading it.
 func (l *RpcLoader) PluginParse(path utils.FilePath) (*Plugin.Identity, Return.Error) {
-	l.Error.SetError("Parse() not implemented yet in PluginLoader: %s", path)
-	return nil, l.Error
+	var identity *Plugin.Identity
+
+	for range Only.Once {
+		var manifest *Plugin.Manifest
+		manifest, l.Error = ParseManifest(path, RpcLoaderName)
+		if l.Error.IsError() {
+			break
+		}
+		identity = &manifest.Identity
+	}
+
+	return identity, l.Error
 }
 
 //
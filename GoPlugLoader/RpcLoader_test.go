@@ -0,0 +1,108 @@
+//go:build !windows
+
+package GoPlugLoader
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+type RpcLoaderSuite struct {
+	suite.Suite
+	dir string
+}
+
+// SetupSuite - Build the test plugins, (see testdata).
+func (s *RpcLoaderSuite) SetupSuite() {
+	if testing.Short() {
+		s.T().Skip("builds plugins")
+	}
+
+	s.dir = s.T().TempDir()
+	for _, name := range []string{"goplug-initfail", "goplug-hello"} {
+		cmd := exec.Command(filepath.Join(runtime.GOROOT(), "bin", "go"), "build", "-o", filepath.Join(s.dir, name), ".")
+		cmd.Dir = filepath.Join("testdata", name)
+		cmd.Env = append(os.Environ(), "GOPROXY=off")
+		out, e := cmd.CombinedOutput()
+		s.Require().NoError(e, string(out))
+	}
+}
+
+// load - Load a test plugin, with its pid written to pidFile when initialised.
+func (s *RpcLoaderSuite) load(name string, pidFile string) (*RpcLoader, PluginItem, Return.Error) {
+	s.T().Setenv("GOPLUG_TEST_PID_FILE", pidFile)
+
+	loader := NewRpcLoader(nil, nil, nil, nil).(*RpcLoader)
+	err := loader.SetPrefix("goplug-")
+	s.Require().False(err.IsError(), err.String())
+	path, err := utils.NewFile(filepath.Join(s.dir, name))
+	s.Require().False(err.IsError(), err.String())
+
+	item, err := loader.PluginLoad(path)
+	return loader, item, err
+}
+
+// pid - The pid of the plugin process, written when initialised.
+func (s *RpcLoaderSuite) pid(pidFile string) int {
+	data, e := os.ReadFile(pidFile)
+	s.Require().NoError(e, "the plugin wasn't initialised")
+	pid, e := strconv.Atoi(string(data))
+	s.Require().NoError(e)
+	return pid
+}
+
+// exited - Wait for the plugin process to exit. Signal 0 fails once it has, (and been reaped).
+func (s *RpcLoaderSuite) exited(pid int) {
+	s.Eventually(func() bool {
+		return syscall.Kill(pid, 0) != nil
+	}, 10*time.Second, 50*time.Millisecond, "the plugin process %d is still running", pid)
+}
+
+func (s *RpcLoaderSuite) TestInitFailed() {
+	pidFile := filepath.Join(s.T().TempDir(), "pid")
+	loader, _, err := s.load("goplug-initfail", pidFile)
+	s.Require().True(err.IsError())
+	s.Contains(err.Error(), "initialise failed")
+	s.Zero(loader.StoreSize(), "a plugin that fails to initialise isn't stored")
+	s.exited(s.pid(pidFile))
+}
+
+func (s *RpcLoaderSuite) TestConnection() {
+	pidFile := filepath.Join(s.T().TempDir(), "pid")
+	loader, item, err := s.load("goplug-hello", pidFile)
+	s.Require().False(err.IsError(), err.String())
+	pid := s.pid(pidFile)
+	want := fmt.Sprintf("Hello: world, from %d", pid)
+
+	// The connection is kept open after loading, so hooks can be called any time later.
+	for i := 0; i < 3; i++ {
+		resp, err := item.CallHook("Hello", "world")
+		s.Require().False(err.IsError(), err.String())
+		s.Equal(want, resp.Value)
+	}
+	s.Nil(syscall.Kill(pid, 0), "one process serves every call")
+
+	err = loader.PluginUnload(item.GetFilename())
+	s.Require().False(err.IsError(), err.String())
+	s.exited(pid)
+
+	_, err = item.CallHook("Hello", "world")
+	s.True(err.IsError())
+	s.Contains(err.Error(), "hook 'Hello' has no function", "the hook only exists within the plugin process")
+}
+
+func TestRpcLoaderSuite(t *testing.T) {
+	suite.Run(t, new(RpcLoaderSuite))
+}
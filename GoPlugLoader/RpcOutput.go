@@ -0,0 +1,213 @@
+package GoPlugLoader
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/MickMake/GoUnify/Only"
+	"github.com/hashicorp/go-hclog"
+	goplugin "github.com/hashicorp/go-plugin"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+const (
+	DefaultOutputLines    = 100      // Lines of output kept for fault reports.
+	DefaultOutputMaxSize  = 10 << 20 // Size a plugin log file is rotated at.
+	DefaultOutputMaxFiles = 3        // Rotated plugin log files kept.
+)
+
+//
+// RpcOutputConfig - Where the stdout and stderr of RPC plugin processes go.
+// ---------------------------------------------------------------------------------------------------- //
+type RpcOutputConfig struct {
+	Dir      string `json:"dir,omitempty"`       // Each plugin's output is also written to <dir>/<plugin>.log, if set.
+	MaxSize  int64  `json:"max_size,omitempty"`  // Size, in bytes, a log file is rotated at, (defaults to DefaultOutputMaxSize).
+	MaxFiles int    `json:"max_files,omitempty"` // Rotated log files kept, (defaults to DefaultOutputMaxFiles).
+	Lines    int    `json:"lines,omitempty"`     // Last lines kept in memory, (defaults to DefaultOutputLines).
+}
+
+// IsValid - Sizes can't be negative.
+func (c RpcOutputConfig) IsValid() Return.Error {
+	var err Return.Error
+	if c.MaxSize < 0 || c.MaxFiles < 0 || c.Lines < 0 {
+		err.SetError("plugin output sizes can't be negative")
+	}
+	return err
+}
+
+// String - Stringer interface.
+func (c RpcOutputConfig) String() string {
+	if c.Dir == "" {
+		return fmt.Sprintf("logger only, last %d lines kept", c.getLines())
+	}
+	return fmt.Sprintf("logger and '%s', rotated at %d bytes, last %d lines kept", c.Dir, c.getMaxSize(), c.getLines())
+}
+
+func (c RpcOutputConfig) getLines() int {
+	if c.Lines == 0 {
+		return DefaultOutputLines
+	}
+	return c.Lines
+}
+
+func (c RpcOutputConfig) getMaxSize() int64 {
+	if c.MaxSize == 0 {
+		return DefaultOutputMaxSize
+	}
+	return c.MaxSize
+}
+
+func (c RpcOutputConfig) getMaxFiles() int {
+	if c.MaxFiles == 0 {
+		return DefaultOutputMaxFiles
+	}
+	return c.MaxFiles
+}
+
+//
+// RpcOutput - The stdout and stderr of a single RPC plugin process.
+// ---------------------------------------------------------------------------------------------------- //
+// Each line is prefixed with the plugin name, and passed to the plugin's logger, an optional log file,
+// and the last lines kept for fault reports, (see PluginFault.Output).
+type RpcOutput struct {
+	Name   string
+	Config RpcOutputConfig
+	stdout *utils.LineWriter
+	stderr *utils.LineWriter
+	logger *utils.Logger
+	file   *utils.RotateFile
+	lines  []string
+	next   int
+	lock   sync.Mutex
+}
+
+// NewRpcOutput - Create a new instance of this structure.
+func NewRpcOutput(name string, config RpcOutputConfig) *RpcOutput {
+	ret := RpcOutput{
+		Name:   name,
+		Config: config,
+	}
+	ret.stdout = utils.NewLineWriter(func(line string) {
+		ret.line(fmt.Sprintf("[%s] %s", name, line), lineLevel(line))
+	})
+	ret.stderr = utils.NewLineWriter(func(line string) {
+		ret.line(fmt.Sprintf("[%s:stderr] %s", name, line), lineLevel(line))
+	})
+	return &ret
+}
+
+// Apply - Route the plugin process output to logger, and the log file, if any.
+func (o *RpcOutput) Apply(config *goplugin.ClientConfig, logger *utils.Logger) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		o.lock.Lock()
+		o.logger = logger
+		o.lock.Unlock()
+
+		if o.Config.Dir != "" {
+			var file *utils.RotateFile
+			file, err = utils.NewRotateFile(filepath.Join(o.Config.Dir, o.Name+".log"), o.Config.getMaxSize(), o.Config.getMaxFiles())
+			if err.IsError() {
+				break
+			}
+
+			o.lock.Lock()
+			o.file = file
+			o.lock.Unlock()
+		}
+
+		config.SyncStdout = o.stdout
+		config.SyncStderr = o.stderr
+	}
+
+	return err
+}
+
+// Tail - The last lines of output, oldest first.
+func (o *RpcOutput) Tail() []string {
+	if o == nil {
+		return nil
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	ret := make([]string, 0, len(o.lines))
+	if len(o.lines) < o.Config.getLines() {
+		return append(ret, o.lines...)
+	}
+	ret = append(ret, o.lines[o.next:]...)
+	return append(ret, o.lines[:o.next]...)
+}
+
+// String - Stringer interface.
+func (o *RpcOutput) String() string {
+	return strings.Join(o.Tail(), "\n")
+}
+
+// Close - Pass on any partial lines, and close the log file.
+func (o *RpcOutput) Close() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if o == nil {
+			break
+		}
+
+		o.stdout.Flush()
+		o.stderr.Flush()
+
+		o.lock.Lock()
+		//goland:noinspection GoDeferInLoop
+		defer o.lock.Unlock()
+		if o.file != nil {
+			err = o.file.Close()
+			o.file = nil
+		}
+	}
+
+	return err
+}
+
+// line - Pass on a single line of output.
+func (o *RpcOutput) line(line string, level hclog.Level) {
+	// Secrets are never logged, (see utils.RegisterSecret).
+	line = utils.Redact(line)
+
+	o.lock.Lock()
+	if max := o.Config.getLines(); len(o.lines) < max {
+		o.lines = append(o.lines, line)
+	} else {
+		o.lines[o.next] = line
+		o.next = (o.next + 1) % max
+	}
+	if o.file != nil {
+		_, _ = o.file.Write([]byte(line + "\n"))
+	}
+	logger := o.logger
+	o.lock.Unlock()
+
+	if logger != nil {
+		logger.Log(level, line)
+	}
+}
+
+// lineLevel - The level of a JSON log line from the plugin, (see utils.LogFormatEnv), or Info.
+func lineLevel(line string) hclog.Level {
+	var entry struct {
+		Level string `json:"@level"`
+	}
+	if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &entry) != nil {
+		return hclog.Info
+	}
+	if level := hclog.LevelFromString(entry.Level); level != hclog.NoLevel {
+		return level
+	}
+	return hclog.Info
+}
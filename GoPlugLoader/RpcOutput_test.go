@@ -0,0 +1,207 @@
+package GoPlugLoader
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	goplugin "github.com/hashicorp/go-plugin"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+type RpcOutputSuite struct {
+	suite.Suite
+	log    *bytes.Buffer
+	logger utils.Logger
+	config goplugin.ClientConfig
+}
+
+// SetupTest - A logger writing to a buffer.
+func (s *RpcOutputSuite) SetupTest() {
+	var err Return.Error
+	s.logger, err = utils.NewLoggerWithOptions("plugin", utils.LoggerOptions{Level: hclog.Info})
+	s.Require().False(err.IsError(), err.String())
+	s.log = new(bytes.Buffer)
+	s.logger.SetOutput(s.log)
+	s.config = goplugin.ClientConfig{}
+}
+
+// output - An RpcOutput applied to the plugin client config.
+func (s *RpcOutputSuite) output(config RpcOutputConfig) *RpcOutput {
+	output := NewRpcOutput("a", config)
+	err := output.Apply(&s.config, &s.logger)
+	s.Require().False(err.IsError(), err.String())
+	s.Require().NotNil(s.config.SyncStdout)
+	s.Require().NotNil(s.config.SyncStderr)
+	return output
+}
+
+func (s *RpcOutputSuite) write(stdout string, stderr string) {
+	_, e := s.config.SyncStdout.Write([]byte(stdout))
+	s.Require().NoError(e)
+	_, e = s.config.SyncStderr.Write([]byte(stderr))
+	s.Require().NoError(e)
+}
+
+func (s *RpcOutputSuite) TestPrefix() {
+	output := s.output(RpcOutputConfig{})
+	s.write("one\ntwo\r\nthr", "error\n")
+	s.Equal([]string{"[a] one", "[a] two", "[a:stderr] error"}, output.Tail())
+
+	s.write("ee\nfour", "")
+	err := output.Close()
+	s.Require().False(err.IsError(), err.String())
+	s.Equal([]string{"[a] one", "[a] two", "[a:stderr] error", "[a] three", "[a] four"}, output.Tail(), "partial lines are kept until closed")
+	s.Equal("[a] one\n[a] two\n[a:stderr] error\n[a] three\n[a] four", output.String())
+
+	log := s.log.String()
+	for _, line := range output.Tail() {
+		s.Contains(log, "[INFO]  plugin: plugin => "+line+"\n")
+	}
+}
+
+func (s *RpcOutputSuite) TestLevel() {
+	s.logger.SetLevel(hclog.Warn)
+	output := s.output(RpcOutputConfig{})
+	s.write(`{"@level":"debug","@message":"debug"}`+"\n"+`{"@level":"warn","@message":"warn"}`+"\n", "{not json}\n")
+	s.Len(output.Tail(), 3, "every line is kept, whatever the level")
+
+	log := s.log.String()
+	s.NotContains(log, `"debug"`)
+	s.Contains(log, `[WARN]  plugin: plugin => [a] {"@level":"warn","@message":"warn"}`)
+	s.NotContains(log, "{not json}", "info")
+
+	tests := []struct {
+		line  string
+		level hclog.Level
+	}{
+		{line: "plain", level: hclog.Info},
+		{line: `{"@level":"error"}`, level: hclog.Error},
+		{line: `{"@level":"trace","@message":"x"}`, level: hclog.Trace},
+		{line: `{"@level":"loud"}`, level: hclog.Info},
+		{line: `{"@level":`, level: hclog.Info},
+		{line: `{}`, level: hclog.Info},
+	}
+	for _, test := range tests {
+		s.Equal(test.level, lineLevel(test.line), test.line)
+	}
+}
+
+func (s *RpcOutputSuite) TestRedact() {
+	utils.NewSecret("rpc-output-secret")
+	output := s.output(RpcOutputConfig{Dir: s.T().TempDir()})
+	s.write("key rpc-output-secret\n", "")
+	err := output.Close()
+	s.Require().False(err.IsError(), err.String())
+
+	s.Equal([]string{"[a] key " + utils.Redacted}, output.Tail())
+	s.NotContains(s.log.String(), "rpc-output-secret")
+	data, e := os.ReadFile(filepath.Join(output.Config.Dir, "a.log"))
+	s.Require().NoError(e)
+	s.Equal("[a] key "+utils.Redacted+"\n", string(data))
+}
+
+func (s *RpcOutputSuite) TestTail() {
+	tests := []struct {
+		name  string
+		lines int
+		write int
+		want  []string
+	}{
+		{name: "none", lines: 3, want: []string{}},
+		{name: "fewer", lines: 3, write: 2, want: []string{"[a] 1", "[a] 2"}},
+		{name: "full", lines: 3, write: 3, want: []string{"[a] 1", "[a] 2", "[a] 3"}},
+		{name: "wrapped", lines: 3, write: 5, want: []string{"[a] 3", "[a] 4", "[a] 5"}},
+		{name: "wrapped twice", lines: 3, write: 7, want: []string{"[a] 5", "[a] 6", "[a] 7"}},
+		{name: "default", write: DefaultOutputLines + 1, want: []string{"[a] 2", "[a] " + fmt.Sprint(DefaultOutputLines+1)}},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			output := s.output(RpcOutputConfig{Lines: test.lines})
+			for i := 1; i <= test.write; i++ {
+				s.write(fmt.Sprintf("%d\n", i), "")
+			}
+
+			tail := output.Tail()
+			if test.name == "default" {
+				s.Len(tail, DefaultOutputLines)
+				s.Equal(test.want, []string{tail[0], tail[len(tail)-1]})
+				return
+			}
+			s.Equal(test.want, tail)
+		})
+	}
+
+	var output *RpcOutput
+	s.Nil(output.Tail())
+	s.Equal("", output.String())
+	err := output.Close()
+	s.False(err.IsError(), "nil output")
+}
+
+func (s *RpcOutputSuite) TestRotate() {
+	dir := filepath.Join(s.T().TempDir(), "logs")
+	output := s.output(RpcOutputConfig{Dir: dir, MaxSize: 22, MaxFiles: 2})
+
+	// Each line is 11 bytes, so two fit in each file.
+	for i := 1; i <= 7; i++ {
+		s.write(fmt.Sprintf("line %d\n", i), "")
+	}
+	err := output.Close()
+	s.Require().False(err.IsError(), err.String())
+
+	files := map[string]string{
+		"a.log":   "[a] line 7\n",
+		"a.log.1": "[a] line 5\n[a] line 6\n",
+		"a.log.2": "[a] line 3\n[a] line 4\n",
+	}
+	entries, e := os.ReadDir(dir)
+	s.Require().NoError(e)
+	s.Len(entries, len(files), "older files are removed")
+	for name, want := range files {
+		data, e := os.ReadFile(filepath.Join(dir, name))
+		s.Require().NoError(e, name)
+		s.Equal(want, string(data), name)
+	}
+
+	// A new process appends to the same file.
+	output = s.output(RpcOutputConfig{Dir: dir, MaxSize: 22, MaxFiles: 2})
+	s.write("line 8\n", "")
+	err = output.Close()
+	s.Require().False(err.IsError(), err.String())
+	data, e := os.ReadFile(filepath.Join(dir, "a.log"))
+	s.Require().NoError(e)
+	s.Equal("[a] line 7\n[a] line 8\n", string(data))
+	s.Equal([]string{"[a] line 8"}, output.Tail(), "only the output of this process")
+}
+
+func (s *RpcOutputSuite) TestConfig() {
+	err := RpcOutputConfig{}.IsValid()
+	s.False(err.IsError(), err.String())
+	for _, config := range []RpcOutputConfig{{MaxSize: -1}, {MaxFiles: -1}, {Lines: -1}} {
+		err := config.IsValid()
+		s.True(err.IsError(), "%+v", config)
+		s.Contains(err.Error(), "can't be negative")
+	}
+
+	s.Equal(fmt.Sprintf("logger only, last %d lines kept", DefaultOutputLines), RpcOutputConfig{}.String())
+	s.True(strings.HasPrefix(RpcOutputConfig{Dir: "logs", Lines: 5}.String(), "logger and 'logs', rotated at"))
+
+	// The dir can't be created.
+	file := filepath.Join(s.T().TempDir(), "file")
+	s.Require().NoError(os.WriteFile(file, nil, 0o600))
+	err = NewRpcOutput("a", RpcOutputConfig{Dir: file}).Apply(&s.config, &s.logger)
+	s.True(err.IsError())
+}
+
+func TestRpcOutputSuite(t *testing.T) {
+	suite.Run(t, new(RpcOutputSuite))
+}
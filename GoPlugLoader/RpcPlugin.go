@@ -6,14 +6,18 @@ import (
 	"net/rpc"
 	"os"
 	"os/exec"
+	"time"
 
 	"github.com/MickMake/GoUnify/Only"
+	"github.com/hashicorp/go-hclog"
 	goplugin "github.com/hashicorp/go-plugin"
 
 	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
 	"github.com/MickMake/GoPlug/utils"
 	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/audit"
 	"github.com/MickMake/GoPlug/utils/store"
+	"github.com/MickMake/GoPlug/utils/trace"
 )
 
 //
@@ -97,7 +101,7 @@ func (p *RpcPlugin) GetPluginPath() *utils.FilePath {
 }
 
 func (p *RpcPlugin) Initialise(args ...any) Return.Error {
-	return p.PluginData.Callback(Plugin.CallbackInitialise, &p.PluginData, args...)
+	return p.callback(Plugin.CallbackInitialise, args...)
 }
 
 func (p *RpcPlugin) Execute(args ...any) Return.Error {
@@ -109,7 +113,15 @@ func (p *RpcPlugin) Run(args ...any) Return.Error {
 }
 
 func (p *RpcPlugin) Notify(args ...any) Return.Error {
-	return p.PluginData.Callback(Plugin.CallbackNotify, &p.PluginData, args...)
+	return p.callback(Plugin.CallbackNotify, args...)
+}
+
+// callback - The callbacks only exist on the plugin side, so calls from the host are forwarded over the RPC connection.
+func (p *RpcPlugin) callback(callback string, args ...any) Return.Error {
+	if p.RpcService.Client == nil {
+		return p.PluginData.Callback(callback, &p.PluginData, args...)
+	}
+	return p.RpcService.Client.Callback(callback, args...)
 }
 
 // ---------------------------------------------------------------------------------------------------- //
@@ -129,6 +141,9 @@ func (p *RpcPlugin) Serve() Return.Error {
 			break
 		}
 
+		// Master reads the plugin's stderr, (see RpcOutput).
+		utils.SetStandardLog(os.Stderr)
+
 		goplugin.Serve(&p.RpcService.ServerConfig)
 	}
 	return p.Error
@@ -185,6 +200,9 @@ func (p *RpcPlugin) Validate() Return.Error {
 			Plugins:         p.Services.GetAsRpcPluginSet(),
 			GRPCServer:      goplugin.DefaultGRPCServer,
 			Logger:          p.Common.Logger.Gethclog(),
+			// With AutoMTLS, go-plugin sets up TLS from the certificate master passes, (PLUGIN_CLIENT_CERT).
+			// With a pinned CA, the certificates are read from the files master passes.
+			TLSProvider: ServerTLSProvider(),
 		}
 
 		p.Error = p.Services.SetRpcService(p.Dynamic.Identity.Name, &RpcPlugin{
@@ -262,32 +280,67 @@ func (p *RpcPlugin) PluginLoad(id string, pluginPath utils.FilePath) Return.Erro
 
 		// ---------------------------------------------------------------------------------------------------- //
 		// Load the plugin and pull in configured data.
+		cmd := exec.Command(pluginPath.GetPath())
+		if file := trace.GetFile(); file != "" {
+			// Spans from both sides of a hook call go to the same file.
+			cmd.Env = append(cmd.Env, trace.FileEnv+"="+file)
+		}
 		p.RpcService.ClientConfig = goplugin.ClientConfig{
 			HandshakeConfig: Plugin.HandshakeConfig,
 			Plugins:         p.PluginData.Services.GetAsRpcPluginSet(),
-			Cmd:             exec.Command(pluginPath.GetPath()),
+			Cmd:             cmd,
 		}
 		p.RpcService.ClientConfig.Logger = plog.Gethclog()
+		p.Error = p.RpcService.Security.Apply(&p.RpcService.ClientConfig, pluginPath)
+		if p.Error.IsError() {
+			break
+		}
+		plog.Debug("RPC channel: %s", p.RpcService.Security)
+
+		if p.RpcService.Sandbox != nil {
+			p.Error = p.RpcService.Sandbox.Apply(&p.RpcService.ClientConfig, cmd)
+			if p.Error.IsError() {
+				break
+			}
+			plog.Debug("RPC limits: %s", p.RpcService.Sandbox.Limits)
+		}
+
+		if p.RpcService.Output != nil {
+			p.Error = p.RpcService.Output.Apply(&p.RpcService.ClientConfig, &plog)
+			if p.Error.IsError() {
+				break
+			}
+			plog.Debug("RPC output: %s", p.RpcService.Output.Config)
+		}
 		p.SetRpcService(p.Common.Id, &GoPluginMaster{}) // p)
 
 		var e error
 		p.RpcService.ClientRef = goplugin.NewClient(&p.RpcService.ClientConfig)
 		if p.RpcService.ClientRef == nil {
 			p.Error.SetError("[%s]: ERROR: RPC client is nil", p.PluginData.Common.Id)
+			p.Error.SetCode(Return.HandshakeFailed)
 			break
 		}
 
+		// The connection is kept open until PluginUnload(), so that hooks can be called later.
+		//goland:noinspection GoDeferInLoop
+		defer func() {
+			if p.Error.IsError() {
+				p.RpcService.ClientRef.Kill()
+			}
+		}()
+
 		p.RpcService.ClientProtocol, e = p.RpcService.ClientRef.Client()
 		if e != nil {
 			p.Error.SetError("[%s]: ERROR: %s", p.Common.Id, e.Error())
+			p.Error.SetCode(Return.HandshakeFailed)
 			break
 		}
-		//goland:noinspection GoDeferInLoop,GoUnhandledErrorResult
-		defer p.RpcService.ClientProtocol.Close()
 
 		e = p.RpcService.ClientProtocol.Ping()
 		if e != nil {
 			p.Error.SetError("[%s]: ERROR: %s\n", id, e.Error())
+			p.Error.SetCode(Return.HandshakeFailed)
 			break
 		}
 
@@ -295,22 +348,25 @@ func (p *RpcPlugin) PluginLoad(id string, pluginPath utils.FilePath) Return.Erro
 		raw, e = p.RpcService.ClientProtocol.Dispense(p.Common.Id)
 		if e != nil {
 			p.Error.SetError("[%s]: ERROR: %s\n", p.Common.Id, e.Error())
+			p.Error.SetCode(Return.HandshakeFailed)
 			break
 		}
 
 		tn := utils.GetTypeName(raw)
 		if tn != "*GoPlugLoader.RpcPluginClient" {
 			p.Error.SetError("[%s]: ERROR: Invalid type - expecting '*RpcPluginClient', got '%s'", p.Common.Id, tn)
+			p.Error.SetCode(Return.HandshakeFailed)
 			break
 		}
 
 		impl := raw.(*RpcPluginClient)
+		impl.Name = id
+		p.RpcService.Client = impl
 		p.PluginData.Dynamic = impl.GetData()
 		if impl.Error.IsError() {
 			p.Error = impl.Error
 			break
 		}
-		p.PluginData.Dynamic.Identity.Print()
 
 		var identity Plugin.Identity
 		identity = impl.Identify()
@@ -327,13 +383,15 @@ func (p *RpcPlugin) PluginLoad(id string, pluginPath utils.FilePath) Return.Erro
 		p.SetRpcService(p.Common.Id, &GoPluginMaster{}) // p)
 		p.SetRawInterface(p)
 		p.SetStructName(identity)
-		log.Printf("[%s]: Name:%s Path: %s\n",
-			p.Common.Id, p.Common.Filename.GetName(), p.Common.Filename.GetPath())
 
-		p.Error = p.Callback(Plugin.CallbackInitialise, p)
+		p.Error = p.shareValues()
 		if p.Error.IsError() {
 			break
 		}
+
+		log.Printf("[%s]: Name:%s Path: %s\n",
+			p.Common.Id, p.Common.Filename.GetName(), p.Common.Filename.GetPath())
+		// Initialise is called by the loader, once host services and config are set.
 	}
 
 	return p.Error
@@ -343,37 +401,203 @@ func (p *RpcPlugin) PluginUnload() Return.Error {
 	for range Only.Once {
 		p.Error.ReturnClear()
 		p.Error.SetPrefix("")
+
+		if p.RpcService.unshare != nil {
+			p.RpcService.unshare()
+			p.RpcService.unshare = nil
+		}
+
+		// Kill() closes the connection and asks the plugin process to exit gracefully.
+		p.RpcService.Client = nil
+		p.RpcService.ClientProtocol = nil
+		if p.RpcService.Sandbox != nil {
+			p.RpcService.Sandbox.Stop()
+		}
+		if p.RpcService.ClientRef != nil {
+			p.RpcService.ClientRef.Kill()
+		}
+		p.Error = p.RpcService.Output.Close()
 	}
 
 	return p.Error
 }
 
+// CallHook - Calls a hook within the RPC plugin process.
+// The hook functions only exist on the plugin side, so calls from the host are forwarded over the RPC connection.
+func (p *RpcPlugin) CallHook(name string, args ...any) (Plugin.HookResponse, Return.Error) {
+	return p.CallHookTrace(trace.SpanContext{}, name, args...)
+}
+
+// CallHookTrace - As CallHook(), within the trace of parent. The trace is carried to the plugin process.
+func (p *RpcPlugin) CallHookTrace(parent trace.SpanContext, name string, args ...any) (Plugin.HookResponse, Return.Error) {
+	if p.RpcService.Client == nil {
+		return p.PluginData.CallHookTrace(parent, name, args...)
+	}
+
+	start := time.Now()
+	span := trace.Start("hook "+name, parent, "plugin", p.Dynamic.Identity.Name, "hook", name, "rpc", true)
+	var resp Plugin.HookResponse
+	for range Only.Once {
+		p.Error = Return.Ok
+		p.Error.SetPrefix("hook[%s]", name)
+
+		hook := p.GetHook(name)
+		if hook == nil {
+			p.Error.SetError("hook '%s' not found", name)
+			p.Error.SetCode(Return.HookNotFound)
+			break
+		}
+
+		p.Error = hook.Args.Validate(args...)
+		if p.Error.IsError() {
+			break
+		}
+
+		if fault := p.Fault(); fault != nil {
+			p.Error.SetError(fault.String())
+			break
+		}
+
+		resp, p.Error = p.RpcService.Client.CallHookTrace(span.Context, name, args...)
+		if fault := p.Fault(); fault != nil && p.Error.IsError() {
+			p.Error.SetError(fault.String())
+		}
+	}
+	p.Error.SetContext(p.Dynamic.Identity.Name, name)
+	Plugin.ObserveHook(p.Dynamic.Identity.Name, name, start, p.Error)
+	audit.Hook(p.Dynamic.Identity.Name, name, args, resp.Value, p.Error)
+	span.End(p.Error)
+	return resp, p.Error
+}
+
+// shareValues - Share the plugin's values, (taken from the plugin process by GetData()), with the plugin process.
+// Master holds them, so they can be stored, (see SetBackend), and so hooks called from either side see the same values.
+func (p *RpcPlugin) shareValues() Return.Error {
+	for range Only.Once {
+		client := p.RpcService.Client
+		values := p.RefValues()
+
+		p.Error = client.ShareValues(values)
+		if p.Error.IsError() {
+			break
+		}
+
+		name := p.GetName()
+		p.RpcService.unshare = values.Subscribe("", func(change store.ValueChange) {
+			err := client.ValuesChanged(change)
+			if err.IsError() {
+				log.Printf("[WARN]: Plugin(%s): value '%s' change not sent: %s", name, change.Key, err.GetError())
+			}
+		})
+	}
+	return p.Error
+}
+
+// SetHost - Set the services master offers to this plugin, and serve them to the plugin process.
+func (p *RpcPlugin) SetHost(host Plugin.HostInterface) Return.Error {
+	for range Only.Once {
+		p.Error = p.PluginData.SetHost(host)
+		if p.Error.IsError() {
+			break
+		}
+
+		if p.RpcService.Client == nil {
+			break
+		}
+
+		p.Error = p.RpcService.Client.SetHost(host)
+	}
+	return p.Error
+}
+
+// SetConfig - Set the config loaded by master, and send it to the plugin process.
+func (p *RpcPlugin) SetConfig(config Plugin.Config) Return.Error {
+	for range Only.Once {
+		p.Error = p.PluginData.SetConfig(config)
+		if p.Error.IsError() {
+			break
+		}
+
+		if p.RpcService.Client == nil {
+			break
+		}
+
+		p.Error = p.RpcService.Client.SetConfig(config)
+	}
+	return p.Error
+}
+
+// SetLogLevel - Change the level of the plugin's logger, both within master and the plugin process.
+func (p *RpcPlugin) SetLogLevel(level hclog.Level) Return.Error {
+	for range Only.Once {
+		p.Error = p.PluginData.SetLogLevel(level)
+		if p.Error.IsError() {
+			break
+		}
+
+		if p.RpcService.Client == nil {
+			break
+		}
+
+		p.Error = p.RpcService.Client.SetLogLevel(level)
+	}
+	return p.Error
+}
+
+// GetPid - The pid of the plugin process, or zero if it isn't running.
+func (p *RpcPlugin) GetPid() int {
+	if p.RpcService.Sandbox != nil {
+		return p.RpcService.Sandbox.GetPid()
+	}
+	if p.RpcService.ClientRef == nil || p.RpcService.ClientRef.Exited() {
+		return 0
+	}
+	if reattach := p.RpcService.ClientRef.ReattachConfig(); reattach != nil {
+		return reattach.Pid
+	}
+	return 0
+}
+
+// GetOutput - The last lines of stdout and stderr from the plugin process.
+func (p *RpcPlugin) GetOutput() []string {
+	return p.RpcService.Output.Tail()
+}
+
+// Fault - The fault, if the plugin process exited while loaded. Only set for sandboxed plugins.
+func (p *RpcPlugin) Fault() *PluginFault {
+	if p.RpcService.Sandbox == nil {
+		return nil
+	}
+	return p.RpcService.Sandbox.Fault()
+}
+
 //
 // ---------------------------------------------------------------------------------------------------- //
 // Mirror methods of RPC interface structure
 
-func (p *RpcPlugin) Server(_ *goplugin.MuxBroker) (any, error) {
+func (p *RpcPlugin) Server(b *goplugin.MuxBroker) (any, error) {
 	p.Dynamic.Error = Return.Ok
-	impl := Plugin.PluginData{
-		Common:   p.Common,
-		Services: p.Services,
-		Dynamic:  p.Dynamic,
-		Error:    p.Error,
-	}
+	impl := p.PluginData // Shares the host with p, so hooks see it once master connects.
 
 	gob.Register(Plugin.PluginData{})
 	gob.Register(store.ValueStruct{})
 	gob.Register(RpcPlugin{})
-	return &RpcPluginServer{Impl: &impl}, nil
+	gob.Register(Plugin.Config{})
+	gob.Register(time.Duration(0))
+	gob.Register(utils.Secret(""))
+	return &RpcPluginServer{Impl: &impl, Broker: b}, nil
 }
 
-func (p *RpcPlugin) Client(_ *goplugin.MuxBroker, c *rpc.Client) (any, error) {
+func (p *RpcPlugin) Client(b *goplugin.MuxBroker, c *rpc.Client) (any, error) {
 	p.Dynamic.Error = Return.Ok
 
 	gob.Register(Plugin.PluginData{})
 	gob.Register(store.ValueStruct{})
 	gob.Register(RpcPlugin{})
-	return &RpcPluginClient{Client: c}, nil
+	gob.Register(Plugin.Config{})
+	gob.Register(time.Duration(0))
+	gob.Register(utils.Secret(""))
+	return &RpcPluginClient{Client: c, Broker: b}, nil
 }
 
 //
@@ -384,6 +608,11 @@ type RpcService struct {
 	ClientConfig   goplugin.ClientConfig
 	ClientRef      *goplugin.Client
 	ClientProtocol goplugin.ClientProtocol
+	Client         *RpcPluginClient
+	Security       RpcSecurity
+	Sandbox        *RpcSandbox
+	Output         *RpcOutput
+	unshare        func() // Stops sending value changes to the plugin process.
 }
 
 // NewRpcService - Create a new instance of this structure.
@@ -393,5 +622,9 @@ func NewRpcService() RpcService {
 		ClientConfig:   goplugin.ClientConfig{},
 		ClientRef:      nil,
 		ClientProtocol: nil,
+		Client:         nil,
+		Security:       RpcSecurity{},
+		Sandbox:        nil,
+		Output:         nil,
 	}
 }
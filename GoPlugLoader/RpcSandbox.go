@@ -0,0 +1,428 @@
+package GoPlugLoader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/MickMake/GoUnify/Only"
+	"github.com/hashicorp/go-hclog"
+	goplugin "github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/go-plugin/runner"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/metrics"
+)
+
+// Amount of plugin stderr kept for fault reports.
+const faultStderrSize = 4096
+
+//
+// PluginFault - An RPC plugin process that exited while loaded.
+// ---------------------------------------------------------------------------------------------------- //
+type PluginFault struct {
+	Plugin   string        `json:"plugin"`
+	Path     string        `json:"path"`
+	Time     time.Time     `json:"time"`
+	Reason   string        `json:"reason"`
+	ExitCode int           `json:"exit_code"`
+	Signal   string        `json:"signal,omitempty"`
+	Limits   Plugin.Limits `json:"limits"`
+	Stderr   string        `json:"stderr,omitempty"` // Tail of the plugin stderr.
+	Output   []string      `json:"output,omitempty"` // Last lines of plugin output, (see RpcOutput).
+}
+
+// String - Stringer interface.
+func (f PluginFault) String() string {
+	return fmt.Sprintf("plugin '%s' faulted: %s", f.Plugin, f.Reason)
+}
+
+//
+// PluginFaults - Faults reported by RPC plugin processes, shared by a loader.
+// ---------------------------------------------------------------------------------------------------- //
+type PluginFaults struct {
+	faults   []PluginFault
+	handlers []func(fault PluginFault)
+	lock     sync.Mutex
+}
+
+// NewPluginFaults - Create a new instance of this structure.
+func NewPluginFaults() *PluginFaults {
+	return &PluginFaults{}
+}
+
+// Report - Record a fault and call the handlers.
+func (f *PluginFaults) Report(fault PluginFault) {
+	log.Printf("[WARN]: Plugin(%s): %s", fault.Plugin, fault)
+	metrics.Inc(metrics.PluginFaults, metrics.Labels{"plugin": fault.Plugin})
+	f.lock.Lock()
+	f.faults = append(f.faults, fault)
+	handlers := append([]func(fault PluginFault){}, f.handlers...)
+	f.lock.Unlock()
+
+	for _, handler := range handlers {
+		handler(fault)
+	}
+}
+
+// OnFault - Add a handler, called from the goroutine that noticed the fault.
+func (f *PluginFaults) OnFault(handler func(fault PluginFault)) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.handlers = append(f.handlers, handler)
+}
+
+// Get - All faults reported so far.
+func (f *PluginFaults) Get() []PluginFault {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]PluginFault{}, f.faults...)
+}
+
+//
+// RpcLimitsConfig - Limits for all RPC plugins, with per-plugin overrides.
+// ---------------------------------------------------------------------------------------------------- //
+type RpcLimitsConfig struct {
+	Default Plugin.Limits            `json:"default"`
+	Plugins map[string]Plugin.Limits `json:"plugins,omitempty"` // Keyed by plugin name.
+}
+
+// Get - Limits for a plugin. Manager overrides win over the manifest, which wins over the manager default.
+func (c *RpcLimitsConfig) Get(name string, manifest *Plugin.Limits) Plugin.Limits {
+	if limits, ok := c.Plugins[name]; ok {
+		return limits
+	}
+	if manifest != nil {
+		return *manifest
+	}
+	return c.Default
+}
+
+// Set - Override Limits for a single plugin.
+func (c *RpcLimitsConfig) Set(name string, limits Plugin.Limits) {
+	if c.Plugins == nil {
+		c.Plugins = make(map[string]Plugin.Limits)
+	}
+	c.Plugins[name] = limits
+}
+
+//
+// RpcSandbox - Applies Limits to an RPC plugin process, and watches for it exiting unexpectedly.
+// ---------------------------------------------------------------------------------------------------- //
+type RpcSandbox struct {
+	Name    string
+	Limits  Plugin.Limits
+	OnFault func(fault PluginFault)
+	Output  *RpcOutput // Last lines of output are added to faults.
+
+	path     utils.FilePath
+	runner   *sandboxRunner
+	fault    *PluginFault
+	stopping bool
+	pid      int
+	lock     sync.Mutex
+}
+
+// NewRpcSandbox - Create a new instance of this structure.
+func NewRpcSandbox(name string, pluginPath utils.FilePath, limits Plugin.Limits) *RpcSandbox {
+	return &RpcSandbox{
+		Name:   name,
+		Limits: limits,
+		path:   pluginPath,
+	}
+}
+
+// Apply - Set up the go-plugin client config to start the plugin process within the sandbox.
+// cmd is the plugin command, (with any environment additions), config.Cmd must not be set.
+func (s *RpcSandbox) Apply(config *goplugin.ClientConfig, cmd *exec.Cmd) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		err = s.Limits.IsValid()
+		if err.IsError() {
+			break
+		}
+
+		cmd.Dir, err = s.Limits.GetDir(s.path.GetDir())
+		if err.IsError() {
+			break
+		}
+
+		cmd.SysProcAttr, err = sandboxSysProcAttr(s.Limits)
+		if err.IsError() {
+			break
+		}
+
+		if s.Limits.MemoryMB > 0 {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("GOMEMLIMIT=%dMiB", s.Limits.MemoryMB))
+		}
+		cmd.Env = append(cmd.Env, sandboxEnv(s.Limits)...)
+		config.SkipHostEnv = s.Limits.RestrictEnv
+
+		err = sandboxShim(cmd, s.Limits)
+		if err.IsError() {
+			break
+		}
+
+		// With a RunnerFunc, go-plugin checks SecureConfig against a placeholder, (exec.Command("")), which always
+		// fails, so it's cleared here and the runner checks the real plugin file instead.
+		secure := config.SecureConfig
+		config.SecureConfig = nil
+		config.Cmd = nil
+		config.RunnerFunc = func(logger hclog.Logger, spec *exec.Cmd, _ string) (runner.Runner, error) {
+			// spec carries the environment go-plugin needs, (magic cookie, AutoMTLS, socket dir).
+			cmd.Env = append(spec.Env, cmd.Env...)
+			cmd.Stdin = spec.Stdin
+			return s.newRunner(logger, cmd, secure)
+		}
+	}
+
+	return err
+}
+
+// Stop - Mark the process as stopping, so it exiting is not reported as a fault.
+func (s *RpcSandbox) Stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.stopping = true
+}
+
+// GetPid - The pid of the plugin process, or zero if it hasn't started.
+func (s *RpcSandbox) GetPid() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.pid
+}
+
+// Fault - The fault, if the plugin process exited unexpectedly.
+func (s *RpcSandbox) Fault() *PluginFault {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.fault
+}
+
+// exited - Called by the runner when the process exits.
+func (s *RpcSandbox) exited(state *os.ProcessState, stderr string) {
+	s.lock.Lock()
+	if s.stopping || s.fault != nil {
+		s.lock.Unlock()
+		return
+	}
+
+	fault := PluginFault{
+		Plugin: s.Name,
+		Path:   s.path.GetPath(),
+		Time:   time.Now(),
+		Limits: s.Limits,
+		Stderr: stderr,
+		Output: s.Output.Tail(),
+	}
+	if state != nil {
+		fault.ExitCode = state.ExitCode()
+		if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			fault.Signal = ws.Signal().String()
+		}
+	}
+	fault.Reason = faultReason(fault)
+	s.fault = &fault
+	onFault := s.OnFault
+	s.lock.Unlock()
+
+	if onFault != nil {
+		onFault(fault)
+	}
+}
+
+// faultReason - Best guess at why a plugin process exited.
+func faultReason(fault PluginFault) string {
+	if reason := signalReason(fault); reason != "" {
+		return reason
+	}
+
+	switch {
+	case fault.Limits.MemoryMB > 0 && strings.Contains(fault.Stderr, "out of memory"):
+		return fmt.Sprintf("memory limit of %dMB exceeded", fault.Limits.MemoryMB)
+	case fault.Limits.OpenFiles > 0 && strings.Contains(fault.Stderr, "too many open files"):
+		return fmt.Sprintf("open files limit of %d exceeded", fault.Limits.OpenFiles)
+	case fault.Signal != "":
+		return "killed by signal " + fault.Signal
+	}
+	return fmt.Sprintf("exited with code %d", fault.ExitCode)
+}
+
+// hasShimLimits - Returns true if limits need the sandbox shim, (rlimits or seccomp).
+func hasShimLimits(limits Plugin.Limits) bool {
+	return limits.MemoryMB > 0 || limits.CPUSeconds > 0 || limits.OpenFiles > 0 || limits.Seccomp
+}
+
+// sandboxEnv - Environment variables from Limits.Env.
+func sandboxEnv(limits Plugin.Limits) []string {
+	var ret []string
+	for _, env := range limits.Env {
+		if strings.Contains(env, "=") {
+			ret = append(ret, env)
+			continue
+		}
+		if value, ok := os.LookupEnv(env); ok {
+			ret = append(ret, env+"="+value)
+		}
+	}
+	return ret
+}
+
+//
+// sandboxRunner - go-plugin runner.Runner that starts the plugin process within the sandbox, (see sandboxShim).
+// ---------------------------------------------------------------------------------------------------- //
+type sandboxRunner struct {
+	sandbox *RpcSandbox
+	logger  hclog.Logger
+	cmd     *exec.Cmd
+	secure  *goplugin.SecureConfig
+	stdout  io.ReadCloser
+	stderr  io.ReadCloser
+	tail    *tailBuffer
+	path    string
+	pid     int
+}
+
+func (s *RpcSandbox) newRunner(logger hclog.Logger, cmd *exec.Cmd, secure *goplugin.SecureConfig) (*sandboxRunner, error) {
+	r := &sandboxRunner{
+		sandbox: s,
+		logger:  logger,
+		cmd:     cmd,
+		secure:  secure,
+		tail:    &tailBuffer{size: faultStderrSize},
+		path:    s.path.GetPath(),
+	}
+
+	var e error
+	r.stdout, e = cmd.StdoutPipe()
+	if e != nil {
+		return nil, e
+	}
+
+	var stderr io.ReadCloser
+	stderr, e = cmd.StderrPipe()
+	if e != nil {
+		return nil, e
+	}
+	r.stderr = &teeReadCloser{ReadCloser: stderr, w: r.tail}
+
+	s.runner = r
+	return r, nil
+}
+
+func (r *sandboxRunner) Start(_ context.Context) error {
+	if r.secure != nil {
+		ok, e := r.secure.Check(r.path)
+		if e != nil {
+			return fmt.Errorf("error verifying checksum: %s", e)
+		}
+		if !ok {
+			return goplugin.ErrChecksumsDoNotMatch
+		}
+	}
+
+	r.logger.Debug("starting sandboxed plugin", "path", r.path, "limits", r.sandbox.Limits.String())
+	e := r.cmd.Start()
+	if e != nil {
+		return e
+	}
+	r.pid = r.cmd.Process.Pid
+	r.sandbox.lock.Lock()
+	r.sandbox.pid = r.pid
+	r.sandbox.lock.Unlock()
+	return nil
+}
+
+func (r *sandboxRunner) Wait(_ context.Context) error {
+	e := r.cmd.Wait()
+	r.sandbox.exited(r.cmd.ProcessState, r.tail.String())
+	return e
+}
+
+func (r *sandboxRunner) Kill(_ context.Context) error {
+	r.sandbox.Stop()
+	if r.cmd.Process != nil {
+		e := r.cmd.Process.Kill()
+		if !errors.Is(e, os.ErrProcessDone) {
+			return e
+		}
+	}
+	return nil
+}
+
+func (r *sandboxRunner) Stdout() io.ReadCloser {
+	return r.stdout
+}
+
+func (r *sandboxRunner) Stderr() io.ReadCloser {
+	return r.stderr
+}
+
+func (r *sandboxRunner) Name() string {
+	return r.path
+}
+
+func (r *sandboxRunner) ID() string {
+	return fmt.Sprintf("%d", r.pid)
+}
+
+func (r *sandboxRunner) Diagnose(_ context.Context) string {
+	return fmt.Sprintf("plugin '%s' failed to start within the sandbox, (%s)\n%s", r.path, r.sandbox.Limits, r.tail)
+}
+
+func (r *sandboxRunner) PluginToHost(pluginNet, pluginAddr string) (string, string, error) {
+	return pluginNet, pluginAddr, nil
+}
+
+func (r *sandboxRunner) HostToPlugin(hostNet, hostAddr string) (string, string, error) {
+	return hostNet, hostAddr, nil
+}
+
+// tailBuffer - Keeps the last size bytes written.
+type tailBuffer struct {
+	buf  []byte
+	size int
+	lock sync.Mutex
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.size {
+		t.buf = t.buf[len(t.buf)-t.size:]
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return string(t.buf)
+}
+
+// teeReadCloser - Copies everything read to w.
+type teeReadCloser struct {
+	io.ReadCloser
+	w io.Writer
+}
+
+func (t *teeReadCloser) Read(p []byte) (int, error) {
+	n, e := t.ReadCloser.Read(p)
+	if n > 0 {
+		_, _ = t.w.Write(p[:n])
+	}
+	return n, e
+}
@@ -0,0 +1,280 @@
+//go:build linux
+
+package GoPlugLoader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/MickMake/GoUnify/Only"
+	"golang.org/x/sys/unix"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// Not defined by x/sys/unix, (see linux/seccomp.h).
+const (
+	seccompSetModeFilter   = 1
+	seccompFlagTsync       = 1
+	seccompRetAllow        = 0x7fff0000
+	seccompRetErrno        = 0x00050000
+	seccompDataNrOffset    = 0
+	seccompDataArchOffset  = 4
+	bpfLoadWordAbsolute    = unix.BPF_LD | unix.BPF_W | unix.BPF_ABS
+	bpfJumpIfEqual         = unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K
+	bpfReturn              = unix.BPF_RET | unix.BPF_K
+	seccompDeniedErrnoCode = uint32(unix.EPERM)
+)
+
+// sandboxNamespaces - Namespaces are supported.
+const sandboxNamespaces = true
+
+var namespaceFlags = map[string]uintptr{
+	Plugin.NamespaceUser:  syscall.CLONE_NEWUSER,
+	Plugin.NamespacePid:   syscall.CLONE_NEWPID,
+	Plugin.NamespaceIpc:   syscall.CLONE_NEWIPC,
+	Plugin.NamespaceUts:   syscall.CLONE_NEWUTS,
+	Plugin.NamespaceNet:   syscall.CLONE_NEWNET,
+	Plugin.NamespaceMount: syscall.CLONE_NEWNS,
+}
+
+// seccompArch - seccomp_data.arch values, for the architectures the filter supports.
+var seccompArch = map[string]uint32{
+	"amd64": unix.AUDIT_ARCH_X86_64,
+	"arm64": unix.AUDIT_ARCH_AARCH64,
+}
+
+// seccompDenied - Syscalls a plugin has no business making. They fail with EPERM.
+var seccompDenied = []uint32{
+	unix.SYS_MOUNT,
+	unix.SYS_UMOUNT2,
+	unix.SYS_PIVOT_ROOT,
+	unix.SYS_SETNS,
+	unix.SYS_UNSHARE,
+	unix.SYS_PTRACE,
+	unix.SYS_PROCESS_VM_READV,
+	unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_KEXEC_LOAD,
+	unix.SYS_INIT_MODULE,
+	unix.SYS_FINIT_MODULE,
+	unix.SYS_DELETE_MODULE,
+	unix.SYS_REBOOT,
+	unix.SYS_SWAPON,
+	unix.SYS_SWAPOFF,
+	unix.SYS_ACCT,
+	unix.SYS_BPF,
+	unix.SYS_PERF_EVENT_OPEN,
+	unix.SYS_SETTIMEOFDAY,
+}
+
+// sandboxSysProcAttr - Namespaces to start the plugin process in.
+func sandboxSysProcAttr(limits Plugin.Limits) (*syscall.SysProcAttr, Return.Error) {
+	var err Return.Error
+
+	if len(limits.Namespaces) == 0 {
+		return nil, err
+	}
+
+	attr := &syscall.SysProcAttr{
+		// Don't leave the plugin running if master dies.
+		Pdeathsig: syscall.SIGKILL,
+	}
+	for _, ns := range limits.Namespaces {
+		attr.Cloneflags |= namespaceFlags[ns]
+	}
+
+	// Unprivileged users can only create other namespaces from within a user namespace.
+	uid := os.Getuid()
+	if uid != 0 {
+		attr.Cloneflags |= syscall.CLONE_NEWUSER
+	}
+
+	// Keep the same ids within the user namespace, so the plugin can still create its socket.
+	if attr.Cloneflags&syscall.CLONE_NEWUSER != 0 {
+		gid := os.Getgid()
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+	}
+
+	return attr, err
+}
+
+// envSandboxShim - Set when master re-executes itself as the sandbox shim, (to the Limits as JSON).
+const envSandboxShim = "GOPLUG_SANDBOX_SHIM"
+
+func init() {
+	// Master re-executes itself to start sandboxed plugins, (see sandboxShim).
+	if limits, ok := os.LookupEnv(envSandboxShim); ok {
+		runSandboxShim(limits)
+	}
+}
+
+// sandboxShim - Start the plugin through master's own binary, which applies the rlimits and seccomp filter
+// to itself, then execs the plugin. Both are then in place before any plugin code runs.
+func sandboxShim(cmd *exec.Cmd, limits Plugin.Limits) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if !hasShimLimits(limits) {
+			break
+		}
+
+		self, e := os.Executable()
+		if e != nil {
+			err.SetError("can't find master binary for the sandbox shim: %s", e)
+			break
+		}
+
+		path, e := filepath.Abs(cmd.Path)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		data, e := json.Marshal(limits)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		cmd.Env = append(cmd.Env, envSandboxShim+"="+string(data))
+		cmd.Args = append([]string{self, path}, cmd.Args[1:]...)
+		cmd.Path = self
+	}
+
+	return err
+}
+
+// runSandboxShim - Apply the limits to this process, then replace it with the plugin, (os.Args[1:]).
+// Never returns.
+func runSandboxShim(data string) {
+	var err Return.Error
+
+	for range Only.Once {
+		if len(os.Args) < 2 {
+			err.SetError("no plugin to exec")
+			break
+		}
+
+		var limits Plugin.Limits
+		e := json.Unmarshal([]byte(data), &limits)
+		if e != nil {
+			err.SetError("invalid limits: %s", e)
+			break
+		}
+
+		e = setRlimits(limits)
+		if e != nil {
+			err.SetError("can't apply limits: %s", e)
+			break
+		}
+
+		if limits.Seccomp {
+			err = installSeccomp()
+			if err.IsError() {
+				break
+			}
+		}
+
+		env := make([]string, 0, len(os.Environ()))
+		for _, value := range os.Environ() {
+			if !strings.HasPrefix(value, envSandboxShim+"=") {
+				env = append(env, value)
+			}
+		}
+
+		e = syscall.Exec(os.Args[1], os.Args[1:], env)
+		err.SetError("can't exec plugin '%s': %s", os.Args[1], e)
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "sandbox: %s\n", err.GetError())
+	os.Exit(1)
+}
+
+// setRlimits - Apply the rlimits to this process. They are kept across exec.
+func setRlimits(limits Plugin.Limits) error {
+	for resource, value := range map[int]uint64{
+		syscall.RLIMIT_DATA:   limits.MemoryMB * 1024 * 1024,
+		syscall.RLIMIT_CPU:    limits.CPUSeconds,
+		syscall.RLIMIT_NOFILE: limits.OpenFiles,
+	} {
+		if value == 0 {
+			continue
+		}
+
+		// syscall.Setrlimit, so the Go runtime doesn't restore its own RLIMIT_NOFILE on exec.
+		e := syscall.Setrlimit(resource, &syscall.Rlimit{Cur: value, Max: value})
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// signalReason - Limits that are enforced by a signal.
+func signalReason(fault PluginFault) string {
+	switch fault.Signal {
+	case syscall.SIGXCPU.String():
+		return fmt.Sprintf("CPU limit of %ds exceeded", fault.Limits.CPUSeconds)
+	case syscall.SIGSYS.String():
+		return "blocked system call"
+	}
+	return ""
+}
+
+// installSeccomp - Install the seccomp filter within this process, (see Limits.Seccomp).
+// Called by the sandbox shim, the filter is kept across exec.
+func installSeccomp() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		arch, ok := seccompArch[runtime.GOARCH]
+		if !ok {
+			err.SetError("seccomp is not supported on %s", runtime.GOARCH)
+			break
+		}
+
+		filter := []unix.SockFilter{
+			{Code: bpfLoadWordAbsolute, K: seccompDataArchOffset},
+			// Deny everything from a foreign architecture, as syscall numbers differ.
+			{Code: bpfJumpIfEqual, Jt: 1, Jf: 0, K: arch},
+			{Code: bpfReturn, K: seccompRetErrno | seccompDeniedErrnoCode},
+			{Code: bpfLoadWordAbsolute, K: seccompDataNrOffset},
+		}
+		for _, nr := range seccompDenied {
+			filter = append(filter,
+				unix.SockFilter{Code: bpfJumpIfEqual, Jt: 0, Jf: 1, K: nr},
+				unix.SockFilter{Code: bpfReturn, K: seccompRetErrno | seccompDeniedErrnoCode},
+			)
+		}
+		filter = append(filter, unix.SockFilter{Code: bpfReturn, K: seccompRetAllow})
+
+		prog := unix.SockFprog{
+			Len:    uint16(len(filter)),
+			Filter: &filter[0],
+		}
+
+		e := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0)
+		if e != nil {
+			err.SetError("can't set no_new_privs: %s", e)
+			break
+		}
+
+		// TSYNC applies the filter to all threads the Go runtime has started.
+		_, _, errno := unix.Syscall(unix.SYS_SECCOMP, seccompSetModeFilter, seccompFlagTsync, uintptr(unsafe.Pointer(&prog)))
+		if errno != 0 {
+			err.SetError("can't install seccomp filter: %s", errno)
+			break
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,103 @@
+//go:build linux
+
+package GoPlugLoader
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+)
+
+type SandboxSuite struct {
+	suite.Suite
+}
+
+// run - Run a command through the sandbox shim, (this test binary), with limits.
+func (s *SandboxSuite) run(limits Plugin.Limits, name string, args ...string) (string, error) {
+	path, e := exec.LookPath(name)
+	if e != nil {
+		s.T().Skipf("%s not found", name)
+	}
+
+	cmd := exec.Command(path, args...)
+	err := sandboxShim(cmd, limits)
+	s.Require().False(err.IsError(), err.String())
+
+	out, e := cmd.CombinedOutput()
+	return strings.TrimSpace(string(out)), e
+}
+
+func (s *SandboxSuite) TestShim() {
+	tests := []struct {
+		name   string
+		limits Plugin.Limits
+		cmd    []string
+		want   string
+		fail   bool
+	}{
+		{
+			name:   "open files",
+			limits: Plugin.Limits{OpenFiles: 64},
+			cmd:    []string{"sh", "-c", "ulimit -n"},
+			want:   "64",
+		},
+		{
+			name:   "cpu",
+			limits: Plugin.Limits{CPUSeconds: 5},
+			cmd:    []string{"sh", "-c", "ulimit -t"},
+			want:   "5",
+		},
+		{
+			name:   "memory",
+			limits: Plugin.Limits{MemoryMB: 256},
+			cmd:    []string{"sh", "-c", "ulimit -d"},
+			want:   "262144",
+		},
+		{
+			name:   "seccomp denies unshare",
+			limits: Plugin.Limits{Seccomp: true},
+			cmd:    []string{"unshare", "--user", "true"},
+			want:   "Operation not permitted",
+			fail:   true,
+		},
+		{
+			name:   "seccomp allows",
+			limits: Plugin.Limits{Seccomp: true},
+			cmd:    []string{"sh", "-c", "echo ok"},
+			want:   "ok",
+		},
+		{
+			name:   "shim env removed",
+			limits: Plugin.Limits{OpenFiles: 64},
+			cmd:    []string{"sh", "-c", "echo ${" + envSandboxShim + ":-unset}"},
+			want:   "unset",
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			out, e := s.run(test.limits, test.cmd[0], test.cmd[1:]...)
+			if test.fail {
+				s.Error(e, out)
+			} else {
+				s.NoError(e, out)
+			}
+			s.Contains(out, test.want)
+		})
+	}
+}
+
+func (s *SandboxSuite) TestNoShim() {
+	cmd := exec.Command("/bin/true")
+	err := sandboxShim(cmd, Plugin.Limits{RestrictEnv: true, Dir: "data"})
+	s.Require().False(err.IsError(), err.String())
+	s.Equal("/bin/true", cmd.Path, "limits that don't need the shim shouldn't wrap the command")
+}
+
+func TestSandboxSuite(t *testing.T) {
+	suite.Run(t, new(SandboxSuite))
+}
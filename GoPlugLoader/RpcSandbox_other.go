@@ -0,0 +1,38 @@
+//go:build !linux
+
+package GoPlugLoader
+
+import (
+	"os/exec"
+	"runtime"
+	"syscall"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// sandboxNamespaces - Namespaces are only supported on Linux.
+const sandboxNamespaces = false
+
+// sandboxSysProcAttr - Namespaces are only supported on Linux.
+func sandboxSysProcAttr(limits Plugin.Limits) (*syscall.SysProcAttr, Return.Error) {
+	var err Return.Error
+	if len(limits.Namespaces) > 0 {
+		err.SetError("namespaces are not supported on %s", runtime.GOOS)
+	}
+	return nil, err
+}
+
+// sandboxShim - rlimits and seccomp are only supported on Linux.
+func sandboxShim(_ *exec.Cmd, limits Plugin.Limits) Return.Error {
+	var err Return.Error
+	if hasShimLimits(limits) {
+		err.SetError("rlimits and seccomp are not supported on %s", runtime.GOOS)
+	}
+	return err
+}
+
+// signalReason - rlimits are not applied, so there are no limit signals.
+func signalReason(_ PluginFault) string {
+	return ""
+}
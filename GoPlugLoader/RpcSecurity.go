@@ -0,0 +1,265 @@
+package GoPlugLoader
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/MickMake/GoUnify/Only"
+	goplugin "github.com/hashicorp/go-plugin"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+const (
+	// Passed to the plugin process when a custom CA is pinned, (see RpcSecurity.CAFile).
+	EnvTLSCert = "GOPLUG_TLS_CERT" // Plugin server certificate file.
+	EnvTLSKey  = "GOPLUG_TLS_KEY"  // Plugin server key file.
+	EnvTLSCA   = "GOPLUG_TLS_CA"   // CA file master's client certificate is verified against.
+
+	// RpcServerName - Server name plugin certificates must be valid for, when a custom CA is pinned.
+	RpcServerName = "localhost"
+)
+
+//
+// RpcSecurity - How the RPC channel between master and an RPC plugin is secured.
+// ---------------------------------------------------------------------------------------------------- //
+// The zero value is secure: AutoMTLS is enabled and the plugin binary checksum is verified before exec.
+// Security is only reduced by explicit choice.
+type RpcSecurity struct {
+	// Disable AutoMTLS. Any local process can then race to connect to the plugin socket.
+	Insecure bool `json:"insecure"`
+
+	// Skip verifying the SHA-256 of the plugin binary just before it is executed.
+	SkipChecksum bool `json:"skip_checksum"`
+
+	// The SHA-256 the plugin binary was validated with, (lockfile entry or signed digest), hex encoded.
+	// Set by the loader. When empty, the binary is hashed when Apply() is called.
+	Sha256 string `json:"-"`
+
+	// Pin a custom CA, instead of AutoMTLS. Both sides must present certificates signed by it.
+	CAFile string `json:"ca_file,omitempty"`
+
+	// Master client certificate and key, (CAFile only).
+	CertFile string `json:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty"`
+
+	// Plugin server certificate and key, (CAFile only), valid for RpcServerName.
+	// Passed to the plugin process as GOPLUG_TLS_CERT and GOPLUG_TLS_KEY.
+	PluginCertFile string `json:"plugin_cert_file,omitempty"`
+	PluginKeyFile  string `json:"plugin_key_file,omitempty"`
+}
+
+// IsValid - Check the combination of options.
+func (s *RpcSecurity) IsValid() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if s.CAFile == "" {
+			break
+		}
+
+		if s.Insecure {
+			err.SetError("insecure can't be combined with a pinned CA")
+			break
+		}
+
+		for name, file := range map[string]string{
+			"cert_file":        s.CertFile,
+			"key_file":         s.KeyFile,
+			"plugin_cert_file": s.PluginCertFile,
+			"plugin_key_file":  s.PluginKeyFile,
+		} {
+			if file == "" {
+				err.AddError("%s is required with a pinned CA", name)
+			}
+		}
+	}
+
+	return err
+}
+
+// Apply - Set up a go-plugin client config for this plugin binary. Must be called after config.Cmd is set.
+func (s *RpcSecurity) Apply(config *goplugin.ClientConfig, pluginPath utils.FilePath) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		err = s.IsValid()
+		if err.IsError() {
+			break
+		}
+
+		if !s.SkipChecksum {
+			sum := s.Sha256
+			if sum == "" {
+				sum, err = pluginPath.Sha256()
+				if err.IsError() {
+					break
+				}
+			}
+
+			checksum, e := hex.DecodeString(sum)
+			if e != nil {
+				err.SetError(e)
+				break
+			}
+
+			// go-plugin hashes the binary again just before exec, so it can't be swapped after validation.
+			config.SecureConfig = &goplugin.SecureConfig{
+				Checksum: checksum,
+				Hash:     sha256.New(),
+			}
+		}
+
+		switch {
+		case s.CAFile != "":
+			config.AutoMTLS = false
+			config.TLSConfig, err = s.ClientTLSConfig()
+			if err.IsError() {
+				break
+			}
+
+			// go-plugin adds master's environment, (unless SkipHostEnv), to these.
+			config.Cmd.Env = append(config.Cmd.Env,
+				fmt.Sprintf("%s=%s", EnvTLSCert, s.PluginCertFile),
+				fmt.Sprintf("%s=%s", EnvTLSKey, s.PluginKeyFile),
+				fmt.Sprintf("%s=%s", EnvTLSCA, s.CAFile),
+			)
+
+		case s.Insecure:
+			config.AutoMTLS = false
+
+		default:
+			config.AutoMTLS = true
+		}
+	}
+
+	return err
+}
+
+// ClientTLSConfig - Master side TLS config for a pinned CA.
+func (s *RpcSecurity) ClientTLSConfig() (*tls.Config, Return.Error) {
+	var ret *tls.Config
+	var err Return.Error
+
+	for range Only.Once {
+		cert, e := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+		if e != nil {
+			err.SetError("can't load client certificate: %s", e)
+			break
+		}
+
+		var pool *x509.CertPool
+		pool, err = loadCertPool(s.CAFile)
+		if err.IsError() {
+			break
+		}
+
+		ret = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			RootCAs:      pool,
+			ServerName:   RpcServerName,
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+
+	return ret, err
+}
+
+// String - Stringer interface.
+func (s RpcSecurity) String() string {
+	var ret string
+	switch {
+	case s.CAFile != "":
+		ret = fmt.Sprintf("mTLS (CA %s)", s.CAFile)
+	case s.Insecure:
+		ret = "INSECURE"
+	default:
+		ret = "AutoMTLS"
+	}
+	if s.SkipChecksum {
+		ret += ", no checksum"
+	} else {
+		ret += ", checksum"
+	}
+	return ret
+}
+
+//
+// RpcSecurityConfig - RpcSecurity for all RPC plugins, with per-plugin overrides.
+// ---------------------------------------------------------------------------------------------------- //
+type RpcSecurityConfig struct {
+	Default RpcSecurity            `json:"default"`
+	Plugins map[string]RpcSecurity `json:"plugins,omitempty"` // Keyed by plugin name.
+}
+
+// Get - RpcSecurity for a plugin.
+func (c *RpcSecurityConfig) Get(name string) RpcSecurity {
+	if s, ok := c.Plugins[name]; ok {
+		return s
+	}
+	return c.Default
+}
+
+// Set - Override RpcSecurity for a single plugin.
+func (c *RpcSecurityConfig) Set(name string, security RpcSecurity) {
+	if c.Plugins == nil {
+		c.Plugins = make(map[string]RpcSecurity)
+	}
+	c.Plugins[name] = security
+}
+
+// ServerTLSProvider - Plugin side TLS config for a pinned CA, read from the files passed by master.
+// Returns nil if master didn't pin a CA, in which case go-plugin sets up AutoMTLS when master asked for it.
+func ServerTLSProvider() func() (*tls.Config, error) {
+	certFile := os.Getenv(EnvTLSCert)
+	keyFile := os.Getenv(EnvTLSKey)
+	caFile := os.Getenv(EnvTLSCA)
+	if certFile == "" && keyFile == "" && caFile == "" {
+		return nil
+	}
+
+	return func() (*tls.Config, error) {
+		cert, e := tls.LoadX509KeyPair(certFile, keyFile)
+		if e != nil {
+			return nil, fmt.Errorf("can't load plugin certificate: %s", e)
+		}
+
+		pool, err := loadCertPool(caFile)
+		if err.IsError() {
+			return nil, err.GetError()
+		}
+
+		return &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    pool,
+			MinVersion:   tls.VersionTLS12,
+		}, nil
+	}
+}
+
+func loadCertPool(file string) (*x509.CertPool, Return.Error) {
+	var pool *x509.CertPool
+	var err Return.Error
+
+	for range Only.Once {
+		var data []byte
+		data, err = utils.ReadFile(file)
+		if err.IsError() {
+			break
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			err.SetError("no certificates found in CA file '%s'", file)
+			break
+		}
+	}
+
+	return pool, err
+}
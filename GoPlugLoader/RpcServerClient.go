@@ -3,13 +3,19 @@ package GoPlugLoader
 import (
 	"encoding/gob"
 	"net/rpc"
+	"strings"
+	"time"
 
+	"github.com/MickMake/GoUnify/Only"
+	"github.com/hashicorp/go-hclog"
 	goplugin "github.com/hashicorp/go-plugin"
 
 	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
 	"github.com/MickMake/GoPlug/utils"
 	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/metrics"
 	"github.com/MickMake/GoPlug/utils/store"
+	"github.com/MickMake/GoPlug/utils/trace"
 )
 
 //
@@ -28,6 +34,9 @@ func (g *GoPluginMaster) Server(b *goplugin.MuxBroker) (any, error) {
 	gob.Register(Plugin.PluginData{})
 	gob.Register(store.ValueStruct{})
 	gob.Register(RpcPlugin{})
+	gob.Register(Plugin.Config{})
+	gob.Register(time.Duration(0))
+	gob.Register(utils.Secret(""))
 	return &ret, nil
 }
 
@@ -36,7 +45,10 @@ func (g *GoPluginMaster) Client(b *goplugin.MuxBroker, c *rpc.Client) (any, erro
 	gob.Register(Plugin.PluginData{})
 	gob.Register(store.ValueStruct{})
 	gob.Register(RpcPlugin{})
-	return &RpcPluginClient{Client: c}, nil
+	gob.Register(Plugin.Config{})
+	gob.Register(time.Duration(0))
+	gob.Register(utils.Secret(""))
+	return &RpcPluginClient{Client: c, Broker: b}, nil
 }
 
 //
@@ -44,18 +56,36 @@ func (g *GoPluginMaster) Client(b *goplugin.MuxBroker, c *rpc.Client) (any, erro
 // ---------------------------------------------------------------------------------------------------- //
 // 2. Client sends RPC request.
 type RpcPluginClient struct {
+	Name   string // Plugin name, for metrics.
 	Client *rpc.Client
+	Broker *goplugin.MuxBroker
 
 	Error Return.Error
 }
 
+// call - Call a method of the plugin process, recording how long it took, (see metrics.RpcCalls).
+func (g *RpcPluginClient) call(method string, args any, reply any) error {
+	start := time.Now()
+	err := g.Client.Call(method, args, reply)
+
+	if metrics.IsEnabled() {
+		labels := metrics.Labels{"plugin": g.Name, "method": strings.TrimPrefix(method, "Plugin.")}
+		metrics.Inc(metrics.RpcCalls, labels)
+		metrics.ObserveSince(metrics.RpcSeconds, start, labels)
+		if err != nil {
+			metrics.Inc(metrics.RpcErrors, labels)
+		}
+	}
+	return err
+}
+
 func (g *RpcPluginClient) GetData() Plugin.DynamicData {
 	g.Error = Return.Ok
 	var resp Plugin.DynamicData
-	err := g.Client.Call("Plugin.GetData", new(any), &resp)
+	err := g.call("Plugin.GetData", new(any), &resp)
 	if err != nil {
-		g.Error.SetError(err)
-		resp.Error.SetError(err)
+		g.Error = Return.NewRemoteError(err)
+		resp.Error = Return.NewRemoteError(err)
 	}
 	return resp
 }
@@ -63,9 +93,9 @@ func (g *RpcPluginClient) GetData() Plugin.DynamicData {
 func (g *RpcPluginClient) Identify() Plugin.Identity {
 	g.Error = Return.Ok
 	var resp Plugin.Identity
-	err := g.Client.Call("Plugin.Identify", new(any), &resp)
+	err := g.call("Plugin.Identify", new(any), &resp)
 	if err != nil {
-		g.Error.SetError(err)
+		g.Error = Return.NewRemoteError(err)
 	}
 	return resp
 }
@@ -73,24 +103,120 @@ func (g *RpcPluginClient) Identify() Plugin.Identity {
 func (g *RpcPluginClient) IdentifyString() string {
 	g.Error = Return.Ok
 	var resp string
-	err := g.Client.Call("Plugin.IdentifyString", new(any), &resp)
+	err := g.call("Plugin.IdentifyString", new(any), &resp)
 	if err != nil {
-		g.Error.SetError(err)
+		g.Error = Return.NewRemoteError(err)
 	}
 
 	return resp
 }
 
 func (g *RpcPluginClient) CallHook(name string, args ...any) (Plugin.HookResponse, Return.Error) {
+	return g.CallHookTrace(trace.SpanContext{}, name, args...)
+}
+
+// CallHookTrace - Call a hook within the plugin process, as part of the trace of parent.
+func (g *RpcPluginClient) CallHookTrace(parent trace.SpanContext, name string, args ...any) (Plugin.HookResponse, Return.Error) {
 	g.Error = Return.Ok
 	var resp Plugin.HookResponse
-	err := g.Client.Call("Plugin.CallHook", &Plugin.HookCallArgs{Name: name, Args: args}, &resp)
+	err := g.call("Plugin.CallHook", &Plugin.HookCallArgs{Name: name, Args: args, Traceparent: parent.Traceparent()}, &resp)
 	if err != nil {
-		g.Error.SetError(err)
+		g.Error = Return.NewRemoteError(err)
 	}
 	return resp, g.Error
 }
 
+// SetHost - Serve master's host services to the plugin process, over a new broker connection.
+func (g *RpcPluginClient) SetHost(host Plugin.HostInterface) Return.Error {
+	g.Error = Return.Ok
+	for range Only.Once {
+		if g.Broker == nil {
+			g.Error.SetError("RPC broker is nil")
+			break
+		}
+
+		id := g.Broker.NextId()
+		go g.Broker.AcceptAndServe(id, &RpcHostServer{Host: host})
+
+		err := g.call("Plugin.SetHost", id, new(any))
+		if err != nil {
+			g.Error = Return.NewRemoteError(err)
+		}
+	}
+	return g.Error
+}
+
+// ShareValues - Serve the plugin's values to the plugin process, over a new broker connection.
+func (g *RpcPluginClient) ShareValues(values *store.ValueStruct) Return.Error {
+	g.Error = Return.Ok
+	for range Only.Once {
+		if g.Broker == nil {
+			g.Error.SetError("RPC broker is nil")
+			break
+		}
+
+		id := g.Broker.NextId()
+		go g.Broker.AcceptAndServe(id, &RpcValueServer{Values: values})
+
+		err := g.call("Plugin.ShareValues", id, new(any))
+		if err != nil {
+			g.Error = Return.NewRemoteError(err)
+		}
+	}
+	return g.Error
+}
+
+// ValuesChanged - Send a change to the plugin's values to the plugin process, for its subscribers.
+func (g *RpcPluginClient) ValuesChanged(change store.ValueChange) Return.Error {
+	var err Return.Error
+	e := g.call("Plugin.ValuesChanged", &change, new(any))
+	if e != nil {
+		err = Return.NewRemoteError(e)
+	}
+	return err
+}
+
+// SetConfig - Send the config loaded by master to the plugin process.
+func (g *RpcPluginClient) SetConfig(config Plugin.Config) Return.Error {
+	g.Error = Return.Ok
+	err := g.call("Plugin.SetConfig", config, new(any))
+	if err != nil {
+		g.Error = Return.NewRemoteError(err)
+	}
+	return g.Error
+}
+
+// SetLogLevel - Change the level of the logger within the plugin process.
+func (g *RpcPluginClient) SetLogLevel(level hclog.Level) Return.Error {
+	g.Error = Return.Ok
+	err := g.call("Plugin.SetLogLevel", level, new(any))
+	if err != nil {
+		g.Error = Return.NewRemoteError(err)
+	}
+	return g.Error
+}
+
+// Callback - Run a callback, (Initialise, Notify, ...), within the plugin process.
+func (g *RpcPluginClient) Callback(callback string, args ...any) Return.Error {
+	span := trace.Start("callback "+callback, trace.SpanContext{}, "plugin", g.Name, "callback", callback, "rpc", true)
+	g.Error = Return.Ok
+	err := g.call("Plugin.Callback", &CallbackArgs{Name: callback, Args: args}, new(any))
+	if err != nil {
+		g.Error = Return.NewRemoteError(err)
+	}
+	Plugin.ObserveCallback(g.Name, callback, g.Error)
+	span.End(g.Error)
+	return g.Error
+}
+
+//
+// CallbackArgs - Arguments of a callback run within the plugin process.
+// ---------------------------------------------------------------------------------------------------- //
+type CallbackArgs struct {
+	Name string `json:"name,omitempty"`
+	Args []any  `json:"args,omitempty"`
+}
+
 //
 // RpcPluginServerInterface
 // ---------------------------------------------------------------------------------------------------- //
@@ -100,13 +226,20 @@ type RpcPluginServerInterface interface {
 	Identify() Plugin.Identity
 	IdentifyString() string
 	CallHook(name string, args ...any) (Plugin.HookResponse, Return.Error)
+	CallHookTrace(parent trace.SpanContext, name string, args ...any) (Plugin.HookResponse, Return.Error)
+	SetHost(host Plugin.HostInterface) Return.Error
+	SetConfig(config Plugin.Config) Return.Error
+	RefValues() *store.ValueStruct
+	SetLogLevel(level hclog.Level) Return.Error
+	Callback(callback string, ctx Plugin.PluginDataInterface, args ...any) Return.Error
 }
 
 //
 // RpcPluginServer
 // ---------------------------------------------------------------------------------------------------- //
 type RpcPluginServer struct {
-	Impl RpcPluginServerInterface
+	Impl   RpcPluginServerInterface
+	Broker *goplugin.MuxBroker
 
 	Error Return.Error
 }
@@ -114,23 +247,90 @@ type RpcPluginServer struct {
 func (s *RpcPluginServer) GetData(_ any, resp *Plugin.DynamicData) error {
 	s.Error = Return.Ok
 	*resp = s.Impl.GetData()
-	return s.Error.GetError()
+	return s.Error.GetRemoteError()
 }
 
 func (s *RpcPluginServer) Identify(_ any, resp *Plugin.Identity) error {
 	s.Error = Return.Ok
 	*resp = s.Impl.Identify()
-	return s.Error.GetError()
+	return s.Error.GetRemoteError()
 }
 
 func (s *RpcPluginServer) IdentifyString(_ any, resp *string) error {
 	s.Error = Return.Ok
 	*resp = s.Impl.IdentifyString()
-	return s.Error.GetError()
+	return s.Error.GetRemoteError()
 }
 
 func (s *RpcPluginServer) CallHook(args Plugin.HookCallArgs, resp *Plugin.HookResponse) error {
 	s.Error = Return.Ok
-	*resp, s.Error = s.Impl.CallHook(args.Name, args.Args...)
-	return s.Error.GetError()
+	// A missing, or invalid, traceparent starts a new trace.
+	parent, _ := trace.ParseTraceparent(args.Traceparent)
+	*resp, s.Error = s.Impl.CallHookTrace(parent, args.Name, args.Args...)
+	return s.Error.GetRemoteError()
+}
+
+// SetHost - Connect back to master's host services.
+func (s *RpcPluginServer) SetHost(id uint32, _ *any) error {
+	s.Error = Return.Ok
+	for range Only.Once {
+		if s.Broker == nil {
+			s.Error.SetError("RPC broker is nil")
+			break
+		}
+
+		conn, err := s.Broker.Dial(id)
+		if err != nil {
+			s.Error.SetError(err)
+			break
+		}
+
+		s.Error = s.Impl.SetHost(&RpcHostClient{Client: rpc.NewClient(conn)})
+	}
+	return s.Error.GetRemoteError()
+}
+
+// ShareValues - Connect back to the values held by master. From now on, both sides see one store.
+func (s *RpcPluginServer) ShareValues(id uint32, _ *any) error {
+	s.Error = Return.Ok
+	for range Only.Once {
+		if s.Broker == nil {
+			s.Error.SetError("RPC broker is nil")
+			break
+		}
+
+		conn, err := s.Broker.Dial(id)
+		if err != nil {
+			s.Error.SetError(err)
+			break
+		}
+
+		s.Impl.RefValues().SetRemote(&RpcValueClient{Client: rpc.NewClient(conn)})
+	}
+	return s.Error.GetRemoteError()
+}
+
+// ValuesChanged - A change to the values held by master, (see ShareValues).
+func (s *RpcPluginServer) ValuesChanged(change store.ValueChange, _ *any) error {
+	s.Impl.RefValues().Notify(change)
+	return nil
+}
+
+// SetConfig - Set the config loaded by master.
+func (s *RpcPluginServer) SetConfig(config Plugin.Config, _ *any) error {
+	s.Error = s.Impl.SetConfig(config)
+	return s.Error.GetRemoteError()
+}
+
+// SetLogLevel - Change the level of the plugin's logger.
+func (s *RpcPluginServer) SetLogLevel(level hclog.Level, _ *any) error {
+	s.Error = s.Impl.SetLogLevel(level)
+	return s.Error.GetRemoteError()
+}
+
+// Callback - Run a callback for master. A callback the plugin doesn't define is only a warning, so isn't returned.
+func (s *RpcPluginServer) Callback(args CallbackArgs, _ *any) error {
+	ctx, _ := s.Impl.(Plugin.PluginDataInterface)
+	s.Error = s.Impl.Callback(args.Name, ctx, args.Args...)
+	return s.Error.GetRemoteError()
 }
@@ -0,0 +1,122 @@
+package GoPlugLoader
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+type RpcErrorSuite struct {
+	suite.Suite
+	plugin *Plugin.PluginData
+	client *RpcPluginClient
+}
+
+// SetupTest - The same plugin data, called natively and through RpcPluginServer/RpcPluginClient.
+func (s *RpcErrorSuite) SetupTest() {
+	s.plugin = Plugin.NewPlugin()
+	err := s.plugin.SetHookIdentity("test")
+	s.Require().False(err.IsError())
+	err = s.plugin.SetHook("Open", hookOpen, "")
+	s.Require().False(err.IsError())
+
+	server := rpc.NewServer()
+	s.Require().NoError(server.RegisterName("Plugin", &RpcPluginServer{Impl: s.plugin}))
+	conn1, conn2 := net.Pipe()
+	go server.ServeConn(conn1)
+	s.client = &RpcPluginClient{Client: rpc.NewClient(conn2)}
+}
+
+func (s *RpcErrorSuite) TearDownTest() {
+	_ = s.client.Client.Close()
+}
+
+// hookOpen - Fails the way a hook would, with a cause, a code and a wrapped error.
+func hookOpen(_ Plugin.HookStruct, args ...any) (Plugin.HookResponse, Return.Error) {
+	var err Return.Error
+	_, e := os.Open(args[0].(string))
+	err.SetError("can't open: %s", e)
+	err.SetCode(Return.ConfigInvalid)
+	err.AddError("giving up")
+	return Plugin.HookResponse{}, err
+}
+
+func (s *RpcErrorSuite) compare(native Return.Error, remote Return.Error) {
+	s.True(native.IsError())
+	s.True(remote.IsError())
+	s.False(native.IsRemote())
+	s.True(remote.IsRemote())
+
+	s.Equal(native.String(), remote.String())
+	s.Equal(native.Error(), remote.Error())
+	s.Equal(native.GetError().Error(), remote.GetError().Error())
+	s.Equal(native.GetCode(), remote.GetCode())
+	s.Equal(native.GetPlugin(), remote.GetPlugin())
+	s.Equal(native.GetHook(), remote.GetHook())
+	s.NotEmpty(remote.GetStack())
+}
+
+func (s *RpcErrorSuite) TestHookError() {
+	_, native := s.plugin.CallHook("Open", "/does/not/exist")
+	_, remote := s.client.CallHook("Open", "/does/not/exist")
+	s.compare(native, remote)
+
+	s.True(errors.Is(native, ErrConfigInvalid))
+	s.True(errors.Is(remote, ErrConfigInvalid))
+	s.True(errors.Is(native, os.ErrNotExist))
+	s.Equal("test", remote.GetPlugin())
+	s.Equal("Open", remote.GetHook())
+}
+
+func (s *RpcErrorSuite) TestHookArgMismatch() {
+	_, native := s.plugin.CallHook("Open")
+	_, remote := s.client.CallHook("Open")
+	s.compare(native, remote)
+	s.True(errors.Is(remote, ErrHookArgMismatch))
+}
+
+func (s *RpcErrorSuite) TestHookNotFound() {
+	_, native := s.plugin.CallHook("Missing")
+	_, remote := s.client.CallHook("Missing")
+	s.compare(native, remote)
+	s.True(errors.Is(remote, ErrHookNotFound))
+}
+
+func (s *RpcErrorSuite) TestEncoding() {
+	_, native := s.plugin.CallHook("Open", "/does/not/exist")
+
+	data, e := native.MarshalBinary()
+	s.Require().NoError(e)
+	var binary Return.Error
+	s.Require().NoError(binary.UnmarshalBinary(data))
+	s.Equal(native.String(), binary.String())
+	s.Equal(native.GetCode(), binary.GetCode())
+	s.True(native.GetTime().Equal(binary.GetTime()))
+
+	data, e = native.MarshalJSON()
+	s.Require().NoError(e)
+	var json Return.Error
+	s.Require().NoError(json.UnmarshalJSON(data))
+	s.Equal(native.String(), json.String())
+	s.Equal(native.GetStack(), json.GetStack())
+	s.True(native.GetTime().Equal(json.GetTime()))
+}
+
+func (s *RpcErrorSuite) TestConnectionError() {
+	_ = s.client.Client.Close()
+	_, remote := s.client.CallHook("Open", "/does/not/exist")
+	s.True(remote.IsError())
+	s.False(remote.IsRemote())
+	s.True(errors.Is(remote, rpc.ErrShutdown))
+}
+
+func TestRpcErrorSuite(t *testing.T) {
+	suite.Run(t, new(RpcErrorSuite))
+}
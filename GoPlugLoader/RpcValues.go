@@ -0,0 +1,43 @@
+package GoPlugLoader
+
+import (
+	"net/rpc"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/store"
+)
+
+// ---------------------------------------------------------------------------------------------------- //
+// An RPC plugin's values, held by master and shared with the plugin process over the go-plugin MuxBroker.
+// Both sides see one store: the plugin process forwards every operation to master, which applies them in order,
+// (last write wins, use CompareAndSwap() for conditional writes), and sends every change back for subscribers.
+
+//
+// RpcValueServer - Master side, serves a plugin's values to the plugin process.
+// ---------------------------------------------------------------------------------------------------- //
+type RpcValueServer struct {
+	Values *store.ValueStruct
+}
+
+func (s *RpcValueServer) ValueOp(op store.ValueOp, resp *store.ValueResult) error {
+	var err Return.Error
+	*resp, err = s.Values.Apply(op)
+	return err.GetRemoteError()
+}
+
+//
+// RpcValueClient - Plugin side, implements store.ValueRemote by calling master.
+// ---------------------------------------------------------------------------------------------------- //
+type RpcValueClient struct {
+	Client *rpc.Client
+}
+
+func (c *RpcValueClient) ValueOp(op store.ValueOp) (store.ValueResult, Return.Error) {
+	var err Return.Error
+	var resp store.ValueResult
+	e := c.Client.Call("Plugin.ValueOp", &op, &resp)
+	if e != nil {
+		err = Return.NewRemoteError(e)
+	}
+	return resp, err
+}
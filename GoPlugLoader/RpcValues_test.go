@@ -0,0 +1,114 @@
+package GoPlugLoader
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	goplugin "github.com/hashicorp/go-plugin"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/utils/store"
+)
+
+type RpcValuesSuite struct {
+	suite.Suite
+	plugin *RpcPlugin // Plugin process side.
+	master *RpcPlugin // Master side, holds the values.
+	client *goplugin.RPCClient
+}
+
+// SetupTest - Master and a plugin process, connected over net.Pipe() with go-plugin's MuxBroker, sharing values.
+func (s *RpcValuesSuite) SetupTest() {
+	s.plugin = NewRpcPlugin()
+	s.master = NewRpcPlugin()
+	s.master.SetValue("shared", "from master")
+
+	conn1, conn2 := net.Pipe()
+	server := &goplugin.RPCServer{
+		Plugins: map[string]goplugin.Plugin{"plugin": s.plugin},
+		Stdout:  new(bytes.Buffer),
+		Stderr:  new(bytes.Buffer),
+	}
+	go server.ServeConn(conn1)
+
+	var e error
+	s.client, e = goplugin.NewRPCClient(conn2, map[string]goplugin.Plugin{"plugin": s.master})
+	s.Require().NoError(e)
+	raw, e := s.client.Dispense("plugin")
+	s.Require().NoError(e)
+	s.master.RpcService.Client = raw.(*RpcPluginClient)
+
+	err := s.master.shareValues()
+	s.Require().False(err.IsError(), err.String())
+}
+
+func (s *RpcValuesSuite) TearDownTest() {
+	s.master.RpcService.unshare()
+	_ = s.client.Close()
+}
+
+// subscribe - Changes seen by a subscriber, (called by the RPC server within the plugin process).
+func subscribe(values store.ValueStore, prefix string) (chan store.ValueChange, func()) {
+	changes := make(chan store.ValueChange, 10)
+	cancel := values.Subscribe(prefix, func(change store.ValueChange) {
+		changes <- change
+	})
+	return changes, cancel
+}
+
+func (s *RpcValuesSuite) receive(changes chan store.ValueChange) store.ValueChange {
+	select {
+	case change := <-changes:
+		return change
+	case <-time.After(5 * time.Second):
+		s.Fail("no change received")
+		return store.ValueChange{}
+	}
+}
+
+func (s *RpcValuesSuite) TestShared() {
+	values := s.plugin.RefValues()
+	s.Equal("from master", values.GetValue("shared"), "master's values are seen once shared")
+
+	s.master.SetValue("a.1", 1)
+	s.Equal(1, values.GetValue("a.1"), "set by master, read by the plugin")
+
+	values.SetValue("a.2", "two")
+	s.Equal("two", s.master.GetValue("a.2"), "set by the plugin, read by master")
+	s.Equal([]string{"a.1", "a.2"}, values.KeysWithPrefix("a."))
+	s.Equal(map[string]any{"a.1": 1, "a.2": "two"}, s.master.RefValues().GetPrefix("a."))
+
+	s.True(values.CompareAndSwap("a.1", 1, 10))
+	s.False(values.CompareAndSwap("a.1", 1, 20), "master holds 10")
+	s.Equal(10, s.master.GetValue("a.1"))
+
+	s.True(values.Delete("a.2"))
+	s.False(s.master.RefValues().ValueExists("a.2"))
+	s.Empty(values.Values, "the plugin holds no values itself")
+}
+
+func (s *RpcValuesSuite) TestSubscribe() {
+	changes, cancel := subscribe(s.plugin.RefValues(), "a.")
+	defer cancel()
+
+	s.master.SetValue("a.1", 1)
+	s.Equal(store.ValueChange{Key: "a.1", New: 1}, s.receive(changes), "changes made by master")
+
+	s.plugin.RefValues().SetValue("a.1", 2)
+	s.Equal(store.ValueChange{Key: "a.1", Old: 1, New: 2}, s.receive(changes), "changes made by the plugin")
+
+	s.master.SetValue("b", 3)
+	s.master.RefValues().Delete("a.1")
+	s.Equal(store.ValueChange{Key: "a.1", Old: 2, Deleted: true}, s.receive(changes), "other prefixes are ignored")
+
+	masterChanges, masterCancel := subscribe(s.master.RefValues(), "")
+	defer masterCancel()
+	s.plugin.RefValues().SetValue("c", "from plugin")
+	s.Equal(store.ValueChange{Key: "c", New: "from plugin"}, s.receive(masterChanges), "master's subscribers see the plugin's changes")
+}
+
+func TestRpcValuesSuite(t *testing.T) {
+	suite.Run(t, new(RpcValuesSuite))
+}
@@ -0,0 +1,97 @@
+// A GoPlug plugin whose Initialise fails, after writing its pid to $GOPLUG_TEST_PID_FILE, (see RpcLoader_test.go).
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader"
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// GoPluginIdentity - Set the GoPlugin identity. This is required for a minimal setup.
+var GoPluginIdentity = Plugin.Identity{
+	Callbacks: Plugin.Callbacks{
+		Initialise: Initialise,
+	},
+	Name:        "initfail",
+	Version:     "0.1.0",
+	Description: "A GoPlug plugin - initfail",
+	Repository:  "https://github.com/MickMake/GoPlug",
+	Maintainers: []string{"nobody@example.com"},
+}
+
+// ---------------------------------------------------------------------------------------------------- //
+
+// init - For a native plugin, global variables need to be set in init(), because main() is never called.
+func init() {
+}
+
+// main - For an RPC plugin, main() will be called. So we can run the RPC server here.
+func main() {
+	var MyRpcPlugin GoPlugLoader.PluginItem
+	var err Return.Error
+
+	for range Only.Once {
+		MyRpcPlugin, err = NewPlugin(Plugin.RpcPluginType)
+		if err.IsError() {
+			break
+		}
+
+		err = MyRpcPlugin.Serve()
+	}
+
+	err.Print()
+}
+
+// NewPlugin - Set up the plugin, with all its hooks, for the given plugin type.
+func NewPlugin(types Plugin.Types) (GoPlugLoader.PluginItem, Return.Error) {
+	var plug GoPlugLoader.PluginItem
+	var err Return.Error
+
+	for range Only.Once {
+		plug, err = GoPlugLoader.NewPluginItem(types, &GoPluginIdentity)
+		if err.IsError() {
+			break
+		}
+
+		err = plug.SetHandshakeConfig(Plugin.HandshakeConfig)
+		if err.IsError() {
+			break
+		}
+
+		err = plug.SetHook("Hello", Hello, "")
+		if err.IsError() {
+			break
+		}
+
+		err = plug.Validate()
+		if err.IsError() {
+			break
+		}
+	}
+
+	return plug, err
+}
+
+// ---------------------------------------------------------------------------------------------------- //
+
+// Initialise - Called by the host after the plugin has been loaded, with its Plugin.Config as the first arg.
+func Initialise(ctx Plugin.PluginDataInterface, args ...any) Return.Error {
+	funcName := utils.GetCaller(0)
+	log.Printf("%s() plugin '%s' failing\n", funcName, ctx.GetName())
+	_ = os.WriteFile(os.Getenv("GOPLUG_TEST_PID_FILE"), []byte(strconv.Itoa(os.Getpid())), 0o600)
+	return Return.NewError("initialise failed")
+}
+
+// Hello - Hook stub, called with a single string arg.
+func Hello(hook Plugin.HookStruct, args ...any) (Plugin.HookResponse, Return.Error) {
+	funcName := utils.GetCaller(0)
+	log.Printf("%s() called with %v\n", funcName, args)
+	return Plugin.NewHookResponse("Hello: " + args[0].(string))
+}
@@ -0,0 +1,20 @@
+// A native plugin without a GoPluginSymbols registry, (see NativeSymbols_test.go).
+package main
+
+import "fmt"
+
+var Value = "value"
+
+var hidden = "hidden"
+
+type Thing struct{}
+
+func (t Thing) Method() string {
+	return "method"
+}
+
+func Func() string {
+	return fmt.Sprint(hidden)
+}
+
+func main() {}
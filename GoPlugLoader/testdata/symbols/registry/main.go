@@ -0,0 +1,12 @@
+// A native plugin with a GoPluginSymbols registry, (see NativeSymbols_test.go).
+package main
+
+import "github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+
+var GoPluginSymbols = Plugin.Symbols{"Listed"}
+
+var Listed = "listed"
+
+var Unlisted = "unlisted"
+
+func main() {}
@@ -0,0 +1,268 @@
+package GoPlug
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader"
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/trace"
+)
+
+// SetGrants - Set the capabilities granted to plugins. Once set, plugins may only use capabilities they declare and are granted.
+func (m *PluginManager) SetGrants(grants Plugin.Grants) Return.Error {
+	for range Only.Once {
+		m.Error = grants.IsValid()
+		if m.Error.IsError() {
+			break
+		}
+
+		rpc, ok := m.Loaders.GetLoader(GoPlugLoader.RpcLoaderName).(*GoPlugLoader.RpcLoader)
+		if ok {
+			m.Error = rpc.SetGrants(grants)
+			if m.Error.IsError() {
+				break
+			}
+		}
+
+		m.grantLock.Lock()
+		m.Grants = grants
+		m.grantLock.Unlock()
+	}
+
+	return m.Error
+}
+
+// GetGrants - The capabilities granted to plugins, (nil if not enforced).
+func (m *PluginManager) GetGrants() Plugin.Grants {
+	m.grantLock.RLock()
+	defer m.grantLock.RUnlock()
+	return m.Grants
+}
+
+// Grant - Grant capabilities to a plugin name, ("*" for all plugins).
+func (m *PluginManager) Grant(name string, capabilities ...string) Return.Error {
+	grants := Plugin.NewGrants()
+	for k, v := range m.GetGrants() {
+		grants.Add(k, v...)
+	}
+	grants.Add(name, capabilities...)
+	return m.SetGrants(grants)
+}
+
+// SetHostHook - Offer a hook to plugins, which call it with Host().CallHostHook(), (needs host-hooks:<name>).
+func (m *PluginManager) SetHostHook(name string, function Plugin.HookFunction, args ...any) Return.Error {
+	m.hostLock.Lock()
+	defer m.hostLock.Unlock()
+	return m.HostHooks.SetHook(name, function, args...)
+}
+
+// GetHostValue - Read a value from the value store shared with plugins.
+func (m *PluginManager) GetHostValue(key string) any {
+	return m.HostValues.GetValue(key)
+}
+
+// SetHostValue - Write a value to the value store shared with plugins.
+func (m *PluginManager) SetHostValue(key string, value any) {
+	m.HostValues.SetValue(key, value)
+}
+
+// newHost - The Plugin.HostFactory given to the loaders.
+func (m *PluginManager) newHost(identity Plugin.Identity) Plugin.HostInterface {
+	for _, capability := range m.GetGrants().Missing(identity) {
+		log.Printf("[WARN]: Plugin(%s): capability '%s' is declared, but not granted", identity.Name, capability)
+	}
+	return &pluginHost{
+		manager:  m,
+		identity: identity,
+	}
+}
+
+//
+// pluginHost - Host services offered to a single plugin, checked against its capabilities.
+// ---------------------------------------------------------------------------------------------------- //
+type pluginHost struct {
+	manager  *PluginManager
+	identity Plugin.Identity
+	parent   trace.SpanContext // Trace of the hook calling, (see WithTrace).
+}
+
+// WithTrace - Implements Plugin.TracedHost.
+func (h *pluginHost) WithTrace(parent trace.SpanContext) Plugin.HostInterface {
+	ret := *h
+	ret.parent = parent
+	return &ret
+}
+
+func (h *pluginHost) CheckCapability(capability string) Return.Error {
+	return h.manager.GetGrants().Check(h.identity, capability)
+}
+
+func (h *pluginHost) CallHostHook(name string, args ...any) (Plugin.HookResponse, Return.Error) {
+	var resp Plugin.HookResponse
+	var err Return.Error
+
+	for range Only.Once {
+		err = h.CheckCapability(Plugin.CapabilityHostHooks + ":" + name)
+		if err.IsError() {
+			break
+		}
+
+		h.manager.hostLock.Lock()
+		hooks := h.manager.HostHooks
+		h.manager.hostLock.Unlock()
+
+		resp, err = hooks.CallHookTrace(h.parent, name, args...)
+	}
+
+	return resp, err
+}
+
+func (h *pluginHost) CallPluginHook(plugin string, name string, args ...any) (Plugin.HookResponse, Return.Error) {
+	var resp Plugin.HookResponse
+	var err Return.Error
+
+	for range Only.Once {
+		err = h.CheckCapability(Plugin.CapabilityPlugins + ":" + plugin)
+		if err.IsError() {
+			break
+		}
+
+		var item *GoPlugLoader.PluginItem
+		item, err = h.manager.getPluginByIdentity(plugin)
+		if err.IsError() {
+			break
+		}
+
+		resp, err = item.CallHookTrace(h.parent, name, args...)
+	}
+
+	return resp, err
+}
+
+func (h *pluginHost) GetHostValue(key string) (any, Return.Error) {
+	var value any
+	var err Return.Error
+
+	for range Only.Once {
+		err = h.CheckCapability(Plugin.CapabilityValuesRead)
+		if err.IsError() {
+			break
+		}
+
+		var ok bool
+		value, ok = h.manager.HostValues.Get(key)
+		if !ok {
+			err.SetError("host value '%s' not found", key)
+			break
+		}
+	}
+
+	return value, err
+}
+
+func (h *pluginHost) SetHostValue(key string, value any) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		err = h.CheckCapability(Plugin.CapabilityValuesWrite)
+		if err.IsError() {
+			break
+		}
+
+		h.manager.SetHostValue(key, value)
+	}
+
+	return err
+}
+
+func (h *pluginHost) HttpGet(url string, headers map[string]string) ([]byte, Return.Error) {
+	var body []byte
+	var err Return.Error
+
+	for range Only.Once {
+		err = h.CheckCapability(Plugin.CapabilityNetwork)
+		if err.IsError() {
+			break
+		}
+
+		fetch := utils.NewHttp()
+		err = fetch.SetUrl("%s", url)
+		if err.IsError() {
+			break
+		}
+		for key, value := range headers {
+			_ = fetch.SetHeader(key, value)
+		}
+
+		body, err = fetch.Get()
+	}
+
+	return body, err
+}
+
+func (h *pluginHost) ReadFile(path string) ([]byte, Return.Error) {
+	var data []byte
+	var err Return.Error
+
+	for range Only.Once {
+		path, err = h.checkPath(Plugin.CapabilityFsRead, path)
+		if err.IsError() {
+			break
+		}
+
+		data, err = utils.ReadFile(path)
+	}
+
+	return data, err
+}
+
+func (h *pluginHost) WriteFile(path string, data []byte) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		path, err = h.checkPath(Plugin.CapabilityFsWrite, path)
+		if err.IsError() {
+			break
+		}
+
+		err = utils.WriteFile(path, data)
+	}
+
+	return err
+}
+
+// checkPath - Check the plugin may use path, (fs:read or fs:write), after resolving symlinks.
+// A file that doesn't exist yet is resolved through its dir. Returns the resolved path.
+func (h *pluginHost) checkPath(kind string, path string) (string, Return.Error) {
+	var err Return.Error
+
+	for range Only.Once {
+		if !filepath.IsAbs(path) {
+			err.SetError("%s: path '%s' is not absolute", Plugin.PermissionDenied, path)
+			err.SetCode(Return.CapabilityDenied)
+			break
+		}
+
+		resolved, e := filepath.EvalSymlinks(path)
+		if os.IsNotExist(e) {
+			var dir string
+			dir, e = filepath.EvalSymlinks(filepath.Dir(path))
+			resolved = filepath.Join(dir, filepath.Base(path))
+		}
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		path = resolved
+
+		err = h.CheckCapability(kind + ":" + path)
+	}
+
+	return path, err
+}
@@ -0,0 +1,195 @@
+package GoPlug
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/store"
+)
+
+type HostSuite struct {
+	suite.Suite
+	dir     string
+	manager *PluginManager
+}
+
+// SetupTest - A manager with a value, and a dir with a file in it, (nothing is loaded).
+func (s *HostSuite) SetupTest() {
+	var e error
+	s.dir, e = filepath.EvalSymlinks(s.T().TempDir())
+	s.Require().NoError(e)
+	s.Require().NoError(os.WriteFile(filepath.Join(s.dir, "file"), []byte("data"), 0o600))
+	s.Require().NoError(os.Symlink("/etc", filepath.Join(s.dir, "etc")))
+
+	s.manager = &PluginManager{
+		HostHooks:  Plugin.NewHookStruct(),
+		HostValues: store.NewValueStruct(),
+	}
+	s.manager.SetHostValue("key", "value")
+	err := s.manager.SetHostHook("Echo", func(_ Plugin.HookStruct, args ...any) (Plugin.HookResponse, Return.Error) {
+		return Plugin.HookResponse{Value: args[0]}, Return.Ok
+	}, "")
+	s.Require().False(err.IsError(), err.String())
+}
+
+// host - The host a plugin declaring capabilities is given, with grants enforced.
+func (s *HostSuite) host(grants Plugin.Grants, capabilities ...string) Plugin.HostInterface {
+	s.manager.Grants = grants
+	return s.manager.newHost(Plugin.Identity{Name: "a", Capabilities: capabilities})
+}
+
+func (s *HostSuite) TestDenied() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	file := filepath.Join(s.dir, "file")
+	all := Plugin.Grants{"a": {Plugin.CapabilityAll}}
+
+	tests := []struct {
+		name         string
+		grants       Plugin.Grants
+		capabilities []string
+		call         func(host Plugin.HostInterface) Return.Error
+		denied       bool
+	}{
+		{
+			name:         "host hook",
+			grants:       all,
+			capabilities: []string{"host-hooks:Echo"},
+			call: func(host Plugin.HostInterface) Return.Error {
+				_, err := host.CallHostHook("Echo", "x")
+				return err
+			},
+		},
+		{
+			name:         "host hook not declared",
+			grants:       all,
+			capabilities: []string{"host-hooks:Other"},
+			call: func(host Plugin.HostInterface) Return.Error {
+				_, err := host.CallHostHook("Echo", "x")
+				return err
+			},
+			denied: true,
+		},
+		{
+			name:         "value read",
+			grants:       all,
+			capabilities: []string{Plugin.CapabilityValuesRead},
+			call: func(host Plugin.HostInterface) Return.Error {
+				_, err := host.GetHostValue("key")
+				return err
+			},
+		},
+		{
+			name:   "value read not declared",
+			grants: all,
+			call: func(host Plugin.HostInterface) Return.Error {
+				_, err := host.GetHostValue("key")
+				return err
+			},
+			denied: true,
+		},
+		{
+			name:         "value write not granted",
+			grants:       Plugin.Grants{"a": {Plugin.CapabilityValuesRead}},
+			capabilities: []string{Plugin.CapabilityValuesWrite},
+			call: func(host Plugin.HostInterface) Return.Error {
+				return host.SetHostValue("key", "changed")
+			},
+			denied: true,
+		},
+		{
+			name:         "http",
+			grants:       all,
+			capabilities: []string{Plugin.CapabilityNetwork},
+			call: func(host Plugin.HostInterface) Return.Error {
+				_, err := host.HttpGet(server.URL, nil)
+				return err
+			},
+		},
+		{
+			name:   "http not declared",
+			grants: all,
+			call: func(host Plugin.HostInterface) Return.Error {
+				_, err := host.HttpGet(server.URL, nil)
+				return err
+			},
+			denied: true,
+		},
+		{
+			name:         "file read",
+			grants:       all,
+			capabilities: []string{"fs:read:" + s.dir},
+			call: func(host Plugin.HostInterface) Return.Error {
+				_, err := host.ReadFile(file)
+				return err
+			},
+		},
+		{
+			name:         "file read through symlink",
+			grants:       all,
+			capabilities: []string{"fs:read:" + s.dir},
+			call: func(host Plugin.HostInterface) Return.Error {
+				_, err := host.ReadFile(filepath.Join(s.dir, "etc", "hostname"))
+				return err
+			},
+			denied: true,
+		},
+		{
+			name:         "file read relative",
+			grants:       all,
+			capabilities: []string{"fs:read:" + s.dir},
+			call: func(host Plugin.HostInterface) Return.Error {
+				_, err := host.ReadFile("file")
+				return err
+			},
+			denied: true,
+		},
+		{
+			name:         "file write read only",
+			grants:       all,
+			capabilities: []string{"fs:read:" + s.dir},
+			call: func(host Plugin.HostInterface) Return.Error {
+				return host.WriteFile(filepath.Join(s.dir, "new"), []byte("data"))
+			},
+			denied: true,
+		},
+		{
+			name:         "file write",
+			grants:       Plugin.Grants{"a": {"fs:write:" + s.dir}},
+			capabilities: []string{"fs:write:" + s.dir},
+			call: func(host Plugin.HostInterface) Return.Error {
+				return host.WriteFile(filepath.Join(s.dir, "new"), []byte("data"))
+			},
+		},
+		{
+			name: "not enforced",
+			call: func(host Plugin.HostInterface) Return.Error {
+				return host.SetHostValue("key", "changed")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			err := test.call(s.host(test.grants, test.capabilities...))
+			s.Equal(test.denied, err.IsError(), err.String())
+			if test.denied {
+				s.Equal(Return.CapabilityDenied, err.GetCode())
+			}
+		})
+	}
+}
+
+func TestHostSuite(t *testing.T) {
+	suite.Run(t, new(HostSuite))
+}
@@ -2,11 +2,13 @@ package GoPlug
 
 import (
 	"log"
-	"sync"
+	"sort"
+	"strings"
 
 	"github.com/MickMake/GoUnify/Only"
 
 	"github.com/MickMake/GoPlug/GoPlugLoader"
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
 	"github.com/MickMake/GoPlug/utils"
 	"github.com/MickMake/GoPlug/utils/Return"
 )
@@ -17,6 +19,151 @@ func (m *PluginManager) ListPlugins() {
 	m.Loaders.StorePrint()
 }
 
+// ListPluginFiles implements the interface method
+// ---------------------------------------------------------------------------------------------------- //
+func (m *PluginManager) ListPluginFiles() map[string][]utils.FilePath {
+	ret := make(map[string][]utils.FilePath)
+
+	for _, name := range []string{GoPlugLoader.NativeLoaderName, GoPlugLoader.RpcLoaderName} {
+		loader := m.Loaders.GetLoader(name)
+		if loader == nil {
+			continue
+		}
+
+		var files []utils.FilePath
+		for _, pDir := range loader.GetFiles() {
+			files = append(files, pDir.Get()...)
+		}
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].GetPath() < files[j].GetPath()
+		})
+		ret[name] = files
+	}
+
+	return ret
+}
+
+// FindPluginPath implements the interface method
+// ---------------------------------------------------------------------------------------------------- //
+func (m *PluginManager) FindPluginPath(id string) (*utils.FilePath, Return.Error) {
+	var pluginPath *utils.FilePath
+	var err Return.Error
+
+	for range Only.Once {
+		files := m.ListPluginFiles()
+		for _, name := range []string{GoPlugLoader.NativeLoaderName, GoPlugLoader.RpcLoaderName} {
+			for index, file := range files[name] {
+				switch id {
+				case file.GetPath(), file.GetBase(), file.GetName(), strings.TrimPrefix(file.GetName(), m.Prefix):
+					pluginPath = &files[name][index]
+				}
+				if pluginPath != nil {
+					break
+				}
+			}
+			if pluginPath != nil {
+				break
+			}
+		}
+
+		if pluginPath == nil {
+			err.SetError("plugin '%s' not found in '%s'", id, m.Loaders.GetDir())
+			break
+		}
+	}
+
+	return pluginPath, err
+}
+
+// ValidatePlugin implements the interface method
+// ---------------------------------------------------------------------------------------------------- //
+func (m *PluginManager) ValidatePlugin(pluginPath utils.FilePath) (*Plugin.Identity, Return.Error) {
+	var identity *Plugin.Identity
+	var err Return.Error
+
+	for range Only.Once {
+		err = m.LoadPlugin(pluginPath)
+		if err.IsError() {
+			break
+		}
+
+		var item *GoPlugLoader.PluginItem
+		item, err = m.GetPlugin(pluginPath)
+		if err.IsNotError() {
+			id := item.Identify()
+			identity = &id
+			_, err = m.Validator.Validate(identity, pluginPath.GetDir())
+		}
+
+		// Always unload, even if validation fails.
+		e := m.UnloadPlugin(pluginPath)
+		if e.IsError() && err.IsNotError() {
+			err = e
+		}
+	}
+
+	return identity, err
+}
+
+// ParsePlugin implements the interface method
+// ---------------------------------------------------------------------------------------------------- //
+func (m *PluginManager) ParsePlugin(pluginPath utils.FilePath) (*Plugin.Identity, Return.Error) {
+	return m.Loaders.PluginParse(pluginPath)
+}
+
+// ValidateManifest implements the interface method
+// ---------------------------------------------------------------------------------------------------- //
+func (m *PluginManager) ValidateManifest(pluginPath utils.FilePath) (*Plugin.Identity, Return.Error) {
+	var identity *Plugin.Identity
+	var err Return.Error
+
+	for range Only.Once {
+		var warning Return.Error
+		if preLoad := m.preLoadValidator(); preLoad != nil {
+			_, err = preLoad.Validate(pluginPath)
+			if err.IsError() {
+				break
+			}
+			warning = err
+		}
+
+		identity, err = m.ParsePlugin(pluginPath)
+		if err.IsError() {
+			break
+		}
+
+		validator := Plugin.NewBaseValidatorChain(&Plugin.JSONFileValidator{}, m.Validator)
+		_, err = validator.Validate(pluginPath.GetDir())
+		if err.IsNotError() && warning.IsWarning() {
+			warning.AddWarning(err.GetWarning())
+			err = warning
+		}
+	}
+
+	return identity, err
+}
+
+// FilterPlugins implements the interface method
+// ---------------------------------------------------------------------------------------------------- //
+func (m *PluginManager) FilterPlugins(match func(pluginPath utils.FilePath, identity *Plugin.Identity) bool) map[string][]utils.FilePath {
+	ret := make(map[string][]utils.FilePath)
+
+	for name, files := range m.ListPluginFiles() {
+		loader := m.Loaders.GetLoader(name)
+		for _, file := range files {
+			identity, err := loader.PluginParse(file)
+			if err.IsError() {
+				identity = nil
+			}
+			if match(file, identity) {
+				ret[name] = append(ret[name], file)
+			}
+		}
+	}
+
+	return ret
+}
+
 // GetPlugin implements the interface method
 // ---------------------------------------------------------------------------------------------------- //
 func (m *PluginManager) GetPlugin(pluginPath utils.FilePath) (*GoPlugLoader.PluginItem, Return.Error) {
@@ -66,6 +213,7 @@ func (m *PluginManager) UnregisterPlugins() Return.Error {
 // LoadPlugin implements the interface method
 // ---------------------------------------------------------------------------------------------------- //
 func (m *PluginManager) LoadPlugin(pluginPath utils.FilePath) Return.Error {
+	var plug GoPlugLoader.PluginItem
 	for range Only.Once {
 		m.Error = pluginPath.FileExists()
 		if m.Error.IsError() {
@@ -77,15 +225,23 @@ func (m *PluginManager) LoadPlugin(pluginPath utils.FilePath) Return.Error {
 		log.Printf("[INFO]: Plugin(%s): Loading", base)
 
 		// load
-		var plug GoPlugLoader.PluginItem
 		plug, m.Error = m.Loaders.PluginLoad(pluginPath)
 		if m.Error.IsError() {
 			log.Printf("[ERROR]: Plugin(%s): Load failed: %s", base, m.Error.String())
 			break
 		}
+
+		m.Error = m.checkLockIdentity(pluginPath, plug.Pluggable.GetIdentity())
+		if m.Error.IsError() {
+			log.Printf("[ERROR]: Plugin(%s): Load failed: %s", base, m.Error.String())
+			_ = m.Loaders.PluginUnload(pluginPath)
+			break
+		}
+		m.observeLoaded(plug.Pluggable.GetIdentity().Name)
 		log.Printf("[INFO]: Plugin(%s): Loaded OK - Native:%v RPC:%v\n",
 			base, plug.IsNativePlugin(), plug.IsRpcPlugin())
 	}
+	m.auditLoad(pluginPath, &plug, m.Error)
 
 	return m.Error
 }
@@ -98,7 +254,13 @@ func (m *PluginManager) UnloadPlugin(pluginPath utils.FilePath) Return.Error {
 		base := pluginPath.SetAltPath(m.Loaders.GetDir(), "[PluginDir]")
 		log.Printf("[INFO]: Plugin(%s): Unloading", base)
 
+		var name string
+		if item, err := m.GetPlugin(pluginPath); !err.IsError() && item != nil && item.Pluggable != nil {
+			name = item.Pluggable.GetIdentity().Name
+		}
+
 		m.Error = m.Loaders.PluginUnload(pluginPath)
+		m.auditUnload(pluginPath, name, m.Error)
 		if m.Error.IsError() {
 			break
 		}
@@ -137,6 +299,11 @@ func (m *PluginManager) Scan() Return.Error {
 			break
 		}
 
+		m.Error = m.scanLock()
+		if m.Error.IsError() {
+			break
+		}
+
 		m.Initialized = true
 		m.Error = Return.Ok
 	}
@@ -168,10 +335,11 @@ func (m *PluginManager) Register() Return.Error {
 // Dispose -
 // ---------------------------------------------------------------------------------------------------- //
 func (m *PluginManager) Dispose() {
-	var wg sync.WaitGroup
-	for _, pinfo := range m.Plugins {
-		m.UnloadPlugin(pinfo.Path)
+	m.stopConfigWatch()
+	m.stopMetrics()
+	for _, item := range m.Loaders.StoreGetAll() {
+		m.UnloadPlugin(item.GetFilename())
 	}
-
-	wg.Wait()
+	m.stopAudit()
+	m.stopTrace()
 }
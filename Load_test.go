@@ -0,0 +1,237 @@
+package GoPlug
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader"
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils"
+)
+
+type ManifestSuite struct {
+	suite.Suite
+	dir     string
+	manager *PluginManager
+}
+
+// SetupTest - A manager of a plugin dir, with plugin files that can't be loaded, so only their manifests are read.
+func (s *ManifestSuite) SetupTest() {
+	var e error
+	s.dir, e = filepath.EvalSymlinks(s.T().TempDir())
+	s.Require().NoError(e)
+
+	s.plugin("goplug-a", utils.PluginJSONFileName, `{
+	"name": "a",
+	"version": "1.0.0",
+	"maintainers": ["Mick <mick@example.com>"],
+	"loader": "rpc"
+}`)
+	s.plugin("goplug-b", utils.PluginYAMLFileName, "name: b\nversion: 2.0.0\nloader: rpc\n")
+	s.plugin("goplug-c", "", "")
+	s.plugin("goplug-d", utils.PluginJSONFileName, `{"name": "d", "version": "1.0.0", "loader": "native"}`)
+	s.plugin("goplug-e", utils.PluginJSONFileName, `{"name": "e", "version": "1.0.0", "entry": "goplug-other"}`)
+	s.plugin("goplug-f", "plugin.yml", "name: f\nversion: one\n")
+
+	// The manager log file is created in the working dir.
+	wd, e := os.Getwd()
+	s.Require().NoError(e)
+	s.Require().NoError(os.Chdir(s.T().TempDir()))
+	defer func() {
+		s.Require().NoError(os.Chdir(wd))
+	}()
+
+	identity := Plugin.Identity{
+		Name:        "manifest",
+		Version:     "0.0.0",
+		PluginTypes: Plugin.RpcPluginType,
+		Callbacks:   Plugin.NewCallbacks(),
+	}
+	manager, err := NewPluginManager(&identity)
+	s.Require().False(err.IsError(), err.String())
+	s.manager = manager.(*PluginManager)
+
+	err = s.manager.SetDir(s.dir)
+	s.Require().False(err.IsError(), err.String())
+	s.scan()
+}
+
+// plugin - Create a plugin dir, holding a plugin file and a manifest, (unless manifest is "").
+func (s *ManifestSuite) plugin(name string, manifest string, data string) {
+	dir := filepath.Join(s.dir, name)
+	s.Require().NoError(os.MkdirAll(dir, 0o755))
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/false\n"), 0o755))
+	if manifest != "" {
+		s.Require().NoError(os.WriteFile(filepath.Join(dir, manifest), []byte(data), 0o644))
+	}
+}
+
+func (s *ManifestSuite) scan() {
+	err := s.manager.Scan()
+	s.Require().False(err.IsError(), err.String())
+}
+
+func (s *ManifestSuite) find(name string) utils.FilePath {
+	pluginPath, err := s.manager.FindPluginPath(name)
+	s.Require().False(err.IsError(), err.String())
+	return *pluginPath
+}
+
+func (s *ManifestSuite) TestParsePlugin() {
+	tests := []struct {
+		name    string
+		plugin  string
+		version string
+		error   string
+	}{
+		{name: "json", plugin: "a", version: "1.0.0"},
+		{name: "yaml", plugin: "b", version: "2.0.0"},
+		{name: "yml", plugin: "f", version: "one"},
+		{name: "no manifest", plugin: "c", error: "no manifest found for plugin"},
+		{name: "other loader", plugin: "d", error: "is for a native plugin, not rpc"},
+		{name: "other entry", plugin: "e", error: "describes entry 'goplug-other', not 'goplug-e'"},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			identity, err := s.manager.ParsePlugin(s.find(test.plugin))
+			if test.error != "" {
+				s.True(err.IsError())
+				s.Contains(err.Error(), test.error)
+				return
+			}
+			s.Require().False(err.IsError(), err.String())
+			s.Equal(test.plugin, identity.Name)
+			s.Equal(test.version, identity.Version)
+		})
+	}
+
+	s.plugin("goplug-a", utils.PluginJSONFileName, `{"name": "a", "loader": "other"}`)
+	_, err := s.manager.ParsePlugin(s.find("a"))
+	s.True(err.IsError())
+	s.Contains(err.Error(), "unknown loader 'other'")
+}
+
+func (s *ManifestSuite) TestFilterPlugins() {
+	tests := []struct {
+		name    string
+		filters map[string]string
+		want    []string
+	}{
+		{name: "all", want: []string{"goplug-a", "goplug-b", "goplug-c", "goplug-d", "goplug-e", "goplug-f"}},
+		{name: "name", filters: map[string]string{"name": "a*"}, want: []string{"goplug-a"}},
+		{name: "version", filters: map[string]string{"version": "?.0.0"}, want: []string{"goplug-a", "goplug-b"}},
+		{name: "both", filters: map[string]string{"name": "b", "version": "1.*"}},
+		{name: "maintainers", filters: map[string]string{"maintainers": "*mick@example.com*"}, want: []string{"goplug-a"}},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			// As 'plugins list --filter' matches.
+			files := s.manager.FilterPlugins(func(_ utils.FilePath, identity *Plugin.Identity) bool {
+				for key, pattern := range test.filters {
+					if identity == nil || !identity.Match(key, pattern) {
+						return false
+					}
+				}
+				return true
+			})
+			s.Empty(files[GoPlugLoader.NativeLoaderName])
+
+			var names []string
+			for _, file := range files[GoPlugLoader.RpcLoaderName] {
+				names = append(names, file.GetName())
+			}
+			s.Equal(test.want, names)
+		})
+	}
+
+	identities := make(map[string]*Plugin.Identity)
+	s.manager.FilterPlugins(func(pluginPath utils.FilePath, identity *Plugin.Identity) bool {
+		identities[pluginPath.GetName()] = identity
+		return true
+	})
+	s.Nil(identities["goplug-c"], "no manifest")
+	s.Nil(identities["goplug-d"], "not for this loader")
+	s.Equal("b", identities["goplug-b"].Name)
+}
+
+func (s *ManifestSuite) TestValidateManifest() {
+	tests := []struct {
+		plugin  string
+		error   string
+		warning string
+	}{
+		{plugin: "a", warning: "plugin source missing"},
+		{plugin: "b", warning: "plugin source missing"},
+		{plugin: "c", error: "no manifest found for plugin"},
+		{plugin: "f", error: "Invalid Semantic Version"},
+	}
+
+	for _, test := range tests {
+		s.Run(test.plugin, func() {
+			// As 'plugins validate --no-load' validates.
+			identity, err := s.manager.ValidateManifest(s.find(test.plugin))
+			if test.error != "" {
+				s.True(err.IsError())
+				s.Contains(err.Error(), test.error)
+				return
+			}
+			s.Require().False(err.IsError(), err.String())
+			s.Equal(test.plugin, identity.Name)
+			s.True(err.IsWarning())
+			s.Contains(err.GetWarning().Error(), test.warning)
+		})
+	}
+
+	s.plugin("goplug-a", utils.PluginJSONFileName, `{"name": "b", "version": "1.0.0"}`)
+	_, err := s.manager.ValidateManifest(s.find("a"))
+	s.True(err.IsError())
+	s.Contains(err.Error(), "Name conflicts")
+}
+
+func (s *ManifestSuite) TestLock() {
+	// Plugins without a usable manifest would be loaded to lock them.
+	for _, name := range []string{"goplug-c", "goplug-d", "goplug-e", "goplug-f"} {
+		s.Require().NoError(os.RemoveAll(filepath.Join(s.dir, name)))
+	}
+	s.scan()
+
+	// As 'plugins lock' writes the lockfile.
+	lock, err := s.manager.LockPlugins("")
+	s.Require().False(err.IsError(), err.String())
+	s.Require().Len(lock.Plugins, 2)
+	s.Equal([]string{"a", "1.0.0"}, []string{lock.Plugins[0].Name, lock.Plugins[0].Version})
+	s.Equal([]string{"b", "2.0.0"}, []string{lock.Plugins[1].Name, lock.Plugins[1].Version})
+	s.FileExists(filepath.Join(s.dir, utils.LockFileName))
+
+	// As 'plugins lock --check' checks it.
+	err = s.manager.SetLockFile("", false)
+	s.Require().False(err.IsError(), err.String())
+	diffs, err := s.manager.CheckLock()
+	s.False(err.IsError(), err.String())
+	s.False(err.IsWarning(), err.String())
+	s.Empty(diffs)
+
+	s.plugin("goplug-a", utils.PluginJSONFileName, `{"name": "a", "version": "1.1.0"}`)
+	diffs, err = s.manager.CheckLock()
+	s.False(err.IsError(), err.String())
+	s.True(err.IsWarning())
+	s.Require().Len(diffs, 1)
+	s.Equal(Plugin.LockStatusChanged, diffs[0].Status)
+	s.Contains(diffs[0].String(), "version '1.1.0' does not match lockfile '1.0.0'")
+
+	// As '--locked' refuses it.
+	err = s.manager.SetLockFile("", true)
+	s.Require().False(err.IsError(), err.String())
+	_, err = s.manager.CheckLock()
+	s.True(err.IsError())
+	s.Contains(err.Error(), "1 plugins differ from the lockfile")
+}
+
+func TestManifestSuite(t *testing.T) {
+	suite.Run(t, new(ManifestSuite))
+}
@@ -0,0 +1,213 @@
+package GoPlug
+
+import (
+	"log"
+	"path/filepath"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader"
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// LockPlugins implements the interface method
+// ---------------------------------------------------------------------------------------------------- //
+func (m *PluginManager) LockPlugins(file string) (*Plugin.Lock, Return.Error) {
+	lock := Plugin.NewLock()
+	var err Return.Error
+
+	for range Only.Once {
+		file = m.lockFilePath(file)
+
+		for name, files := range m.ListPluginFiles() {
+			for _, pluginPath := range files {
+				var identity *Plugin.Identity
+				identity, err = m.lockIdentity(pluginPath)
+				if err.IsError() {
+					err.SetError("can't lock plugin '%s': %s", pluginPath.GetPath(), err.GetError())
+					break
+				}
+
+				var entry Plugin.LockEntry
+				entry, err = Plugin.NewLockEntry(m.Loaders.GetDir(), pluginPath, name, identity)
+				if err.IsError() {
+					break
+				}
+				lock.Add(entry)
+			}
+			if err.IsError() {
+				break
+			}
+		}
+		if err.IsError() {
+			break
+		}
+
+		err = lock.Save(file)
+		if err.IsError() {
+			break
+		}
+		log.Printf("[INFO]: Locked %d plugins in '%s'", len(lock.Plugins), file)
+	}
+
+	return lock, err
+}
+
+// SetLockFile implements the interface method
+// ---------------------------------------------------------------------------------------------------- //
+func (m *PluginManager) SetLockFile(file string, refuse bool) Return.Error {
+	for range Only.Once {
+		if file == "" && m.Loaders.GetDir() == "" {
+			m.Error.SetError("plugin dir not set")
+			break
+		}
+
+		m.Lock, m.Error = Plugin.LoadLock(m.lockFilePath(file))
+		if m.Error.IsError() {
+			m.Lock = nil
+			break
+		}
+		m.LockRefuse = refuse
+
+		m.Error = m.Loaders.SetPreLoadValidator(m.preLoadValidator())
+	}
+
+	return m.Error
+}
+
+// CheckLock implements the interface method
+// ---------------------------------------------------------------------------------------------------- //
+func (m *PluginManager) CheckLock() (Plugin.LockDiffs, Return.Error) {
+	var diffs Plugin.LockDiffs
+	var err Return.Error
+
+	for range Only.Once {
+		if m.Lock == nil {
+			err.SetError("no lockfile set")
+			break
+		}
+
+		var found []Plugin.LockEntry
+		for name, files := range m.ListPluginFiles() {
+			for _, pluginPath := range files {
+				// Only the manifest is read, plugins aren't loaded. The version is compared when known.
+				identity, _ := m.ParsePlugin(pluginPath)
+
+				var entry Plugin.LockEntry
+				entry, err = Plugin.NewLockEntry(m.Loaders.GetDir(), pluginPath, name, identity)
+				if err.IsError() {
+					break
+				}
+				found = append(found, entry)
+			}
+			if err.IsError() {
+				break
+			}
+		}
+		if err.IsError() {
+			break
+		}
+
+		diffs = m.Lock.Compare(found)
+		if len(diffs) > 0 {
+			err.SetWarning("%d plugins differ from the lockfile", len(diffs))
+			if m.LockRefuse {
+				err.SetError("%d plugins differ from the lockfile", len(diffs))
+			}
+		}
+	}
+
+	return diffs, err
+}
+
+// scanLock - Report the plugins that are missing, extra or changed against the lockfile, if set.
+// With LockRefuse, any difference is an error.
+func (m *PluginManager) scanLock() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if m.Lock == nil {
+			break
+		}
+
+		var diffs Plugin.LockDiffs
+		diffs, err = m.CheckLock()
+		for _, diff := range diffs {
+			log.Printf("[WARN]: Lockfile: %s", diff)
+		}
+		if err.IsError() {
+			err.SetCode(Return.PluginRefused)
+			break
+		}
+		err = Return.Ok
+	}
+
+	return err
+}
+
+// checkLockIdentity - Compare the identity of a loaded plugin against the lockfile version.
+func (m *PluginManager) checkLockIdentity(pluginPath utils.FilePath, identity Plugin.Identity) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if m.Lock == nil {
+			break
+		}
+
+		entry := m.Lock.Get(Plugin.LockFile(m.Loaders.GetDir(), pluginPath))
+		if entry == nil || entry.Version == identity.Version {
+			break
+		}
+
+		msg := "plugin '%s' version '%s' does not match lockfile '%s'"
+		if m.LockRefuse {
+			err.SetError(msg, pluginPath.GetPath(), identity.Version, entry.Version)
+			break
+		}
+		log.Printf("[WARN]: Plugin(%s): "+msg, pluginPath.GetName(), pluginPath.GetPath(), identity.Version, entry.Version)
+	}
+
+	return err
+}
+
+// lockIdentity - Identity of a plugin, from its manifest, or by loading it.
+func (m *PluginManager) lockIdentity(pluginPath utils.FilePath) (*Plugin.Identity, Return.Error) {
+	var identity *Plugin.Identity
+	var err Return.Error
+
+	for range Only.Once {
+		identity, err = m.ParsePlugin(pluginPath)
+		if err.IsNotError() && identity != nil && identity.Version != "" {
+			break
+		}
+
+		err = m.LoadPlugin(pluginPath)
+		if err.IsError() {
+			break
+		}
+
+		var item *GoPlugLoader.PluginItem
+		item, err = m.GetPlugin(pluginPath)
+		if err.IsNotError() {
+			id := item.Identify()
+			identity = &id
+		}
+
+		e := m.UnloadPlugin(pluginPath)
+		if e.IsError() && err.IsNotError() {
+			err = e
+		}
+	}
+
+	return identity, err
+}
+
+// lockFilePath - The lockfile path, (defaults to goplug.lock within the plugin dir).
+func (m *PluginManager) lockFilePath(file string) string {
+	if file == "" {
+		return filepath.Join(m.Loaders.GetDir(), utils.LockFileName)
+	}
+	return file
+}
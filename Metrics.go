@@ -0,0 +1,153 @@
+package GoPlug
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader"
+	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/metrics"
+)
+
+// MetricsPath - Where ServeMetrics() serves metrics.
+const MetricsPath = "/metrics"
+
+// SetMetricsSink - Also send metrics to sink, (nil stops). Metrics are only recorded once a sink is set, or GetMetrics() is called.
+func (m *PluginManager) SetMetricsSink(sink metrics.Sink) Return.Error {
+	m.MetricsSink = sink
+	m.setMetricsSink()
+	return Return.Ok
+}
+
+// GetMetrics - The Registry metrics are kept in, for Prometheus. Recording starts on first use.
+func (m *PluginManager) GetMetrics() *metrics.Registry {
+	if m.Metrics == nil {
+		m.Metrics = metrics.NewRegistry()
+		m.setMetricsSink()
+	}
+	return m.Metrics
+}
+
+// ServeMetrics - Serve GetMetrics(), in the Prometheus text format, from http://<addr>/metrics. Returns once listening.
+func (m *PluginManager) ServeMetrics(addr string) Return.Error {
+	for range Only.Once {
+		if m.metricsHttp != nil {
+			m.Error.SetError("metrics are already served")
+			break
+		}
+
+		listener, e := net.Listen("tcp", addr)
+		if e != nil {
+			m.Error.SetError(e)
+			break
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle(MetricsPath, m.GetMetrics())
+		m.metricsHttp = &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		go func(server *http.Server) {
+			e := server.Serve(listener)
+			if e != nil && e != http.ErrServerClosed {
+				log.Printf("[ERROR]: Metrics: %s", e)
+			}
+		}(m.metricsHttp)
+		log.Printf("[INFO]: Serving metrics from http://%s%s", listener.Addr(), MetricsPath)
+	}
+
+	return m.Error
+}
+
+// stopMetrics - Stop ServeMetrics(), if running, and collecting from this manager.
+func (m *PluginManager) stopMetrics() {
+	metrics.OnCollect(m.metricsName(), nil)
+	if m.metricsHttp == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = m.metricsHttp.Shutdown(ctx)
+	m.metricsHttp = nil
+}
+
+// setMetricsSink - Send metrics to the Registry and the sink, if set.
+func (m *PluginManager) setMetricsSink() {
+	var sinks metrics.Multi
+	if m.Metrics != nil {
+		sinks = append(sinks, m.Metrics)
+	}
+	if m.MetricsSink != nil {
+		sinks = append(sinks, m.MetricsSink)
+	}
+
+	switch len(sinks) {
+	case 0:
+		metrics.SetSink(nil)
+		metrics.OnCollect(m.metricsName(), nil)
+		return
+	case 1:
+		metrics.SetSink(sinks[0])
+	default:
+		metrics.SetSink(sinks)
+	}
+	metrics.OnCollect(m.metricsName(), m.collectMemory)
+}
+
+func (m *PluginManager) metricsName() string {
+	return fmt.Sprintf("PluginManager(%p)", m)
+}
+
+// observeLoaded - Record a plugin being loaded again, (see metrics.PluginRestarts).
+func (m *PluginManager) observeLoaded(name string) {
+	if m.loaded == nil {
+		m.loaded = make(map[string]bool)
+	}
+	if m.loaded[name] {
+		metrics.Inc(metrics.PluginRestarts, metrics.Labels{"plugin": name})
+	}
+	m.loaded[name] = true
+}
+
+// collectMemory - Set the memory gauge of master, and of each RPC plugin process. Plugins no longer running are set to zero.
+func (m *PluginManager) collectMemory() {
+	if mem, ok := metrics.ProcessMemory(os.Getpid()); ok {
+		metrics.SetGauge(metrics.ProcessMemoryBytes, float64(mem), metrics.Labels{"process": "master"})
+	}
+
+	running := make(map[string]bool)
+	for _, item := range m.GetPlugins() {
+		rpc, ok := item.Pluggable.(*GoPlugLoader.RpcPlugin)
+		if !ok {
+			continue
+		}
+
+		pid := rpc.GetPid()
+		if pid == 0 {
+			continue
+		}
+
+		if mem, ok := metrics.ProcessMemory(pid); ok {
+			name := rpc.GetIdentity().Name
+			metrics.SetGauge(metrics.ProcessMemoryBytes, float64(mem), metrics.Labels{"process": name})
+			running[name] = true
+		}
+	}
+
+	for name := range m.memory {
+		if !running[name] {
+			metrics.SetGauge(metrics.ProcessMemoryBytes, 0, metrics.Labels{"process": name})
+		}
+	}
+	m.memory = running
+}
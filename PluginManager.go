@@ -1,17 +1,27 @@
 package GoPlug
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/MickMake/GoUnify/Only"
+	"github.com/fsnotify/fsnotify"
+	"github.com/hashicorp/go-hclog"
 	goplugin "github.com/hashicorp/go-plugin"
 
 	"github.com/MickMake/GoPlug/GoPlugLoader"
 	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
 	"github.com/MickMake/GoPlug/utils"
 	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/audit"
+	"github.com/MickMake/GoPlug/utils/metrics"
+	"github.com/MickMake/GoPlug/utils/store"
+	"github.com/MickMake/GoPlug/utils/trace"
 )
 
 //
@@ -38,6 +48,25 @@ type Manager interface {
 	// ListPlugins - Print out all the plugins found.
 	ListPlugins()
 
+	// ListPluginFiles - Get the plugin files found by Scan(), keyed by loader type.
+	ListPluginFiles() map[string][]utils.FilePath
+
+	// FindPluginPath - Find a scanned plugin file by name, (with or without the prefix), or path.
+	FindPluginPath(id string) (*utils.FilePath, Return.Error)
+
+	// ValidatePlugin - Load a plugin, run its identity through the Validator chain, then unload it.
+	ValidatePlugin(pluginPath utils.FilePath) (*Plugin.Identity, Return.Error)
+
+	// ParsePlugin - Read the identity of a plugin from its manifest, without loading it.
+	ParsePlugin(pluginPath utils.FilePath) (*Plugin.Identity, Return.Error)
+
+	// ValidateManifest - Validate the manifest of a plugin, without loading it.
+	ValidateManifest(pluginPath utils.FilePath) (*Plugin.Identity, Return.Error)
+
+	// FilterPlugins - Plugin files, keyed by loader type, whose manifest identity matches.
+	// The identity is nil for plugins without a manifest.
+	FilterPlugins(match func(pluginPath utils.FilePath, identity *Plugin.Identity) bool) map[string][]utils.FilePath
+
 	// RegisterPlugins - Load all the plugins from the base plugin dir.
 	RegisterPlugins() Return.Error
 
@@ -61,9 +90,146 @@ type Manager interface {
 	// CheckPlugin - Get the plugin with the specified name.
 	CheckPlugin(pluginPath utils.FilePath) (*GoPlugLoader.PluginItem, Return.Error)
 
-	// BuildPlugins - Build plugins.
+	// BuildPlugins - Build native and RPC plugins from the plugin source dirs.
+	// Each dir may contain a manifest with build settings. Up-to-date builds are skipped.
 	BuildPlugins() Return.Error
 
+	// BuildPluginsContext - As BuildPlugins(), builds are stopped when ctx is done.
+	BuildPluginsContext(ctx context.Context) Return.Error
+
+	// SetBuildOptions - Set the number of parallel builds and the per build timeout.
+	SetBuildOptions(options BuildOptions) Return.Error
+
+	// SetToolchainCheck - Check native plugins against the build info of master before loading.
+	// With rebuild, incompatible plugins are rebuilt from their plugin source dir first.
+	SetToolchainCheck(enabled bool, rebuild bool) Return.Error
+
+	// SetTrustedKeys - Only load plugin files signed by one of these keys. An empty set disables the check.
+	SetTrustedKeys(keys Plugin.TrustedKeys) Return.Error
+
+	// LoadTrustedKeys - As SetTrustedKeys(), with keys read from files, (see Plugin.LoadTrustedKeys).
+	LoadTrustedKeys(files ...string) Return.Error
+
+	// SetRpcSecurity - Set how the RPC channel to plugins is secured, (AutoMTLS and checksum by default).
+	SetRpcSecurity(security GoPlugLoader.RpcSecurity) Return.Error
+
+	// SetPluginRpcSecurity - As SetRpcSecurity(), for a single plugin name.
+	SetPluginRpcSecurity(name string, security GoPlugLoader.RpcSecurity) Return.Error
+
+	// SetRpcLimits - Set resource limits and sandboxing for RPC plugin processes, unless the manifest declares its own.
+	SetRpcLimits(limits Plugin.Limits) Return.Error
+
+	// SetPluginRpcLimits - As SetRpcLimits(), for a single plugin name. Takes precedence over the manifest.
+	SetPluginRpcLimits(name string, limits Plugin.Limits) Return.Error
+
+	// SetRpcOutput - Set where the stdout and stderr of RPC plugin processes go. Lines are always prefixed with the
+	// plugin name and passed to its logger, optionally also to a rotated log file per plugin.
+	SetRpcOutput(output GoPlugLoader.RpcOutputConfig) Return.Error
+
+	// GetPluginOutput - The last lines of stdout and stderr from a loaded RPC plugin.
+	GetPluginOutput(name string) ([]string, Return.Error)
+
+	// SetLogLevel - Set the log level of the manager, and of plugins without their own, (trace, debug, info, warn, error or off).
+	SetLogLevel(level string) Return.Error
+
+	// SetPluginLogLevel - As SetLogLevel(), for a single plugin name. Applied straight away if the plugin is loaded,
+	// (RPC plugins are told over RPC).
+	SetPluginLogLevel(name string, level string) Return.Error
+
+	// SetMetricsSink - Also send metrics, (hook calls, loads, callbacks, ...), to sink, (see metrics.Sink).
+	SetMetricsSink(sink metrics.Sink) Return.Error
+
+	// GetMetrics - The Registry metrics are kept in, for Prometheus. Recording starts on first use.
+	GetMetrics() *metrics.Registry
+
+	// ServeMetrics - Serve GetMetrics(), in the Prometheus text format, from http://<addr>/metrics.
+	ServeMetrics(addr string) Return.Error
+
+	// SetTraceExporter - Send spans, (plugin loads, hook calls, callbacks, ...), to exporter, (see trace.Exporter).
+	// Trace context is carried over RPC, so spans within RPC plugins join the trace of the call.
+	SetTraceExporter(exporter trace.Exporter) Return.Error
+
+	// SetTraceFile - Append spans to file, as lines of JSON, (see trace.JSONFileExporter).
+	SetTraceFile(file string) Return.Error
+
+	// SetAudit - Record which plugins are loaded, (with SHA-256 and version), and unloaded, and by whom,
+	// and which hooks are called, with redacted arguments and results, to a hash-chained audit log, (see audit.Verify).
+	SetAudit(config audit.Config) Return.Error
+
+	// SetGrants - Set the capabilities granted to plugins, keyed by plugin name, ("*" for all).
+	// Once set, plugins may only use capabilities they both declare and are granted. Nil trusts all plugins.
+	SetGrants(grants Plugin.Grants) Return.Error
+
+	// GetGrants - The capabilities granted to plugins, (nil if not enforced).
+	GetGrants() Plugin.Grants
+
+	// Grant - Grant capabilities to a plugin name, ("*" for all plugins).
+	Grant(name string, capabilities ...string) Return.Error
+
+	// SetHostHook - Offer a hook to plugins, which call it with Host().CallHostHook(), (needs host-hooks:<name>).
+	SetHostHook(name string, function Plugin.HookFunction, args ...any) Return.Error
+
+	// GetHostValue - Read a value from the value store shared with plugins.
+	GetHostValue(key string) any
+
+	// SetHostValue - Write a value to the value store shared with plugins.
+	SetHostValue(key string, value any)
+
+	// SetConfigDir - Set the dir plugin config files, (<name>.json, <name>.yaml or <name>.toml), are loaded from.
+	// Defaults to 'config' within the plugin dir.
+	SetConfigDir(dir string) Return.Error
+	GetConfigDir() string
+
+	// LoadPluginConfig - Load a plugin's config file, apply GOPLUG_<NAME>_<KEY> env overrides, and validate it against Identity.Config.
+	// Plugins are given their config before Initialise.
+	LoadPluginConfig(identity Plugin.Identity) (Plugin.Config, Return.Error)
+
+	// GetPluginConfig - The config a loaded plugin was given.
+	GetPluginConfig(name string) (Plugin.Config, Return.Error)
+
+	// ReloadConfig - Reload the config of all loaded plugins, calling Notify with the new config on those that changed.
+	ReloadConfig() Return.Error
+
+	// WatchConfig - As ReloadConfig(), whenever a file within the config dir changes.
+	WatchConfig() Return.Error
+
+	// SetSecretsProviders - Set the providers config values of the form 'secret:<name>' are resolved from, tried in order.
+	// Defaults to GOPLUG_SECRET_<NAME> environment variables.
+	SetSecretsProviders(providers ...Plugin.SecretsProvider) Return.Error
+
+	// GetSecret - Get a secret by name from the providers. The value is redacted from logs, dumps and saved files.
+	GetSecret(name string) (utils.Secret, Return.Error)
+
+	// SetDataDir - Set the dir persistent plugin values are stored in, as <dir>/<name>/values.log.
+	// Defaults to 'data' within the plugin dir.
+	SetDataDir(dir string) Return.Error
+	GetDataDir() string
+
+	// SetPersistentValues - Keep the values of these plugins, ("*" for all), across restarts and reloads.
+	// Must be set before the plugins are loaded.
+	SetPersistentValues(names ...string) Return.Error
+
+	// GetPluginFaults - RPC plugin processes that exited while loaded, (limit violations, crashes).
+	GetPluginFaults() []GoPlugLoader.PluginFault
+
+	// OnPluginFault - Call handler when an RPC plugin process exits while loaded.
+	OnPluginFault(handler func(fault GoPlugLoader.PluginFault)) Return.Error
+
+	// LockPlugins - Write a lockfile pinning the name, version, loader type and SHA-256 of each scanned plugin.
+	// An empty file defaults to goplug.lock within the plugin dir.
+	LockPlugins(file string) (*Plugin.Lock, Return.Error)
+
+	// SetLockFile - Check plugin files against a lockfile before loading.
+	// Plugins that are not in the lockfile, or have changed, are reported, or with refuse, not loaded.
+	// Scan() reports plugins missing from, extra to, or changed since the lockfile, and with refuse fails.
+	SetLockFile(file string, refuse bool) Return.Error
+
+	// CheckLock - Compare the scanned plugin files against the lockfile, reporting missing, extra and changed plugins.
+	CheckLock() (Plugin.LockDiffs, Return.Error)
+
+	// GetBuildReport - Return the report of the last BuildPlugins() run.
+	GetBuildReport() *BuildReport
+
 	Scan() Return.Error
 
 	Register() Return.Error
@@ -85,19 +251,48 @@ type Manager interface {
 // PluginManager
 // ---------------------------------------------------------------------------------------------------- //
 type PluginManager struct {
-	Config      *Plugin.Identity             `json:"config"`      //
-	PluginDir   utils.FilePath               `json:"plugin_dir"`  //
-	CmdFile     utils.FilePath               `json:"cmd_file"`    //
-	FileGlob    string                       `json:"file_glob"`   // glob match for plugin filenames
-	Prefix      string                       `json:"prefix"`      //
-	Plugins     GoPlugLoader.PluginInfoMap   `json:"-"`           // Info for found plugins
-	Initialized bool                         `json:"initialized"` // has been Initialized
-	Loaders     GoPlugLoader.LoaderInterface `json:"-"`           //
-	Validator   Plugin.Validator             `json:"-"`           //
-	Logger      *utils.Logger                `json:"-"`           //
-	Logfile     *utils.FilePath              `json:"logfile"`     //
-	Error       Return.Error                 `json:"-"`           //
-	pluginImpl  goplugin.Plugin              // Plugin implementation dummy interface
+	Config       *Plugin.Identity               `json:"config"`        //
+	PluginDir    utils.FilePath                 `json:"plugin_dir"`    //
+	CmdFile      utils.FilePath                 `json:"cmd_file"`      //
+	FileGlob     string                         `json:"file_glob"`     // glob match for plugin filenames
+	Prefix       string                         `json:"prefix"`        //
+	Plugins      GoPlugLoader.PluginInfoMap     `json:"-"`             // Info for found plugins
+	Initialized  bool                           `json:"initialized"`   // has been Initialized
+	Loaders      GoPlugLoader.LoaderInterface   `json:"-"`             //
+	Validator    Plugin.Validator               `json:"-"`             //
+	TrustedKeys  Plugin.TrustedKeys             `json:"-"`             // Keys plugin files must be signed with
+	Lock         *Plugin.Lock                   `json:"-"`             // Lockfile plugin files are checked against
+	LockRefuse   bool                           `json:"lock_refuse"`   // Refuse plugins that differ from the lockfile
+	RpcSecurity  GoPlugLoader.RpcSecurityConfig `json:"rpc_security"`  // How RPC channels are secured
+	RpcLimits    GoPlugLoader.RpcLimitsConfig   `json:"rpc_limits"`    // Limits applied to RPC plugin processes
+	RpcOutput    GoPlugLoader.RpcOutputConfig   `json:"rpc_output"`    // Where RPC plugin output goes
+	LogLevels    utils.LogLevels                `json:"log_levels"`    // Log level of plugins, ("*" for all)
+	Grants       Plugin.Grants                  `json:"grants"`        // Capabilities granted to plugins, (nil trusts all)
+	HostHooks    Plugin.HookStruct              `json:"-"`             // Hooks offered to plugins
+	HostValues   store.ValueStruct              `json:"-"`             // Values shared with plugins
+	ConfigDir    string                         `json:"config_dir"`    // Dir plugin config files are loaded from
+	DataDir      string                         `json:"data_dir"`      // Dir persistent plugin values are stored in
+	Persist      []string                       `json:"persist"`       // Plugins whose values are persistent, ("*" for all)
+	Secrets      Plugin.Secrets                 `json:"-"`             // Providers 'secret:<name>' config values are resolved from
+	BuildReport  *BuildReport                   `json:"-"`             // Report of the last BuildPlugins()
+	BuildOptions BuildOptions                   `json:"build_options"` // How BuildPlugins() runs
+	Metrics      *metrics.Registry              `json:"-"`             // Metrics kept for Prometheus, (see GetMetrics)
+	MetricsSink  metrics.Sink                   `json:"-"`             // Where else metrics are sent
+	Audit        audit.Config                   `json:"audit"`         // Audit log, (see SetAudit)
+	Logger       *utils.Logger                  `json:"-"`             //
+	Logfile      *utils.FilePath                `json:"logfile"`       //
+	Error        Return.Error                   `json:"-"`             //
+	pluginImpl   goplugin.Plugin                // Plugin implementation dummy interface
+	rebuilding   atomic.Bool                    // A toolchain rebuild is in progress
+	hostLock     sync.Mutex                     // Guards HostHooks
+	grantLock    sync.RWMutex                   // Guards Grants
+	configWatch  *fsnotify.Watcher              // Running WatchConfig()
+	configLock   sync.Mutex                     // Guards configWatch
+	metricsHttp  *http.Server                   // Running ServeMetrics()
+	traceFile    *trace.JSONFileExporter        // Set by SetTraceFile()
+	auditLog     *audit.Log                     // Set by SetAudit()
+	loaded       map[string]bool                // Plugins loaded so far, (see metrics.PluginRestarts)
+	memory       map[string]bool                // Plugins with a memory gauge
 }
 
 // NewPluginManager is constructor of PluginManager
@@ -133,7 +328,7 @@ func NewPluginManager(config *Plugin.Identity) (Manager, Return.Error) {
 
 		var impl GoPlugLoader.RpcDefaultStruct
 
-		manager = &PluginManager{
+		m := &PluginManager{
 			Config:      config,
 			PluginDir:   base,
 			CmdFile:     file,
@@ -144,10 +339,35 @@ func NewPluginManager(config *Plugin.Identity) (Manager, Return.Error) {
 			pluginImpl:  impl,
 			Loaders:     GoPlugLoader.NewLoaders(&base, &file, config, &l),
 			Validator:   Plugin.NewBaseValidatorChain(&Plugin.IdentityValidator{}),
+			HostHooks:   Plugin.NewHookStruct(),
+			HostValues:  store.NewValueStruct(),
+			Secrets:     Plugin.Secrets{Plugin.NewEnvSecrets("")},
 			Logger:      &l,
 			Error:       err,
 			// validator: Plugin.NewBaseValidatorChain(&Plugin.JSONFileValidator{}, &Plugin.IdentityValidator{}, &Plugin.LocalSourceValidator{}),
 		}
+		manager = m
+
+		// Host hooks are reported as master's own, (eg: in errors and metrics).
+		err = m.HostHooks.SetHookIdentity(config.Name)
+		if err.IsError() {
+			break
+		}
+
+		err = m.Loaders.SetHostFactory(m.newHost)
+		if err.IsError() {
+			break
+		}
+
+		err = m.Loaders.SetConfigFactory(m.LoadPluginConfig)
+		if err.IsError() {
+			break
+		}
+
+		err = m.Loaders.SetValuesFactory(m.newValues)
+		if err.IsError() {
+			break
+		}
 
 		err = manager.SetPluginTypes(config.PluginTypes)
 		if err.IsError() {
@@ -174,6 +394,268 @@ func (m *PluginManager) SetPluginTypes(pluginTypes Plugin.Types) Return.Error {
 	return m.Loaders.SetPluginTypes(pluginTypes)
 }
 
+func (m *PluginManager) SetToolchainCheck(enabled bool, rebuild bool) Return.Error {
+	for range Only.Once {
+		native, ok := m.Loaders.GetLoader(GoPlugLoader.NativeLoaderName).(*GoPlugLoader.NativeLoader)
+		if !ok {
+			m.Error.SetError("native loader not available")
+			break
+		}
+
+		check := GoPlugLoader.ToolchainCheck{
+			Enabled: enabled,
+		}
+		if rebuild {
+			check.Rebuild = m.rebuildIncompatible
+		}
+		m.Error = native.SetToolchainCheck(check)
+	}
+
+	return m.Error
+}
+
+func (m *PluginManager) SetTrustedKeys(keys Plugin.TrustedKeys) Return.Error {
+	m.TrustedKeys = keys
+	m.Error = m.Loaders.SetPreLoadValidator(m.preLoadValidator())
+	return m.Error
+}
+
+func (m *PluginManager) LoadTrustedKeys(files ...string) Return.Error {
+	for range Only.Once {
+		var keys Plugin.TrustedKeys
+		keys, m.Error = Plugin.LoadTrustedKeys(files...)
+		if m.Error.IsError() {
+			break
+		}
+
+		m.Error = m.SetTrustedKeys(keys)
+	}
+
+	return m.Error
+}
+
+func (m *PluginManager) SetRpcSecurity(security GoPlugLoader.RpcSecurity) Return.Error {
+	config := m.RpcSecurity
+	config.Default = security
+	return m.setRpcSecurity(config)
+}
+
+func (m *PluginManager) SetPluginRpcSecurity(name string, security GoPlugLoader.RpcSecurity) Return.Error {
+	config := GoPlugLoader.RpcSecurityConfig{
+		Default: m.RpcSecurity.Default,
+		Plugins: make(map[string]GoPlugLoader.RpcSecurity),
+	}
+	for k, v := range m.RpcSecurity.Plugins {
+		config.Plugins[k] = v
+	}
+	config.Set(name, security)
+	return m.setRpcSecurity(config)
+}
+
+func (m *PluginManager) setRpcSecurity(config GoPlugLoader.RpcSecurityConfig) Return.Error {
+	for range Only.Once {
+		rpc, ok := m.Loaders.GetLoader(GoPlugLoader.RpcLoaderName).(*GoPlugLoader.RpcLoader)
+		if !ok {
+			m.Error.SetError("RPC loader not available")
+			break
+		}
+
+		m.Error = rpc.SetRpcSecurity(config)
+		if m.Error.IsError() {
+			break
+		}
+		m.RpcSecurity = config
+	}
+
+	return m.Error
+}
+
+func (m *PluginManager) SetRpcLimits(limits Plugin.Limits) Return.Error {
+	config := m.RpcLimits
+	config.Default = limits
+	return m.setRpcLimits(config)
+}
+
+func (m *PluginManager) SetPluginRpcLimits(name string, limits Plugin.Limits) Return.Error {
+	config := GoPlugLoader.RpcLimitsConfig{
+		Default: m.RpcLimits.Default,
+		Plugins: make(map[string]Plugin.Limits),
+	}
+	for k, v := range m.RpcLimits.Plugins {
+		config.Plugins[k] = v
+	}
+	config.Set(name, limits)
+	return m.setRpcLimits(config)
+}
+
+func (m *PluginManager) setRpcLimits(config GoPlugLoader.RpcLimitsConfig) Return.Error {
+	for range Only.Once {
+		rpc, ok := m.Loaders.GetLoader(GoPlugLoader.RpcLoaderName).(*GoPlugLoader.RpcLoader)
+		if !ok {
+			m.Error.SetError("RPC loader not available")
+			break
+		}
+
+		m.Error = rpc.SetRpcLimits(config)
+		if m.Error.IsError() {
+			break
+		}
+		m.RpcLimits = config
+	}
+
+	return m.Error
+}
+
+func (m *PluginManager) SetRpcOutput(output GoPlugLoader.RpcOutputConfig) Return.Error {
+	for range Only.Once {
+		rpc, ok := m.Loaders.GetLoader(GoPlugLoader.RpcLoaderName).(*GoPlugLoader.RpcLoader)
+		if !ok {
+			m.Error.SetError("RPC loader not available")
+			break
+		}
+
+		m.Error = rpc.SetRpcOutput(output)
+		if m.Error.IsError() {
+			break
+		}
+		m.RpcOutput = output
+	}
+
+	return m.Error
+}
+
+func (m *PluginManager) GetPluginOutput(name string) ([]string, Return.Error) {
+	var ret []string
+
+	for range Only.Once {
+		var item *GoPlugLoader.PluginItem
+		item, m.Error = m.getPluginByIdentity(name)
+		if m.Error.IsError() {
+			break
+		}
+
+		rpc, ok := item.Pluggable.(*GoPlugLoader.RpcPlugin)
+		if !ok {
+			m.Error.SetError("plugin '%s' is not an RPC plugin", name)
+			break
+		}
+		ret = rpc.GetOutput()
+	}
+
+	return ret, m.Error
+}
+
+func (m *PluginManager) SetLogLevel(level string) Return.Error {
+	for range Only.Once {
+		m.Error = m.SetPluginLogLevel("*", level)
+		if m.Error.IsError() {
+			break
+		}
+
+		var l hclog.Level
+		l, m.Error = utils.ParseLogLevel(level)
+		if m.Error.IsError() {
+			break
+		}
+		m.Logger.SetLevel(l)
+	}
+
+	return m.Error
+}
+
+func (m *PluginManager) SetPluginLogLevel(name string, level string) Return.Error {
+	for range Only.Once {
+		var l hclog.Level
+		l, m.Error = utils.ParseLogLevel(level)
+		if m.Error.IsError() {
+			break
+		}
+
+		levels := m.LogLevels.Copy()
+		levels[name] = l
+		m.Error = m.Loaders.SetLogLevels(levels)
+		if m.Error.IsError() {
+			break
+		}
+		m.LogLevels = levels
+
+		// Plugins already loaded.
+		for _, item := range m.GetPlugins() {
+			if name != "*" && item.Pluggable.GetIdentity().Name != name {
+				continue
+			}
+			e := item.Pluggable.SetLogLevel(levels.Get(item.Pluggable.GetIdentity().Name))
+			if e.IsError() {
+				m.Error.AddError("%s", e.GetError())
+			}
+		}
+	}
+
+	return m.Error
+}
+
+func (m *PluginManager) GetPluginFaults() []GoPlugLoader.PluginFault {
+	rpc, ok := m.Loaders.GetLoader(GoPlugLoader.RpcLoaderName).(*GoPlugLoader.RpcLoader)
+	if !ok {
+		return nil
+	}
+	return rpc.GetPluginFaults()
+}
+
+func (m *PluginManager) OnPluginFault(handler func(fault GoPlugLoader.PluginFault)) Return.Error {
+	for range Only.Once {
+		rpc, ok := m.Loaders.GetLoader(GoPlugLoader.RpcLoaderName).(*GoPlugLoader.RpcLoader)
+		if !ok {
+			m.Error.SetError("RPC loader not available")
+			break
+		}
+
+		rpc.OnPluginFault(handler)
+	}
+
+	return m.Error
+}
+
+// preLoadValidator - Validator chain run against plugin files before they are opened or executed.
+// Returns nil when there is nothing to check.
+func (m *PluginManager) preLoadValidator() Plugin.Validator {
+	var validators []Plugin.Validator
+
+	if len(m.TrustedKeys) > 0 {
+		validators = append(validators, &Plugin.SignatureValidator{Keys: m.TrustedKeys})
+	}
+	if m.Lock != nil {
+		validators = append(validators, &Plugin.LockValidator{Lock: m.Lock, BaseDir: m.Loaders.GetDir(), Refuse: m.LockRefuse})
+	}
+
+	if len(validators) == 0 {
+		return nil
+	}
+	return Plugin.NewBaseValidatorChain(validators...)
+}
+
+// rebuildIncompatible - ToolchainCheck.Rebuild callback.
+func (m *PluginManager) rebuildIncompatible(pluginPath utils.FilePath, report *GoPlugLoader.ToolchainReport) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if !report.IsToolchainMismatch() {
+			// Module versions come from the plugin's go.mod, a rebuild won't change them.
+			err.SetError("dependency versions differ, update the plugin go.mod")
+			break
+		}
+
+		if !m.rebuilding.CompareAndSwap(false, true) {
+			err.SetError("rebuild already in progress")
+			break
+		}
+		err = m.RebuildPlugin(pluginPath)
+		m.rebuilding.Store(false)
+	}
+
+	return err
+}
+
 func (m *PluginManager) SetPrefix(prefix string) Return.Error {
 	m.Prefix = prefix
 	return m.Loaders.SetPrefix(prefix)
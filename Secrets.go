@@ -0,0 +1,56 @@
+package GoPlug
+
+import (
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// SetSecretsProviders - Set the providers secrets are resolved from, tried in order, (defaults to env vars).
+func (m *PluginManager) SetSecretsProviders(providers ...Plugin.SecretsProvider) Return.Error {
+	for range Only.Once {
+		for _, provider := range providers {
+			if provider == nil {
+				m.Error.SetError("secrets provider is nil")
+				break
+			}
+		}
+		if m.Error.IsError() {
+			break
+		}
+
+		m.Secrets = providers
+	}
+
+	return m.Error
+}
+
+// GetSecret - Get a secret by name. The value is redacted from logs from now on.
+func (m *PluginManager) GetSecret(name string) (utils.Secret, Return.Error) {
+	return m.Secrets.Get(name)
+}
+
+// resolveSecrets - Replace 'secret:<name>' config values with the secret.
+func (m *PluginManager) resolveSecrets(values map[string]any) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		for key, value := range values {
+			name, ok := Plugin.IsSecretRef(value)
+			if !ok {
+				continue
+			}
+
+			secret, e := m.GetSecret(name)
+			if e.IsError() {
+				err.AddError("config option '%s': %s", key, e.GetError())
+				continue
+			}
+			values[key] = secret
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,45 @@
+package GoPlug
+
+import (
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/trace"
+)
+
+// SetTraceExporter - Send spans, (plugin loads, hook calls, callbacks, ...), to exporter, (nil discards them).
+func (m *PluginManager) SetTraceExporter(exporter trace.Exporter) Return.Error {
+	m.stopTrace()
+	trace.SetExporter(exporter)
+	return Return.Ok
+}
+
+// SetTraceFile - Append spans to file, as lines of JSON. RPC plugins loaded afterwards append their spans to the same file.
+func (m *PluginManager) SetTraceFile(file string) Return.Error {
+	for range Only.Once {
+		var exporter *trace.JSONFileExporter
+		exporter, m.Error = trace.NewJSONFileExporter(file)
+		if m.Error.IsError() {
+			break
+		}
+
+		m.stopTrace()
+		trace.SetExporter(exporter)
+		m.traceFile = exporter
+	}
+
+	return m.Error
+}
+
+// stopTrace - Stop exporting to the file from SetTraceFile(), if set.
+func (m *PluginManager) stopTrace() {
+	if m.traceFile == nil {
+		return
+	}
+
+	if trace.GetExporter() == trace.Exporter(m.traceFile) {
+		trace.SetExporter(nil)
+	}
+	_ = m.traceFile.Close()
+	m.traceFile = nil
+}
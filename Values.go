@@ -0,0 +1,70 @@
+package GoPlug
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/store"
+)
+
+// SetDataDir - Set the dir persistent plugin values are stored in, (defaults to 'data' within the plugin dir).
+func (m *PluginManager) SetDataDir(dir string) Return.Error {
+	for range Only.Once {
+		if dir == "" {
+			m.DataDir = ""
+			break
+		}
+
+		var e error
+		m.DataDir, e = filepath.Abs(dir)
+		if e != nil {
+			m.Error.SetError(e)
+			break
+		}
+	}
+
+	return m.Error
+}
+
+// GetDataDir - The dir persistent plugin values are stored in.
+func (m *PluginManager) GetDataDir() string {
+	if m.DataDir == "" {
+		return filepath.Join(m.Loaders.GetDir(), utils.DataDirName)
+	}
+	return m.DataDir
+}
+
+// SetPersistentValues - Keep the values of these plugins, ("*" for all), across restarts and reloads.
+func (m *PluginManager) SetPersistentValues(names ...string) Return.Error {
+	m.Persist = names
+	return m.Error
+}
+
+// isPersistent - Are the values of a plugin persistent?
+func (m *PluginManager) isPersistent(name string) bool {
+	for _, n := range m.Persist {
+		if n == "*" || n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// newValues - The Plugin.ValuesFactory given to the loaders.
+func (m *PluginManager) newValues(identity Plugin.Identity) (store.ValueBackend, Return.Error) {
+	if !m.isPersistent(identity.Name) {
+		return nil, Return.Ok
+	}
+
+	// The name comes from the plugin, it mustn't reach outside the data dir.
+	name := identity.Name
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return nil, Return.NewError("plugin name '%s' can't be used as a data dir", name)
+	}
+	return store.NewValueFile(filepath.Join(m.GetDataDir(), name))
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/MickMake/GoUnify/Only"
+	"github.com/MickMake/GoUnify/cmdHelp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/audit"
+)
+
+const (
+	flagAuditHead = "head"
+)
+
+//goland:noinspection GoNameStartsWithPackageName
+type CmdAudit struct {
+	CmdDefault
+
+	Head string
+}
+
+func NewCmdAudit() *CmdAudit {
+	var ret *CmdAudit
+
+	for range Only.Once {
+		ret = &CmdAudit{
+			CmdDefault: CmdDefault{
+				Error:   nil,
+				cmd:     nil,
+				SelfCmd: nil,
+			},
+		}
+	}
+
+	return ret
+}
+
+func (c *CmdAudit) AttachCommand(cmd *cobra.Command) *cobra.Command {
+	for range Only.Once {
+		if cmd == nil {
+			break
+		}
+		c.cmd = cmd
+
+		// ******************************************************************************** //
+		var cmdAudit = &cobra.Command{
+			Use:                   "audit",
+			Aliases:               []string{},
+			Annotations:           map[string]string{"group": "Audit"},
+			Short:                 fmt.Sprintf("Audit log commands."),
+			Long:                  fmt.Sprintf("Audit log commands, (see 'plugins --%s').", flagPluginsAudit),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               cmds.GoPlugArgs,
+			RunE:                  c.CmdAudit,
+			Args:                  cobra.MinimumNArgs(0),
+		}
+		cmd.AddCommand(cmdAudit)
+		cmdAudit.Example = cmdHelp.PrintExamples(cmdAudit, "verify audit.log")
+		c.SelfCmd = cmdAudit
+
+		// ******************************************************************************** //
+		var cmdAuditVerify = &cobra.Command{
+			Use:                   "verify <audit log> ...",
+			Aliases:               []string{},
+			Annotations:           map[string]string{"group": "Audit"},
+			Short:                 fmt.Sprintf("Verify the hash chain of audit logs."),
+			Long:                  fmt.Sprintf("Verify the hash chain of audit logs, reporting the first entry that has been changed, added or removed. Logs written with a key are verified with the key from %s. The head printed, (or logged when the log was closed), can be checked with --%s to detect a truncated or rewritten log.", audit.KeyEnv, flagAuditHead),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               cmds.GoPlugArgs,
+			RunE:                  c.CmdAuditVerify,
+			Args:                  cobra.MinimumNArgs(1),
+		}
+		cmdAudit.AddCommand(cmdAuditVerify)
+		cmdAuditVerify.Example = cmdHelp.PrintExamples(cmdAuditVerify, "audit.log", "audit.log --head 42:9f86d081884c7d65...")
+		cmdAuditVerify.Flags().StringVarP(&c.Head, flagAuditHead, "", "", fmt.Sprintf("The log must contain this head, ('<seq>:<hash>'), a single log only."))
+	}
+	return c.SelfCmd
+}
+
+func (c *CmdAudit) AttachFlags(cmd *cobra.Command, _ *viper.Viper) {
+	for range Only.Once {
+		if cmd == nil {
+			break
+		}
+	}
+}
+
+func (c *CmdAudit) CmdAudit(cmd *cobra.Command, _ []string) error {
+	return cmd.Help()
+}
+
+func (c *CmdAudit) CmdAuditVerify(_ *cobra.Command, args []string) error {
+	for range Only.Once {
+		var head audit.Head
+		if c.Head != "" {
+			if len(args) > 1 {
+				c.Error = errors.New(fmt.Sprintf("--%s checks a single audit log", flagAuditHead))
+				break
+			}
+
+			var err Return.Error
+			head, err = audit.ParseHead(c.Head)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+		key := utils.NewSecret(os.Getenv(audit.KeyEnv))
+
+		var failed int
+		for _, file := range args {
+			last, err := audit.Verify(file, key, head)
+			if err.IsError() {
+				fmt.Printf("FAILED: %s: %s\n", file, err.GetError())
+				failed++
+				continue
+			}
+			fmt.Printf("OK: %s (%d entries, head %s)\n", file, last.Seq, last)
+		}
+
+		if failed > 0 {
+			c.Error = errors.New(fmt.Sprintf("%d of %d audit logs failed verification", failed, len(args)))
+			break
+		}
+	}
+
+	return c.Error
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/MickMake/GoUnify/Only"
+	"github.com/MickMake/GoUnify/cmdHelp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/scaffold"
+)
+
+const (
+	flagNewNative     = "native"
+	flagNewRpc        = "rpc"
+	flagNewBoth       = "both"
+	flagNewDir        = "dir"
+	flagNewHooks      = "hook"
+	flagNewReplace    = "replace"
+	flagNewMaintainer = "maintainer"
+	flagNewForce      = "force"
+	flagNewTidy       = "tidy"
+)
+
+//goland:noinspection GoNameStartsWithPackageName
+type CmdNew struct {
+	CmdDefault
+
+	Native     bool
+	Rpc        bool
+	Both       bool
+	Dir        string
+	Hooks      []string
+	Replace    string
+	Maintainer string
+	Force      bool
+	Tidy       bool
+}
+
+func NewCmdNew() *CmdNew {
+	var ret *CmdNew
+
+	for range Only.Once {
+		ret = &CmdNew{
+			CmdDefault: CmdDefault{
+				Error:   nil,
+				cmd:     nil,
+				SelfCmd: nil,
+			},
+			Dir:  ".",
+			Tidy: true,
+		}
+	}
+
+	return ret
+}
+
+func (c *CmdNew) AttachCommand(cmd *cobra.Command) *cobra.Command {
+	for range Only.Once {
+		if cmd == nil {
+			break
+		}
+		c.cmd = cmd
+
+		// ******************************************************************************** //
+		var cmdNew = &cobra.Command{
+			Use:                   "new",
+			Aliases:               []string{"create"},
+			Annotations:           map[string]string{"group": "New"},
+			Short:                 fmt.Sprintf("Create new GoPlug projects."),
+			Long:                  fmt.Sprintf("Create new GoPlug projects."),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               nil,
+			RunE:                  c.CmdNew,
+			Args:                  cobra.MinimumNArgs(0),
+		}
+		cmd.AddCommand(cmdNew)
+		cmdNew.Example = cmdHelp.PrintExamples(cmdNew, "plugin <name>")
+		c.SelfCmd = cmdNew
+
+		// ******************************************************************************** //
+		var cmdNewPlugin = &cobra.Command{
+			Use:                   "plugin <name>",
+			Aliases:               []string{},
+			Annotations:           map[string]string{"group": "New"},
+			Short:                 fmt.Sprintf("Create a new plugin source dir."),
+			Long:                  fmt.Sprintf("Create a new plugin source dir, with main.go, main_test.go, Makefile and go.mod."),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               cmds.GoPlugArgs,
+			RunE:                  c.CmdNewPlugin,
+			Args:                  cobra.ExactArgs(1),
+		}
+		cmdNew.AddCommand(cmdNewPlugin)
+		cmdNewPlugin.Example = cmdHelp.PrintExamples(cmdNewPlugin,
+			"hello",
+			"hello --native",
+			"hello --rpc --hook Get --hook Set",
+			"hello --dir examples/plugins --replace ../../../",
+		)
+	}
+	return c.SelfCmd
+}
+
+func (c *CmdNew) AttachFlags(cmd *cobra.Command, viper *viper.Viper) {
+	for range Only.Once {
+		if cmd == nil {
+			break
+		}
+
+		cmd.PersistentFlags().BoolVarP(&c.Native, flagNewNative, "", false, fmt.Sprintf("Create a native plugin only."))
+		cmd.PersistentFlags().BoolVarP(&c.Rpc, flagNewRpc, "", false, fmt.Sprintf("Create an RPC plugin only."))
+		cmd.PersistentFlags().BoolVarP(&c.Both, flagNewBoth, "", false, fmt.Sprintf("Create a plugin supporting both native and RPC, (default)."))
+		cmd.PersistentFlags().StringVarP(&c.Dir, flagNewDir, "", c.Dir, fmt.Sprintf("Parent directory of the new plugin."))
+		viper.SetDefault(flagNewDir, c.Dir)
+		cmd.PersistentFlags().StringSliceVarP(&c.Hooks, flagNewHooks, "", nil, fmt.Sprintf("Hook stubs to create, (default 'Hello')."))
+		cmd.PersistentFlags().StringVarP(&c.Replace, flagNewReplace, "", "", fmt.Sprintf("Local GoPlug source path for a go.mod replace directive."))
+		cmd.PersistentFlags().StringVarP(&c.Maintainer, flagNewMaintainer, "", "", fmt.Sprintf("Plugin maintainer."))
+		cmd.PersistentFlags().BoolVarP(&c.Force, flagNewForce, "", false, fmt.Sprintf("Overwrite existing files."))
+		cmd.PersistentFlags().BoolVarP(&c.Tidy, flagNewTidy, "", c.Tidy, fmt.Sprintf("Run 'go mod tidy' after creating."))
+	}
+}
+
+func (c *CmdNew) CmdNew(cmd *cobra.Command, args []string) error {
+	for range Only.Once {
+		if len(args) == 0 {
+			c.Error = cmd.Help()
+			break
+		}
+	}
+
+	return c.Error
+}
+
+func (c *CmdNew) CmdNewPlugin(_ *cobra.Command, args []string) error {
+	for range Only.Once {
+		options := scaffold.PluginOptions{
+			Name:       args[0],
+			Dir:        c.Dir,
+			Hooks:      c.Hooks,
+			Maintainer: c.Maintainer,
+			Replace:    c.Replace,
+			Force:      c.Force,
+		}
+
+		switch {
+		case c.Both:
+			options.Types = Plugin.AllPluginTypes
+		case c.Native && c.Rpc:
+			options.Types = Plugin.AllPluginTypes
+		case c.Native:
+			options.Types = Plugin.NativePluginType
+		case c.Rpc:
+			options.Types = Plugin.RpcPluginType
+		default:
+			options.Types = Plugin.AllPluginTypes
+		}
+
+		files, err := scaffold.NewPlugin(options)
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+
+		for _, file := range files {
+			fmt.Printf("Created: %s\n", file)
+		}
+
+		if !c.Tidy {
+			break
+		}
+
+		options.Defaults()
+		tidy := exec.Command("go", "mod", "tidy")
+		tidy.Dir = options.Target()
+		tidy.Stdout = os.Stdout
+		tidy.Stderr = os.Stderr
+		e := tidy.Run()
+		if e != nil {
+			c.Error = errors.New(fmt.Sprintf("go mod tidy failed in %s: %s", tidy.Dir, e))
+			break
+		}
+	}
+
+	return c.Error
+}
@@ -0,0 +1,903 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/MickMake/GoUnify/Only"
+	"github.com/MickMake/GoUnify/cmdHelp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/MickMake/GoPlug"
+	"github.com/MickMake/GoPlug/GoPlugLoader"
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/defaults"
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+	"github.com/MickMake/GoPlug/utils/audit"
+	"github.com/MickMake/GoPlug/utils/trace"
+)
+
+const (
+	flagPluginsDir      = "plugin-dir"
+	flagPluginsGlob     = "plugin-glob"
+	flagPluginsType     = "plugin-type"
+	flagPluginsRebuild  = "rebuild"
+	flagPluginsKeys     = "trusted-keys"
+	flagPluginsLock     = "lockfile"
+	flagPluginsLocked   = "locked"
+	flagPluginsRpcCA    = "rpc-ca"
+	flagPluginsRpcNoTLS = "insecure-rpc"
+	flagPluginsRpcLimit = "rpc-limits"
+	flagPluginsGrant    = "grant"
+	flagPluginsConfig   = "plugin-config"
+	flagPluginsSecDir   = "secrets-dir"
+	flagPluginsSecFile  = "secrets-file"
+	flagPluginsDataDir  = "data-dir"
+	flagPluginsPersist  = "persist-values"
+	flagPluginsLogDir   = "plugin-log-dir"
+	flagPluginsLogLevel = "log-level"
+	flagPluginsLogJSON  = "log-json"
+	flagPluginsMetrics  = "metrics-addr"
+	flagPluginsTrace    = "trace-file"
+	flagPluginsAudit    = "audit-file"
+	flagPluginsActor    = "audit-actor"
+	flagPluginsRedact   = "audit-redact"
+
+	flagPluginsFilter = "filter"
+	flagPluginsNoLoad = "no-load"
+	flagPluginsCheck  = "check"
+
+	flagPluginsBuildWorkers = "workers"
+	flagPluginsBuildTimeout = "build-timeout"
+)
+
+//goland:noinspection GoNameStartsWithPackageName
+type CmdPlugins struct {
+	CmdDefault
+
+	Dir     string
+	Glob    string
+	Type    string
+	Rebuild bool
+	Keys    []string
+	Lock    string
+	Locked  bool
+	RpcCA   []string
+	NoTLS   bool
+	Limits  []string
+	Grants  []string
+	Config  string
+	SecDir  string
+	SecFile string
+	DataDir string
+	Persist []string
+	LogDir  string
+	Levels  []string
+	LogJSON bool
+	Metrics string
+	Trace   string
+	Audit   string
+	Actor   string
+	Redact  []string
+
+	Filters []string
+	NoLoad  bool
+	Check   bool
+
+	BuildWorkers int
+	BuildTimeout time.Duration
+
+	manager GoPlug.Manager
+	secrets Plugin.SecretsStore
+}
+
+func NewCmdPlugins() *CmdPlugins {
+	var ret *CmdPlugins
+
+	for range Only.Once {
+		ret = &CmdPlugins{
+			CmdDefault: CmdDefault{
+				Error:   nil,
+				cmd:     nil,
+				SelfCmd: nil,
+			},
+			Dir:     "",
+			Glob:    "goplug-*",
+			Type:    "all",
+			manager: nil,
+		}
+	}
+
+	return ret
+}
+
+func (c *CmdPlugins) AttachCommand(cmd *cobra.Command) *cobra.Command {
+	for range Only.Once {
+		if cmd == nil {
+			break
+		}
+		c.cmd = cmd
+
+		// ******************************************************************************** //
+		var cmdPlugins = &cobra.Command{
+			Use:                   "plugins",
+			Aliases:               []string{"plugin"},
+			Annotations:           map[string]string{"group": "Plugins"},
+			Short:                 fmt.Sprintf("Manage GoPlug plugins within a directory."),
+			Long:                  fmt.Sprintf("Manage GoPlug plugins within a directory."),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               nil,
+			RunE:                  c.CmdPlugins,
+			Args:                  cobra.MinimumNArgs(0),
+		}
+		cmd.AddCommand(cmdPlugins)
+		cmdPlugins.Example = cmdHelp.PrintExamples(cmdPlugins, "list", "inspect <plugin>", "validate [plugin]", "call <plugin> <hook> [args...]", "build", "lock")
+		c.SelfCmd = cmdPlugins
+
+		// ******************************************************************************** //
+		var cmdPluginsList = &cobra.Command{
+			Use:                   "list",
+			Aliases:               []string{"ls"},
+			Annotations:           map[string]string{"group": "Plugins"},
+			Short:                 fmt.Sprintf("List plugins found, with their loader type."),
+			Long:                  fmt.Sprintf("List plugins found, with their loader type."),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               c.PluginsArgs,
+			RunE:                  c.CmdPluginsList,
+			Args:                  cobra.ExactArgs(0),
+		}
+		cmdPlugins.AddCommand(cmdPluginsList)
+		cmdPluginsList.Example = cmdHelp.PrintExamples(cmdPluginsList, "", "--plugin-type rpc", "--filter name=hello*", "--filter version=1.* --filter maintainers=*mickmake*")
+		cmdPluginsList.Flags().StringSliceVarP(&c.Filters, flagPluginsFilter, "", nil, fmt.Sprintf("Only list plugins whose manifest matches 'key=pattern', (name, version, maintainers, description, repository)."))
+
+		// ******************************************************************************** //
+		var cmdPluginsInspect = &cobra.Command{
+			Use:                   "inspect",
+			Aliases:               []string{"show"},
+			Annotations:           map[string]string{"group": "Plugins"},
+			Short:                 fmt.Sprintf("Show a plugin's identity, hooks and exported symbols."),
+			Long:                  fmt.Sprintf("Show a plugin's identity, hooks and exported symbols."),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               c.PluginsArgs,
+			RunE:                  c.CmdPluginsInspect,
+			Args:                  cobra.ExactArgs(1),
+		}
+		cmdPlugins.AddCommand(cmdPluginsInspect)
+		cmdPluginsInspect.Example = cmdHelp.PrintExamples(cmdPluginsInspect, "simple", "goplug-simple.so")
+
+		// ******************************************************************************** //
+		var cmdPluginsValidate = &cobra.Command{
+			Use:                   "validate",
+			Aliases:               []string{"check"},
+			Annotations:           map[string]string{"group": "Plugins"},
+			Short:                 fmt.Sprintf("Run plugins through the validator chain."),
+			Long:                  fmt.Sprintf("Run plugins through the validator chain. With no args, all plugins found are validated."),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               c.PluginsArgs,
+			RunE:                  c.CmdPluginsValidate,
+			Args:                  cobra.MinimumNArgs(0),
+		}
+		cmdPlugins.AddCommand(cmdPluginsValidate)
+		cmdPluginsValidate.Example = cmdHelp.PrintExamples(cmdPluginsValidate, "", "simple", "--no-load")
+		cmdPluginsValidate.Flags().BoolVarP(&c.NoLoad, flagPluginsNoLoad, "", false, fmt.Sprintf("Only validate plugin manifests, without loading the plugins."))
+
+		// ******************************************************************************** //
+		var cmdPluginsCall = &cobra.Command{
+			Use:                   "call",
+			Aliases:               []string{"exec"},
+			Annotations:           map[string]string{"group": "Plugins"},
+			Short:                 fmt.Sprintf("Call a plugin hook."),
+			Long:                  fmt.Sprintf("Call a plugin hook. Args are converted to the types declared by the hook."),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               c.PluginsArgs,
+			RunE:                  c.CmdPluginsCall,
+			Args:                  cobra.MinimumNArgs(2),
+		}
+		cmdPlugins.AddCommand(cmdPluginsCall)
+		cmdPluginsCall.Example = cmdHelp.PrintExamples(cmdPluginsCall, "simple TestExec4 hello 42")
+
+		// ******************************************************************************** //
+		var cmdPluginsConfig = &cobra.Command{
+			Use:                   "config",
+			Aliases:               []string{},
+			Annotations:           map[string]string{"group": "Plugins"},
+			Short:                 fmt.Sprintf("Show the config a plugin is given."),
+			Long:                  fmt.Sprintf("Show the config a plugin is given, from its config file and %s_<PLUGIN>_<KEY> environment variables.", Plugin.ConfigEnvPrefix),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               c.PluginsArgs,
+			RunE:                  c.CmdPluginsConfig,
+			Args:                  cobra.ExactArgs(1),
+		}
+		cmdPlugins.AddCommand(cmdPluginsConfig)
+		cmdPluginsConfig.Example = cmdHelp.PrintExamples(cmdPluginsConfig, "openweathermap", "openweathermap --plugin-config /etc/goplug")
+
+		// ******************************************************************************** //
+		var cmdPluginsSecrets = &cobra.Command{
+			Use:                   "secrets",
+			Aliases:               []string{"secret"},
+			Annotations:           map[string]string{"group": "Plugins"},
+			Short:                 fmt.Sprintf("List, set or delete secrets."),
+			Long:                  fmt.Sprintf("List the secrets within --%s or --%s, set a secret from stdin, or delete one. Plugin config refers to them as '%s<name>'.", flagPluginsSecFile, flagPluginsSecDir, Plugin.SecretRefPrefix),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               c.PluginsArgs,
+			RunE:                  c.CmdPluginsSecrets,
+			Args:                  cobra.RangeArgs(0, 2),
+		}
+		cmdPlugins.AddCommand(cmdPluginsSecrets)
+		cmdPluginsSecrets.Example = cmdHelp.PrintExamples(cmdPluginsSecrets, "--secrets-dir ~/.goplug/secrets", "set api_key --secrets-dir ~/.goplug/secrets < key.txt", "delete api_key --secrets-file secrets.json")
+
+		// ******************************************************************************** //
+		var cmdPluginsBuild = &cobra.Command{
+			Use:                   "build",
+			Aliases:               []string{},
+			Annotations:           map[string]string{"group": "Plugins"},
+			Short:                 fmt.Sprintf("Build plugins from source."),
+			Long:                  fmt.Sprintf("Build plugins from source."),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               c.PluginsArgs,
+			RunE:                  c.CmdPluginsBuild,
+			Args:                  cobra.ExactArgs(0),
+		}
+		cmdPlugins.AddCommand(cmdPluginsBuild)
+		cmdPluginsBuild.Example = cmdHelp.PrintExamples(cmdPluginsBuild, "", "--workers 2 --build-timeout 5m")
+		cmdPluginsBuild.Flags().IntVarP(&c.BuildWorkers, flagPluginsBuildWorkers, "", 0, fmt.Sprintf("Maximum number of parallel builds, (defaults to the number of CPUs)."))
+		cmdPluginsBuild.Flags().DurationVarP(&c.BuildTimeout, flagPluginsBuildTimeout, "", 0, fmt.Sprintf("Kill a single build after this long, (defaults to no timeout)."))
+
+		// ******************************************************************************** //
+		var cmdPluginsLock = &cobra.Command{
+			Use:                   "lock",
+			Aliases:               []string{},
+			Annotations:           map[string]string{"group": "Plugins"},
+			Short:                 fmt.Sprintf("Write, or check, the plugin lockfile."),
+			Long:                  fmt.Sprintf("Write a lockfile pinning the name, version, loader type and SHA-256 of each plugin found, (defaults to '%s' within the plugin dir).", utils.LockFileName),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               c.PluginsArgs,
+			RunE:                  c.CmdPluginsLock,
+			Args:                  cobra.ExactArgs(0),
+		}
+		cmdPlugins.AddCommand(cmdPluginsLock)
+		cmdPluginsLock.Example = cmdHelp.PrintExamples(cmdPluginsLock, "", "--check", "--lockfile deploy/goplug.lock")
+		cmdPluginsLock.Flags().BoolVarP(&c.Check, flagPluginsCheck, "", false, fmt.Sprintf("Report plugins that are missing, extra, or changed, compared to the lockfile."))
+	}
+	return c.SelfCmd
+}
+
+func (c *CmdPlugins) AttachFlags(cmd *cobra.Command, viper *viper.Viper) {
+	for range Only.Once {
+		if cmd == nil {
+			break
+		}
+
+		cmd.PersistentFlags().StringVarP(&c.Dir, flagPluginsDir, "", c.Dir, fmt.Sprintf("Plugin base directory, (defaults to the current directory)."))
+		viper.SetDefault(flagPluginsDir, c.Dir)
+		cmd.PersistentFlags().StringVarP(&c.Glob, flagPluginsGlob, "", c.Glob, fmt.Sprintf("Plugin filename match."))
+		viper.SetDefault(flagPluginsGlob, c.Glob)
+		cmd.PersistentFlags().StringVarP(&c.Type, flagPluginsType, "", c.Type, fmt.Sprintf("Plugin loader type: 'all', 'native', 'rpc'."))
+		viper.SetDefault(flagPluginsType, c.Type)
+		cmd.PersistentFlags().BoolVarP(&c.Rebuild, flagPluginsRebuild, "", false, fmt.Sprintf("Rebuild native plugins that are incompatible with this binary."))
+		cmd.PersistentFlags().StringSliceVarP(&c.Keys, flagPluginsKeys, "", nil, fmt.Sprintf("Trusted public key files, only plugins signed by one of these keys are loaded."))
+		cmd.PersistentFlags().StringVarP(&c.Lock, flagPluginsLock, "", "", fmt.Sprintf("Report plugins that differ from this lockfile, (see 'plugins lock')."))
+		cmd.PersistentFlags().StringSliceVarP(&c.RpcCA, flagPluginsRpcCA, "", nil, fmt.Sprintf("Pin a CA for RPC plugins, instead of AutoMTLS: 'ca,cert,key,plugin-cert,plugin-key' files."))
+		cmd.PersistentFlags().BoolVarP(&c.NoTLS, flagPluginsRpcNoTLS, "", false, fmt.Sprintf("Disable AutoMTLS for RPC plugins."))
+		cmd.PersistentFlags().StringSliceVarP(&c.Limits, flagPluginsRpcLimit, "", nil, fmt.Sprintf("Limits for RPC plugins without their own: 'memory=MB,cpu=secs,files=n,restrict-env,env=NAME,dir=d,namespaces=pid:net,seccomp'."))
+		cmd.PersistentFlags().StringArrayVarP(&c.Grants, flagPluginsGrant, "", nil, fmt.Sprintf("Grant a capability to a plugin, ('plugin=capability', '*' for all plugins). Once given, capabilities are enforced."))
+		cmd.PersistentFlags().StringVarP(&c.Config, flagPluginsConfig, "", "", fmt.Sprintf("Dir of plugin config files, '<plugin>.{json,yaml,toml}', (defaults to '%s' within the plugin dir).", utils.ConfigDirName))
+		cmd.PersistentFlags().StringVarP(&c.SecDir, flagPluginsSecDir, "", "", fmt.Sprintf("Dir of secret files, one per secret, readable only by the owner."))
+		cmd.PersistentFlags().StringVarP(&c.SecFile, flagPluginsSecFile, "", "", fmt.Sprintf("Encrypted secrets file, the passphrase is taken from %s.", Plugin.SecretPassEnv))
+		cmd.PersistentFlags().StringVarP(&c.DataDir, flagPluginsDataDir, "", "", fmt.Sprintf("Dir persistent plugin values are stored in, (defaults to '%s' within the plugin dir).", utils.DataDirName))
+		cmd.PersistentFlags().StringSliceVarP(&c.Persist, flagPluginsPersist, "", nil, fmt.Sprintf("Keep the values of these plugins across restarts, ('*' for all)."))
+		cmd.PersistentFlags().StringVarP(&c.LogDir, flagPluginsLogDir, "", "", fmt.Sprintf("Also write the output of each RPC plugin to '<plugin>.log' within this dir, rotated at %dMB.", GoPlugLoader.DefaultOutputMaxSize>>20))
+		cmd.PersistentFlags().StringArrayVarP(&c.Levels, flagPluginsLogLevel, "", nil, fmt.Sprintf("Log level, (trace, debug, info, warn, error or off), or 'plugin=level' for a single plugin."))
+		cmd.PersistentFlags().BoolVarP(&c.LogJSON, flagPluginsLogJSON, "", false, fmt.Sprintf("Log JSON lines, (also set by %s=json).", utils.LogFormatEnv))
+		cmd.PersistentFlags().StringVarP(&c.Metrics, flagPluginsMetrics, "", "", fmt.Sprintf("Serve metrics, in the Prometheus text format, from http://<addr>%s.", GoPlug.MetricsPath))
+		cmd.PersistentFlags().StringVarP(&c.Trace, flagPluginsTrace, "", "", fmt.Sprintf("Append trace spans, (plugin loads, hook calls, ...), to this file as JSON lines, (also set by %s).", trace.FileEnv))
+		cmd.PersistentFlags().StringVarP(&c.Audit, flagPluginsAudit, "", "", fmt.Sprintf("Record plugin loads, unloads and hook calls to this hash-chained audit log, (keyed by %s if set, see 'audit verify').", audit.KeyEnv))
+		cmd.PersistentFlags().StringVarP(&c.Actor, flagPluginsActor, "", "", fmt.Sprintf("Who is recorded in the audit log as loading and unloading plugins, (defaults to the OS user)."))
+		cmd.PersistentFlags().StringArrayVarP(&c.Redact, flagPluginsRedact, "", nil, fmt.Sprintf("Redact hook parameters in the audit log, as '<hook>=<param>,...', (numbered from 0, '*' for all, or '%s').", audit.ParamResult))
+		cmd.PersistentFlags().BoolVarP(&c.Locked, flagPluginsLocked, "", false, fmt.Sprintf("Refuse plugins that differ from the lockfile, (defaults to '%s' within the plugin dir).", utils.LockFileName))
+	}
+}
+
+// PluginsArgs - Set up the plugin manager from the command line flags.
+func (c *CmdPlugins) PluginsArgs(cmd *cobra.Command, args []string) error {
+	for range Only.Once {
+		c.Error = cmds.ProcessArgs(cmd, args)
+		if c.Error != nil {
+			break
+		}
+
+		var types Plugin.Types
+		switch strings.ToLower(c.Type) {
+		case "", "all", "both":
+			types = Plugin.AllPluginTypes
+		case GoPlugLoader.NativeLoaderName:
+			types = Plugin.NativePluginType
+		case GoPlugLoader.RpcLoaderName:
+			types = Plugin.RpcPluginType
+		default:
+			c.Error = errors.New(fmt.Sprintf("unknown plugin type '%s'", c.Type))
+		}
+		if c.Error != nil {
+			break
+		}
+
+		identity := Plugin.Identity{
+			Name:        defaults.BinaryName,
+			Version:     defaults.BinaryVersion,
+			Description: defaults.Description,
+			Repository:  "https://" + defaults.SourceRepo,
+			Maintainers: []string{"mick@mickmake.com"},
+			PluginTypes: types,
+			Callbacks:   Plugin.NewCallbacks(),
+		}
+
+		if c.LogJSON {
+			// Before any logger is created, and inherited by RPC plugin processes.
+			c.Error = os.Setenv(utils.LogFormatEnv, "json")
+			if c.Error != nil {
+				break
+			}
+		}
+
+		var err Return.Error
+		c.manager, err = GoPlug.NewPluginManager(&identity)
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+
+		if c.Dir == "" {
+			c.Dir, c.Error = os.Getwd()
+			if c.Error != nil {
+				break
+			}
+		}
+
+		err = c.manager.SetDir(c.Dir)
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+
+		err = c.manager.SetToolchainCheck(true, c.Rebuild)
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+
+		if len(c.Keys) > 0 {
+			err = c.manager.LoadTrustedKeys(c.Keys...)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+
+		if c.NoTLS || len(c.RpcCA) > 0 {
+			security := GoPlugLoader.RpcSecurity{Insecure: c.NoTLS}
+			if len(c.RpcCA) > 0 {
+				if len(c.RpcCA) != 5 {
+					c.Error = errors.New(fmt.Sprintf("--%s expects 5 files: ca,cert,key,plugin-cert,plugin-key", flagPluginsRpcCA))
+					break
+				}
+				security.CAFile = c.RpcCA[0]
+				security.CertFile = c.RpcCA[1]
+				security.KeyFile = c.RpcCA[2]
+				security.PluginCertFile = c.RpcCA[3]
+				security.PluginKeyFile = c.RpcCA[4]
+			}
+
+			err = c.manager.SetRpcSecurity(security)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+
+		if len(c.Limits) > 0 {
+			var limits Plugin.Limits
+			limits, err = Plugin.ParseLimits(c.Limits...)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+
+			err = c.manager.SetRpcLimits(limits)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+
+		if c.Config != "" {
+			err = c.manager.SetConfigDir(c.Config)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+
+		if c.SecDir != "" || c.SecFile != "" {
+			// Env vars first, so a secret can be overridden.
+			providers := []Plugin.SecretsProvider{Plugin.NewEnvSecrets("")}
+			if c.SecDir != "" {
+				var keyring *Plugin.KeyringSecrets
+				keyring, err = Plugin.NewKeyringSecrets(c.SecDir)
+				if err.IsError() {
+					c.Error = err.GetError()
+					break
+				}
+				providers = append(providers, keyring)
+				c.secrets = keyring
+			}
+			if c.SecFile != "" {
+				var file *Plugin.EncryptedFileSecrets
+				file, err = Plugin.NewEncryptedFileSecrets(c.SecFile, utils.NewSecret(os.Getenv(Plugin.SecretPassEnv)))
+				if err.IsError() {
+					c.Error = err.GetError()
+					break
+				}
+				providers = append(providers, file)
+				c.secrets = file
+			}
+
+			err = c.manager.SetSecretsProviders(providers...)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+
+		if c.DataDir != "" {
+			err = c.manager.SetDataDir(c.DataDir)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+
+		if len(c.Persist) > 0 {
+			err = c.manager.SetPersistentValues(c.Persist...)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+
+		if c.Metrics != "" {
+			err = c.manager.ServeMetrics(c.Metrics)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+
+		if c.Trace != "" {
+			err = c.manager.SetTraceFile(c.Trace)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+
+		if c.Audit != "" {
+			config := audit.Config{
+				File:  c.Audit,
+				Actor: c.Actor,
+				Key:   utils.NewSecret(os.Getenv(audit.KeyEnv)),
+			}
+			for _, rule := range c.Redact {
+				err = config.Redactions.Add(rule)
+				if err.IsError() {
+					break
+				}
+			}
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+
+			err = c.manager.SetAudit(config)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+
+		for _, level := range c.Levels {
+			name, l, found := strings.Cut(level, "=")
+			if found {
+				err = c.manager.SetPluginLogLevel(name, l)
+			} else {
+				err = c.manager.SetLogLevel(level)
+			}
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+		if c.Error != nil {
+			break
+		}
+
+		if c.LogDir != "" {
+			err = c.manager.SetRpcOutput(GoPlugLoader.RpcOutputConfig{Dir: c.LogDir})
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+
+		if len(c.Grants) > 0 {
+			var grants Plugin.Grants
+			grants, err = Plugin.ParseGrants(c.Grants...)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+
+			err = c.manager.SetGrants(grants)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+
+		if (c.Lock != "" || c.Locked) && cmd.Name() != "lock" {
+			err = c.manager.SetLockFile(c.Lock, c.Locked)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+		}
+
+		err = c.manager.SetFileGlob(c.Glob)
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+
+		err = c.manager.Scan()
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+	}
+
+	return c.Error
+}
+
+func (c *CmdPlugins) CmdPlugins(cmd *cobra.Command, args []string) error {
+	for range Only.Once {
+		if len(args) == 0 {
+			c.Error = cmd.Help()
+			break
+		}
+	}
+
+	return c.Error
+}
+
+func (c *CmdPlugins) CmdPluginsList(_ *cobra.Command, _ []string) error {
+	for range Only.Once {
+		filters := make(map[string]string)
+		for _, filter := range c.Filters {
+			key, pattern, ok := strings.Cut(filter, "=")
+			if !ok {
+				c.Error = errors.New(fmt.Sprintf("invalid filter '%s', expecting 'key=pattern'", filter))
+				break
+			}
+			filters[key] = pattern
+		}
+		if c.Error != nil {
+			break
+		}
+
+		// Plugins are matched against their manifest, they are not loaded.
+		identities := make(map[string]*Plugin.Identity)
+		files := c.manager.FilterPlugins(func(pluginPath utils.FilePath, identity *Plugin.Identity) bool {
+			identities[pluginPath.GetPath()] = identity
+			for key, pattern := range filters {
+				if identity == nil || !identity.Match(key, pattern) {
+					return false
+				}
+			}
+			return true
+		})
+
+		var count int
+		for _, loader := range []string{GoPlugLoader.NativeLoaderName, GoPlugLoader.RpcLoaderName} {
+			for _, file := range files[loader] {
+				name, version := "-", "-"
+				if identity := identities[file.GetPath()]; identity != nil {
+					name, version = identity.Name, identity.Version
+				}
+				fmt.Printf("%-8s %-24s %-16s %-10s %s\n", loader, file.GetName(), name, version, file.GetPath())
+				count++
+			}
+		}
+		fmt.Printf("# %d plugins found in %s\n", count, c.Dir)
+	}
+
+	return c.Error
+}
+
+func (c *CmdPlugins) CmdPluginsInspect(_ *cobra.Command, args []string) error {
+	for range Only.Once {
+		var item *GoPlugLoader.PluginItem
+		item, c.Error = c.loadPlugin(args[0])
+		if c.Error != nil {
+			break
+		}
+		//goland:noinspection GoDeferInLoop
+		defer c.manager.Dispose()
+
+		fmt.Printf("# Plugin: %s\n", item.GetPluginPath().GetPath())
+		fmt.Printf("Type:\t%s\n", item.GetPluginType())
+		identity := item.Identify()
+		fmt.Print(identity.String())
+		fmt.Print(item.Hooks().String())
+
+		if native, ok := item.Pluggable.(*GoPlugLoader.NativePlugin); ok {
+			fmt.Print(native.Service.String())
+		}
+	}
+
+	return c.Error
+}
+
+func (c *CmdPlugins) CmdPluginsValidate(_ *cobra.Command, args []string) error {
+	for range Only.Once {
+		var paths []utils.FilePath
+		if len(args) == 0 {
+			files := c.manager.ListPluginFiles()
+			paths = append(paths, files[GoPlugLoader.NativeLoaderName]...)
+			paths = append(paths, files[GoPlugLoader.RpcLoaderName]...)
+		}
+		for _, arg := range args {
+			pluginPath, err := c.manager.FindPluginPath(arg)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+			paths = append(paths, *pluginPath)
+		}
+		if c.Error != nil {
+			break
+		}
+
+		var failed int
+		for _, pluginPath := range paths {
+			var identity *Plugin.Identity
+			var err Return.Error
+			if c.NoLoad {
+				identity, err = c.manager.ValidateManifest(pluginPath)
+			} else {
+				identity, err = c.manager.ValidatePlugin(pluginPath)
+			}
+			var name string
+			if identity != nil {
+				name = identity.Name + " " + identity.Version
+			}
+
+			switch {
+			case err.IsError():
+				fmt.Printf("FAILED\t%s\t%s\t%v\n", pluginPath.GetPath(), name, err.GetError())
+				failed++
+			case err.IsWarning():
+				fmt.Printf("WARNING\t%s\t%s\t%v\n", pluginPath.GetPath(), name, err.GetWarning())
+			default:
+				fmt.Printf("OK\t%s\t%s\n", pluginPath.GetPath(), name)
+			}
+		}
+
+		if failed > 0 {
+			c.Error = errors.New(fmt.Sprintf("%d/%d plugins failed validation", failed, len(paths)))
+			break
+		}
+	}
+
+	return c.Error
+}
+
+func (c *CmdPlugins) CmdPluginsLock(_ *cobra.Command, _ []string) error {
+	for range Only.Once {
+		if !c.Check {
+			lock, err := c.manager.LockPlugins(c.Lock)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+
+			for _, entry := range lock.Plugins {
+				fmt.Printf("%s\t%s\t%s\t%s\t%s\n", entry.Loader, entry.File, entry.Name, entry.Version, entry.Sha256)
+			}
+			break
+		}
+
+		err := c.manager.SetLockFile(c.Lock, false)
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+
+		diffs, err := c.manager.CheckLock()
+		fmt.Print(diffs)
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+		if err.IsWarning() {
+			c.Error = err.GetWarning()
+			break
+		}
+		fmt.Printf("All plugins match the lockfile.\n")
+	}
+
+	return c.Error
+}
+
+func (c *CmdPlugins) CmdPluginsCall(_ *cobra.Command, args []string) error {
+	for range Only.Once {
+		var item *GoPlugLoader.PluginItem
+		item, c.Error = c.loadPlugin(args[0])
+		if c.Error != nil {
+			break
+		}
+		//goland:noinspection GoDeferInLoop
+		defer c.manager.Dispose()
+
+		hookArgs, err := item.GetHookArgs(args[1])
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+
+		var values []any
+		values, err = hookArgs.Parse(args[2:]...)
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+
+		var response Plugin.HookResponse
+		response, err = item.CallHook(args[1], values...)
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+
+		response.Print()
+	}
+
+	return c.Error
+}
+
+func (c *CmdPlugins) CmdPluginsConfig(_ *cobra.Command, args []string) error {
+	for range Only.Once {
+		var item *GoPlugLoader.PluginItem
+		item, c.Error = c.loadPlugin(args[0])
+		if c.Error != nil {
+			break
+		}
+		//goland:noinspection GoDeferInLoop
+		defer c.manager.Dispose()
+
+		identity := item.Identify()
+		fmt.Printf("# Plugin: %s\n", item.GetPluginPath().GetPath())
+		fmt.Printf("# Config dir: %s\n", c.manager.GetConfigDir())
+		if len(identity.Config) > 0 {
+			fmt.Printf("Schema:\n%s", identity.Config)
+		}
+		fmt.Printf("Config:\n%s", item.GetConfig())
+	}
+
+	return c.Error
+}
+
+func (c *CmdPlugins) CmdPluginsSecrets(_ *cobra.Command, args []string) error {
+	for range Only.Once {
+		if c.secrets == nil {
+			c.Error = errors.New(fmt.Sprintf("no secrets store, use --%s or --%s", flagPluginsSecDir, flagPluginsSecFile))
+			break
+		}
+
+		var err Return.Error
+		switch {
+		case len(args) == 0:
+			var names []string
+			names, err = c.secrets.Names()
+			for _, name := range names {
+				fmt.Println(name)
+			}
+
+		case args[0] == "set" && len(args) == 2:
+			data, e := io.ReadAll(os.Stdin)
+			if e != nil {
+				c.Error = e
+				break
+			}
+			err = c.secrets.Set(args[1], utils.NewSecret(strings.TrimRight(string(data), "\r\n")))
+
+		case args[0] == "delete" && len(args) == 2:
+			err = c.secrets.Delete(args[1])
+
+		default:
+			c.Error = errors.New(fmt.Sprintf("expected 'set <name>' or 'delete <name>'"))
+		}
+		if err.IsError() {
+			c.Error = err.GetError()
+		}
+	}
+
+	return c.Error
+}
+
+func (c *CmdPlugins) CmdPluginsBuild(_ *cobra.Command, _ []string) error {
+	for range Only.Once {
+		err := c.manager.SetBuildOptions(GoPlug.BuildOptions{
+			Workers: c.BuildWorkers,
+			Timeout: c.BuildTimeout,
+		})
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+
+		// Stop the builds on Ctrl-C.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		//goland:noinspection GoDeferInLoop
+		defer stop()
+
+		err = c.manager.BuildPluginsContext(ctx)
+		if report := c.manager.GetBuildReport(); report != nil {
+			report.Print()
+		}
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+	}
+
+	return c.Error
+}
+
+// loadPlugin - Find and load a plugin by name or path.
+func (c *CmdPlugins) loadPlugin(id string) (*GoPlugLoader.PluginItem, error) {
+	var item *GoPlugLoader.PluginItem
+	var err Return.Error
+
+	for range Only.Once {
+		var pluginPath *utils.FilePath
+		pluginPath, err = c.manager.FindPluginPath(id)
+		if err.IsError() {
+			break
+		}
+
+		err = c.manager.LoadPlugin(*pluginPath)
+		if err.IsError() {
+			break
+		}
+
+		item, err = c.manager.GetPlugin(*pluginPath)
+	}
+
+	return item, err.GetError()
+}
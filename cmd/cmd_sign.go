@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MickMake/GoUnify/Only"
+	"github.com/MickMake/GoUnify/cmdHelp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+)
+
+const (
+	flagSignKey         = "key"
+	flagSignManifest    = "manifest"
+	flagSignDir         = "dir"
+	flagSignForce       = "force"
+	flagSignTrustedKeys = "trusted-keys"
+)
+
+//goland:noinspection GoNameStartsWithPackageName
+type CmdSign struct {
+	CmdDefault
+
+	Key         string
+	Manifest    bool
+	Dir         string
+	Force       bool
+	TrustedKeys []string
+}
+
+func NewCmdSign() *CmdSign {
+	var ret *CmdSign
+
+	for range Only.Once {
+		ret = &CmdSign{
+			CmdDefault: CmdDefault{
+				Error:   nil,
+				cmd:     nil,
+				SelfCmd: nil,
+			},
+			Dir: ".",
+		}
+	}
+
+	return ret
+}
+
+func (c *CmdSign) AttachCommand(cmd *cobra.Command) *cobra.Command {
+	for range Only.Once {
+		if cmd == nil {
+			break
+		}
+		c.cmd = cmd
+
+		// ******************************************************************************** //
+		var cmdSign = &cobra.Command{
+			Use:                   "sign <plugin file> ...",
+			Aliases:               []string{},
+			Annotations:           map[string]string{"group": "Sign"},
+			Short:                 fmt.Sprintf("Sign plugin files."),
+			Long:                  fmt.Sprintf("Sign the SHA-256 of plugin files with an ed25519 private key, writing a detached '<file>%s' or adding the signature to the plugin manifest.", Plugin.SignatureFileExtension),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               cmds.GoPlugArgs,
+			RunE:                  c.CmdSign,
+			Args:                  cobra.MinimumNArgs(0),
+		}
+		cmd.AddCommand(cmdSign)
+		cmdSign.Example = cmdHelp.PrintExamples(cmdSign,
+			"--key release.key plugins/goplug-hello/goplug-hello",
+			"--key release.key --manifest plugins/goplug-hello/goplug-hello.so",
+		)
+		cmdSign.Flags().StringVarP(&c.Key, flagSignKey, "", "", fmt.Sprintf("Private key file, (see 'sign keygen')."))
+		cmdSign.Flags().BoolVarP(&c.Manifest, flagSignManifest, "", false, fmt.Sprintf("Add the signature to the plugin manifest, instead of a '%s' file.", Plugin.SignatureFileExtension))
+		c.SelfCmd = cmdSign
+
+		// ******************************************************************************** //
+		var cmdSignKeygen = &cobra.Command{
+			Use:                   "keygen <name>",
+			Aliases:               []string{},
+			Annotations:           map[string]string{"group": "Sign"},
+			Short:                 fmt.Sprintf("Create a signing key pair."),
+			Long:                  fmt.Sprintf("Create an ed25519 key pair, written to '<name>%s', (keep secret), and '<name>%s', (add to the trusted keys).", Plugin.PrivateKeyFileExtension, Plugin.PublicKeyFileExtension),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               cmds.GoPlugArgs,
+			RunE:                  c.CmdSignKeygen,
+			Args:                  cobra.ExactArgs(1),
+		}
+		cmdSign.AddCommand(cmdSignKeygen)
+		cmdSignKeygen.Example = cmdHelp.PrintExamples(cmdSignKeygen, "release", "release --dir ~/.goplug")
+		cmdSignKeygen.Flags().StringVarP(&c.Dir, flagSignDir, "", c.Dir, fmt.Sprintf("Directory to write the key files to."))
+		cmdSignKeygen.Flags().BoolVarP(&c.Force, flagSignForce, "", false, fmt.Sprintf("Overwrite existing key files."))
+
+		// ******************************************************************************** //
+		var cmdSignVerify = &cobra.Command{
+			Use:                   "verify <plugin file> ...",
+			Aliases:               []string{},
+			Annotations:           map[string]string{"group": "Sign"},
+			Short:                 fmt.Sprintf("Verify plugin file signatures."),
+			Long:                  fmt.Sprintf("Verify plugin file signatures against trusted public keys."),
+			DisableFlagParsing:    false,
+			DisableFlagsInUseLine: false,
+			PreRunE:               cmds.GoPlugArgs,
+			RunE:                  c.CmdSignVerify,
+			Args:                  cobra.MinimumNArgs(1),
+		}
+		cmdSign.AddCommand(cmdSignVerify)
+		cmdSignVerify.Example = cmdHelp.PrintExamples(cmdSignVerify, "--trusted-keys release.pub plugins/goplug-hello/goplug-hello")
+		cmdSignVerify.Flags().StringSliceVarP(&c.TrustedKeys, flagSignTrustedKeys, "", nil, fmt.Sprintf("Trusted public key files."))
+	}
+	return c.SelfCmd
+}
+
+func (c *CmdSign) AttachFlags(cmd *cobra.Command, _ *viper.Viper) {
+	for range Only.Once {
+		if cmd == nil {
+			break
+		}
+	}
+}
+
+func (c *CmdSign) CmdSign(cmd *cobra.Command, args []string) error {
+	for range Only.Once {
+		if len(args) == 0 {
+			c.Error = cmd.Help()
+			break
+		}
+
+		if c.Key == "" {
+			c.Error = errors.New(fmt.Sprintf("a private key is required, (--%s)", flagSignKey))
+			break
+		}
+
+		key, err := Plugin.LoadPrivateKey(c.Key)
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+
+		for _, file := range args {
+			var written string
+			written, err = Plugin.SignFile(file, key, c.Manifest)
+			if err.IsError() {
+				c.Error = err.GetError()
+				break
+			}
+			fmt.Printf("Signed: %s -> %s\n", file, written)
+		}
+	}
+
+	return c.Error
+}
+
+func (c *CmdSign) CmdSignKeygen(_ *cobra.Command, args []string) error {
+	for range Only.Once {
+		files, err := Plugin.GenerateKey(c.Dir, args[0], c.Force)
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+
+		for _, file := range files {
+			fmt.Printf("Created: %s\n", file)
+		}
+	}
+
+	return c.Error
+}
+
+func (c *CmdSign) CmdSignVerify(_ *cobra.Command, args []string) error {
+	for range Only.Once {
+		if len(c.TrustedKeys) == 0 {
+			c.Error = errors.New(fmt.Sprintf("trusted keys are required, (--%s)", flagSignTrustedKeys))
+			break
+		}
+
+		keys, err := Plugin.LoadTrustedKeys(c.TrustedKeys...)
+		if err.IsError() {
+			c.Error = err.GetError()
+			break
+		}
+
+		var failed int
+		for _, file := range args {
+			signer, err := Plugin.VerifySignature(file, keys)
+			if err.IsError() {
+				fmt.Printf("FAILED: %s\n", err.GetError())
+				failed++
+				continue
+			}
+			fmt.Printf("OK: %s (signed by '%s')\n", file, signer)
+		}
+
+		if failed > 0 {
+			c.Error = errors.New(fmt.Sprintf("%d of %d plugin files failed verification", failed, len(args)))
+			break
+		}
+	}
+
+	return c.Error
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/MickMake/GoUnify/Only"
 	"github.com/spf13/cobra"
 
@@ -9,11 +11,16 @@ import (
 	"github.com/MickMake/GoUnify/Unify"
 
 	"github.com/MickMake/GoPlug/defaults"
+	"github.com/MickMake/GoPlug/utils"
 )
 
 type Cmds struct {
-	Unify *Unify.Unify
-	Api   *CmdApi
+	Unify   *Unify.Unify
+	Api     *CmdApi
+	Plugins *CmdPlugins
+	New     *CmdNew
+	Sign    *CmdSign
+	Audit   *CmdAudit
 
 	ConfigDir   string
 	CacheDir    string
@@ -59,6 +66,18 @@ func init() {
 		cmds.Api.AttachCommand(cmdRoot)
 		cmds.Api.AttachFlags(cmdRoot, cmds.Unify.GetViper())
 
+		cmds.Plugins = NewCmdPlugins()
+		cmds.Plugins.AttachFlags(cmds.Plugins.AttachCommand(cmdRoot), cmds.Unify.GetViper())
+
+		cmds.New = NewCmdNew()
+		cmds.New.AttachFlags(cmds.New.AttachCommand(cmdRoot), cmds.Unify.GetViper())
+
+		cmds.Sign = NewCmdSign()
+		cmds.Sign.AttachFlags(cmds.Sign.AttachCommand(cmdRoot), cmds.Unify.GetViper())
+
+		cmds.Audit = NewCmdAudit()
+		cmds.Audit.AttachFlags(cmds.Audit.AttachCommand(cmdRoot), cmds.Unify.GetViper())
+
 		// cmds.Info = NewCmdInfo()
 		// cmds.Info.AttachCommand(cmdRoot)
 	}
@@ -68,6 +87,8 @@ func Execute() error {
 	var err error
 
 	for range Only.Once {
+		utils.SetStandardLog(os.Stderr)
+
 		// Execute adds all child commands to the root command and sets flags appropriately.
 		// This is called by main.main(). It only needs to happen once to the rootCmd.
 		err = cmds.Unify.Execute()
@@ -0,0 +1,491 @@
+// Package goplugtest - Test plugins from their source dir with go test.
+//
+// Each plugin is built into a temp dir, as a native and/or RPC plugin, and loaded through a real
+// GoPlug.PluginManager. Tests get a Harness to call hooks, read values, add host hooks and check logs.
+// Plugin processes are stopped, and the temp dir removed, when the test ends. Builds only use the
+// module cache, so tests run offline. Native plugins can't be unloaded, so their builds are kept, (see NativeCacheDir).
+//
+// Log output is captured by replacing the process wide log outputs, (log.SetOutput and utils.SetLogOutput), and
+// the manager is created after changing the working dir, so tests using a Harness must not call t.Parallel().
+package goplugtest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"go/build"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug"
+	"github.com/MickMake/GoPlug/GoPlugLoader"
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+const (
+	// DefaultBuildTimeout - Kill a plugin build after this long.
+	DefaultBuildTimeout = 5 * time.Minute
+
+	// NativeCacheDir - Native plugin builds are kept in this dir, within os.TempDir(), (see Harness.buildNative).
+	NativeCacheDir = "goplugtest"
+)
+
+//
+// Options - How New() builds and loads a plugin.
+// ---------------------------------------------------------------------------------------------------- //
+type Options struct {
+	Types     Plugin.Types                   // Loader types Run() tests, (defaults to the plugin's manifest, or both)
+	HostHooks map[string]Plugin.HookFunction // Host hooks set before the plugin is loaded
+	ConfigDir string                         // Dir plugin config files are loaded from, (see Manager.SetConfigDir)
+	Timeout   time.Duration                  // Build timeout, (defaults to DefaultBuildTimeout)
+}
+
+//
+// Harness - A plugin built from source and loaded through a real PluginManager.
+// ---------------------------------------------------------------------------------------------------- //
+type Harness struct {
+	T       testing.TB
+	Type    string                   // Loader type, (GoPlugLoader.NativeLoaderName or GoPlugLoader.RpcLoaderName)
+	Dir     string                   // Plugin source dir
+	TempDir string                   // Where the plugin is built
+	File    utils.FilePath           // The built plugin
+	Manager *GoPlug.PluginManager    //
+	Item    *GoPlugLoader.PluginItem // The loaded plugin
+	Logs    *Logs                    // Captured log lines
+}
+
+// Run - Run test against the plugin in dir, as a subtest for each loader type, (see Options.Types).
+// Not for use with t.Parallel(), (see New).
+func Run(t *testing.T, dir string, options Options, test func(t *testing.T, h *Harness)) {
+	t.Helper()
+
+	types := options.Types
+	if !types.Native && !types.Rpc {
+		types = getTypes(t, dir)
+	}
+
+	for _, loaderType := range []string{GoPlugLoader.NativeLoaderName, GoPlugLoader.RpcLoaderName} {
+		if (loaderType == GoPlugLoader.NativeLoaderName && !types.Native) || (loaderType == GoPlugLoader.RpcLoaderName && !types.Rpc) {
+			continue
+		}
+
+		t.Run(loaderType, func(t *testing.T) {
+			test(t, New(t, dir, loaderType, options))
+		})
+	}
+}
+
+// New - Build the plugin in dir as loaderType, and load it. Fails the test on error.
+// Log output is captured process wide until the test ends, so it must not be used with t.Parallel().
+func New(t testing.TB, dir string, loaderType string, options Options) *Harness {
+	t.Helper()
+
+	h := &Harness{
+		T:       t,
+		Type:    loaderType,
+		TempDir: t.TempDir(),
+		Logs:    NewLogs(),
+	}
+
+	var e error
+	h.Dir, e = filepath.Abs(dir)
+	if e != nil {
+		t.Fatalf("goplugtest: %s", e)
+	}
+
+	// Registered after TempDir(), so runs before it's removed.
+	// Log outputs are process wide, a parallel test would capture, (and restore), another's.
+	output := log.Writer()
+	loggers := utils.GetLogOutput()
+	t.Cleanup(func() {
+		h.Close()
+		log.SetOutput(output)
+		utils.SetLogOutput(loggers)
+		if t.Failed() {
+			t.Logf("goplugtest: %s plugin logs:\n%s", h.Type, h.Logs)
+		}
+	})
+	log.SetOutput(h.Logs)
+	utils.SetLogOutput(h.Logs)
+
+	err := h.newManager(options)
+	if err.IsError() {
+		t.Fatalf("goplugtest: %s", err)
+	}
+
+	err = h.build(options)
+	if err.IsError() {
+		t.Fatalf("goplugtest: %s", err)
+	}
+
+	err = h.load()
+	if err.IsError() {
+		t.Fatalf("goplugtest: %s", err)
+	}
+
+	return h
+}
+
+// CallHook - Call a plugin hook.
+func (h *Harness) CallHook(name string, args ...any) (Plugin.HookResponse, Return.Error) {
+	return h.Item.CallHook(name, args...)
+}
+
+// MustCallHook - Call a plugin hook, failing the test on error.
+func (h *Harness) MustCallHook(name string, args ...any) Plugin.HookResponse {
+	h.T.Helper()
+	resp, err := h.CallHook(name, args...)
+	if err.IsError() {
+		h.T.Fatalf("goplugtest: %s", err)
+	}
+	return resp
+}
+
+// GetValue - Read a plugin value, (RPC plugin values are shared with master).
+func (h *Harness) GetValue(key string) any {
+	return h.Item.GetValue(key)
+}
+
+// SetValue - Write a plugin value.
+func (h *Harness) SetValue(key string, value any) {
+	h.Item.SetValue(key, value)
+}
+
+// SetHostHook - Add a hook the plugin can call on the host, (see Plugin.HostInterface). Fails the test on error.
+func (h *Harness) SetHostHook(name string, function Plugin.HookFunction, args ...any) {
+	h.T.Helper()
+	err := h.Manager.SetHostHook(name, function, args...)
+	if err.IsError() {
+		h.T.Fatalf("goplugtest: %s", err)
+	}
+}
+
+// WaitLog - Returns true once a captured log line contains s, (waits up to DefaultLogWait).
+func (h *Harness) WaitLog(s string) bool {
+	return h.Logs.Wait(s, DefaultLogWait)
+}
+
+// AssertLog - Fail the test if no captured log line contains s, (waits up to DefaultLogWait).
+func (h *Harness) AssertLog(s string) {
+	h.T.Helper()
+	if !h.WaitLog(s) {
+		h.T.Errorf("goplugtest: no log line contains '%s'", s)
+	}
+}
+
+// AssertNoLog - Fail the test if a log line captured so far contains s.
+func (h *Harness) AssertNoLog(s string) {
+	h.T.Helper()
+	if h.Logs.Contains(s) {
+		h.T.Errorf("goplugtest: a log line contains '%s'", s)
+	}
+}
+
+// Close - Unload the plugin, stopping its process. Called when the test ends.
+func (h *Harness) Close() {
+	if h.Manager == nil {
+		return
+	}
+	h.Manager.Dispose()
+	h.Manager.Logger.Close()
+	h.Manager = nil
+	h.Item = nil
+}
+
+// newManager - A PluginManager logging to h.Logs.
+func (h *Harness) newManager(options Options) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		identity := Plugin.Identity{
+			Name:        "goplugtest",
+			Version:     "0.0.0",
+			PluginTypes: Plugin.AllPluginTypes,
+			Callbacks:   Plugin.NewCallbacks(),
+		}
+
+		// The manager log file is created in the working dir, so keep it out of the plugin source dir.
+		var manager GoPlug.Manager
+		err = h.inDir(h.TempDir, func() Return.Error {
+			var err Return.Error
+			manager, err = GoPlug.NewPluginManager(&identity)
+			return err
+		})
+		if err.IsError() {
+			break
+		}
+		h.Manager = manager.(*GoPlug.PluginManager)
+		h.Manager.Logger.SetOutput(h.Logs)
+
+		err = h.Manager.SetDir(h.TempDir)
+		if err.IsError() {
+			break
+		}
+
+		// Plugins are named by their file, without the prefix.
+		err = h.Manager.SetPrefix(h.Manager.Prefix)
+		if err.IsError() {
+			break
+		}
+
+		err = h.Manager.SetConfigDir(options.ConfigDir)
+		if err.IsError() {
+			break
+		}
+
+		for name, function := range options.HostHooks {
+			err = h.Manager.SetHostHook(name, function)
+			if err.IsError() {
+				break
+			}
+		}
+	}
+
+	return err
+}
+
+// build - Build the plugin into h.TempDir, (native plugins into NativeCacheDir).
+func (h *Harness) build(options Options) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		var target *GoPlug.BuildTarget
+		target, err = h.Manager.NewBuildTarget(h.Dir)
+		if err.IsError() {
+			break
+		}
+		target.OutDir = h.TempDir
+
+		if h.Type == GoPlugLoader.NativeLoaderName {
+			err = h.buildNative(target, options)
+			break
+		}
+
+		err = h.run(target.Command(h.Type), options)
+		if err.IsError() {
+			break
+		}
+
+		h.File, err = utils.NewFile(target.Output(h.Type))
+	}
+
+	return err
+}
+
+// buildNative - A native plugin can't be unloaded, or loaded again from another file, within a process.
+// So each build is kept, and reused, in a dir named by a hash of its inputs and of the test binary.
+func (h *Harness) buildNative(target *GoPlug.BuildTarget, options Options) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		// Native plugins must be built as the test binary was.
+		none := ""
+		target.Settings.GcFlags = &none
+
+		var hash string
+		hash, err = target.Hash(h.Type)
+		if err.IsError() {
+			break
+		}
+
+		var binary string
+		binary, err = testBinaryHash()
+		if err.IsError() {
+			break
+		}
+
+		sum := sha256.Sum256([]byte(hash + binary))
+		dir := filepath.Join(os.TempDir(), NativeCacheDir, hex.EncodeToString(sum[:8]))
+		target.OutDir = dir
+		if utils.IsFile(target.Output(h.Type)) {
+			h.File, err = utils.NewFile(target.Output(h.Type))
+			break
+		}
+
+		e := os.MkdirAll(filepath.Dir(dir), 0o700)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		// Built elsewhere, then moved into place, so a dir in use is never changed.
+		var tmp string
+		tmp, e = os.MkdirTemp(filepath.Dir(dir), "build-")
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		//goland:noinspection GoDeferInLoop,GoUnhandledErrorResult
+		defer os.RemoveAll(tmp)
+
+		target.OutDir = tmp
+		// Built from its files, so the package is named 'plugin/unnamed-<hash>', instead of the package under test.
+		command := target.Command(h.Type)
+		var files []string
+		files, err = goFiles(target.Dir)
+		if err.IsError() {
+			break
+		}
+		command.Args = append(command.Args[:len(command.Args)-1], files...)
+		command.Dir = target.Dir
+
+		err = h.run(command, options)
+		if err.IsError() {
+			break
+		}
+
+		// Another test process may have got there first.
+		e = os.Rename(tmp, dir)
+		target.OutDir = dir
+		if e != nil && !utils.IsFile(target.Output(h.Type)) {
+			err.SetError(e)
+			break
+		}
+		h.File, err = utils.NewFile(target.Output(h.Type))
+	}
+
+	return err
+}
+
+// run - Run the build of target.
+func (h *Harness) run(command GoPlug.ExecOptions, options Options) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		command.Command = goCommand()
+		// Only the module cache is used, so builds run offline.
+		command.Env = append(command.Env, "GOPROXY=off")
+		command.Timeout = options.Timeout
+		if command.Timeout == 0 {
+			command.Timeout = DefaultBuildTimeout
+		}
+
+		out, e := command.ExecContext(context.Background())
+		if e != nil {
+			err.SetError("building %s plugin '%s': %s\n%s", h.Type, h.Dir, e, out)
+			break
+		}
+	}
+
+	return err
+}
+
+// load - Load the built plugin.
+func (h *Harness) load() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		err = h.Manager.LoadPlugin(h.File)
+		if err.IsError() {
+			break
+		}
+
+		h.Item, err = h.Manager.GetPlugin(h.File)
+	}
+
+	return err
+}
+
+// inDir - Run fn within dir.
+func (h *Harness) inDir(dir string, fn func() Return.Error) Return.Error {
+	cwd, e := os.Getwd()
+	if e != nil {
+		return Return.NewError(e)
+	}
+	if e = os.Chdir(dir); e != nil {
+		return Return.NewError(e)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer os.Chdir(cwd)
+	return fn()
+}
+
+// goFiles - The Go files of the package in dir, (without tests, or files excluded by build constraints).
+func goFiles(dir string) ([]string, Return.Error) {
+	var ret []string
+	var err Return.Error
+
+	for range Only.Once {
+		entries, e := os.ReadDir(dir)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+			if ok, _ := build.Default.MatchFile(dir, name); ok {
+				ret = append(ret, name)
+			}
+		}
+
+		if len(ret) == 0 {
+			err.SetError("no Go files in '%s'", dir)
+			break
+		}
+	}
+
+	return ret, err
+}
+
+// getTypes - Loader types of the plugin in dir, from its manifest, or both.
+func getTypes(t *testing.T, dir string) Plugin.Types {
+	manifest, err := Plugin.LoadManifest(dir)
+	if err.IsError() {
+		t.Fatalf("goplugtest: %s", err)
+	}
+	if manifest.Build.Types != nil {
+		return *manifest.Build.Types
+	}
+	return Plugin.AllPluginTypes
+}
+
+// goCommand - The go command of the toolchain the test binary was built with, so native plugins match it.
+func goCommand() string {
+	name := filepath.Join(runtime.GOROOT(), "bin", "go")
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	if utils.IsFile(name) {
+		return name
+	}
+	return "go"
+}
+
+var binary = struct {
+	hash string
+	err  Return.Error
+	once sync.Once
+}{}
+
+// testBinaryHash - SHA-256 of the running test binary.
+func testBinaryHash() (string, Return.Error) {
+	binary.once.Do(func() {
+		file, e := os.Executable()
+		if e != nil {
+			binary.err.SetError(e)
+			return
+		}
+
+		var path utils.FilePath
+		path, binary.err = utils.NewFile(file)
+		if binary.err.IsError() {
+			return
+		}
+		binary.hash, binary.err = path.Sha256()
+	})
+	return binary.hash, binary.err
+}
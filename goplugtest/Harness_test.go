@@ -0,0 +1,68 @@
+package goplugtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader"
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// testPlugin - A plugin generated by 'new plugin test --rpc --hook Hello'.
+const testPlugin = "testdata/goplug-test"
+
+type HarnessSuite struct {
+	suite.Suite
+}
+
+func (s *HarnessSuite) SetupSuite() {
+	if testing.Short() {
+		s.T().Skip("builds a plugin")
+	}
+}
+
+func (s *HarnessSuite) TestRun() {
+	var loaded []string
+	Run(s.T(), testPlugin, Options{Types: Plugin.Types{Rpc: true}}, func(t *testing.T, h *Harness) {
+		loaded = append(loaded, h.Type)
+		s.Require().NotNil(h.Item)
+		s.FileExists(h.File.GetPath())
+		s.Equal("test", h.Item.Pluggable.GetName())
+
+		resp := h.MustCallHook("Hello", "test")
+		s.Equal("Hello: test", resp.AsString())
+		h.AssertLog("called with [test]")
+		h.AssertNoLog("called with [other]")
+
+		_, err := h.CallHook("Missing")
+		s.True(err.IsError(), "calling a missing hook fails")
+
+		h.SetValue("key", "value")
+		s.Equal("value", h.GetValue("key"))
+
+		h.Close()
+		s.Nil(h.Manager, "Close() unloads the plugin")
+		s.Nil(h.Item)
+	})
+	s.Equal([]string{GoPlugLoader.RpcLoaderName}, loaded, "only the loader types asked for are run")
+}
+
+func (s *HarnessSuite) TestHostHooks() {
+	options := Options{
+		HostHooks: map[string]Plugin.HookFunction{
+			"Echo": func(_ Plugin.HookStruct, args ...any) (Plugin.HookResponse, Return.Error) {
+				return Plugin.HookResponse{Value: args[0]}, Return.Ok
+			},
+		},
+	}
+
+	h := New(s.T(), testPlugin, GoPlugLoader.RpcLoaderName, options)
+	s.NotNil(h.Manager.HostHooks.GetHook("Echo"), "host hooks are set before the plugin is loaded")
+	s.Equal(GoPlugLoader.RpcLoaderName, h.Type)
+}
+
+func TestHarnessSuite(t *testing.T) {
+	suite.Run(t, new(HarnessSuite))
+}
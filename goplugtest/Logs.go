@@ -0,0 +1,80 @@
+package goplugtest
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MickMake/GoPlug/utils"
+)
+
+// DefaultLogWait - How long WaitLog(), (and AssertLog()), wait for a line, as RPC plugin output arrives asynchronously.
+const DefaultLogWait = 5 * time.Second
+
+//
+// Logs - Log lines captured from the manager, its loggers and plugins, (including RPC plugin output).
+// ---------------------------------------------------------------------------------------------------- //
+type Logs struct {
+	writer *utils.LineWriter
+	lines  []string
+	lock   sync.Mutex
+}
+
+// NewLogs - Create a new instance of this structure.
+func NewLogs() *Logs {
+	var ret Logs
+	ret.writer = utils.NewLineWriter(func(line string) {
+		ret.lock.Lock()
+		defer ret.lock.Unlock()
+		ret.lines = append(ret.lines, line)
+	})
+	return &ret
+}
+
+// Write - Implements io.Writer.
+func (l *Logs) Write(p []byte) (int, error) {
+	return l.writer.Write(p)
+}
+
+// Lines - Lines captured so far.
+func (l *Logs) Lines() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]string{}, l.lines...)
+}
+
+// Contains - Returns true if a line captured so far contains s.
+func (l *Logs) Contains(s string) bool {
+	for _, line := range l.Lines() {
+		if strings.Contains(line, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Wait - Returns true once a line contains s, or false after timeout.
+func (l *Logs) Wait(s string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if l.Contains(s) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Reset - Forget the lines captured so far.
+func (l *Logs) Reset() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.lines = nil
+}
+
+// String - Stringer interface.
+func (l *Logs) String() string {
+	return strings.Join(l.Lines(), "\n")
+}
@@ -0,0 +1,47 @@
+package goplugtest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LogsSuite struct {
+	suite.Suite
+	logs *Logs
+}
+
+func (s *LogsSuite) SetupTest() {
+	s.logs = NewLogs()
+}
+
+func (s *LogsSuite) TestLines() {
+	_, _ = fmt.Fprint(s.logs, "first\nsec")
+	s.Equal([]string{"first"}, s.logs.Lines(), "a partial line isn't captured until it ends")
+
+	_, _ = fmt.Fprint(s.logs, "ond\nthird\n")
+	s.Equal([]string{"first", "second", "third"}, s.logs.Lines())
+	s.Equal("first\nsecond\nthird", s.logs.String())
+	s.True(s.logs.Contains("cond"))
+	s.False(s.logs.Contains("fourth"))
+
+	s.logs.Reset()
+	s.Empty(s.logs.Lines())
+	s.False(s.logs.Contains("first"))
+}
+
+func (s *LogsSuite) TestWait() {
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = fmt.Fprintln(s.logs, "arrived")
+	}()
+
+	s.True(s.logs.Wait("arrived", DefaultLogWait))
+	s.False(s.logs.Wait("never", 50*time.Millisecond))
+}
+
+func TestLogsSuite(t *testing.T) {
+	suite.Run(t, new(LogsSuite))
+}
@@ -0,0 +1,94 @@
+// A GoPlug plugin - test
+package main
+
+import (
+	"log"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader"
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// GoPluginIdentity - Set the GoPlugin identity. This is required for a minimal setup.
+var GoPluginIdentity = Plugin.Identity{
+	Callbacks: Plugin.Callbacks{
+		Initialise: Initialise,
+	},
+	Name:        "test",
+	Version:     "0.1.0",
+	Description: "A GoPlug plugin - test",
+	Repository:  "https://github.com/MickMake/GoPlug",
+	Maintainers: []string{"nobody@example.com"},
+}
+
+// ---------------------------------------------------------------------------------------------------- //
+
+// init - For a native plugin, global variables need to be set in init(), because main() is never called.
+func init() {
+}
+
+// main - For an RPC plugin, main() will be called. So we can run the RPC server here.
+func main() {
+	var MyRpcPlugin GoPlugLoader.PluginItem
+	var err Return.Error
+
+	for range Only.Once {
+		MyRpcPlugin, err = NewPlugin(Plugin.RpcPluginType)
+		if err.IsError() {
+			break
+		}
+
+		err = MyRpcPlugin.Serve()
+	}
+
+	err.Print()
+}
+
+// NewPlugin - Set up the plugin, with all its hooks, for the given plugin type.
+func NewPlugin(types Plugin.Types) (GoPlugLoader.PluginItem, Return.Error) {
+	var plug GoPlugLoader.PluginItem
+	var err Return.Error
+
+	for range Only.Once {
+		plug, err = GoPlugLoader.NewPluginItem(types, &GoPluginIdentity)
+		if err.IsError() {
+			break
+		}
+
+		err = plug.SetHandshakeConfig(Plugin.HandshakeConfig)
+		if err.IsError() {
+			break
+		}
+
+		err = plug.SetHook("Hello", Hello, "")
+		if err.IsError() {
+			break
+		}
+
+		err = plug.Validate()
+		if err.IsError() {
+			break
+		}
+	}
+
+	return plug, err
+}
+
+// ---------------------------------------------------------------------------------------------------- //
+
+// Initialise - Called by the host after the plugin has been loaded, with its Plugin.Config as the first arg.
+func Initialise(ctx Plugin.PluginDataInterface, args ...any) Return.Error {
+	funcName := utils.GetCaller(0)
+	log.Printf("%s() plugin '%s' initialised\n", funcName, ctx.GetName())
+	return Return.Ok
+}
+
+// Hello - Hook stub, called with a single string arg.
+func Hello(hook Plugin.HookStruct, args ...any) (Plugin.HookResponse, Return.Error) {
+	funcName := utils.GetCaller(0)
+	log.Printf("%s() called with %v\n", funcName, args)
+	return Plugin.NewHookResponse("Hello: " + args[0].(string))
+}
@@ -0,0 +1,255 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+	"github.com/MickMake/GoPlug/defaults"
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// The plugin templates follow the layout of the plugins in examples/plugins.
+//
+//go:embed templates/plugin/*.tmpl
+var pluginTemplates embed.FS
+
+const (
+	GoPlugModule = "github.com/MickMake/GoPlug"
+	OnlyModule   = "github.com/MickMake/GoUnify/Only"
+
+	DefaultPrefix      = "goplug-"
+	DefaultVersion     = "0.1.0"
+	DefaultGoVersion   = "1.21" // GoPlug's own go.mod, (log/slog), 'go mod tidy' raises lower versions to it.
+	DefaultOnlyVersion = "v0.0.0-20221125023651-ff4a37b1928a"
+)
+
+// pluginFiles - Template name to generated filename.
+var pluginFiles = map[string]string{
+	"main.go.tmpl":      "main.go",
+	"main_test.go.tmpl": "main_test.go",
+	"Makefile.tmpl":     "Makefile",
+	"go.mod.tmpl":       "go.mod",
+}
+
+var validName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_\-]*$`)
+
+//
+// PluginOptions - Everything needed to generate a new plugin source dir.
+// ---------------------------------------------------------------------------------------------------- //
+type PluginOptions struct {
+	Name        string       // Plugin name, as used in Identity.Name.
+	Dir         string       // Parent dir, the plugin is created in Dir/<Prefix><Name>.
+	Prefix      string       // Filename prefix, (defaults to "goplug-").
+	Types       Plugin.Types // Which plugin types to generate.
+	Hooks       []string     // Hook stubs to generate.
+	Description string
+	Version     string
+	Maintainer  string
+	Repository  string
+
+	GoVersion     string // Go version for go.mod.
+	GoPlugVersion string // Required version of GoPlug.
+	OnlyVersion   string // Required version of GoUnify/Only.
+	Replace       string // Optional local path for a go.mod replace directive of GoPlug.
+	Force         bool   // Overwrite existing files.
+}
+
+// Binary - The filename of the built plugin, (without extension).
+func (o PluginOptions) Binary() string {
+	return o.Prefix + o.Name
+}
+
+// Native - Generate native plugin support.
+func (o PluginOptions) Native() bool {
+	return o.Types.Native
+}
+
+// Rpc - Generate RPC plugin support.
+func (o PluginOptions) Rpc() bool {
+	return o.Types.Rpc
+}
+
+// Target - The plugin source dir that will be created.
+func (o PluginOptions) Target() string {
+	return filepath.Join(o.Dir, o.Binary())
+}
+
+// Defaults - Fill in any unset options.
+func (o *PluginOptions) Defaults() {
+	if o.Prefix == "" {
+		o.Prefix = DefaultPrefix
+	}
+	if !o.Types.Native && !o.Types.Rpc {
+		o.Types = Plugin.AllPluginTypes
+	}
+	if len(o.Hooks) == 0 {
+		o.Hooks = []string{"Hello"}
+	}
+	if o.Description == "" {
+		o.Description = "A GoPlug plugin - " + o.Name
+	}
+	if o.Version == "" {
+		o.Version = DefaultVersion
+	}
+	if o.Maintainer == "" {
+		o.Maintainer = "nobody@example.com"
+	}
+	if o.Repository == "" {
+		o.Repository = "https://" + defaults.SourceRepo
+	}
+	if o.GoVersion == "" {
+		o.GoVersion = DefaultGoVersion
+	}
+
+	goPlug, only := moduleVersions()
+	if o.GoPlugVersion == "" {
+		o.GoPlugVersion = goPlug
+	}
+	if o.OnlyVersion == "" {
+		o.OnlyVersion = only
+	}
+}
+
+// IsValid - Check the options before generating.
+func (o *PluginOptions) IsValid() Return.Error {
+	var err Return.Error
+	for range Only.Once {
+		if !validName.MatchString(o.Name) {
+			err.SetError("invalid plugin name '%s'", o.Name)
+			break
+		}
+
+		for _, hook := range o.Hooks {
+			if !validName.MatchString(hook) || strings.Contains(hook, "-") {
+				err.SetError("invalid hook name '%s'", hook)
+				break
+			}
+		}
+	}
+	return err
+}
+
+// NewPlugin - Generate a new plugin source dir from the plugin templates.
+// Returns the list of files created.
+func NewPlugin(options PluginOptions) ([]string, Return.Error) {
+	var files []string
+	var err Return.Error
+
+	for range Only.Once {
+		err.SetPrefix("scaffold: ")
+		options.Defaults()
+
+		err = options.IsValid()
+		if err.IsError() {
+			break
+		}
+
+		target := options.Target()
+
+		// Check every file before writing any, so a plugin is never half generated.
+		var names []string
+		for tmpl := range pluginFiles {
+			names = append(names, tmpl)
+		}
+		sort.Strings(names)
+
+		rendered := make(map[string][]byte)
+		for _, tmpl := range names {
+			filename := filepath.Join(target, pluginFiles[tmpl])
+			if !options.Force {
+				if _, e := os.Stat(filename); e == nil {
+					err.SetError("file '%s' already exists", filename)
+					break
+				}
+			}
+
+			rendered[filename], err = Render(tmpl, options)
+			if err.IsError() {
+				break
+			}
+		}
+		if err.IsError() {
+			break
+		}
+
+		e := os.MkdirAll(target, 0755)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		for filename, data := range rendered {
+			err = utils.WriteFile(filename, data)
+			if err.IsError() {
+				break
+			}
+			files = append(files, filename)
+		}
+		sort.Strings(files)
+	}
+
+	return files, err
+}
+
+// Render - Render a single plugin template. Go files are gofmt'ed.
+func Render(name string, options PluginOptions) ([]byte, Return.Error) {
+	var ret []byte
+	var err Return.Error
+
+	for range Only.Once {
+		t, e := template.ParseFS(pluginTemplates, "templates/plugin/"+name)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		var buf bytes.Buffer
+		e = t.Execute(&buf, options)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		ret = buf.Bytes()
+
+		if strings.HasSuffix(name, ".go.tmpl") {
+			ret, e = format.Source(ret)
+			if e != nil {
+				err.SetError("%s: %s", name, e)
+				break
+			}
+		}
+	}
+
+	return ret, err
+}
+
+// moduleVersions - Versions of GoPlug and GoUnify/Only this binary was built with.
+func moduleVersions() (string, string) {
+	goPlug := "v" + defaults.BinaryVersion
+	only := DefaultOnlyVersion
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			switch {
+			case dep.Path == GoPlugModule && dep.Replace == nil && strings.HasPrefix(dep.Version, "v"):
+				goPlug = dep.Version
+			case dep.Path == OnlyModule && dep.Replace == nil && strings.HasPrefix(dep.Version, "v"):
+				only = dep.Version
+			}
+		}
+	}
+
+	return goPlug, only
+}
@@ -0,0 +1,114 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/GoPlugLoader/Plugin"
+)
+
+type PluginSuite struct {
+	suite.Suite
+	options PluginOptions
+}
+
+func (s *PluginSuite) SetupTest() {
+	s.options = PluginOptions{
+		Name:   "hello",
+		Dir:    s.T().TempDir(),
+		Prefix: DefaultPrefix,
+		Types:  Plugin.Types{Rpc: true},
+		Hooks:  []string{"Hello", "Goodbye"},
+	}
+}
+
+// files - The files scaffolded, in order.
+func (s *PluginSuite) files() []string {
+	target := s.options.Target()
+	return []string{
+		filepath.Join(target, "Makefile"),
+		filepath.Join(target, "go.mod"),
+		filepath.Join(target, "main.go"),
+		filepath.Join(target, "main_test.go"),
+	}
+}
+
+func (s *PluginSuite) TestNewPlugin() {
+	files, err := NewPlugin(s.options)
+	s.Require().False(err.IsError(), err.String())
+	s.Equal(s.files(), files)
+	s.Equal(filepath.Join(s.options.Dir, "goplug-hello"), s.options.Target())
+
+	data, e := os.ReadFile(filepath.Join(s.options.Target(), "main.go"))
+	s.Require().NoError(e)
+	s.Contains(string(data), `"Hello"`)
+	s.Contains(string(data), `"Goodbye"`)
+
+	data, e = os.ReadFile(filepath.Join(s.options.Target(), "go.mod"))
+	s.Require().NoError(e)
+	s.Contains(string(data), GoPlugModule)
+}
+
+func (s *PluginSuite) TestExists() {
+	for _, existing := range []string{"Makefile", "main_test.go"} {
+		s.Run(existing, func() {
+			s.SetupTest()
+			s.Require().NoError(os.MkdirAll(s.options.Target(), 0755))
+			filename := filepath.Join(s.options.Target(), existing)
+			s.Require().NoError(os.WriteFile(filename, []byte("mine"), 0644))
+
+			files, err := NewPlugin(s.options)
+			s.True(err.IsError())
+			s.Contains(err.Error(), "file '"+filename+"' already exists")
+			s.Empty(files)
+
+			entries, e := os.ReadDir(s.options.Target())
+			s.Require().NoError(e)
+			s.Len(entries, 1, "nothing is written when any file exists")
+			data, e := os.ReadFile(filename)
+			s.Require().NoError(e)
+			s.Equal("mine", string(data))
+
+			s.options.Force = true
+			files, err = NewPlugin(s.options)
+			s.Require().False(err.IsError(), err.String())
+			s.Equal(s.files(), files)
+			data, e = os.ReadFile(filename)
+			s.Require().NoError(e)
+			s.NotEqual("mine", string(data), "overwritten with Force")
+		})
+	}
+}
+
+func (s *PluginSuite) TestInvalid() {
+	tests := []struct {
+		name  string
+		hooks []string
+		want  string
+	}{
+		{name: "1hello", want: "invalid plugin name '1hello'"},
+		{name: "hello world", want: "invalid plugin name 'hello world'"},
+		{name: "hello", hooks: []string{"Say-Hello"}, want: "invalid hook name 'Say-Hello'"},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.SetupTest()
+			s.options.Name = test.name
+			s.options.Hooks = test.hooks
+
+			files, err := NewPlugin(s.options)
+			s.True(err.IsError())
+			s.Contains(err.Error(), test.want)
+			s.Empty(files)
+			s.NoDirExists(s.options.Target())
+		})
+	}
+}
+
+func TestPluginSuite(t *testing.T) {
+	suite.Run(t, new(PluginSuite))
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"io"
@@ -665,6 +667,8 @@ func (p *FilePath) SaveObject(ref any) Return.Error {
 			err.SetError(e)
 			break
 		}
+		// Secrets are never saved, (see RegisterSecret).
+		data = RedactBytes(data)
 
 		file := p.ChangeExtension("json")
 		err = WriteFile(file.path, data)
@@ -698,6 +702,38 @@ func (p *FilePath) LoadObject(ref any) Return.Error {
 	return err
 }
 
+// Sha256 - Return the hex encoded SHA-256 of the file contents.
+func (p *FilePath) Sha256() (string, Return.Error) {
+	var ret string
+	var err Return.Error
+
+	for range Only.Once {
+		err = p.FileExists()
+		if err.IsError() {
+			break
+		}
+
+		f, e := os.Open(p.path)
+		if e != nil {
+			err.SetError(ErrorIO, p.path, e)
+			break
+		}
+		//goland:noinspection GoDeferInLoop,GoUnhandledErrorResult
+		defer f.Close()
+
+		h := sha256.New()
+		_, e = io.Copy(h, f)
+		if e != nil {
+			err.SetError(ErrorIO, p.path, e)
+			break
+		}
+
+		ret = hex.EncodeToString(h.Sum(nil))
+	}
+
+	return ret, err
+}
+
 // stat check the existence of the specified file
 // If file exists, return true
 func (p *FilePath) stat(path string) Return.Error {
@@ -843,6 +879,13 @@ func IsDir(filePath string) bool {
 	return err == nil && fi.Mode().IsDir()
 }
 
+// IsFile checks if the file is a regular file
+func IsFile(filePath string) bool {
+	fi, err := os.Stat(filePath)
+
+	return err == nil && fi.Mode().IsRegular()
+}
+
 // ReadFile - read file
 func ReadFile(filePath string) ([]byte, Return.Error) {
 	var data []byte
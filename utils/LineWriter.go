@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+//
+// LineWriter - An io.Writer that splits output into lines and passes each line to a function.
+// ---------------------------------------------------------------------------------------------------- //
+type LineWriter struct {
+	fn   func(line string)
+	buf  []byte
+	lock sync.Mutex
+}
+
+// NewLineWriter - Create a new instance of this structure.
+func NewLineWriter(fn func(line string)) *LineWriter {
+	return &LineWriter{
+		fn: fn,
+	}
+}
+
+// Write - Implements io.Writer. Partial lines are held until the next newline, or Flush().
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		index := bytes.IndexByte(w.buf, '\n')
+		if index < 0 {
+			break
+		}
+		w.fn(strings.TrimSuffix(string(w.buf[:index]), "\r"))
+		w.buf = w.buf[index+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush - Pass on any remaining partial line.
+func (w *LineWriter) Flush() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if len(w.buf) > 0 {
+		w.fn(string(w.buf))
+		w.buf = nil
+	}
+}
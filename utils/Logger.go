@@ -5,6 +5,9 @@ import (
 	"io"
 	"log"
 	"os"
+	"regexp"
+	"strings"
+	"sync"
 
 	"github.com/MickMake/GoUnify/Only"
 	"github.com/hashicorp/go-hclog"
@@ -12,39 +15,107 @@ import (
 	"github.com/MickMake/GoPlug/utils/Return"
 )
 
+const (
+	// LogLevelEnv - Default level of new loggers, (trace, debug, info, warn, error or off).
+	LogLevelEnv = "GOPLUG_LOG_LEVEL"
+
+	// LogFormatEnv - Set to "json" for JSON log lines. Inherited by RPC plugin processes, so master can parse their levels.
+	LogFormatEnv = "GOPLUG_LOG_FORMAT"
+)
+
+// DefaultLogLevel - Level of new loggers, unless set by LoggerOptions or LogLevelEnv.
+var DefaultLogLevel = hclog.Debug
+
+//
+// Logger
+// ---------------------------------------------------------------------------------------------------- //
+// Info(), Debug(), ... take a Sprintf() format. Log() and With() take key/value pairs, kept as fields.
+// The level is per logger, (and shared with its Named() sub-loggers), so it can be changed while running.
+// A Logger never changes the output of the standard log package, (see SetStandardLog).
 type Logger struct {
 	Name string
-	out  io.Writer
+	out  *logOutput
 	file *os.File
 	log  hclog.Logger
+	json bool
+}
+
+//
+// LoggerOptions - How NewLoggerWithOptions() sets up a Logger.
+// ---------------------------------------------------------------------------------------------------- //
+type LoggerOptions struct {
+	File   string      // Log file, (defaults to GetLogOutput()).
+	Level  hclog.Level // Defaults to LogLevelEnv, then DefaultLogLevel.
+	JSON   bool        // JSON log lines, (also set by LogFormatEnv).
+	Fields []any       // Key/value pairs added to every line.
+}
+
+// logOutput - Where a Logger, and its sub-loggers, write. Swapped by SetLogFile().
+type logOutput struct {
+	out  io.Writer
+	lock sync.Mutex
+}
+
+func (o *logOutput) Write(p []byte) (int, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.out.Write(p)
+}
+
+func (o *logOutput) set(out io.Writer) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	// Secrets are never logged, (see RegisterSecret).
+	o.out = NewRedactWriter(out)
 }
 
 func NewLogger(name string, filename string) (Logger, Return.Error) {
+	return NewLoggerWithOptions(name, LoggerOptions{File: filename})
+}
+
+// NewLoggerWithOptions - Create a new instance of this structure.
+func NewLoggerWithOptions(name string, options LoggerOptions) (Logger, Return.Error) {
 	var ret Logger
 	var err Return.Error
 
 	for range Only.Once {
 		ret.Name = name
+		ret.out = &logOutput{}
+		ret.json = options.JSON || strings.EqualFold(os.Getenv(LogFormatEnv), "json")
+
+		level := options.Level
+		if level == hclog.NoLevel {
+			level = DefaultLogLevel
+			if env := os.Getenv(LogLevelEnv); env != "" {
+				level, err = ParseLogLevel(env)
+				if err.IsError() {
+					err.SetError("%s: %s", LogLevelEnv, err.GetError())
+					break
+				}
+			}
+		}
+
+		err = ret.SetLogFile(options.File)
+		if err.IsError() {
+			break
+		}
+
+		color := hclog.ColorOff
+		if options.File == "" && !ret.json && GetLogOutput() == os.Stderr && isTerminal(os.Stderr) {
+			color = hclog.ForceColor
+		}
 
-		ret.SetLogFile(filename)
 		ret.log = hclog.New(&hclog.LoggerOptions{
-			Name:                     name,
-			Level:                    hclog.Debug,
-			Output:                   ret.out,
-			Mutex:                    nil,
-			JSONFormat:               false,
-			IncludeLocation:          false,
-			AdditionalLocationOffset: 0,
-			TimeFormat:               "",
-			TimeFn:                   nil,
-			DisableTime:              true,
-			Color:                    hclog.ForceColor,
-			ColorHeaderOnly:          false,
-			ColorHeaderAndFields:     false,
-			Exclude:                  nil,
-			IndependentLevels:        false,
-			SubloggerHook:            nil,
+			Name:        name,
+			Level:       level,
+			Output:      ret.out,
+			JSONFormat:  ret.json,
+			DisableTime: !ret.json,
+			Color:       color,
 		})
+		if len(options.Fields) > 0 {
+			ret.log = ret.log.With(options.Fields...)
+		}
 		ret.Info("[%s] Logger started", name)
 	}
 
@@ -67,10 +138,16 @@ func (l *Logger) Gethclog() hclog.Logger {
 	return l.log
 }
 
+// IsJSON - Returns true if log lines are JSON.
+func (l *Logger) IsJSON() bool {
+	return l.json
+}
+
 func (l *Logger) GetLevel() hclog.Level {
 	return l.log.GetLevel()
 }
 
+// SetLevel - Change the level of this logger, and its sub-loggers, while running.
 func (l *Logger) SetLevel(level hclog.Level) {
 	l.log.SetLevel(level)
 }
@@ -79,6 +156,51 @@ func (l *Logger) SetNamed(name string) {
 	l.log.Named(name)
 }
 
+// Named - Create a sub-logger, sharing the same output.
+func (l *Logger) Named(name string) *Logger {
+	return &Logger{
+		Name: l.Name + "." + name,
+		out:  l.out,
+		file: nil,
+		log:  l.log.Named(name),
+		json: l.json,
+	}
+}
+
+// With - Create a sub-logger, sharing the same output, that adds the key/value pairs to every line.
+func (l *Logger) With(args ...any) *Logger {
+	return &Logger{
+		Name: l.Name,
+		out:  l.out,
+		file: nil,
+		log:  l.log.With(args...),
+		json: l.json,
+	}
+}
+
+// StandardLogger - A standard log package Logger writing to this logger, at the level found within each line,
+// (eg: "[WARN]: ..."), or Info.
+func (l *Logger) StandardLogger() *log.Logger {
+	return log.New(&standardWriter{
+		out: l.log.StandardWriter(&hclog.StandardLoggerOptions{
+			InferLevels: true,
+		}),
+	}, "", 0)
+}
+
+// standardLevel - The "[WARN]:" form of a level, used by the standard log package calls within GoPlug.
+var standardLevel = regexp.MustCompile(`^\[(TRACE|DEBUG|INFO|WARN|ERROR|ERR)]:`)
+
+// standardWriter - Drops the ':' after a "[WARN]:" level, which hclog would otherwise keep within the message.
+type standardWriter struct {
+	out io.Writer
+}
+
+func (w *standardWriter) Write(p []byte) (int, error) {
+	_, e := w.out.Write(standardLevel.ReplaceAll(p, []byte("[$1]")))
+	return len(p), e
+}
+
 func (l *Logger) Close() {
 	if l == nil {
 		return
@@ -88,68 +210,159 @@ func (l *Logger) Close() {
 	}
 	//goland:noinspection GoUnhandledErrorResult
 	l.file.Close()
+	l.file = nil
 }
 
-func (l *Logger) Info(msg string, args ...any) {
+// Log - Log msg with key/value pair fields, (eg: Log(hclog.Warn, "slow hook", "hook", name, "took", d)).
+func (l *Logger) Log(level hclog.Level, msg string, args ...any) {
 	if l.log != nil {
-		l.log.Info(l.Name + " => " + fmt.Sprintf(msg, args...))
+		l.log.Log(level, l.Name+" => "+msg, args...)
+	}
+}
+
+func (l *Logger) Trace(msg string, args ...any) {
+	if l.log != nil && l.log.IsTrace() {
+		l.log.Trace(l.Name + " => " + fmt.Sprintf(msg, args...))
 	}
 }
 
 func (l *Logger) Debug(msg string, args ...any) {
-	if l.log != nil {
+	if l.log != nil && l.log.IsDebug() {
 		l.log.Debug(l.Name + " => " + fmt.Sprintf(msg, args...))
 	}
 }
 
+func (l *Logger) Info(msg string, args ...any) {
+	if l.log != nil && l.log.IsInfo() {
+		l.log.Info(l.Name + " => " + fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *Logger) Warn(msg string, args ...any) {
+	if l.log != nil && l.log.IsWarn() {
+		l.log.Warn(l.Name + " => " + fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *Logger) Error(msg string, args ...any) {
+	if l.log != nil && l.log.IsError() {
+		l.log.Error(l.Name + " => " + fmt.Sprintf(msg, args...))
+	}
+}
+
 func (l *Logger) SetName(name string) {
 	if name != "" {
 		l.Name = name
 	}
 }
 
+// SetLogFile - Write to filename, or GetLogOutput() if empty. Sub-loggers write there too.
 func (l *Logger) SetLogFile(filename string) Return.Error {
 	var err Return.Error
 
 	for range Only.Once {
+		if l.out == nil {
+			l.out = &logOutput{}
+		}
+
 		if filename == "" {
-			if l.file != nil {
-				//goland:noinspection GoUnhandledErrorResult
-				l.file.Close()
-			}
-			l.file = nil
-			l.out = os.Stderr
-			// l.out = l.log.StandardLogger(&hclog.StandardLoggerOptions{
-			// 	InferLevels:              true,
-			// 	InferLevelsWithTimestamp: true,
-			// 	ForceLevel:               l.log.GetLevel(),
-			// })
-			log.SetOutput(l.out)
-			log.SetPrefix("")
-			log.SetFlags(log.Lshortfile)
+			l.out.set(GetLogOutput())
+			l.Close()
 			break
 		}
 
-		// @TODO - Maybe use l.log.StandardWriter() instead, then can use log.SetOutput()
-		var e error
-		l.out, e = os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+		f, e := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
 		if e != nil {
 			err.SetError("error opening file: %v", e)
 			break
 		}
-		// l.out = l.file
+		l.out.set(f)
+		l.Close()
+		l.file = f
+	}
 
-		// l.out = l.log.StandardWriter(&hclog.StandardLoggerOptions{
-		// 	InferLevels:              true,
-		// 	InferLevelsWithTimestamp: true,
-		// 	ForceLevel:               l.log.GetLevel(),
-		// })
-		log.SetOutput(l.out)
-		log.SetPrefix("")
-		log.SetFlags(log.Lshortfile)
-		// mw := io.MultiWriter(os.Stdout, logFile)
+	return err
+}
 
+// SetOutput - Write to out, (eg: to capture log lines in tests). Sub-loggers write there too.
+func (l *Logger) SetOutput(out io.Writer) {
+	if l.out == nil {
+		l.out = &logOutput{}
 	}
+	l.out.set(out)
+	l.Close()
+}
 
-	return err
+var defaultOutput = struct {
+	out  io.Writer
+	lock sync.RWMutex
+}{}
+
+// SetLogOutput - Where Loggers created without a log file write from now on, (nil for stderr).
+func SetLogOutput(out io.Writer) {
+	defaultOutput.lock.Lock()
+	defer defaultOutput.lock.Unlock()
+	defaultOutput.out = out
+}
+
+// GetLogOutput - Where Loggers created without a log file write, (see SetLogOutput).
+func GetLogOutput() io.Writer {
+	defaultOutput.lock.RLock()
+	defer defaultOutput.lock.RUnlock()
+	if defaultOutput.out == nil {
+		return os.Stderr
+	}
+	return defaultOutput.out
+}
+
+// ParseLogLevel - Level from its name, (trace, debug, info, warn, error or off).
+func ParseLogLevel(name string) (hclog.Level, Return.Error) {
+	var err Return.Error
+	level := hclog.LevelFromString(strings.TrimSpace(name))
+	if level == hclog.NoLevel {
+		err.SetError("unknown log level '%s', (trace, debug, info, warn, error or off)", name)
+	}
+	return level, err
+}
+
+// SetStandardLog - Send the standard log package output to out, with secrets redacted and the file:line of each call.
+// For applications, (eg: the CLI, or an RPC plugin process), a Logger never calls this.
+func SetStandardLog(out io.Writer) {
+	log.SetOutput(NewRedactWriter(out))
+	log.SetPrefix("")
+	log.SetFlags(log.Lshortfile)
+}
+
+// isTerminal - Returns true if f is a terminal, (so colour can be used).
+func isTerminal(f *os.File) bool {
+	fi, e := f.Stat()
+	if e != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+//
+// LogLevels - Log levels keyed by plugin name, ("*" for all plugins).
+// ---------------------------------------------------------------------------------------------------- //
+type LogLevels map[string]hclog.Level
+
+// Get - The level for a plugin, or hclog.NoLevel if none is set.
+func (l LogLevels) Get(name string) hclog.Level {
+	if level, ok := l[name]; ok {
+		return level
+	}
+	if level, ok := l["*"]; ok {
+		return level
+	}
+	return hclog.NoLevel
+}
+
+// Copy - A copy, that can be changed without changing l.
+func (l LogLevels) Copy() LogLevels {
+	ret := make(LogLevels)
+	for name, level := range l {
+		ret[name] = level
+	}
+	return ret
 }
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// Slog - A log/slog Logger writing to this logger.
+func (l *Logger) Slog() *slog.Logger {
+	return slog.New(l.SlogHandler())
+}
+
+// SlogHandler - A log/slog Handler writing to this logger. Attributes become key/value fields, (group.key within groups).
+func (l *Logger) SlogHandler() slog.Handler {
+	return &SlogHandler{logger: l}
+}
+
+//
+// SlogHandler - Adapts a Logger to log/slog.
+// ---------------------------------------------------------------------------------------------------- //
+type SlogHandler struct {
+	logger *Logger
+	fields []any
+	group  string
+}
+
+// Enabled - Implements slog.Handler.
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.GetLevel() <= SlogLevel(level)
+}
+
+// Handle - Implements slog.Handler.
+func (h *SlogHandler) Handle(_ context.Context, record slog.Record) error {
+	fields := append([]any{}, h.fields...)
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendSlogAttr(fields, h.group, attr)
+		return true
+	})
+
+	h.logger.Log(SlogLevel(record.Level), record.Message, fields...)
+	return nil
+}
+
+// WithAttrs - Implements slog.Handler.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	ret := *h
+	ret.fields = append([]any{}, h.fields...)
+	for _, attr := range attrs {
+		ret.fields = appendSlogAttr(ret.fields, h.group, attr)
+	}
+	return &ret
+}
+
+// WithGroup - Implements slog.Handler.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	ret := *h
+	ret.group = slogKey(h.group, name)
+	return &ret
+}
+
+// SlogLevel - The hclog level of a log/slog level.
+func SlogLevel(level slog.Level) hclog.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return hclog.Trace
+	case level < slog.LevelInfo:
+		return hclog.Debug
+	case level < slog.LevelWarn:
+		return hclog.Info
+	case level < slog.LevelError:
+		return hclog.Warn
+	}
+	return hclog.Error
+}
+
+// appendSlogAttr - Add an attribute, (and those within a group attribute), as key/value pairs.
+func appendSlogAttr(fields []any, group string, attr slog.Attr) []any {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		group = slogKey(group, attr.Key)
+		for _, a := range attr.Value.Group() {
+			fields = appendSlogAttr(fields, group, a)
+		}
+		return fields
+	}
+
+	return append(fields, slogKey(group, attr.Key), attr.Value.Any())
+}
+
+// slogKey - group.key
+func slogKey(group string, key string) string {
+	if group == "" {
+		return key
+	}
+	if key == "" {
+		return group
+	}
+	return group + "." + key
+}
@@ -0,0 +1,262 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/suite"
+)
+
+type LoggerSuite struct {
+	suite.Suite
+	file string
+}
+
+// SetupTest - Loggers write to a file, so their output can be read back.
+func (s *LoggerSuite) SetupTest() {
+	s.file = filepath.Join(s.T().TempDir(), "test.log")
+}
+
+func (s *LoggerSuite) logger(name string, options LoggerOptions) *Logger {
+	options.File = s.file
+	logger, err := NewLoggerWithOptions(name, options)
+	s.Require().False(err.IsError(), err.String())
+	s.T().Cleanup(logger.Close)
+	return &logger
+}
+
+// lines - The lines written to the log file, after the "Logger started" line, if any.
+func (s *LoggerSuite) lines() []string {
+	data, e := os.ReadFile(s.file)
+	s.Require().NoError(e)
+
+	var ret []string
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		if line != "" && !strings.Contains(line, "Logger started") {
+			ret = append(ret, line)
+		}
+	}
+	return ret
+}
+
+// json - The JSON lines written to the log file, after the "Logger started" line.
+func (s *LoggerSuite) json() []map[string]any {
+	var ret []map[string]any
+	for _, line := range s.lines() {
+		var entry map[string]any
+		s.Require().NoError(json.Unmarshal([]byte(line), &entry), line)
+		delete(entry, "@timestamp")
+		ret = append(ret, entry)
+	}
+	return ret
+}
+
+func (s *LoggerSuite) TestLevel() {
+	tests := []struct {
+		level hclog.Level
+		want  []string
+	}{
+		{level: hclog.Trace, want: []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR"}},
+		{level: hclog.Debug, want: []string{"DEBUG", "INFO", "WARN", "ERROR"}},
+		{level: hclog.Info, want: []string{"INFO", "WARN", "ERROR"}},
+		{level: hclog.Warn, want: []string{"WARN", "ERROR"}},
+		{level: hclog.Error, want: []string{"ERROR"}},
+		{level: hclog.Off},
+	}
+
+	for _, test := range tests {
+		s.Run(test.level.String(), func() {
+			s.SetupTest()
+			logger := s.logger("level", LoggerOptions{Level: test.level})
+			s.Equal(test.level, logger.GetLevel())
+
+			logger.Trace("%s", "trace")
+			logger.Debug("%s", "debug")
+			logger.Info("%s", "info")
+			logger.Warn("%s", "warn")
+			logger.Error("%s", "error")
+
+			var want []string
+			for _, level := range test.want {
+				want = append(want, "["+level+"]"+strings.Repeat(" ", 6-len(level))+"level: level => "+strings.ToLower(level))
+			}
+			s.Equal(want, s.lines())
+		})
+	}
+}
+
+func (s *LoggerSuite) TestSetLevel() {
+	logger := s.logger("parent", LoggerOptions{Level: hclog.Info})
+	named := logger.Named("child")
+	with := logger.With("key", "value")
+	s.Equal("parent.child", named.Name)
+
+	named.Debug("hidden")
+	logger.SetLevel(hclog.Debug)
+	s.Equal(hclog.Debug, named.GetLevel(), "shared with sub-loggers")
+	named.Debug("shown")
+	with.Debug("with")
+	logger.SetLevel(hclog.Error)
+	with.Warn("hidden")
+
+	s.Equal([]string{
+		"[DEBUG] parent.child: parent.child => shown",
+		"[DEBUG] parent: parent => with: key=value",
+	}, s.lines())
+}
+
+func (s *LoggerSuite) TestEnv() {
+	s.T().Setenv(LogLevelEnv, "warn")
+	s.Equal(hclog.Warn, s.logger("env", LoggerOptions{}).GetLevel())
+	s.Equal(hclog.Trace, s.logger("env", LoggerOptions{Level: hclog.Trace}).GetLevel(), "options first")
+
+	s.T().Setenv(LogLevelEnv, "")
+	s.Equal(DefaultLogLevel, s.logger("env", LoggerOptions{}).GetLevel())
+
+	s.T().Setenv(LogLevelEnv, "loud")
+	_, err := NewLoggerWithOptions("env", LoggerOptions{File: s.file})
+	s.True(err.IsError())
+	s.Contains(err.Error(), LogLevelEnv+": unknown log level 'loud'")
+
+	s.T().Setenv(LogLevelEnv, "")
+	s.False(s.logger("env", LoggerOptions{}).IsJSON())
+	s.T().Setenv(LogFormatEnv, "JSON")
+	s.True(s.logger("env", LoggerOptions{}).IsJSON())
+}
+
+func (s *LoggerSuite) TestFields() {
+	logger := s.logger("fields", LoggerOptions{Level: hclog.Info, Fields: []any{"plugin", "a"}})
+	logger.Log(hclog.Warn, "slow hook", "hook", "Hello", "took", 2)
+	logger.With("call", 1).Info("100%% %s", "done")
+	logger.Log(hclog.Debug, "hidden")
+
+	s.Equal([]string{
+		"[WARN]  fields: fields => slow hook: plugin=a hook=Hello took=2",
+		"[INFO]  fields: fields => 100% done: call=1 plugin=a",
+	}, s.lines())
+}
+
+func (s *LoggerSuite) TestJSON() {
+	logger := s.logger("json", LoggerOptions{Level: hclog.Info, JSON: true, Fields: []any{"plugin", "a"}})
+	s.True(logger.IsJSON())
+	logger.Log(hclog.Warn, "slow hook", "hook", "Hello", "took", 2)
+	logger.Named("sub").With("call", 1).Error("failed: %s", "reason")
+
+	s.Equal([]map[string]any{
+		{"@level": "warn", "@message": "json => slow hook", "@module": "json", "plugin": "a", "hook": "Hello", "took": float64(2)},
+		{"@level": "error", "@message": "json.sub => failed: reason", "@module": "json.sub", "plugin": "a", "call": float64(1)},
+	}, s.json())
+
+	// As master reads the level of plugin output, (see GoPlugLoader.RpcOutput).
+	for _, line := range s.lines() {
+		s.True(strings.HasPrefix(line, "{"), line)
+	}
+}
+
+func (s *LoggerSuite) TestRedact() {
+	NewSecret("logger-secret")
+	logger := s.logger("redact", LoggerOptions{Level: hclog.Info})
+	logger.Info("key %s", "logger-secret")
+	logger.Log(hclog.Info, "field", "key", "logger-secret")
+
+	s.Equal([]string{
+		"[INFO]  redact: redact => key " + Redacted,
+		"[INFO]  redact: redact => field: key=" + Redacted,
+	}, s.lines())
+}
+
+func (s *LoggerSuite) TestStandardLogger() {
+	logger := s.logger("std", LoggerOptions{Level: hclog.Info})
+	std := logger.StandardLogger()
+	std.Print("[WARN]: warning")
+	std.Print("[DEBUG]: hidden")
+	std.Print("plain")
+	std.Print("[ERROR] without a colon")
+	std.Print("not a level [WARN]: warning")
+
+	s.Equal([]string{
+		"[WARN]  std: warning",
+		"[INFO]  std: plain",
+		"[ERROR] std: without a colon",
+		"[INFO]  std: not a level [WARN]: warning",
+	}, s.lines())
+}
+
+func (s *LoggerSuite) TestLogLevels() {
+	levels := LogLevels{"a": hclog.Warn}
+	s.Equal(hclog.Warn, levels.Get("a"))
+	s.Equal(hclog.NoLevel, levels.Get("b"), "none set")
+
+	all := levels.Copy()
+	all["*"] = hclog.Error
+	s.Equal(hclog.Error, all.Get("b"), "all plugins")
+	s.Equal(hclog.Warn, all.Get("a"), "the plugin first")
+	s.Equal(LogLevels{"a": hclog.Warn}, levels, "a copy")
+
+	tests := []struct {
+		name  string
+		level hclog.Level
+		error bool
+	}{
+		{name: "trace", level: hclog.Trace},
+		{name: "WARN", level: hclog.Warn},
+		{name: " debug\n", level: hclog.Debug},
+		{name: "off", level: hclog.Off},
+		{name: "loud", level: hclog.NoLevel, error: true},
+		{name: "", level: hclog.NoLevel, error: true},
+	}
+	for _, test := range tests {
+		level, err := ParseLogLevel(test.name)
+		s.Equal(test.level, level, test.name)
+		s.Equal(test.error, err.IsError(), test.name)
+	}
+}
+
+func (s *LoggerSuite) TestSlog() {
+	logger := s.logger("slog", LoggerOptions{Level: hclog.Info, JSON: true})
+	log := logger.Slog()
+
+	log.Info("hello", "a", 1)
+	log.Debug("hidden")
+	log.With("b", 2).WithGroup("g").With("c", 3).Warn("grouped", "d", 4, slog.Group("h", "e", 5))
+	log.WithGroup("").Error("no group", slog.Group("", "f", 6), slog.Attr{})
+	log.Log(context.Background(), slog.LevelDebug-4, "trace, hidden")
+
+	s.Equal([]map[string]any{
+		{"@level": "info", "@message": "slog => hello", "@module": "slog", "a": float64(1)},
+		{"@level": "warn", "@message": "slog => grouped", "@module": "slog", "b": float64(2), "g.c": float64(3), "g.d": float64(4), "g.h.e": float64(5)},
+		{"@level": "error", "@message": "slog => no group", "@module": "slog", "f": float64(6)},
+	}, s.json())
+
+	handler := logger.SlogHandler()
+	s.False(handler.Enabled(context.Background(), slog.LevelDebug))
+	s.True(handler.Enabled(context.Background(), slog.LevelInfo))
+	logger.SetLevel(hclog.Trace)
+	s.True(handler.Enabled(context.Background(), slog.LevelDebug-4), "follows the logger level")
+
+	tests := []struct {
+		level slog.Level
+		want  hclog.Level
+	}{
+		{level: slog.LevelDebug - 4, want: hclog.Trace},
+		{level: slog.LevelDebug, want: hclog.Debug},
+		{level: slog.LevelDebug + 1, want: hclog.Debug},
+		{level: slog.LevelInfo, want: hclog.Info},
+		{level: slog.LevelWarn, want: hclog.Warn},
+		{level: slog.LevelError, want: hclog.Error},
+		{level: slog.LevelError + 4, want: hclog.Error},
+	}
+	for _, test := range tests {
+		s.Equal(test.want, SlogLevel(test.level), test.level.String())
+	}
+}
+
+func TestLoggerSuite(t *testing.T) {
+	suite.Run(t, new(LoggerSuite))
+}
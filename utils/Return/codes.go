@@ -0,0 +1,23 @@
+package Return
+
+//
+// Code - A machine readable error code. Codes are errors too, so can be matched with errors.Is(err, Return.PluginNotFound).
+// ---------------------------------------------------------------------------------------------------- //
+type Code string
+
+//goland:noinspection GoUnusedConst
+const (
+	PluginNotFound     Code = "PluginNotFound"     // No plugin, (file or loaded), of that name.
+	PluginRefused      Code = "PluginRefused"      // A validator, (signature, lockfile, ...), refused the plugin file.
+	PluginIncompatible Code = "PluginIncompatible" // A native plugin was built with a different toolchain or dependencies.
+	HandshakeFailed    Code = "HandshakeFailed"    // The RPC plugin process could not be started or connected to.
+	HookNotFound       Code = "HookNotFound"       // The plugin has no hook of that name.
+	HookArgMismatch    Code = "HookArgMismatch"    // Hook args differ, in number or type, from those the hook was set with.
+	CapabilityDenied   Code = "CapabilityDenied"   // The plugin hasn't declared, or wasn't granted, a capability.
+	ConfigInvalid      Code = "ConfigInvalid"      // Plugin config doesn't match the plugin's schema.
+	SecretNotFound     Code = "SecretNotFound"     // No secrets provider holds a secret of that name.
+)
+
+func (c Code) Error() string {
+	return string(c)
+}
@@ -3,15 +3,47 @@ package Return
 import (
 	"bytes"
 	"encoding/gob"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/rpc"
+	"strings"
+	"time"
 )
 
+// RemoteErrorPrefix - Marks an RPC error message that holds an encoded Error, (see GetRemoteError).
+const RemoteErrorPrefix = "Return.Error:"
+
+//
+// errorData - The encoded form of an Error, used by both the binary and JSON encodings.
+// ---------------------------------------------------------------------------------------------------- //
+type errorData struct {
+	Prefix  string     `json:"prefix,omitempty"`
+	When    *time.Time `json:"when,omitempty"`
+	Error   *causeData `json:"error,omitempty"`
+	Warning *causeData `json:"warning,omitempty"`
+	Code    Code       `json:"code,omitempty"`
+	Plugin  string     `json:"plugin,omitempty"`
+	Hook    string     `json:"hook,omitempty"`
+	Remote  bool       `json:"remote,omitempty"`
+	Stack   string     `json:"stack,omitempty"`
+}
+
+// causeData - An error within the cause chain.
+type causeData struct {
+	Message string      `json:"message"`
+	Type    string      `json:"type,omitempty"` // Go type of the error, (eg: *fs.PathError).
+	Code    Code        `json:"code,omitempty"` // Set if the error is a Code.
+	Return  *errorData  `json:"return,omitempty"`
+	Causes  []causeData `json:"causes,omitempty"`
+}
+
+// MarshalBinary - gob encoding, keeping the message, warning, prefix, time, code, context, causes and stack.
 func (e Error) MarshalBinary() ([]byte, error) {
-	// A simple encoding: plain text.
 	var b bytes.Buffer
-	_, err := fmt.Fprintln(&b, e.prefix, e.when, e.err, e.warning)
+	err := gob.NewEncoder(&b).Encode(e.encode())
 	if err != nil {
 		return nil, err
 	}
@@ -21,21 +53,162 @@ func (e Error) MarshalBinary() ([]byte, error) {
 
 // UnmarshalBinary modifies the receiver so it must take a pointer receiver.
 func (e *Error) UnmarshalBinary(data []byte) error {
-	// A simple encoding: plain text.
-	b := bytes.NewBuffer(data)
-	_, err := fmt.Fscanln(b, &e.prefix, &e.when, &e.err, &e.warning)
-	return err
+	var d errorData
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&d)
+	if err != nil {
+		return err
+	}
+
+	*e = d.decode()
+	return nil
+}
+
+// MarshalJSON - JSON encoding, holding the same as MarshalBinary.
+func (e Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.encode())
+}
+
+// UnmarshalJSON - Decode an Error encoded by MarshalJSON.
+func (e *Error) UnmarshalJSON(data []byte) error {
+	var d errorData
+	err := json.Unmarshal(data, &d)
+	if err != nil {
+		return err
+	}
+
+	*e = d.decode()
+	return nil
+}
+
+// GetRemoteError - Returns nil, or the error to return from an RPC server method.
+// net/rpc only sends the message of an error, so the whole Error is encoded within it, for NewRemoteError.
+func (e *Error) GetRemoteError() error {
+	if e.err == nil {
+		return nil
+	}
+
+	data, err := e.MarshalJSON()
+	if err != nil {
+		return e.GetError()
+	}
+	return errors.New(RemoteErrorPrefix + string(data))
+}
+
+// NewRemoteError - Create an Error from an RPC call error. An error from GetRemoteError is decoded, and marked as
+// remote, other errors, (eg: a broken connection), are used as is.
+func NewRemoteError(err error) Error {
+	var ret Error
+	if err == nil {
+		return ret
+	}
+
+	var server rpc.ServerError
+	if errors.As(err, &server) && strings.HasPrefix(string(server), RemoteErrorPrefix) {
+		e := ret.UnmarshalJSON([]byte(strings.TrimPrefix(string(server), RemoteErrorPrefix)))
+		if e == nil {
+			ret.remote = true
+			return ret
+		}
+	}
+
+	ret.SetError(err)
+	return ret
+}
+
+// encode - The encoded form of the error.
+func (e Error) encode() errorData {
+	ret := errorData{
+		Prefix: e.prefix,
+		Code:   e.code,
+		Plugin: e.plugin,
+		Hook:   e.hook,
+		Remote: e.remote,
+	}
+	if !e.when.IsZero() {
+		when := e.when
+		ret.When = &when
+	}
+	if e.err != nil {
+		ret.Error = encodeCause(e.err)
+		ret.Stack = e.GetStack()
+	}
+	if e.warning != nil {
+		ret.Warning = encodeCause(e.warning)
+	}
+	return ret
+}
+
+// decode - The error from its encoded form.
+func (d errorData) decode() Error {
+	ret := Error{
+		prefix: d.Prefix,
+		code:   d.Code,
+		plugin: d.Plugin,
+		hook:   d.Hook,
+		remote: d.Remote,
+		stack:  d.Stack,
+	}
+	if d.When != nil {
+		ret.when = *d.When
+	}
+	if d.Error != nil {
+		ret.err = d.Error.decode()
+	}
+	if d.Warning != nil {
+		ret.warning = d.Warning.decode()
+	}
+	return ret
+}
+
+// encodeCause - An error, and the errors it wraps.
+func encodeCause(err error) *causeData {
+	ret := causeData{
+		Message: err.Error(),
+		Type:    fmt.Sprintf("%T", err),
+	}
+
+	var causes []error
+	switch v := err.(type) {
+	case Error:
+		d := v.encode()
+		ret.Return = &d
+	case Code:
+		ret.Code = v
+	case interface{ Unwrap() []error }:
+		causes = v.Unwrap()
+	case interface{ Unwrap() error }:
+		causes = []error{v.Unwrap()}
+	}
+
+	for _, cause := range causes {
+		if cause != nil {
+			ret.Causes = append(ret.Causes, *encodeCause(cause))
+		}
+	}
+	return &ret
+}
+
+// decode - Errors from another process keep their message and causes, but not their type, (except for an Error or
+// a Code), so errors.Is() matches Codes, but not other sentinels.
+func (c causeData) decode() error {
+	if c.Return != nil {
+		return c.Return.decode()
+	}
+	if c.Code != "" {
+		return c.Code
+	}
+
+	var causes []error
+	for _, cause := range c.Causes {
+		causes = append(causes, cause.decode())
+	}
+	return &wrapError{msg: c.Message, causes: causes}
 }
 
 func (e *Error) GobNewEncoder(network *io.Writer) {
 	// Create an encoder and send a value.
 	enc := gob.NewEncoder(*network)
-	err := enc.Encode(Error{
-		prefix:  e.prefix,
-		when:    e.when,
-		err:     e.err,
-		warning: e.warning,
-	})
+	err := enc.Encode(*e)
 	if err != nil {
 		log.Fatal("encode:", err)
 	}
@@ -4,17 +4,28 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"runtime"
 	"time"
 )
 
 //
 // Error
 // ---------------------------------------------------------------------------------------------------- //
+// Error implements error. Errors passed to SetError() and AddError(), (as the format or an arg), are kept as its
+// causes, so errors.Is() and errors.As() see them. A Code, plus the plugin and hook the error came from, are optional.
+// Errors received from the other side of an RPC connection are marked as remote, and keep the stack of where they
+// were set, (see GetRemoteError and NewRemoteError).
 type Error struct {
 	prefix  string
 	when    time.Time
 	err     error
 	warning error
+	code    Code
+	plugin  string
+	hook    string
+	remote  bool
+	stack   string
+	callers *[]uintptr
 }
 
 var Ok Error
@@ -35,6 +46,14 @@ func NewError(format any, args ...any) Error {
 	return e
 }
 
+// NewCodeError - Create an error with a Code, (see Code).
+func NewCodeError(code Code, format any, args ...any) Error {
+	var e Error
+	e.SetError(format, args...)
+	e.SetCode(code)
+	return e
+}
+
 func NewWarning(format any, args ...any) Error {
 	var e Error
 	e.SetWarning(format, args...)
@@ -55,10 +74,10 @@ func (e *Error) format(format any, args ...any) string {
 		// v is a string here, so e.g. v + " Yeah!" is possible.
 		str = fmt.Sprintf("%v", v)
 		str = fmt.Sprintf(str, args...)
-	case error:
-		str = fmt.Sprintf("%s", format)
 	case Error:
-		str = fmt.Sprintf("%s", format)
+		str = v.Error()
+	case error:
+		str = v.Error()
 	}
 
 	return str
@@ -81,6 +100,12 @@ func (e *Error) Copy(err Error) Error {
 		when:    err.when,
 		err:     err.err,
 		warning: err.warning,
+		code:    err.code,
+		plugin:  err.plugin,
+		hook:    err.hook,
+		remote:  err.remote,
+		stack:   err.stack,
+		callers: err.callers,
 	}
 	return *e
 }
@@ -100,8 +125,12 @@ func (e *Error) SetError(format any, args ...any) {
 		return
 	}
 	e.when = time.Now()
-	e.err = errors.New(str)
+	e.err = wrap(str, format, args...)
 	e.warning = nil
+	e.code = ""
+	e.remote = false
+	e.stack = ""
+	e.callers = getCallers()
 }
 
 func (e *Error) AddError(format string, args ...any) {
@@ -111,18 +140,24 @@ func (e *Error) AddError(format string, args ...any) {
 	}
 	e.when = time.Now()
 	e.warning = nil
+	err := wrap(str, format, args...)
 	if e.err == nil {
-		e.err = errors.New(str)
+		e.err = err
+		e.callers = getCallers()
 		return
 	}
-	e.err = errors.New(fmt.Sprintf("%s / %s", e.err, str))
+	e.err = &wrapError{
+		msg:    fmt.Sprintf("%s / %s", e.err, str),
+		causes: []error{e.err, err},
+	}
 }
 
+// GetError - Returns nil, or a copy of the error, (which errors.As() can find as a Return.Error).
 func (e *Error) GetError() error {
 	if e.err == nil {
 		return nil
 	}
-	return errors.New(fmt.Sprintf("%s%v", e.prefix, e.err))
+	return *e
 }
 
 func (e *Error) SetWarning(format any, args ...any) {
@@ -197,6 +232,91 @@ func (e *Error) Print() {
 	fmt.Print(str)
 }
 
+// Error - error interface, the message without "ERROR: ", (see String).
+func (e Error) Error() string {
+	if e.err != nil {
+		return e.prefix + e.err.Error()
+	}
+	if e.warning != nil {
+		return e.prefix + e.warning.Error()
+	}
+	return ""
+}
+
+// Unwrap - The causes, for errors.Is() and errors.As().
+func (e Error) Unwrap() []error {
+	if e.err == nil {
+		return nil
+	}
+	return []error{e.err}
+}
+
+// Is - Matches a Code, (eg: errors.Is(err, Return.PluginNotFound)).
+func (e Error) Is(target error) bool {
+	code, ok := target.(Code)
+	return ok && e.code != "" && e.code == code
+}
+
+// SetCode - Set the machine readable code of the error, (see Code).
+func (e *Error) SetCode(code Code) {
+	e.code = code
+}
+
+// GetCode - The code of the error, or the first code found within its causes.
+func (e *Error) GetCode() Code {
+	if e.code != "" || e.err == nil {
+		return e.code
+	}
+	var cause Error
+	if errors.As(e.err, &cause) {
+		return cause.GetCode()
+	}
+	return ""
+}
+
+// SetContext - Set the plugin, and hook, the error came from. Empty values are left as they are.
+func (e *Error) SetContext(plugin string, hook string) {
+	if plugin != "" {
+		e.plugin = plugin
+	}
+	if hook != "" {
+		e.hook = hook
+	}
+}
+
+// GetPlugin - The plugin the error came from, if known.
+func (e *Error) GetPlugin() string {
+	return e.plugin
+}
+
+// GetHook - The hook the error came from, if known.
+func (e *Error) GetHook() string {
+	return e.hook
+}
+
+// IsRemote - Returns true if the error came from the other side of an RPC connection, (eg: an RPC plugin).
+func (e *Error) IsRemote() bool {
+	return e.remote
+}
+
+// GetStack - The stack of where the error was set, (within the plugin process, for a remote error).
+func (e *Error) GetStack() string {
+	if e.stack != "" || e.callers == nil {
+		return e.stack
+	}
+
+	var ret string
+	frames := runtime.CallersFrames(*e.callers)
+	for {
+		frame, more := frames.Next()
+		ret += fmt.Sprintf("%s()\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return ret
+}
+
 func (e Error) String() string {
 	if e.err != nil {
 		return fmt.Sprintf("%sERROR: %v\n", e.prefix, e.err)
@@ -206,3 +326,49 @@ func (e Error) String() string {
 	}
 	return ""
 }
+
+//
+// wrapError - A message, with the errors that caused it.
+// ---------------------------------------------------------------------------------------------------- //
+type wrapError struct {
+	msg    string
+	causes []error
+}
+
+func (w *wrapError) Error() string {
+	return w.msg
+}
+
+func (w *wrapError) Unwrap() []error {
+	return w.causes
+}
+
+// getCallers - The callers of SetError or AddError, for GetStack.
+func getCallers() *[]uintptr {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	pcs = pcs[:n]
+	return &pcs
+}
+
+// wrap - Create the error for a message, keeping any errors given as the format or args as its causes.
+func wrap(str string, format any, args ...any) error {
+	var causes []error
+	for _, arg := range append([]any{format}, args...) {
+		switch v := arg.(type) {
+		case Error:
+			if v.err != nil {
+				causes = append(causes, v)
+			}
+		case error:
+			if v != nil {
+				causes = append(causes, v)
+			}
+		}
+	}
+
+	if len(causes) == 0 {
+		return errors.New(str)
+	}
+	return &wrapError{msg: str, causes: causes}
+}
@@ -2,7 +2,11 @@
 package Return
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/rpc"
+	"os"
 	"testing"
 	"time"
 
@@ -269,3 +273,143 @@ func (s *StructSuite) TestErrorformat0() {
 func TestStructSuite(t *testing.T) {
 	suite.Run(t, new(StructSuite))
 }
+
+type ErrorChainSuite struct {
+	suite.Suite
+}
+
+// notExist - An error caused by os.ErrNotExist, as SetError() is usually called.
+func notExist() Error {
+	var err Error
+	_, e := os.Open("/does/not/exist")
+	err.SetError("can't open: %s", e)
+	return err
+}
+
+func (s *ErrorChainSuite) TestIs() {
+	tests := []struct {
+		name   string
+		err    func() Error
+		target error
+		want   bool
+	}{
+		{name: "SetError cause", err: notExist, target: os.ErrNotExist, want: true},
+		{name: "SetError format", err: func() Error { return NewError(os.ErrNotExist) }, target: os.ErrNotExist, want: true},
+		{name: "AddError keeps first cause", err: func() Error {
+			err := notExist()
+			err.AddError("giving up")
+			return err
+		}, target: os.ErrNotExist, want: true},
+		{name: "AddError cause", err: func() Error {
+			err := NewError("first")
+			err.AddError("second: %s", os.ErrPermission)
+			return err
+		}, target: os.ErrPermission, want: true},
+		{name: "other sentinel", err: notExist, target: os.ErrPermission},
+		{name: "no cause", err: func() Error { return NewError("plain") }, target: os.ErrNotExist},
+		{name: "Code", err: func() Error { return NewCodeError(ConfigInvalid, "bad") }, target: ConfigInvalid, want: true},
+		{name: "other Code", err: func() Error { return NewCodeError(ConfigInvalid, "bad") }, target: PluginNotFound},
+		{name: "no Code", err: func() Error { return NewError("bad") }, target: ConfigInvalid},
+		{name: "wrapped Code", err: func() Error { return NewError("loading: %s", NewCodeError(PluginRefused, "unsigned")) }, target: PluginRefused, want: true},
+		{name: "Code cause", err: func() Error { return NewError("refused: %s", PluginRefused) }, target: PluginRefused, want: true},
+		{name: "SetError clears Code", err: func() Error {
+			err := NewCodeError(ConfigInvalid, "bad")
+			err.SetError("again")
+			return err
+		}, target: ConfigInvalid},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			err := test.err()
+			s.Equal(test.want, errors.Is(err, test.target))
+			s.Equal(test.want, errors.Is(err.GetError(), test.target), "GetError() keeps the causes")
+		})
+	}
+}
+
+func (s *ErrorChainSuite) TestAs() {
+	err := NewError("loading: %s", NewCodeError(PluginRefused, "unsigned"))
+	var cause Error
+	s.Require().True(errors.As(err.GetError(), &cause))
+	s.Equal("loading: unsigned", cause.Error(), "the first Error found is the error itself")
+
+	var path *os.PathError
+	err = notExist()
+	s.True(errors.As(err, &path))
+	s.Equal("/does/not/exist", path.Path)
+}
+
+func (s *ErrorChainSuite) TestGetCode() {
+	err := NewCodeError(ConfigInvalid, "bad")
+	s.Equal(ConfigInvalid, err.GetCode())
+
+	err = NewError("loading: %s", NewError("validating: %s", NewCodeError(PluginRefused, "unsigned")))
+	s.Equal(PluginRefused, err.GetCode(), "found through wrapped causes")
+	err.SetCode(HandshakeFailed)
+	s.Equal(HandshakeFailed, err.GetCode(), "the error's own code first")
+
+	err = NewError("first")
+	err.AddError("second: %s", NewCodeError(SecretNotFound, "missing"))
+	s.Equal(SecretNotFound, err.GetCode(), "found through AddError")
+
+	err = notExist()
+	s.Equal(Code(""), err.GetCode())
+	s.Equal(Code(""), Ok.GetCode())
+}
+
+// ErrorService - An RPC server, returning errors as RPC plugins do.
+type ErrorService struct{}
+
+func (ErrorService) Fail(code Code, _ *string) error {
+	err := notExist()
+	err.AddError("wrapped: %s", NewCodeError(code, "inner"))
+	err.SetContext("plugin", "hook")
+	return err.GetRemoteError()
+}
+
+func (ErrorService) Plain(_ string, _ *string) error {
+	return errors.New("plain error")
+}
+
+func (ErrorService) Ok(_ string, _ *string) error {
+	return Ok.GetRemoteError()
+}
+
+func (s *ErrorChainSuite) TestRemote() {
+	server := rpc.NewServer()
+	s.Require().NoError(server.Register(ErrorService{}))
+	conn1, conn2 := net.Pipe()
+	go server.ServeConn(conn1)
+	client := rpc.NewClient(conn2)
+
+	native := notExist()
+	native.AddError("wrapped: %s", NewCodeError(PluginRefused, "inner"))
+	remote := NewRemoteError(client.Call("ErrorService.Fail", PluginRefused, new(string)))
+	s.True(remote.IsError())
+	s.True(remote.IsRemote())
+	s.Equal(native.Error(), remote.Error())
+	s.Equal(PluginRefused, remote.GetCode(), "the code of a wrapped cause")
+	s.True(errors.Is(remote, PluginRefused), "Codes are matched")
+	s.False(errors.Is(remote, os.ErrNotExist), "other sentinels are not, (only their message is sent)")
+	s.Equal("plugin", remote.GetPlugin())
+	s.Equal("hook", remote.GetHook())
+	s.Contains(remote.GetStack(), "notExist")
+
+	remote = NewRemoteError(client.Call("ErrorService.Plain", "", new(string)))
+	s.True(remote.IsError())
+	s.False(remote.IsRemote(), "not an encoded Error")
+	s.Equal("plain error", remote.Error())
+
+	remote = NewRemoteError(client.Call("ErrorService.Ok", "", new(string)))
+	s.False(remote.IsError())
+
+	s.Require().NoError(client.Close())
+	remote = NewRemoteError(client.Call("ErrorService.Ok", "", new(string)))
+	s.False(remote.IsRemote())
+	s.True(errors.Is(remote, rpc.ErrShutdown))
+}
+
+func TestErrorChainSuite(t *testing.T) {
+	suite.Run(t, new(ErrorChainSuite))
+}
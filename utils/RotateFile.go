@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+//
+// RotateFile - An io.Writer appending to a file, which is rotated once it reaches MaxSize.
+// ---------------------------------------------------------------------------------------------------- //
+// The rotated files are <file>.1, (the newest), to <file>.<MaxFiles>, older files are removed.
+type RotateFile struct {
+	File     string
+	MaxSize  int64 // Bytes, zero never rotates.
+	MaxFiles int   // Rotated files kept.
+	fh       *os.File
+	size     int64
+	lock     sync.Mutex
+}
+
+// NewRotateFile - Create a new instance of this structure. The dir of file is created if it doesn't exist.
+func NewRotateFile(file string, maxSize int64, maxFiles int) (*RotateFile, Return.Error) {
+	ret := RotateFile{
+		File:     file,
+		MaxSize:  maxSize,
+		MaxFiles: maxFiles,
+	}
+	var err Return.Error
+
+	for range Only.Once {
+		e := os.MkdirAll(filepath.Dir(file), 0o700)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		err = ret.open()
+	}
+
+	return &ret, err
+}
+
+// Write - Implements io.Writer. Rotates the file first, if p would take it over MaxSize.
+func (r *RotateFile) Write(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.fh == nil {
+		return 0, os.ErrClosed
+	}
+
+	if r.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.MaxSize {
+		err := r.rotate()
+		if err.IsError() {
+			return 0, err.GetError()
+		}
+	}
+
+	n, e := r.fh.Write(p)
+	r.size += int64(n)
+	return n, e
+}
+
+// Close - Close the file.
+func (r *RotateFile) Close() Return.Error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var err Return.Error
+	if r.fh != nil {
+		err.SetError(r.fh.Close())
+		r.fh = nil
+	}
+	return err
+}
+
+// open - Open the file for appending.
+func (r *RotateFile) open() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		var e error
+		r.fh, e = os.OpenFile(r.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		var fi os.FileInfo
+		fi, e = r.fh.Stat()
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		r.size = fi.Size()
+	}
+
+	return err
+}
+
+// rotate - Shift <file>.N to <file>.N+1, <file> to <file>.1, then open a new <file>. Called with the lock held.
+func (r *RotateFile) rotate() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		e := r.fh.Close()
+		r.fh = nil
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		if r.MaxFiles < 1 {
+			e = os.Remove(r.File)
+			if e != nil && !os.IsNotExist(e) {
+				err.SetError(e)
+				break
+			}
+			err = r.open()
+			break
+		}
+
+		_ = os.Remove(fmt.Sprintf("%s.%d", r.File, r.MaxFiles))
+		for i := r.MaxFiles - 1; i > 0; i-- {
+			e = os.Rename(fmt.Sprintf("%s.%d", r.File, i), fmt.Sprintf("%s.%d", r.File, i+1))
+			if e != nil && !os.IsNotExist(e) {
+				err.SetError(e)
+				break
+			}
+		}
+		if err.IsError() {
+			break
+		}
+
+		e = os.Rename(r.File, r.File+".1")
+		if e != nil && !os.IsNotExist(e) {
+			err.SetError(e)
+			break
+		}
+
+		err = r.open()
+	}
+
+	return err
+}
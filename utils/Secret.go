@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted - Printed in place of a secret.
+const Redacted = "********"
+
+// SecretMinLength - Shorter values are not redacted from output, they would match too much.
+const SecretMinLength = 4
+
+//
+// Secret - A string that is never printed, logged or saved as JSON. Use Reveal() to get the value.
+// ---------------------------------------------------------------------------------------------------- //
+// Values created with NewSecret() are also redacted from log output, dumps and saved files, (see Redact).
+type Secret string
+
+// NewSecret - Create a secret, and register its value for redaction.
+func NewSecret(value string) Secret {
+	RegisterSecret(value)
+	return Secret(value)
+}
+
+// Reveal - The secret value.
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// IsEmpty - Returns true if the secret has no value.
+func (s Secret) IsEmpty() bool {
+	return s == ""
+}
+
+// String - Stringer interface, always redacted.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// GoString - GoStringer interface, so %#v is redacted too.
+func (s Secret) GoString() string {
+	return `"` + s.String() + `"`
+}
+
+// MarshalJSON - Secrets are never written as JSON.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// ---------------------------------------------------------------------------------------------------- //
+// Registry of secret values, redacted from output.
+
+var secrets = struct {
+	values   map[string]bool
+	replacer *strings.Replacer
+	lock     sync.RWMutex
+}{
+	values: make(map[string]bool),
+}
+
+// RegisterSecret - Redact a value from log output, dumps and saved files from now on, (including JSON escaped).
+func RegisterSecret(value string) {
+	if len(value) < SecretMinLength {
+		return
+	}
+
+	secrets.lock.Lock()
+	defer secrets.lock.Unlock()
+
+	if secrets.values[value] {
+		return
+	}
+	secrets.values[value] = true
+
+	// As it's written within JSON, (json.Marshal escapes '"', '\', '<', '>', '&', ...).
+	if data, e := json.Marshal(value); e == nil {
+		if encoded := string(data[1 : len(data)-1]); encoded != value {
+			secrets.values[encoded] = true
+		}
+	}
+
+	// Longest first, so a secret containing another is redacted whole.
+	var values []string
+	for v := range secrets.values {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+
+	var pairs []string
+	for _, v := range values {
+		pairs = append(pairs, v, Redacted)
+	}
+	secrets.replacer = strings.NewReplacer(pairs...)
+}
+
+// Redact - Replace registered secret values within a string.
+func Redact(s string) string {
+	secrets.lock.RLock()
+	defer secrets.lock.RUnlock()
+
+	if secrets.replacer == nil {
+		return s
+	}
+	return secrets.replacer.Replace(s)
+}
+
+// RedactBytes - As Redact(), for byte slices.
+func RedactBytes(data []byte) []byte {
+	secrets.lock.RLock()
+	defer secrets.lock.RUnlock()
+
+	if secrets.replacer == nil {
+		return data
+	}
+	return []byte(secrets.replacer.Replace(string(data)))
+}
+
+//
+// RedactWriter - Redacts registered secret values from everything written to it.
+// ---------------------------------------------------------------------------------------------------- //
+// Each Write() is redacted on its own, which suits loggers writing a line at a time.
+type RedactWriter struct {
+	Writer io.Writer
+}
+
+// NewRedactWriter - Create a new instance of this structure.
+func NewRedactWriter(w io.Writer) *RedactWriter {
+	return &RedactWriter{Writer: w}
+}
+
+func (w *RedactWriter) Write(p []byte) (int, error) {
+	_, err := w.Writer.Write(RedactBytes(p))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
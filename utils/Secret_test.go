@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SecretSuite struct {
+	suite.Suite
+}
+
+func (s *SecretSuite) TestSaveObject() {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{name: "plain", secret: "plain-secret"},
+		{name: "html escaped", secret: "p&ss<word>"},
+		{name: "quote", secret: `a"b-secret`},
+		{name: "backslash", secret: `back\slash`},
+		{name: "line separator", secret: "line sep"},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			value := NewSecret(test.secret)
+			file, err := NewFile(filepath.Join(s.T().TempDir(), "object.json"))
+			s.Require().False(err.IsError(), err.String())
+
+			err = file.SaveObject(map[string]any{"password": value.Reveal(), "note": "key is " + test.secret})
+			s.Require().False(err.IsError(), err.String())
+
+			data, e := os.ReadFile(file.GetPath())
+			s.Require().NoError(e)
+			encoded, e := json.Marshal(test.secret)
+			s.Require().NoError(e)
+			s.NotContains(string(data), test.secret)
+			s.NotContains(string(data), string(encoded[1:len(encoded)-1]))
+			s.Contains(string(data), `"password":"`+Redacted+`"`)
+			s.Contains(string(data), `"note":"key is `+Redacted+`"`)
+		})
+	}
+}
+
+func (s *SecretSuite) TestRedact() {
+	NewSecret("r3dact&me")
+	s.Equal("a "+Redacted+" b", Redact("a r3dact&me b"))
+	s.Equal("a "+Redacted+" b", Redact(`a r3dact\u0026me b`), "as escaped by json.Marshal")
+	s.Equal("a "+Redacted+" b", string(RedactBytes([]byte("a r3dact&me b"))))
+	NewSecret("abc")
+	s.Equal("abc", Redact("abc"), "short values aren't registered")
+}
+
+func TestSecretSuite(t *testing.T) {
+	suite.Run(t, new(SecretSuite))
+}
@@ -0,0 +1,496 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+const (
+	EventLoad   = "load"   // A plugin was loaded, (or failed to load).
+	EventUnload = "unload" // A plugin was unloaded.
+	EventHook   = "hook"   // A hook was called, (plugin or host).
+
+	// MaxValue - Longer argument and result values are truncated.
+	MaxValue = 1024
+
+	// KeyEnv - Env var holding the audit log key, (see Config.Key).
+	KeyEnv = "GOPLUG_AUDIT_KEY"
+)
+
+//
+// Config - Where the audit log is written, and what is redacted.
+// ---------------------------------------------------------------------------------------------------- //
+type Config struct {
+	File       string       `json:"file"`                 // Audit log file, appended to
+	Actor      string       `json:"actor,omitempty"`      // Who administers plugins, (defaults to the OS user)
+	Redactions Redactions   `json:"redactions,omitempty"` // Hook parameters that are redacted
+	Key        utils.Secret `json:"-"`                    // Entries are hashed with HMAC-SHA256 using this key, if set
+}
+
+//
+// Entry - A single line of the audit log.
+// ---------------------------------------------------------------------------------------------------- //
+// Hash is the SHA-256 of the entry, (as JSON, without Hash), and Prev the Hash of the entry before it,
+// so changing, adding or removing any line breaks the chain, (see Verify).
+// Without a key, (see Config.Key), anyone able to write the log can recompute the whole chain, and removing entries
+// from the end doesn't break it. Check against a Head recorded elsewhere to detect either.
+type Entry struct {
+	Seq     uint64    `json:"seq"`
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Actor   string    `json:"actor,omitempty"`
+	Plugin  string    `json:"plugin,omitempty"`
+	Version string    `json:"version,omitempty"`
+	Loader  string    `json:"loader,omitempty"`
+	File    string    `json:"file,omitempty"`
+	Sha256  string    `json:"sha256,omitempty"`
+	Hook    string    `json:"hook,omitempty"`
+	Args    []string  `json:"args,omitempty"`
+	Result  string    `json:"result,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	Prev    string    `json:"prev"`
+	Hash    string    `json:"hash"`
+}
+
+// GetHash - SHA-256 of the entry, without Hash, (HMAC-SHA256 if key is set).
+func (e Entry) GetHash(key utils.Secret) (string, Return.Error) {
+	var err Return.Error
+	e.Hash = ""
+	data, e2 := json.Marshal(e)
+	if e2 != nil {
+		err.SetError(e2)
+		return "", err
+	}
+
+	if key.IsEmpty() {
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:]), err
+	}
+	mac := hmac.New(sha256.New, []byte(key.Reveal()))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), err
+}
+
+//
+// Head - The last entry of an audit log, recorded elsewhere to check the log against, (see Verify).
+// ---------------------------------------------------------------------------------------------------- //
+type Head struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// ParseHead - Parse a head, as written by Head.String(), ("<seq>:<hash>").
+func ParseHead(head string) (Head, Return.Error) {
+	var ret Head
+	var err Return.Error
+
+	for range Only.Once {
+		seq, hash, found := strings.Cut(head, ":")
+		var e error
+		ret.Seq, e = strconv.ParseUint(seq, 10, 64)
+		if !found || e != nil || hash == "" {
+			err.SetError("invalid head '%s', should be '<seq>:<hash>'", head)
+			break
+		}
+		ret.Hash = hash
+	}
+
+	return ret, err
+}
+
+// IsEmpty - Returns true if there are no entries.
+func (h Head) IsEmpty() bool {
+	return h.Seq == 0
+}
+
+// String - Stringer interface.
+func (h Head) String() string {
+	return fmt.Sprintf("%d:%s", h.Seq, h.Hash)
+}
+
+//
+// Log - An open audit log.
+// ---------------------------------------------------------------------------------------------------- //
+type Log struct {
+	Config Config
+	file   *os.File
+	seq    uint64
+	last   string
+	lock   sync.Mutex
+}
+
+// Open - Open the audit log for appending, carrying on the chain of any entries already in it.
+func Open(config Config) (*Log, Return.Error) {
+	var ret Log
+	var err Return.Error
+
+	for range Only.Once {
+		if config.File == "" {
+			err.SetError("no audit log file")
+			break
+		}
+
+		if config.Actor == "" {
+			config.Actor = GetUser()
+		}
+		ret.Config = config
+
+		e := os.MkdirAll(filepath.Dir(config.File), 0o700)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		var last *Entry
+		last, err = readLast(config.File)
+		if err.IsError() {
+			break
+		}
+		if last != nil {
+			ret.seq = last.Seq
+			ret.last = last.Hash
+		}
+
+		ret.file, e = os.OpenFile(config.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+	}
+
+	return &ret, err
+}
+
+// Record - Chain the entry onto the log, and write it. Seq, Time, Actor, Prev and Hash are set here.
+func (l *Log) Record(entry Entry) Return.Error {
+	var err Return.Error
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for range Only.Once {
+		if l.file == nil {
+			err.SetError("audit log '%s' is closed", l.Config.File)
+			break
+		}
+
+		entry.Seq = l.seq + 1
+		entry.Time = time.Now().UTC()
+		if entry.Actor == "" {
+			entry.Actor = l.Config.Actor
+		}
+		entry.Prev = l.last
+		entry.Hash, err = entry.GetHash(l.Config.Key)
+		if err.IsError() {
+			break
+		}
+
+		data, e := json.Marshal(entry)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		_, e = l.file.Write(append(data, '\n'))
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		l.seq = entry.Seq
+		l.last = entry.Hash
+	}
+
+	return err
+}
+
+// Hook - Record a hook call, with its arguments and result redacted, (see Redactions).
+// The values of redacted arguments are also redacted from the result and error, (eg: a result echoing a password).
+func (l *Log) Hook(plugin string, hook string, args []any, result any, err Return.Error) Return.Error {
+	entry := Entry{
+		Event:  EventHook,
+		Plugin: plugin,
+		Hook:   hook,
+	}
+
+	var redacted []string
+	for i, arg := range args {
+		if l.Config.Redactions.IsRedacted(hook, fmt.Sprint(i)) {
+			entry.Args = append(entry.Args, utils.Redacted)
+			redacted = append(redacted, fmt.Sprint(arg), strings.Trim(format(arg), `"`))
+			continue
+		}
+		entry.Args = append(entry.Args, Value(arg))
+	}
+
+	switch {
+	case err.IsError():
+		entry.Error = truncate(scrub(utils.Redact(err.Error()), redacted))
+	case result == nil:
+	case l.Config.Redactions.IsRedacted(hook, ParamResult):
+		entry.Result = utils.Redacted
+	default:
+		entry.Result = truncate(scrub(format(result), redacted))
+	}
+
+	return l.Record(entry)
+}
+
+// GetHead - The last entry written, (see Verify).
+func (l *Log) GetHead() Head {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return Head{Seq: l.seq, Hash: l.last}
+}
+
+// Close - Close the file, logging the head so the log can be checked against it, (see Verify).
+func (l *Log) Close() Return.Error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var err Return.Error
+	if l.file != nil {
+		if e := l.file.Close(); e != nil {
+			err.SetError(e)
+		}
+		l.file = nil
+		log.Printf("[INFO]: Audit: '%s' closed at head %s", l.Config.File, Head{Seq: l.seq, Hash: l.last})
+	}
+	return err
+}
+
+// Value - A value as it's written to the audit log: JSON, with registered secrets redacted, and truncated at MaxValue.
+func Value(value any) string {
+	return truncate(format(value))
+}
+
+// format - A value as JSON, with registered secrets redacted.
+func format(value any) string {
+	var ret string
+	data, e := json.Marshal(value)
+	if e != nil {
+		ret = fmt.Sprintf("%v", value)
+	} else {
+		ret = string(data)
+	}
+	return utils.Redact(ret)
+}
+
+// truncate - Truncate a value at MaxValue.
+func truncate(value string) string {
+	if len(value) > MaxValue {
+		return value[:MaxValue] + "..."
+	}
+	return value
+}
+
+// scrub - Redact each of values from s.
+func scrub(s string, values []string) string {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		s = strings.ReplaceAll(s, value, utils.Redacted)
+	}
+	return s
+}
+
+// GetUser - The OS user, (the default actor).
+func GetUser() string {
+	if u, e := user.Current(); e == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return fmt.Sprintf("uid:%d", os.Getuid())
+}
+
+// Verify - Check the hash chain of an audit log, hashed with key, (see Config.Key). Returns the head of the log,
+// and the first break in the chain. If head isn't empty, the log must contain it, (it was truncated or rewritten if not).
+func Verify(file string, key utils.Secret, head Head) (Head, Return.Error) {
+	var ret Head
+	var err Return.Error
+
+	for range Only.Once {
+		fh, e := os.Open(file)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		//goland:noinspection GoDeferInLoop,GoUnhandledErrorResult
+		defer fh.Close()
+
+		var prev Entry
+		scanner := bufio.NewScanner(fh)
+		scanner.Buffer(nil, 1<<24)
+		for line := 1; scanner.Scan(); line++ {
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+
+			var entry Entry
+			e = json.Unmarshal(scanner.Bytes(), &entry)
+			if e != nil {
+				err.SetError("line %d: %s", line, e)
+				break
+			}
+
+			if e2 := verifyEntry(prev, entry, key); e2.IsError() {
+				err.SetError("line %d: %s", line, e2.Error())
+				break
+			}
+			if entry.Seq == head.Seq && entry.Hash != head.Hash {
+				err.SetError("line %d: seq %d does not match the head %s, (the log has been rewritten)", line, entry.Seq, head)
+				break
+			}
+			prev = entry
+		}
+		if err.IsError() {
+			break
+		}
+
+		if e = scanner.Err(); e != nil {
+			err.SetError(e)
+			break
+		}
+
+		ret = Head{Seq: prev.Seq, Hash: prev.Hash}
+		if ret.Seq < head.Seq {
+			err.SetError("the log ends at seq %d, before the head %s, (it has been truncated)", ret.Seq, head)
+			break
+		}
+	}
+
+	return ret, err
+}
+
+// verifyEntry - Check entry follows prev, (the zero Entry before the first).
+func verifyEntry(prev Entry, entry Entry, key utils.Secret) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if entry.Seq != prev.Seq+1 {
+			err.SetError("seq %d follows %d", entry.Seq, prev.Seq)
+			break
+		}
+
+		if entry.Prev != prev.Hash {
+			err.SetError("seq %d: prev hash does not match the entry before it", entry.Seq)
+			break
+		}
+
+		var hash string
+		hash, err = entry.GetHash(key)
+		if err.IsError() {
+			break
+		}
+		if hash != entry.Hash {
+			err.SetError("seq %d: hash does not match the entry, (it has been changed, or was hashed with another key)", entry.Seq)
+			break
+		}
+	}
+
+	return err
+}
+
+// readLast - The last entry of an audit log, or nil if it doesn't exist or is empty.
+func readLast(file string) (*Entry, Return.Error) {
+	var ret *Entry
+	var err Return.Error
+
+	for range Only.Once {
+		data, e := os.ReadFile(file)
+		if os.IsNotExist(e) {
+			break
+		}
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			break
+		}
+		if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+
+		var entry Entry
+		e = json.Unmarshal(data, &entry)
+		if e != nil {
+			err.SetError("audit log '%s' is corrupt, (%s)", file, e)
+			break
+		}
+		ret = &entry
+	}
+
+	return ret, err
+}
+
+//
+// The audit log plugins and hooks are recorded to.
+// ---------------------------------------------------------------------------------------------------- //
+var (
+	current *Log
+	lock    sync.RWMutex
+)
+
+// SetLog - Record to l, (nil stops recording).
+func SetLog(l *Log) {
+	lock.Lock()
+	defer lock.Unlock()
+	current = l
+}
+
+// GetLog - The audit log being recorded to, or nil.
+func GetLog() *Log {
+	lock.RLock()
+	defer lock.RUnlock()
+	return current
+}
+
+// IsEnabled - Returns true if an audit log is set.
+func IsEnabled() bool {
+	return GetLog() != nil
+}
+
+// Record - Record an entry to the audit log, if set.
+func Record(entry Entry) {
+	l := GetLog()
+	if l == nil {
+		return
+	}
+	if err := l.Record(entry); err.IsError() {
+		log.Printf("[WARN]: Audit: %s", err)
+	}
+}
+
+// Hook - Record a hook call to the audit log, if set.
+func Hook(plugin string, hook string, args []any, result any, err Return.Error) {
+	l := GetLog()
+	if l == nil {
+		return
+	}
+	if e := l.Hook(plugin, hook, args, result, err); e.IsError() {
+		log.Printf("[WARN]: Audit: %s", e)
+	}
+}
@@ -0,0 +1,291 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+type AuditSuite struct {
+	suite.Suite
+	file string
+	head Head
+}
+
+// SetupTest - A log of three hook calls.
+func (s *AuditSuite) SetupTest() {
+	s.file = filepath.Join(s.T().TempDir(), "audit.log")
+	l := s.open(Config{})
+	for _, arg := range []string{"a", "b", "c"} {
+		s.ok(l.Hook("p", "Hello", []any{arg}, "Hello: "+arg, Return.Ok))
+	}
+	s.head = l.GetHead()
+	s.ok(l.Close())
+}
+
+// ok - Fail unless err is Ok.
+func (s *AuditSuite) ok(err Return.Error) {
+	s.Require().False(err.IsError(), err.String())
+}
+
+// open - Open the log, with config.
+func (s *AuditSuite) open(config Config) *Log {
+	config.File = s.file
+	l, err := Open(config)
+	s.ok(err)
+	return l
+}
+
+// entries - The entries of the log.
+func (s *AuditSuite) entries() []Entry {
+	fh, e := os.Open(s.file)
+	s.Require().NoError(e)
+	defer fh.Close()
+
+	var ret []Entry
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		var entry Entry
+		s.Require().NoError(json.Unmarshal(scanner.Bytes(), &entry))
+		ret = append(ret, entry)
+	}
+	return ret
+}
+
+// write - Replace the log with entries.
+func (s *AuditSuite) write(entries []Entry) {
+	var lines []string
+	for _, entry := range entries {
+		data, e := json.Marshal(entry)
+		s.Require().NoError(e)
+		lines = append(lines, string(data))
+	}
+	s.Require().NoError(os.WriteFile(s.file, []byte(strings.Join(lines, "\n")+"\n"), 0o600))
+}
+
+func (s *AuditSuite) TestRedact() {
+	tests := []struct {
+		name   string
+		rule   string
+		args   []any
+		result any
+		err    Return.Error
+		secret string
+		want   Entry
+	}{
+		{
+			name:   "arg",
+			rule:   "Hello=0",
+			args:   []any{"secretword", "x"},
+			result: "Hello: secretword",
+			secret: "secretword",
+			want:   Entry{Args: []string{utils.Redacted, `"x"`}, Result: `"Hello: ` + utils.Redacted + `"`},
+		},
+		{
+			name:   "arg quoted",
+			rule:   "Hello=0",
+			args:   []any{`se"cret`},
+			result: map[string]any{"echo": `se"cret`},
+			want:   Entry{Args: []string{utils.Redacted}, Result: `{"echo":"` + utils.Redacted + `"}`},
+		},
+		{
+			name:   "arg in error",
+			rule:   "Login=1",
+			args:   []any{"user", "hunter22"},
+			err:    Return.NewError("bad password 'hunter22'"),
+			secret: "hunter22",
+			want:   Entry{Args: []string{`"user"`, utils.Redacted}, Error: "bad password '" + utils.Redacted + "'"},
+		},
+		{
+			name:   "result",
+			rule:   "Hello=result",
+			args:   []any{"x"},
+			result: "Hello: x",
+			want:   Entry{Args: []string{`"x"`}, Result: utils.Redacted},
+		},
+		{
+			name:   "all",
+			rule:   "*=*",
+			args:   []any{"secretword", 42},
+			result: "Hello: secretword",
+			secret: "secretword",
+			want:   Entry{Args: []string{utils.Redacted, utils.Redacted}, Result: `"Hello: ` + utils.Redacted + `"`},
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.SetupTest()
+			s.Require().NoError(os.Remove(s.file))
+
+			var config Config
+			s.ok(config.Redactions.Add(test.rule))
+			l := s.open(config)
+			hook := strings.Split(test.rule, "=")[0]
+			if hook == "*" {
+				hook = "Hello"
+			}
+			s.ok(l.Hook("p", hook, test.args, test.result, test.err))
+			s.ok(l.Close())
+
+			data, e := os.ReadFile(s.file)
+			s.Require().NoError(e)
+			if test.secret != "" {
+				s.NotContains(string(data), test.secret)
+			}
+
+			entry := s.entries()[0]
+			s.Equal(test.want.Args, entry.Args)
+			s.Equal(test.want.Result, entry.Result)
+			s.Equal(test.want.Error, entry.Error)
+		})
+	}
+}
+
+func (s *AuditSuite) TestVerify() {
+	key := utils.Secret("audit-key")
+
+	tests := []struct {
+		name   string
+		key    utils.Secret
+		tamper func(entries []Entry) []Entry
+		head   bool
+		error  string
+	}{
+		{name: "intact"},
+		{name: "intact with head", head: true},
+		{
+			name: "entry changed",
+			tamper: func(entries []Entry) []Entry {
+				entries[1].Result = `"Hello: z"`
+				return entries
+			},
+			error: "seq 2: hash does not match",
+		},
+		{
+			name: "entry removed",
+			tamper: func(entries []Entry) []Entry {
+				return append(entries[:1], entries[2:]...)
+			},
+			error: "seq 3 follows 1",
+		},
+		{
+			name: "truncated",
+			tamper: func(entries []Entry) []Entry {
+				return entries[:2]
+			},
+		},
+		{
+			name: "truncated with head",
+			tamper: func(entries []Entry) []Entry {
+				return entries[:2]
+			},
+			head:  true,
+			error: "truncated",
+		},
+		{
+			name:   "rewritten",
+			tamper: s.rehash(""),
+		},
+		{
+			name:   "rewritten with head",
+			tamper: s.rehash(""),
+			head:   true,
+			error:  "rewritten",
+		},
+		{
+			name:   "rewritten with key",
+			key:    key,
+			tamper: s.rehash(""),
+			error:  "hash does not match",
+		},
+		{
+			name:   "wrong key",
+			key:    "other-key",
+			tamper: s.rehash(key),
+			error:  "hash does not match",
+		},
+		{
+			name:   "key",
+			key:    key,
+			tamper: s.rehash(key),
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.SetupTest()
+			head := s.head
+			if test.tamper != nil {
+				s.write(test.tamper(s.entries()))
+			}
+			if !test.head {
+				head = Head{}
+			}
+
+			last, err := Verify(s.file, test.key, head)
+			if test.error != "" {
+				s.True(err.IsError())
+				s.Contains(err.Error(), test.error)
+				return
+			}
+			s.ok(err)
+			s.NotZero(last.Seq)
+		})
+	}
+}
+
+// rehash - Change an entry, and recompute the chain with key.
+func (s *AuditSuite) rehash(key utils.Secret) func(entries []Entry) []Entry {
+	return func(entries []Entry) []Entry {
+		entries[1].Result = `"Hello: z"`
+		var prev string
+		for i := range entries {
+			entries[i].Prev = prev
+			var err Return.Error
+			entries[i].Hash, err = entries[i].GetHash(key)
+			s.ok(err)
+			prev = entries[i].Hash
+		}
+		return entries
+	}
+}
+
+func (s *AuditSuite) TestKeyedLog() {
+	s.Require().NoError(os.Remove(s.file))
+	key := utils.Secret("audit-key")
+	l := s.open(Config{Key: key})
+	s.ok(l.Hook("p", "Hello", []any{"a"}, "Hello: a", Return.Ok))
+	head := l.GetHead()
+	s.ok(l.Close())
+
+	last, err := Verify(s.file, key, head)
+	s.ok(err)
+	s.Equal(head, last)
+
+	_, err = Verify(s.file, "", Head{})
+	s.True(err.IsError(), "a keyed log doesn't verify without the key")
+}
+
+func (s *AuditSuite) TestParseHead() {
+	head, err := ParseHead(s.head.String())
+	s.ok(err)
+	s.Equal(s.head, head)
+
+	for _, invalid := range []string{"", "3", "x:abc", "3:"} {
+		_, err = ParseHead(invalid)
+		s.True(err.IsError(), invalid)
+	}
+}
+
+func TestAuditSuite(t *testing.T) {
+	suite.Run(t, new(AuditSuite))
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// ParamResult - Redacts the result of a hook, (see Redactions).
+const ParamResult = "result"
+
+//
+// Redactions - Hook parameters that are redacted in the audit log, keyed by hook name, ("*" for all hooks).
+// ---------------------------------------------------------------------------------------------------- //
+// Hook names may be patterns, (see path.Match). Parameters are numbered from 0, "*" is all of them,
+// and "result" the result. Registered secrets are always redacted, (see utils.RegisterSecret).
+type Redactions map[string][]string
+
+// ParseRedaction - Parse a redaction rule, ("<hook>=<param>,...", eg: "Login=1,result").
+func ParseRedaction(rule string) (string, []string, Return.Error) {
+	var err Return.Error
+
+	hook, params, found := strings.Cut(rule, "=")
+	hook = strings.TrimSpace(hook)
+	if !found || hook == "" {
+		err.SetError("invalid redaction '%s', should be '<hook>=<param>,...'", rule)
+		return "", nil, err
+	}
+
+	var ret []string
+	for _, param := range strings.Split(params, ",") {
+		param = strings.TrimSpace(param)
+		if param == "" {
+			continue
+		}
+		if _, e := strconv.Atoi(param); e != nil && param != "*" && param != ParamResult {
+			err.SetError("invalid redaction '%s', parameter '%s' should be a number, '*' or '%s'", rule, param, ParamResult)
+			return "", nil, err
+		}
+		ret = append(ret, param)
+	}
+
+	return hook, ret, err
+}
+
+// Add - Add a redaction rule, (see ParseRedaction).
+func (r *Redactions) Add(rule string) Return.Error {
+	hook, params, err := ParseRedaction(rule)
+	if err.IsError() {
+		return err
+	}
+
+	if *r == nil {
+		*r = make(Redactions)
+	}
+	(*r)[hook] = append((*r)[hook], params...)
+	return err
+}
+
+// IsRedacted - Returns true if a parameter of hook is redacted.
+func (r Redactions) IsRedacted(hook string, param string) bool {
+	for pattern, params := range r {
+		if ok, _ := path.Match(pattern, hook); !ok {
+			continue
+		}
+		for _, p := range params {
+			if p == param || (p == "*" && param != ParamResult) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -19,6 +19,18 @@ const (
 	// PluginJSONFileName is the pre-defined filename of plugin metadata json file
 	PluginJSONFileName = "plugin.json"
 
+	// PluginYAMLFileName is the pre-defined filename of plugin metadata yaml file
+	PluginYAMLFileName = "plugin.yaml"
+
+	// LockFileName is the pre-defined filename of the plugin dir lockfile
+	LockFileName = "goplug.lock"
+
+	// ConfigDirName is the pre-defined dir, within the plugin dir, of plugin config files
+	ConfigDirName = "config"
+
+	// DataDirName is the pre-defined dir, within the plugin dir, of persistent plugin data
+	DataDirName = "data"
+
 	// PluginSourceModeLocal defines the local mode
 	PluginSourceModeLocal = "local_so"
 
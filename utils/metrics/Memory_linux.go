@@ -0,0 +1,29 @@
+//go:build linux
+
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ProcessMemory - Resident memory of a process, in bytes, from /proc/<pid>/statm.
+func ProcessMemory(pid int) (uint64, bool) {
+	data, e := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if e != nil {
+		return 0, false
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, false
+	}
+
+	pages, e := strconv.ParseUint(fields[1], 10, 64)
+	if e != nil {
+		return 0, false
+	}
+	return pages * uint64(os.Getpagesize()), true
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package metrics
+
+import (
+	"os"
+	"runtime"
+)
+
+// ProcessMemory - Memory of a process, in bytes. Other processes can only be read on Linux,
+// so this is the memory Go has taken from the OS for this process, (runtime.MemStats.Sys).
+func ProcessMemory(pid int) (uint64, bool) {
+	if pid != os.Getpid() {
+		return 0, false
+	}
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.Sys, true
+}
@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// Metrics recorded by GoPlug, (see Help for what each one is).
+const (
+	PluginLoads        = "goplug_plugin_loads_total"    // loader, result
+	PluginLoadSeconds  = "goplug_plugin_load_seconds"   // loader
+	PluginRestarts     = "goplug_plugin_restarts_total" // plugin
+	PluginFaults       = "goplug_plugin_faults_total"   // plugin
+	HookCalls          = "goplug_hook_calls_total"      // plugin, hook
+	HookErrors         = "goplug_hook_errors_total"     // plugin, hook, code
+	HookSeconds        = "goplug_hook_seconds"          // plugin, hook
+	RpcCalls           = "goplug_rpc_calls_total"       // plugin, method
+	RpcErrors          = "goplug_rpc_errors_total"      // plugin, method
+	RpcSeconds         = "goplug_rpc_seconds"           // plugin, method
+	Callbacks          = "goplug_callbacks_total"       // plugin, callback, result
+	ProcessMemoryBytes = "goplug_process_memory_bytes"  // process
+)
+
+// Help - Description of each metric.
+var Help = map[string]string{
+	PluginLoads:        "Plugin loads, by loader type and result.",
+	PluginLoadSeconds:  "Time taken to load a plugin, by loader type.",
+	PluginRestarts:     "Plugins loaded again, after being unloaded or faulting.",
+	PluginFaults:       "RPC plugin processes that exited unexpectedly.",
+	HookCalls:          "Hook calls, by plugin and hook.",
+	HookErrors:         "Hook calls that returned an error, by plugin, hook and error code.",
+	HookSeconds:        "Time taken by hook calls, by plugin and hook.",
+	RpcCalls:           "Calls made to RPC plugin processes, by plugin and method.",
+	RpcErrors:          "Calls to RPC plugin processes that failed, by plugin and method.",
+	RpcSeconds:         "Time taken by calls to RPC plugin processes, by plugin and method.",
+	Callbacks:          "Plugin callbacks, (initialise, notify, ...), by plugin, callback and result.",
+	ProcessMemoryBytes: "Resident memory of master, and of each RPC plugin process.",
+}
+
+// Results, for the "result" label.
+const (
+	ResultOk      = "ok"
+	ResultWarning = "warning"
+	ResultError   = "error"
+)
+
+// Labels - Label names and values of a single series.
+type Labels map[string]string
+
+//
+// Sink - Where metrics are sent.
+// ---------------------------------------------------------------------------------------------------- //
+// Set with SetSink(), (Discard by default). Registry keeps them for Prometheus, other sinks can forward them anywhere.
+type Sink interface {
+	// AddCounter - Add to a counter.
+	AddCounter(name string, value float64, labels Labels)
+
+	// Observe - Add a value to a histogram, (durations are in seconds).
+	Observe(name string, value float64, labels Labels)
+
+	// SetGauge - Set a gauge.
+	SetGauge(name string, value float64, labels Labels)
+}
+
+// Discard - A Sink that drops everything.
+type Discard struct{}
+
+func (Discard) AddCounter(string, float64, Labels) {}
+func (Discard) Observe(string, float64, Labels)    {}
+func (Discard) SetGauge(string, float64, Labels)   {}
+
+// Multi - A Sink sending to each of its sinks.
+type Multi []Sink
+
+func (m Multi) AddCounter(name string, value float64, labels Labels) {
+	for _, sink := range m {
+		sink.AddCounter(name, value, labels)
+	}
+}
+
+func (m Multi) Observe(name string, value float64, labels Labels) {
+	for _, sink := range m {
+		sink.Observe(name, value, labels)
+	}
+}
+
+func (m Multi) SetGauge(name string, value float64, labels Labels) {
+	for _, sink := range m {
+		sink.SetGauge(name, value, labels)
+	}
+}
+
+var (
+	sink       Sink = Discard{}
+	collectors      = make(map[string]func())
+	lock       sync.RWMutex
+)
+
+// SetSink - Send metrics to sink, (nil discards them).
+func SetSink(s Sink) {
+	if s == nil {
+		s = Discard{}
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	sink = s
+}
+
+// GetSink - Where metrics are sent.
+func GetSink() Sink {
+	lock.RLock()
+	defer lock.RUnlock()
+	return sink
+}
+
+// IsEnabled - Returns true if metrics are being kept, so callers can skip the work of recording them.
+func IsEnabled() bool {
+	_, ok := GetSink().(Discard)
+	return !ok
+}
+
+// Inc - Add one to a counter.
+func Inc(name string, labels Labels) {
+	GetSink().AddCounter(name, 1, labels)
+}
+
+// Observe - Add a value to a histogram.
+func Observe(name string, value float64, labels Labels) {
+	GetSink().Observe(name, value, labels)
+}
+
+// ObserveSince - Add the seconds since start to a histogram.
+func ObserveSince(name string, start time.Time, labels Labels) {
+	GetSink().Observe(name, time.Since(start).Seconds(), labels)
+}
+
+// SetGauge - Set a gauge.
+func SetGauge(name string, value float64, labels Labels) {
+	GetSink().SetGauge(name, value, labels)
+}
+
+// OnCollect - Set a named function setting gauges that are only read when collected, (eg: memory). Nil removes it.
+func OnCollect(name string, collector func()) {
+	lock.Lock()
+	defer lock.Unlock()
+	if collector == nil {
+		delete(collectors, name)
+		return
+	}
+	collectors[name] = collector
+}
+
+// Collect - Call the OnCollect() functions. Registry calls this before writing, other sinks call it when they need to.
+func Collect() {
+	lock.RLock()
+	c := make([]func(), 0, len(collectors))
+	for _, collector := range collectors {
+		c = append(c, collector)
+	}
+	lock.RUnlock()
+
+	for _, collector := range c {
+		collector()
+	}
+}
@@ -0,0 +1,252 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets - Histogram bucket upper bounds, in seconds.
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Types of metric, as written in the Prometheus "# TYPE" line.
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+//
+// Registry - A Sink keeping metrics in memory, written in the Prometheus text format.
+// ---------------------------------------------------------------------------------------------------- //
+// A Registry is an http.Handler, so it can be served as /metrics, (see PluginManager.ServeMetrics).
+// A metric keeps the type it was first recorded as, recording it as another type is ignored, (and logged once).
+type Registry struct {
+	Buckets []float64 // Histogram bucket upper bounds, (DefaultBuckets if nil). Set before anything is recorded.
+	metrics map[string]*metric
+	lock    sync.Mutex
+}
+
+// metric - All series of a single metric name.
+type metric struct {
+	kind     string
+	series   map[string]*series
+	mismatch bool // Recorded as another kind, (already logged).
+}
+
+// series - A single set of labels.
+type series struct {
+	labels  string // Formatted, (eg: {plugin="a",hook="Hello"}).
+	value   float64
+	buckets []uint64
+	count   uint64
+}
+
+// NewRegistry - Create a new instance of this structure.
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: make(map[string]*metric),
+	}
+}
+
+// AddCounter - Implements Sink.
+func (r *Registry) AddCounter(name string, value float64, labels Labels) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if s := r.get(name, TypeCounter, labels); s != nil {
+		s.value += value
+	}
+}
+
+// Observe - Implements Sink.
+func (r *Registry) Observe(name string, value float64, labels Labels) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	s := r.get(name, TypeHistogram, labels)
+	if s == nil {
+		return
+	}
+	for i, bound := range r.getBuckets() {
+		if value <= bound {
+			s.buckets[i]++
+		}
+	}
+	s.value += value
+	s.count++
+}
+
+// SetGauge - Implements Sink.
+func (r *Registry) SetGauge(name string, value float64, labels Labels) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if s := r.get(name, TypeGauge, labels); s != nil {
+		s.value = value
+	}
+}
+
+// Get - The value of a counter or gauge, or the sum of a histogram. Zero if it hasn't been recorded.
+func (r *Registry) Get(name string, labels Labels) float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	m, ok := r.metrics[name]
+	if !ok {
+		return 0
+	}
+	s, ok := m.series[formatLabels(labels)]
+	if !ok {
+		return 0
+	}
+	return s.value
+}
+
+// WritePrometheus - Write all metrics in the Prometheus text format, after calling the OnCollect() functions.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	Collect()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	out := bufio.NewWriter(w)
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		m := r.metrics[name]
+		if help, ok := Help[name]; ok {
+			_, _ = fmt.Fprintf(out, "# HELP %s %s\n", name, help)
+		}
+		_, _ = fmt.Fprintf(out, "# TYPE %s %s\n", name, m.kind)
+
+		keys := make([]string, 0, len(m.series))
+		for key := range m.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := m.series[key]
+			if m.kind != TypeHistogram {
+				_, _ = fmt.Fprintf(out, "%s%s %s\n", name, s.labels, formatValue(s.value))
+				continue
+			}
+
+			for i, bound := range r.getBuckets() {
+				_, _ = fmt.Fprintf(out, "%s_bucket%s %d\n", name, withLabel(s.labels, "le", formatValue(bound)), s.buckets[i])
+			}
+			_, _ = fmt.Fprintf(out, "%s_bucket%s %d\n", name, withLabel(s.labels, "le", "+Inf"), s.count)
+			_, _ = fmt.Fprintf(out, "%s_sum%s %s\n", name, s.labels, formatValue(s.value))
+			_, _ = fmt.Fprintf(out, "%s_count%s %d\n", name, s.labels, s.count)
+		}
+	}
+
+	return out.Flush()
+}
+
+// ServeHTTP - Implements http.Handler, serving WritePrometheus().
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = r.WritePrometheus(w)
+}
+
+// String - Stringer interface.
+func (r *Registry) String() string {
+	var ret strings.Builder
+	_ = r.WritePrometheus(&ret)
+	return ret.String()
+}
+
+// get - The series of a metric, created if needed, or nil if the metric is another kind. Called with the lock held.
+func (r *Registry) get(name string, kind string, labels Labels) *series {
+	if r.metrics == nil {
+		r.metrics = make(map[string]*metric)
+	}
+
+	m, ok := r.metrics[name]
+	if !ok {
+		m = &metric{kind: kind, series: make(map[string]*series)}
+		r.metrics[name] = m
+	}
+	if m.kind != kind {
+		if !m.mismatch {
+			log.Printf("[WARN]: metric '%s' is a %s, not recorded as a %s", name, m.kind, kind)
+			m.mismatch = true
+		}
+		return nil
+	}
+
+	key := formatLabels(labels)
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{labels: key}
+		if kind == TypeHistogram {
+			s.buckets = make([]uint64, len(r.getBuckets()))
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+func (r *Registry) getBuckets() []float64 {
+	if r.Buckets == nil {
+		return DefaultBuckets
+	}
+	return r.Buckets
+}
+
+// formatLabels - {name="value",...}, sorted by name, or empty if there are none.
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var ret strings.Builder
+	ret.WriteString("{")
+	for i, name := range names {
+		if i > 0 {
+			ret.WriteString(",")
+		}
+		ret.WriteString(name + `="` + escapeLabel(labels[name]) + `"`)
+	}
+	ret.WriteString("}")
+	return ret.String()
+}
+
+// withLabel - Add a label to formatted labels.
+func withLabel(labels string, name string, value string) string {
+	label := name + `="` + escapeLabel(value) + `"`
+	if labels == "" {
+		return "{" + label + "}"
+	}
+	return strings.TrimSuffix(labels, "}") + "," + label + "}"
+}
+
+// escapeLabel - Escape a label value as the Prometheus text format does, (only \, " and newline).
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
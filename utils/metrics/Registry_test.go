@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RegistrySuite struct {
+	suite.Suite
+	registry *Registry
+}
+
+func (s *RegistrySuite) SetupTest() {
+	s.registry = NewRegistry()
+	s.registry.Buckets = []float64{1}
+}
+
+func (s *RegistrySuite) TestKindMismatch() {
+	labels := Labels{"plugin": "a"}
+	s.registry.AddCounter("test_total", 1, labels)
+
+	s.NotPanics(func() {
+		s.registry.Observe("test_total", 2, labels)
+		s.registry.SetGauge("test_total", 5, labels)
+	})
+	s.Equal(1.0, s.registry.Get("test_total", labels), "a counter is kept as a counter")
+	s.Contains(s.registry.String(), "# TYPE test_total counter\n")
+}
+
+func (s *RegistrySuite) TestLabels() {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "a", want: `test{plugin="a"} 1`},
+		{value: `back\slash`, want: `test{plugin="back\\slash"} 1`},
+		{value: `"quoted"`, want: `test{plugin="\"quoted\""} 1`},
+		{value: "new\nline", want: `test{plugin="new\nline"} 1`},
+		{value: "tab\tü", want: "test{plugin=\"tab\tü\"} 1"},
+	}
+
+	for _, test := range tests {
+		s.Run(test.want, func() {
+			s.SetupTest()
+			s.registry.SetGauge("test", 1, Labels{"plugin": test.value})
+			s.Contains(s.registry.String(), test.want+"\n")
+		})
+	}
+
+	s.registry.Observe("test_seconds", 0.5, Labels{"hook": `a"b`})
+	s.Contains(s.registry.String(), `test_seconds_bucket{hook="a\"b",le="1"} 1`)
+}
+
+func TestRegistrySuite(t *testing.T) {
+	suite.Run(t, new(RegistrySuite))
+}
@@ -0,0 +1,376 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// ValueFileName - The log file within a ValueFile dir.
+const ValueFileName = "values.log"
+
+const (
+	valueFileCompactMin = 1000     // Don't compact logs with fewer records than this.
+	valueFileRecordMax  = 64 << 20 // Longer records are corrupt.
+)
+
+func init() {
+	RegisterValueType(time.Time{})
+	RegisterValueType(time.Duration(0))
+	RegisterValueType(map[string]any{})
+	RegisterValueType([]any{})
+}
+
+// RegisterValueType - Register a type stored within a ValueStruct, so a ValueBackend can restore it as the same type.
+// Basic types, slices of them, time.Time and time.Duration are registered already.
+func RegisterValueType(value any) {
+	gob.Register(value)
+}
+
+//
+// ValueBackend - Durable storage for a ValueStruct, (see ValueStruct.SetBackend).
+// ---------------------------------------------------------------------------------------------------- //
+type ValueBackend interface {
+	// Load - Read the stored values.
+	Load() (map[string]any, Return.Error)
+
+	// Write - Store a single change.
+	Write(change ValueChange) Return.Error
+
+	// Compact - Replace everything stored with values.
+	Compact(values map[string]any) Return.Error
+
+	// Close - Flush and close the storage.
+	Close() Return.Error
+}
+
+//
+// ValueFile - A ValueBackend held as an append-only log, <dir>/values.log.
+// ---------------------------------------------------------------------------------------------------- //
+// Each change is a record of [length][CRC-32][gob encoded key and value], so values keep their type.
+// Each record is synced as it's written, so a change is stored once Write returns.
+// A record torn by a crash fails its checksum, and is dropped along with anything after it. The log it was read from
+// is kept alongside, (values.log.<time>.corrupt), when it's next compacted.
+// A record with a good checksum whose value can't be decoded, (eg: a type not registered with RegisterValueType),
+// isn't loaded, but is kept as it is when the log is compacted.
+// The log is compacted into a snapshot, (written to a temp file then renamed), when loaded and when it grows.
+type ValueFile struct {
+	File    string
+	file    *os.File
+	records int
+	raw     map[string][]byte // Records that couldn't be decoded, by key.
+	damaged bool              // The log wasn't read to the end.
+}
+
+// valueRecord - A single log record.
+type valueRecord struct {
+	Key     string
+	Value   any
+	Deleted bool
+}
+
+// valueRecordKey - A log record, without its value.
+type valueRecordKey struct {
+	Key     string
+	Deleted bool
+}
+
+// NewValueFile - Create a new instance of this structure. The dir is created if it doesn't exist.
+func NewValueFile(dir string) (*ValueFile, Return.Error) {
+	var ret ValueFile
+	var err Return.Error
+
+	for range Only.Once {
+		e := os.MkdirAll(dir, 0o700)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		ret.File = filepath.Join(dir, ValueFileName)
+	}
+
+	return &ret, err
+}
+
+// Load - Replay the log. A torn or corrupt tail is dropped, and records that can't be decoded are skipped.
+func (f *ValueFile) Load() (map[string]any, Return.Error) {
+	ret := make(map[string]any)
+	var err Return.Error
+
+	for range Only.Once {
+		f.records = 0
+		f.raw = make(map[string][]byte)
+		f.damaged = false
+
+		fh, e := os.Open(f.File)
+		if os.IsNotExist(e) {
+			break
+		}
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		//goland:noinspection GoUnhandledErrorResult,GoDeferInLoop
+		defer fh.Close()
+
+		reader := bufio.NewReader(fh)
+		for {
+			var payload []byte
+			payload, e = readValueRecord(reader)
+			if e == io.EOF {
+				break
+			}
+			if e != nil {
+				// Everything up to here is good, the rest is lost.
+				err.AddWarning("%s: dropped corrupt records after %d: %s", f.File, f.records, e)
+				f.damaged = true
+				break
+			}
+			f.records++
+
+			var record valueRecord
+			e = gob.NewDecoder(bytes.NewReader(payload)).Decode(&record)
+			if e != nil {
+				// The record is intact, so it's kept for whoever can decode it.
+				var key valueRecordKey
+				e2 := gob.NewDecoder(bytes.NewReader(payload)).Decode(&key)
+				if e2 != nil {
+					err.AddWarning("%s: dropped record %d: %s", f.File, f.records, e)
+					f.damaged = true
+					continue
+				}
+				delete(ret, key.Key)
+				if key.Deleted {
+					delete(f.raw, key.Key)
+					continue
+				}
+				err.AddWarning("%s: value '%s' not loaded: %s", f.File, key.Key, e)
+				f.raw[key.Key] = payload
+				continue
+			}
+
+			delete(f.raw, record.Key)
+			if record.Deleted {
+				delete(ret, record.Key)
+				continue
+			}
+			ret[record.Key] = record.Value
+		}
+	}
+
+	return ret, err
+}
+
+// Write - Append a change to the log.
+func (f *ValueFile) Write(change ValueChange) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if f.file == nil {
+			err = f.open()
+			if err.IsError() {
+				break
+			}
+		}
+
+		data, e := encodeValueRecord(valueRecord{Key: change.Key, Value: change.New, Deleted: change.Deleted})
+		if e != nil {
+			err.SetError("value '%s' not stored: %s", change.Key, e)
+			break
+		}
+
+		_, e = f.file.Write(data)
+		if e == nil {
+			e = f.file.Sync()
+		}
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+		f.records++
+		delete(f.raw, change.Key)
+	}
+
+	return err
+}
+
+// Compact - Replace the log with a snapshot of values, if the log has grown, (or no longer matches values).
+// Records Load couldn't decode are kept, unless values has the same key.
+func (f *ValueFile) Compact(values map[string]any) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if !f.damaged && f.file != nil && (f.records < valueFileCompactMin || f.records < 2*len(values)) {
+			break
+		}
+
+		var buf bytes.Buffer
+		var records int
+		for key, value := range values {
+			data, e := encodeValueRecord(valueRecord{Key: key, Value: value})
+			if e != nil {
+				err.AddError("value '%s' not stored: %s", key, e)
+				continue
+			}
+			buf.Write(data)
+			records++
+		}
+		for key, payload := range f.raw {
+			if _, ok := values[key]; ok {
+				continue
+			}
+			buf.Write(encodeValuePayload(payload))
+			records++
+		}
+
+		if f.file != nil {
+			//goland:noinspection GoUnhandledErrorResult
+			f.file.Close()
+			f.file = nil
+		}
+
+		if f.damaged {
+			// The log wasn't read to the end, so it's kept for whoever can recover the rest.
+			backup := fmt.Sprintf("%s.%s.corrupt", f.File, time.Now().Format("20060102-150405"))
+			e := os.Rename(f.File, backup)
+			if e != nil {
+				err.SetError(e)
+				break
+			}
+			logWarn("%s: kept as %s", f.File, backup)
+			f.damaged = false
+		}
+
+		tmp := f.File + ".tmp"
+		e := writeSynced(tmp, buf.Bytes())
+		if e != nil {
+			_ = os.Remove(tmp)
+			err.SetError(e)
+			break
+		}
+
+		e = os.Rename(tmp, f.File)
+		if e != nil {
+			_ = os.Remove(tmp)
+			err.SetError(e)
+			break
+		}
+		f.records = records
+
+		// Values that can't be stored are reported, but don't stop the rest.
+		if e2 := f.open(); e2.IsError() {
+			err = e2
+		}
+	}
+
+	return err
+}
+
+// Close - Close the log.
+func (f *ValueFile) Close() Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		if f.file == nil {
+			break
+		}
+
+		e := f.file.Sync()
+		if e != nil {
+			err.SetError(e)
+		}
+		e = f.file.Close()
+		if e != nil {
+			err.SetError(e)
+		}
+		f.file = nil
+	}
+
+	return err
+}
+
+// open - Open the log for appending.
+func (f *ValueFile) open() Return.Error {
+	var err Return.Error
+	var e error
+	f.file, e = os.OpenFile(f.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if e != nil {
+		err.SetError(e)
+	}
+	return err
+}
+
+// encodeValueRecord - [length][CRC-32][gob].
+func encodeValueRecord(record valueRecord) ([]byte, error) {
+	var payload bytes.Buffer
+	e := gob.NewEncoder(&payload).Encode(&record)
+	if e != nil {
+		return nil, e
+	}
+	return encodeValuePayload(payload.Bytes()), nil
+}
+
+// encodeValuePayload - [length][CRC-32][payload].
+func encodeValuePayload(payload []byte) []byte {
+	data := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(data[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(data[4:8], crc32.ChecksumIEEE(payload))
+	return append(data, payload...)
+}
+
+// readValueRecord - Read a record's payload, checking its length and CRC. Returns io.EOF at a clean end of the log.
+func readValueRecord(reader io.Reader) ([]byte, error) {
+	var header [8]byte
+	_, e := io.ReadFull(reader, header[:])
+	if e != nil {
+		if e == io.ErrUnexpectedEOF {
+			return nil, errors.New("torn record header")
+		}
+		return nil, e
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > valueFileRecordMax {
+		return nil, errors.New("record too long")
+	}
+
+	payload := make([]byte, length)
+	_, e = io.ReadFull(reader, payload)
+	if e != nil {
+		return nil, errors.New("torn record")
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, errors.New("record checksum mismatch")
+	}
+
+	return payload, nil
+}
+
+// writeSynced - Write a file, and sync it to disk before returning.
+func writeSynced(file string, data []byte) error {
+	fh, e := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if e != nil {
+		return e
+	}
+
+	_, e = fh.Write(data)
+	if e == nil {
+		e = fh.Sync()
+	}
+	if e2 := fh.Close(); e == nil {
+		e = e2
+	}
+	return e
+}
@@ -0,0 +1,227 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// valueFileType - A value type that's stored, then renamed so it can't be decoded.
+type valueFileType struct {
+	A int
+}
+
+type ValueFileSuite struct {
+	suite.Suite
+	dir  string
+	file *ValueFile
+}
+
+// SetupTest - A log holding "a", "b" and "c".
+func (s *ValueFileSuite) SetupTest() {
+	RegisterValueType(valueFileType{})
+	s.dir = s.T().TempDir()
+	s.file = s.open()
+	for _, key := range []string{"a", "b", "c"} {
+		err := s.file.Write(ValueChange{Key: key, New: key})
+		s.Require().False(err.IsError(), err.String())
+	}
+	s.ok(s.file.Close())
+}
+
+// open - The log as the next process would see it.
+func (s *ValueFileSuite) open() *ValueFile {
+	ret, err := NewValueFile(s.dir)
+	s.Require().False(err.IsError(), err.String())
+	return ret
+}
+
+// ok - Fail unless err is Ok.
+func (s *ValueFileSuite) ok(err Return.Error) {
+	s.Require().False(err.IsError(), err.String())
+}
+
+// read - The raw log.
+func (s *ValueFileSuite) read() []byte {
+	data, e := os.ReadFile(filepath.Join(s.dir, ValueFileName))
+	s.Require().NoError(e)
+	return data
+}
+
+// write - Replace the raw log.
+func (s *ValueFileSuite) write(data []byte) {
+	s.Require().NoError(os.WriteFile(filepath.Join(s.dir, ValueFileName), data, 0o600))
+}
+
+// backups - The corrupt logs kept by Compact.
+func (s *ValueFileSuite) backups() []string {
+	ret, e := filepath.Glob(filepath.Join(s.dir, ValueFileName+".*.corrupt"))
+	s.Require().NoError(e)
+	return ret
+}
+
+func (s *ValueFileSuite) TestLoad() {
+	tests := []struct {
+		name    string
+		tamper  func()
+		want    map[string]any
+		warning bool
+		backup  bool
+	}{
+		{
+			name: "intact",
+			want: map[string]any{"a": "a", "b": "b", "c": "c"},
+		},
+		{
+			name: "torn tail",
+			tamper: func() {
+				data := s.read()
+				s.write(data[:len(data)-3])
+			},
+			want:    map[string]any{"a": "a", "b": "b"},
+			warning: true,
+			backup:  true,
+		},
+		{
+			name: "checksum mismatch",
+			tamper: func() {
+				data := s.read()
+				i := bytes.LastIndex(data, []byte("b"))
+				data[i] = 'x'
+				s.write(data)
+			},
+			want:    map[string]any{"a": "a"},
+			warning: true,
+			backup:  true,
+		},
+		{
+			name: "value not decoded",
+			tamper: func() {
+				f := s.open()
+				s.ok(f.Write(ValueChange{Key: "d", New: valueFileType{A: 1}}))
+				s.ok(f.Write(ValueChange{Key: "e", New: "e"}))
+				s.ok(f.Close())
+				s.write(renameValueType(s.read()))
+			},
+			want:    map[string]any{"a": "a", "b": "b", "c": "c", "e": "e"},
+			warning: true,
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.SetupTest()
+			if test.tamper != nil {
+				test.tamper()
+			}
+
+			f := s.open()
+			values, err := f.Load()
+			s.Require().False(err.IsError(), err.String())
+			s.Equal(test.warning, err.IsWarning(), err.String())
+			s.Equal(test.want, values)
+
+			err = f.Compact(values)
+			s.Require().False(err.IsError(), err.String())
+			s.ok(f.Close())
+			s.Equal(test.backup, len(s.backups()) == 1, "a partly read log is kept")
+
+			// Everything loaded is still there after compacting.
+			values2, err := s.open().Load()
+			s.Require().False(err.IsError(), err.String())
+			s.Equal(test.want, values2)
+		})
+	}
+}
+
+func (s *ValueFileSuite) TestNotDecodedKept() {
+	f := s.open()
+	s.ok(f.Write(ValueChange{Key: "d", New: valueFileType{A: 1}}))
+	s.ok(f.Close())
+	s.write(renameValueType(s.read()))
+
+	// Compacted while "d" can't be decoded, then readable again once its type is back.
+	f = s.open()
+	values, err := f.Load()
+	s.Require().False(err.IsError(), err.String())
+	s.NotContains(values, "d")
+	f.records = valueFileCompactMin
+	s.ok(f.Compact(values))
+	s.ok(f.Close())
+
+	s.write(bytes.ReplaceAll(s.read(), []byte("valueFileTypX"), []byte("valueFileType")))
+	s.write(rechecksum(s.read()))
+	values, err = s.open().Load()
+	s.Require().False(err.IsError(), err.String())
+	s.Equal(valueFileType{A: 1}, values["d"])
+}
+
+func (s *ValueFileSuite) TestCompact() {
+	f := s.open()
+	values, err := f.Load()
+	s.Require().False(err.IsError(), err.String())
+	for i := 0; i < valueFileCompactMin; i++ {
+		s.ok(f.Write(ValueChange{Key: "a", New: "changed"}))
+	}
+	values["a"] = "changed"
+	s.ok(f.Write(ValueChange{Key: "b", Deleted: true}))
+	delete(values, "b")
+
+	err = f.Compact(values)
+	s.Require().False(err.IsError(), err.String())
+	s.Equal(2, f.records)
+	s.ok(f.Close())
+	s.Empty(s.backups())
+
+	loaded, err := s.open().Load()
+	s.Require().False(err.IsError(), err.String())
+	s.Equal(map[string]any{"a": "changed", "c": "c"}, loaded)
+}
+
+func (s *ValueFileSuite) TestNotCompacted() {
+	f := s.open()
+	values, err := f.Load()
+	s.Require().False(err.IsError(), err.String())
+	s.ok(f.Write(ValueChange{Key: "d", New: "d"}))
+	before := s.read()
+
+	// Small logs are left alone.
+	values["d"] = "d"
+	err = f.Compact(values)
+	s.Require().False(err.IsError(), err.String())
+	s.Equal(before, s.read())
+	s.ok(f.Close())
+}
+
+// renameValueType - A log whose valueFileType values can't be decoded, with good checksums.
+func renameValueType(data []byte) []byte {
+	return rechecksum(bytes.ReplaceAll(data, []byte("valueFileType"), []byte("valueFileTypX")))
+}
+
+// rechecksum - Recompute the checksum of every record.
+func rechecksum(data []byte) []byte {
+	var ret []byte
+	reader := bytes.NewReader(data)
+	for {
+		var header [8]byte
+		_, e := io.ReadFull(reader, header[:])
+		if e != nil {
+			break
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(header[0:4]))
+		_, _ = io.ReadFull(reader, payload)
+		ret = append(ret, encodeValuePayload(payload)...)
+	}
+	return ret
+}
+
+func TestValueFileSuite(t *testing.T) {
+	suite.Run(t, new(ValueFileSuite))
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+//goland:noinspection GoUnusedConst
+const (
+	ValueOpGet    = "get"
+	ValueOpSet    = "set"
+	ValueOpDelete = "delete"
+	ValueOpCas    = "cas"
+	ValueOpKeys   = "keys"
+	ValueOpPrefix = "prefix"
+)
+
+//
+// ValueRemote - A ValueStruct held elsewhere, (eg: by master, for an RPC plugin), see ValueStruct.SetRemote().
+// ---------------------------------------------------------------------------------------------------- //
+type ValueRemote interface {
+	// ValueOp - Run an operation against the remote store, (see ValueStruct.Apply).
+	ValueOp(op ValueOp) (ValueResult, Return.Error)
+}
+
+// ValueOp - A single ValueStore operation.
+type ValueOp struct {
+	Op    string `json:"op"`
+	Key   string `json:"key,omitempty"` // Key, or prefix for ValueOpKeys and ValueOpPrefix.
+	Old   any    `json:"old,omitempty"` // ValueOpCas only.
+	Value any    `json:"value,omitempty"`
+}
+
+// ValueResult - The result of a ValueOp.
+type ValueResult struct {
+	Value  any            `json:"value,omitempty"`
+	Ok     bool           `json:"ok,omitempty"`
+	Keys   []string       `json:"keys,omitempty"`
+	Values map[string]any `json:"values,omitempty"`
+}
+
+// Apply - Run an operation against this store. Used to serve a ValueRemote.
+func (p *ValueStruct) Apply(op ValueOp) (ValueResult, Return.Error) {
+	var ret ValueResult
+	var err Return.Error
+
+	switch op.Op {
+	case ValueOpGet:
+		ret.Value, ret.Ok = p.Get(op.Key)
+	case ValueOpSet:
+		p.SetValue(op.Key, op.Value)
+		ret.Ok = true
+	case ValueOpDelete:
+		ret.Ok = p.Delete(op.Key)
+	case ValueOpCas:
+		ret.Ok = p.CompareAndSwap(op.Key, op.Old, op.Value)
+	case ValueOpKeys:
+		ret.Keys = p.KeysWithPrefix(op.Key)
+	case ValueOpPrefix:
+		ret.Values = p.GetPrefix(op.Key)
+	default:
+		err.SetError("unknown value operation '%s'", op.Op)
+	}
+
+	return ret, err
+}
+
+// SetRemote - Forward every operation to a remote store, so both sides see one store. Nil stops forwarding.
+// Values held locally are dropped, (in place, so copies share the remote), the remote store is expected to have them already.
+// Subscribers are only called by Notify(), with changes made by either side.
+func (p *ValueStruct) SetRemote(remote ValueRemote) {
+	refs := p.getRefs()
+	refs.lock.Lock()
+	defer refs.lock.Unlock()
+
+	refs.remote = remote
+	if remote != nil {
+		clear(p.Values)
+	}
+}
+
+// Notify - Call subscribers with a change made to the remote store.
+func (p *ValueStruct) Notify(change ValueChange) {
+	refs := p.getRefs()
+	refs.lock.RLock()
+	subscribers := refs.match(change.Key)
+	refs.lock.RUnlock()
+
+	notify(subscribers, change)
+}
+
+// getRemote - The remote store, if any.
+func (p *ValueStruct) getRemote() ValueRemote {
+	refs := p.getRefs()
+	refs.lock.RLock()
+	defer refs.lock.RUnlock()
+	return refs.remote
+}
+
+// remoteOp - Run an operation against the remote store. Errors are logged, as ValueStore methods don't return them.
+func remoteOp(remote ValueRemote, op ValueOp) ValueResult {
+	ret, err := remote.ValueOp(op)
+	if err.IsError() {
+		logWarn("value '%s' %s: %s", op.Key, op.Op, err.GetError())
+	}
+	return ret
+}
@@ -2,14 +2,23 @@ package store
 
 import (
 	"fmt"
+	"log"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/utils"
+	"github.com/MickMake/GoPlug/utils/Return"
 )
 
 // ---------------------------------------------------------------------------------------------------- //
 // Value store interface and methods
 
 //
-// ValueStore - Getter/Setter for string map of interfaces{}
+// ValueStore - Getter/Setter for string map of interfaces{}, safe for concurrent use.
 // ---------------------------------------------------------------------------------------------------- //
 type ValueStore interface {
 	// NewValueStore - Set up the ValueStore map.
@@ -21,12 +30,34 @@ type ValueStore interface {
 	// ValueNotExists - Inverse of Exists()
 	ValueNotExists(key string) bool
 
-	// GetValue - Get a key's value.
+	// Get - Get a key's value, false if the key doesn't exist. See also GetAs().
+	Get(key string) (any, bool)
+
+	// GetValue - Get a key's value, nil if the key doesn't exist.
 	GetValue(key string) any
 
 	// SetValue - Set a key value pair.
 	SetValue(key string, value any)
 
+	// Delete - Remove a key, false if it didn't exist.
+	Delete(key string) bool
+
+	// CompareAndSwap - Set a key to value, only if its current value is old, (nil for a key that doesn't exist).
+	CompareAndSwap(key string, old any, value any) bool
+
+	// Keys - Sorted keys.
+	Keys() []string
+
+	// KeysWithPrefix - Sorted keys starting with prefix.
+	KeysWithPrefix(prefix string) []string
+
+	// GetPrefix - Copy of the key value pairs whose key starts with prefix.
+	GetPrefix(prefix string) map[string]any
+
+	// Subscribe - Call fn with every change to a key starting with prefix, ("" for all keys).
+	// Returns a function that cancels the subscription.
+	Subscribe(prefix string, fn func(change ValueChange)) func()
+
 	// CountValues - Return the number of entries.
 	CountValues() int
 
@@ -37,74 +68,366 @@ type ValueStore interface {
 // NewValueStore - Create a ValueStore interface structure instance.
 //goland:noinspection GoUnusedExportedFunction
 func NewValueStore() ValueStore {
-	return &ValueStruct{
-		Values: make(map[string]any),
+	ret := NewValueStruct()
+	return &ret
+}
+
+// GetAs - Get a key's value as type T. False if the key doesn't exist, or holds another type.
+func GetAs[T any](store ValueStore, key string) (T, bool) {
+	var ret T
+	value, ok := store.Get(key)
+	if !ok {
+		return ret, false
 	}
+	ret, ok = value.(T)
+	return ret, ok
+}
+
+// ValueChange - A change to a key, given to Subscribe() functions.
+type ValueChange struct {
+	Key     string
+	Old     any  // nil if the key didn't exist.
+	New     any  // nil if the key was deleted.
+	Deleted bool //
 }
 
 //
 // ValueStruct
 // ---------------------------------------------------------------------------------------------------- //
+// Copies of a ValueStruct share the same values, lock and subscriptions.
+// A ValueStruct may forward everything to a remote store, (see SetRemote).
 type ValueStruct struct {
 	Values map[string]any `json:"values"`
+	refs   *valueRefs
 }
 
+// valueRefs - Shared between copies of a ValueStruct.
+type valueRefs struct {
+	lock        sync.RWMutex
+	subscribers map[int]valueSubscriber
+	next        int
+	backend     ValueBackend
+	remote      ValueRemote
+}
+
+type valueSubscriber struct {
+	prefix string
+	fn     func(change ValueChange)
+}
+
+// valueRefsLock - Guards setting up the refs of a ValueStruct not created by NewValueStruct(), (eg: decoded).
+var valueRefsLock sync.Mutex
+
 // NewValueStruct - Create a ValueStore interface structure instance.
 func NewValueStruct() ValueStruct {
 	return ValueStruct{
 		Values: make(map[string]any),
+		refs:   &valueRefs{subscribers: make(map[int]valueSubscriber)},
 	}
 }
 
-// NewValueStore - Create a ValueStore interface structure instance.
+// getRefs - The shared lock and subscriptions, set up on first use.
+func (p *ValueStruct) getRefs() *valueRefs {
+	valueRefsLock.Lock()
+	defer valueRefsLock.Unlock()
+
+	if p.refs == nil {
+		p.refs = &valueRefs{subscribers: make(map[int]valueSubscriber)}
+	}
+	if p.Values == nil {
+		p.Values = make(map[string]any)
+	}
+	return p.refs
+}
+
+// NewValueStore - Remove all values. Cleared in place, so copies still share the store.
 func (p *ValueStruct) NewValueStore() {
-	p.Values = make(map[string]any)
+	refs := p.getRefs()
+	refs.lock.Lock()
+	defer refs.lock.Unlock()
+	clear(p.Values)
 }
 
 // ValueExists - Check if a key exists.
 func (p *ValueStruct) ValueExists(key string) bool {
-	key = strings.TrimSpace(key)
-	if _, ok := p.Values[key]; ok {
-		return true
-	}
-	return false
+	_, ok := p.Get(key)
+	return ok
 }
 
 // ValueNotExists - Inverse of ValueExists()
 func (p *ValueStruct) ValueNotExists(key string) bool {
-	key = strings.TrimSpace(key)
-	if _, ok := p.Values[key]; ok {
-		return false
+	_, ok := p.Get(key)
+	return !ok
+}
+
+// Get - Get a key's value, false if the key doesn't exist.
+func (p *ValueStruct) Get(key string) (any, bool) {
+	if remote := p.getRemote(); remote != nil {
+		ret := remoteOp(remote, ValueOp{Op: ValueOpGet, Key: strings.TrimSpace(key)})
+		return ret.Value, ret.Ok
 	}
-	return true
+
+	refs := p.getRefs()
+	refs.lock.RLock()
+	defer refs.lock.RUnlock()
+
+	value, ok := p.Values[strings.TrimSpace(key)]
+	return value, ok
 }
 
-// GetValue - Get a key's value.
+// GetValue - Get a key's value, nil if the key doesn't exist.
 func (p *ValueStruct) GetValue(key string) any {
-	key = strings.TrimSpace(key)
-	if value, ok := p.Values[key]; ok {
-		return value
-	}
-	return new(any)
+	value, _ := p.Get(key)
+	return value
 }
 
 // SetValue - Set a key value pair.
 func (p *ValueStruct) SetValue(key string, value any) {
 	key = strings.TrimSpace(key)
+	if remote := p.getRemote(); remote != nil {
+		remoteOp(remote, ValueOp{Op: ValueOpSet, Key: key, Value: value})
+		return
+	}
+
+	refs := p.getRefs()
+
+	refs.lock.Lock()
+	old := p.Values[key]
+	p.Values[key] = value
+	refs.write(ValueChange{Key: key, New: value}, p.Values)
+	subscribers := refs.match(key)
+	refs.lock.Unlock()
+
+	notify(subscribers, ValueChange{Key: key, Old: old, New: value})
+}
+
+// Delete - Remove a key, false if it didn't exist.
+func (p *ValueStruct) Delete(key string) bool {
+	key = strings.TrimSpace(key)
+	if remote := p.getRemote(); remote != nil {
+		return remoteOp(remote, ValueOp{Op: ValueOpDelete, Key: key}).Ok
+	}
+
+	refs := p.getRefs()
+
+	refs.lock.Lock()
+	old, ok := p.Values[key]
+	if !ok {
+		refs.lock.Unlock()
+		return false
+	}
+	delete(p.Values, key)
+	refs.write(ValueChange{Key: key, Deleted: true}, p.Values)
+	subscribers := refs.match(key)
+	refs.lock.Unlock()
+
+	notify(subscribers, ValueChange{Key: key, Old: old, Deleted: true})
+	return true
+}
+
+// CompareAndSwap - Set a key to value, only if its current value is old, (nil for a key that doesn't exist).
+// Values are compared with reflect.DeepEqual().
+func (p *ValueStruct) CompareAndSwap(key string, old any, value any) bool {
+	key = strings.TrimSpace(key)
+	if remote := p.getRemote(); remote != nil {
+		return remoteOp(remote, ValueOp{Op: ValueOpCas, Key: key, Old: old, Value: value}).Ok
+	}
+
+	refs := p.getRefs()
+
+	refs.lock.Lock()
+	current, ok := p.Values[key]
+	if (old == nil && ok) || (old != nil && (!ok || !reflect.DeepEqual(current, old))) {
+		refs.lock.Unlock()
+		return false
+	}
 	p.Values[key] = value
+	refs.write(ValueChange{Key: key, New: value}, p.Values)
+	subscribers := refs.match(key)
+	refs.lock.Unlock()
+
+	notify(subscribers, ValueChange{Key: key, Old: current, New: value})
+	return true
+}
+
+// Keys - Sorted keys.
+func (p *ValueStruct) Keys() []string {
+	return p.KeysWithPrefix("")
+}
+
+// KeysWithPrefix - Sorted keys starting with prefix.
+func (p *ValueStruct) KeysWithPrefix(prefix string) []string {
+	if remote := p.getRemote(); remote != nil {
+		ret := remoteOp(remote, ValueOp{Op: ValueOpKeys, Key: prefix}).Keys
+		if ret == nil {
+			ret = make([]string, 0)
+		}
+		return ret
+	}
+
+	refs := p.getRefs()
+	refs.lock.RLock()
+	defer refs.lock.RUnlock()
+
+	ret := make([]string, 0)
+	for key := range p.Values {
+		if strings.HasPrefix(key, prefix) {
+			ret = append(ret, key)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// GetPrefix - Copy of the key value pairs whose key starts with prefix.
+func (p *ValueStruct) GetPrefix(prefix string) map[string]any {
+	if remote := p.getRemote(); remote != nil {
+		ret := remoteOp(remote, ValueOp{Op: ValueOpPrefix, Key: prefix}).Values
+		if ret == nil {
+			ret = make(map[string]any)
+		}
+		return ret
+	}
+
+	refs := p.getRefs()
+	refs.lock.RLock()
+	defer refs.lock.RUnlock()
+
+	ret := make(map[string]any)
+	for key, value := range p.Values {
+		if strings.HasPrefix(key, prefix) {
+			ret[key] = value
+		}
+	}
+	return ret
+}
+
+// Subscribe - Call fn with every change to a key starting with prefix, ("" for all keys).
+// fn is called after the change is made, from the goroutine that made it. Returns a function that cancels the subscription.
+func (p *ValueStruct) Subscribe(prefix string, fn func(change ValueChange)) func() {
+	refs := p.getRefs()
+	refs.lock.Lock()
+	defer refs.lock.Unlock()
+
+	id := refs.next
+	refs.next++
+	refs.subscribers[id] = valueSubscriber{prefix: prefix, fn: fn}
+
+	return func() {
+		refs.lock.Lock()
+		defer refs.lock.Unlock()
+		delete(refs.subscribers, id)
+	}
+}
+
+// SetBackend - Store values durably. Stored values are restored, (values already set take precedence),
+// and every change from now on is written to the backend. A nil backend stops storing values.
+func (p *ValueStruct) SetBackend(backend ValueBackend) Return.Error {
+	var err Return.Error
+
+	for range Only.Once {
+		refs := p.getRefs()
+		refs.lock.Lock()
+		defer refs.lock.Unlock()
+
+		if refs.backend != nil {
+			err = refs.backend.Close()
+			refs.backend = nil
+			if err.IsError() {
+				break
+			}
+		}
+		if backend == nil {
+			break
+		}
+
+		var values map[string]any
+		values, err = backend.Load()
+		if err.IsError() {
+			break
+		}
+		if err.IsWarning() {
+			logWarn("%s", err.GetWarning())
+			err = Return.Ok
+		}
+
+		for key, value := range values {
+			if _, ok := p.Values[key]; !ok {
+				p.Values[key] = value
+			}
+		}
+
+		refs.backend = backend
+		err = backend.Compact(p.Values)
+	}
+
+	return err
+}
+
+// Close - Close the backend, if any. Values are kept in memory.
+func (p *ValueStruct) Close() Return.Error {
+	return p.SetBackend(nil)
 }
 
 // CountValues - Return the number of entries.
 func (p *ValueStruct) CountValues() int {
+	if p.getRemote() != nil {
+		return len(p.Keys())
+	}
+
+	refs := p.getRefs()
+	refs.lock.RLock()
+	defer refs.lock.RUnlock()
 	return len(p.Values)
 }
 
 // String - Stringer interface.
 func (p ValueStruct) String() string {
 	var ret string
-	for key, value := range p.Values {
+	for _, key := range p.Keys() {
 		ret += fmt.Sprintf("ValueStruct[%s] => %v\n",
-			key, value)
+			key, p.GetValue(key))
+	}
+	return utils.Redact(ret)
+}
+
+// write - Store a change, if there's a backend, called with the lock held.
+func (r *valueRefs) write(change ValueChange, values map[string]any) {
+	if r.backend == nil {
+		return
+	}
+
+	err := r.backend.Write(change)
+	if err.IsError() {
+		logWarn("%s", err.GetError())
+		return
+	}
+
+	err = r.backend.Compact(values)
+	if err.IsError() {
+		logWarn("%s", err.GetError())
+	}
+}
+
+// match - Subscribers to a key, called with the lock held.
+func (r *valueRefs) match(key string) []valueSubscriber {
+	var ret []valueSubscriber
+	for _, subscriber := range r.subscribers {
+		if strings.HasPrefix(key, subscriber.prefix) {
+			ret = append(ret, subscriber)
+		}
 	}
 	return ret
 }
+
+// logWarn - Log a warning, for methods that can't return errors.
+func logWarn(format string, args ...any) {
+	log.Printf("[WARN]: "+format, args...)
+}
+
+// notify - Call subscribers, without the lock held, so they may use the store.
+func notify(subscribers []valueSubscriber, change ValueChange) {
+	for _, subscriber := range subscribers {
+		subscriber.fn(change)
+	}
+}
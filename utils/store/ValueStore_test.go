@@ -0,0 +1,200 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+type ValueStoreSuite struct {
+	suite.Suite
+	values ValueStruct
+}
+
+// SetupTest - A store holding "a.1", "a.2" and "b".
+func (s *ValueStoreSuite) SetupTest() {
+	s.values = NewValueStruct()
+	s.values.SetValue("a.1", 1)
+	s.values.SetValue("a.2", "two")
+	s.values.SetValue("b", 3.0)
+}
+
+func (s *ValueStoreSuite) TestGet() {
+	value, ok := s.values.Get(" a.1 ")
+	s.True(ok, "keys are trimmed")
+	s.Equal(1, value)
+
+	value, ok = s.values.Get("missing")
+	s.False(ok)
+	s.Nil(value)
+	s.True(s.values.ValueExists("b"))
+	s.True(s.values.ValueNotExists("missing"))
+
+	i, ok := GetAs[int](&s.values, "a.1")
+	s.True(ok)
+	s.Equal(1, i)
+	_, ok = GetAs[int](&s.values, "a.2")
+	s.False(ok, "another type")
+	_, ok = GetAs[string](&s.values, "missing")
+	s.False(ok)
+}
+
+func (s *ValueStoreSuite) TestDelete() {
+	s.True(s.values.Delete("a.1"))
+	s.False(s.values.Delete("a.1"))
+	s.False(s.values.ValueExists("a.1"))
+	s.Equal(2, s.values.CountValues())
+}
+
+func (s *ValueStoreSuite) TestCompareAndSwap() {
+	tests := []struct {
+		name  string
+		key   string
+		old   any
+		value any
+		want  bool
+		final any
+	}{
+		{name: "matches", key: "a.1", old: 1, value: 2, want: true, final: 2},
+		{name: "differs", key: "a.1", old: 5, value: 2, final: 1},
+		{name: "missing, nil old", key: "c", old: nil, value: "new", want: true, final: "new"},
+		{name: "exists, nil old", key: "b", old: nil, value: 4.0, final: 3.0},
+		{name: "missing", key: "c", old: "x", value: "new", final: nil},
+		{name: "deep equal", key: "m", old: nil, value: map[string]any{"x": 1}, want: true, final: map[string]any{"x": 1}},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.SetupTest()
+			s.Equal(test.want, s.values.CompareAndSwap(test.key, test.old, test.value))
+			s.Equal(test.final, s.values.GetValue(test.key))
+		})
+	}
+
+	s.values.SetValue("m", map[string]any{"x": 1})
+	s.True(s.values.CompareAndSwap("m", map[string]any{"x": 1}, "swapped"), "values are compared deeply")
+}
+
+func (s *ValueStoreSuite) TestPrefix() {
+	s.Equal([]string{"a.1", "a.2", "b"}, s.values.Keys())
+	s.Equal([]string{"a.1", "a.2"}, s.values.KeysWithPrefix("a."))
+	s.Equal([]string{}, s.values.KeysWithPrefix("z"))
+	s.Equal(map[string]any{"a.1": 1, "a.2": "two"}, s.values.GetPrefix("a."))
+
+	// A copy, changing it doesn't change the store.
+	values := s.values.GetPrefix("")
+	values["a.1"] = 100
+	s.Equal(1, s.values.GetValue("a.1"))
+}
+
+func (s *ValueStoreSuite) TestSubscribe() {
+	var changes []ValueChange
+	cancel := s.values.Subscribe("a.", func(change ValueChange) {
+		changes = append(changes, change)
+	})
+
+	s.values.SetValue("a.1", 10)
+	s.values.SetValue("b", 30.0)
+	s.values.Delete("a.2")
+	s.values.CompareAndSwap("a.3", nil, "three")
+	cancel()
+	s.values.SetValue("a.1", 11)
+
+	s.Equal([]ValueChange{
+		{Key: "a.1", Old: 1, New: 10},
+		{Key: "a.2", Old: "two", Deleted: true},
+		{Key: "a.3", New: "three"},
+	}, changes)
+}
+
+func (s *ValueStoreSuite) TestSubscribeUsesStore() {
+	s.values.Subscribe("", func(change ValueChange) {
+		if change.Key == "a.1" {
+			s.values.SetValue("seen", s.values.GetValue("a.1"))
+		}
+	})
+	s.values.SetValue("a.1", 5)
+	s.Equal(5, s.values.GetValue("seen"), "subscribers are called without the lock held")
+}
+
+func (s *ValueStoreSuite) TestCopiesShare() {
+	ref := s.values
+	var copies ValueStore = &ref
+
+	s.values.SetValue("c", "set on original")
+	s.Equal("set on original", copies.GetValue("c"))
+
+	s.values.NewValueStore()
+	s.Zero(copies.CountValues(), "cleared for copies too")
+	copies.SetValue("d", 4)
+	s.Equal(4, s.values.GetValue("d"))
+
+	s.values.SetRemote(remoteStruct{values: NewValueStruct()})
+	s.Zero(len(ref.Values), "dropped for copies too")
+	copies.SetValue("e", 5)
+	s.Equal(5, s.values.GetValue("e"), "copies use the remote")
+}
+
+func (s *ValueStoreSuite) TestConcurrent() {
+	const workers = 8
+	const count = 200
+
+	var notified atomic.Int64
+	cancel := s.values.Subscribe("counter", func(ValueChange) {
+		notified.Add(1)
+	})
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			copied := s.values
+			for i := 0; i < count; i++ {
+				// Increment with CompareAndSwap, retrying when another worker got there first.
+				for {
+					old, _ := GetAs[int](&copied, "counter")
+					var prev any
+					if copied.ValueExists("counter") {
+						prev = old
+					}
+					if copied.CompareAndSwap("counter", prev, old+1) {
+						break
+					}
+				}
+
+				key := fmt.Sprintf("w%d.%d", w, i%10)
+				copied.SetValue(key, i)
+				_ = copied.KeysWithPrefix(fmt.Sprintf("w%d.", w))
+				_ = copied.GetPrefix("w")
+				copied.Delete(key)
+				cancelOne := copied.Subscribe(key, func(ValueChange) {})
+				cancelOne()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	s.Equal(workers*count, s.values.GetValue("counter"))
+	s.Equal(int64(workers*count), notified.Load())
+	s.Empty(s.values.KeysWithPrefix("w"))
+}
+
+// remoteStruct - A ValueRemote applying operations to another ValueStruct, (as master does for an RPC plugin).
+type remoteStruct struct {
+	values ValueStruct
+}
+
+func (r remoteStruct) ValueOp(op ValueOp) (ValueResult, Return.Error) {
+	return r.values.Apply(op)
+}
+
+func TestValueStoreSuite(t *testing.T) {
+	suite.Run(t, new(ValueStoreSuite))
+}
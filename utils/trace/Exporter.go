@@ -0,0 +1,143 @@
+package trace
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/MickMake/GoUnify/Only"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+// FileEnv - Export spans to this JSON file, (see JSONFileExporter). Passed on to RPC plugin processes,
+// so spans from both sides of a hook call end up in one file.
+const FileEnv = "GOPLUG_TRACE_FILE"
+
+//
+// Exporter - Where ended spans are sent.
+// ---------------------------------------------------------------------------------------------------- //
+// Set with SetExporter(), (Discard by default, unless FileEnv is set).
+type Exporter interface {
+	Export(span *Span)
+}
+
+// Discard - An Exporter that drops everything.
+type Discard struct{}
+
+func (Discard) Export(*Span) {}
+
+var (
+	exporter Exporter = Discard{}
+	lock     sync.RWMutex
+)
+
+func init() {
+	file := os.Getenv(FileEnv)
+	if file == "" {
+		return
+	}
+
+	e, err := NewJSONFileExporter(file)
+	if err.IsError() {
+		log.Printf("[WARN]: %s: %s", FileEnv, err.GetError())
+		return
+	}
+	SetExporter(e)
+}
+
+// SetExporter - Send ended spans to e, (nil discards them).
+func SetExporter(e Exporter) {
+	if e == nil {
+		e = Discard{}
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	exporter = e
+}
+
+// GetExporter - Where ended spans are sent.
+func GetExporter() Exporter {
+	lock.RLock()
+	defer lock.RUnlock()
+	return exporter
+}
+
+// GetFile - The file spans are exported to, if the exporter is a JSONFileExporter.
+func GetFile() string {
+	if e, ok := GetExporter().(*JSONFileExporter); ok {
+		return e.File
+	}
+	return ""
+}
+
+//
+// JSONFileExporter - Appends each span to a file, as a line of JSON. For development.
+// ---------------------------------------------------------------------------------------------------- //
+type JSONFileExporter struct {
+	File string
+	fh   *os.File
+	lock sync.Mutex
+}
+
+// NewJSONFileExporter - Create a new instance of this structure. The dir of file is created if it doesn't exist.
+func NewJSONFileExporter(file string) (*JSONFileExporter, Return.Error) {
+	var ret JSONFileExporter
+	var err Return.Error
+
+	for range Only.Once {
+		var e error
+		ret.File, e = filepath.Abs(file)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		e = os.MkdirAll(filepath.Dir(ret.File), 0o700)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+
+		ret.fh, e = os.OpenFile(ret.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if e != nil {
+			err.SetError(e)
+			break
+		}
+	}
+
+	return &ret, err
+}
+
+// Export - Implements Exporter. Each span is a single write, so processes can share the file.
+func (e *JSONFileExporter) Export(span *Span) {
+	span.lock.Lock()
+	data, err := json.Marshal(span)
+	span.lock.Unlock()
+	if err != nil {
+		log.Printf("[WARN]: trace: %s", err)
+		return
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.fh == nil {
+		return
+	}
+	_, _ = e.fh.Write(append(data, '\n'))
+}
+
+// Close - Close the file.
+func (e *JSONFileExporter) Close() Return.Error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	var err Return.Error
+	if e.fh != nil {
+		err.SetError(e.fh.Close())
+		e.fh = nil
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+type ExporterSuite struct {
+	suite.Suite
+	file     string
+	exporter *JSONFileExporter
+}
+
+// SetupTest - Export to a file within a dir that doesn't exist yet.
+func (s *ExporterSuite) SetupTest() {
+	s.file = filepath.Join(s.T().TempDir(), "traces", "spans.json")
+	var err Return.Error
+	s.exporter, err = NewJSONFileExporter(s.file)
+	s.Require().False(err.IsError(), err.String())
+	SetExporter(s.exporter)
+}
+
+func (s *ExporterSuite) TearDownTest() {
+	SetExporter(nil)
+	_ = s.exporter.Close()
+}
+
+// lines - The JSON lines written to the file.
+func (s *ExporterSuite) lines() []map[string]any {
+	fh, e := os.Open(s.file)
+	s.Require().NoError(e)
+	defer fh.Close()
+
+	var ret []map[string]any
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		var line map[string]any
+		s.Require().NoError(json.Unmarshal(scanner.Bytes(), &line), scanner.Text())
+		ret = append(ret, line)
+	}
+	s.Require().NoError(scanner.Err())
+	return ret
+}
+
+func (s *ExporterSuite) TestExport() {
+	s.Equal(s.file, GetFile())
+
+	root := Start("load", SpanContext{}, "plugin", "a")
+	child := Start("hook Hello", root.Context, "hook", "Hello")
+	child.End(Return.NewError("failed"))
+	root.End(Return.Ok)
+
+	lines := s.lines()
+	s.Require().Len(lines, 2, "a line per span, as they end")
+
+	s.Equal("hook Hello", lines[0]["name"])
+	s.Equal(root.TraceID, lines[0]["trace_id"])
+	s.Equal(child.SpanID, lines[0]["span_id"])
+	s.Equal(root.SpanID, lines[0]["parent_id"])
+	s.Equal(map[string]any{"hook": "Hello"}, lines[0]["attributes"])
+	s.Equal("failed", lines[0]["error"])
+
+	s.Equal("load", lines[1]["name"])
+	s.NotContains(lines[1], "parent_id")
+	s.NotContains(lines[1], "error")
+	s.NotContains(lines[1], "Context")
+
+	start, e := time.Parse(time.RFC3339Nano, lines[1]["start"].(string))
+	s.Require().NoError(e)
+	end, e := time.Parse(time.RFC3339Nano, lines[1]["end"].(string))
+	s.Require().NoError(e)
+	s.True(start.Equal(root.StartTime))
+	s.True(end.Equal(root.EndTime))
+}
+
+func (s *ExporterSuite) TestAppend() {
+	Start("first", SpanContext{}).End(Return.Ok)
+	err := s.exporter.Close()
+	s.Require().False(err.IsError(), err.String())
+	Start("closed", SpanContext{}).End(Return.Ok)
+
+	exporter, err := NewJSONFileExporter(s.file)
+	s.Require().False(err.IsError(), err.String())
+	defer exporter.Close()
+	exporter.Export(Start("second", SpanContext{}))
+
+	lines := s.lines()
+	s.Require().Len(lines, 2, "spans ended once closed are dropped")
+	s.Equal("first", lines[0]["name"])
+	s.Equal("second", lines[1]["name"], "appended")
+}
+
+func (s *ExporterSuite) TestConcurrent() {
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			span := Start("span", SpanContext{})
+			for j := 0; j < 10; j++ {
+				span.SetAttribute("key", j)
+			}
+			span.End(Return.Ok)
+		}()
+	}
+	wg.Wait()
+
+	s.Len(s.lines(), 20, "every line is whole")
+}
+
+func TestExporterSuite(t *testing.T) {
+	suite.Run(t, new(ExporterSuite))
+}
@@ -0,0 +1,166 @@
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+//
+// SpanContext - W3C trace context of a span, (https://www.w3.org/TR/trace-context/).
+// ---------------------------------------------------------------------------------------------------- //
+// The zero SpanContext is invalid, spans started from it begin a new trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// ParseTraceparent - SpanContext from a traceparent header, (00-<trace id>-<span id>-<flags>).
+func ParseTraceparent(traceparent string) (SpanContext, Return.Error) {
+	var ret SpanContext
+	var err Return.Error
+
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		err.SetError("invalid traceparent '%s'", traceparent)
+		return ret, err
+	}
+
+	var version, flags [1]byte
+	ok := decodeHex(version[:], parts[0]) && decodeHex(ret.TraceID[:], parts[1]) && decodeHex(ret.SpanID[:], parts[2]) && decodeHex(flags[:], parts[3])
+	if !ok || !ret.IsValid() {
+		err.SetError("invalid traceparent '%s'", traceparent)
+		return SpanContext{}, err
+	}
+	ret.Sampled = flags[0]&1 == 1
+
+	return ret, err
+}
+
+// IsValid - Returns true if neither id is all zeros.
+func (c SpanContext) IsValid() bool {
+	return c.TraceID != [16]byte{} && c.SpanID != [8]byte{}
+}
+
+// GetTraceID - Trace id, as lower case hex.
+func (c SpanContext) GetTraceID() string {
+	return hex.EncodeToString(c.TraceID[:])
+}
+
+// GetSpanID - Span id, as lower case hex.
+func (c SpanContext) GetSpanID() string {
+	return hex.EncodeToString(c.SpanID[:])
+}
+
+// Traceparent - The traceparent header, or empty if invalid.
+func (c SpanContext) Traceparent() string {
+	if !c.IsValid() {
+		return ""
+	}
+	flags := 0
+	if c.Sampled {
+		flags = 1
+	}
+	return fmt.Sprintf("00-%s-%s-%02x", c.GetTraceID(), c.GetSpanID(), flags)
+}
+
+// String - Stringer interface.
+func (c SpanContext) String() string {
+	return c.Traceparent()
+}
+
+//
+// Span - A timed operation, (a plugin load, a hook call, ...), within a trace.
+// ---------------------------------------------------------------------------------------------------- //
+type Span struct {
+	Name       string         `json:"name"`
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	ParentID   string         `json:"parent_id,omitempty"`
+	StartTime  time.Time      `json:"start"`
+	EndTime    time.Time      `json:"end"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Context    SpanContext    `json:"-"`
+	lock       sync.Mutex
+}
+
+// Start - Start a span, within the trace of parent, or a new trace if parent is invalid.
+// attrs are key/value pairs, (eg: Start("hook", parent, "plugin", name)).
+func Start(name string, parent SpanContext, attrs ...any) *Span {
+	ret := Span{
+		Name:      name,
+		StartTime: time.Now(),
+		Context:   SpanContext{Sampled: true},
+	}
+
+	if parent.IsValid() {
+		ret.Context.TraceID = parent.TraceID
+		ret.Context.Sampled = parent.Sampled
+		ret.ParentID = parent.GetSpanID()
+	} else {
+		_, _ = rand.Read(ret.Context.TraceID[:])
+	}
+	_, _ = rand.Read(ret.Context.SpanID[:])
+	ret.TraceID = ret.Context.GetTraceID()
+	ret.SpanID = ret.Context.GetSpanID()
+
+	for i := 0; i+1 < len(attrs); i += 2 {
+		ret.SetAttribute(fmt.Sprint(attrs[i]), attrs[i+1])
+	}
+
+	return &ret
+}
+
+// SetAttribute - Add a key/value to the span.
+func (s *Span) SetAttribute(key string, value any) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]any)
+	}
+	s.Attributes[key] = value
+}
+
+// End - End the span, with an error if it failed, and pass it to the exporter, if sampled.
+func (s *Span) End(err Return.Error) {
+	s.lock.Lock()
+	if !s.EndTime.IsZero() {
+		s.lock.Unlock()
+		return
+	}
+	s.EndTime = time.Now()
+	if err.IsError() {
+		s.Error = err.Error()
+	}
+	s.lock.Unlock()
+
+	if s.Context.Sampled {
+		GetExporter().Export(s)
+	}
+}
+
+// GetDuration - Time taken by the span, so far if it hasn't ended.
+func (s *Span) GetDuration() time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.EndTime.IsZero() {
+		return time.Since(s.StartTime)
+	}
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// decodeHex - Decode exactly len(dst) bytes of lower case hex.
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != len(dst)*2 || strings.ToLower(s) != s {
+		return false
+	}
+	_, e := hex.Decode(dst, []byte(s))
+	return e == nil
+}
@@ -0,0 +1,152 @@
+package trace
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/MickMake/GoPlug/utils/Return"
+)
+
+const (
+	traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	spanID  = "00f067aa0ba902b7"
+)
+
+type TraceSuite struct {
+	suite.Suite
+	spans *recorder
+}
+
+// recorder - An Exporter keeping every span.
+type recorder struct {
+	spans []*Span
+	lock  sync.Mutex
+}
+
+func (r *recorder) Export(span *Span) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func (s *TraceSuite) SetupTest() {
+	s.spans = &recorder{}
+	SetExporter(s.spans)
+}
+
+func (s *TraceSuite) TearDownTest() {
+	SetExporter(nil)
+}
+
+func (s *TraceSuite) TestParseTraceparent() {
+	tests := []struct {
+		name        string
+		traceparent string
+		sampled     bool
+		valid       bool
+	}{
+		{name: "sampled", traceparent: "00-" + traceID + "-" + spanID + "-01", sampled: true, valid: true},
+		{name: "not sampled", traceparent: "00-" + traceID + "-" + spanID + "-00", valid: true},
+		{name: "other flags", traceparent: "00-" + traceID + "-" + spanID + "-03", sampled: true, valid: true},
+		{name: "spaces", traceparent: " 00-" + traceID + "-" + spanID + "-01 ", sampled: true, valid: true},
+		{name: "future version", traceparent: "01-" + traceID + "-" + spanID + "-01-more", sampled: true, valid: true},
+		{name: "empty", traceparent: ""},
+		{name: "version ff", traceparent: "ff-" + traceID + "-" + spanID + "-01"},
+		{name: "version not hex", traceparent: "zz-" + traceID + "-" + spanID + "-01"},
+		{name: "version 00 with more", traceparent: "00-" + traceID + "-" + spanID + "-01-more"},
+		{name: "version length", traceparent: "000-" + traceID + "-" + spanID + "-01"},
+		{name: "zero trace id", traceparent: "00-00000000000000000000000000000000-" + spanID + "-01"},
+		{name: "zero span id", traceparent: "00-" + traceID + "-0000000000000000-01"},
+		{name: "upper case trace id", traceparent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-" + spanID + "-01"},
+		{name: "upper case span id", traceparent: "00-" + traceID + "-00F067AA0BA902B7-01"},
+		{name: "upper case flags", traceparent: "00-" + traceID + "-" + spanID + "-0A"},
+		{name: "short trace id", traceparent: "00-" + traceID[2:] + "-" + spanID + "-01"},
+		{name: "long span id", traceparent: "00-" + traceID + "-" + spanID + "00-01"},
+		{name: "not hex", traceparent: "00-" + traceID + "-" + spanID[:15] + "g-01"},
+		{name: "missing flags", traceparent: "00-" + traceID + "-" + spanID},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			ctx, err := ParseTraceparent(test.traceparent)
+			if !test.valid {
+				s.True(err.IsError())
+				s.Contains(err.Error(), "invalid traceparent")
+				s.False(ctx.IsValid())
+				s.Equal(SpanContext{}, ctx)
+				return
+			}
+
+			s.Require().False(err.IsError(), err.String())
+			s.True(ctx.IsValid())
+			s.Equal(traceID, ctx.GetTraceID())
+			s.Equal(spanID, ctx.GetSpanID())
+			s.Equal(test.sampled, ctx.Sampled)
+		})
+	}
+}
+
+func (s *TraceSuite) TestTraceparent() {
+	traceparent := "00-" + traceID + "-" + spanID + "-01"
+	ctx, err := ParseTraceparent(traceparent)
+	s.Require().False(err.IsError(), err.String())
+	s.Equal(traceparent, ctx.Traceparent())
+	s.Equal(traceparent, ctx.String())
+
+	ctx.Sampled = false
+	s.Equal("00-"+traceID+"-"+spanID+"-00", ctx.Traceparent())
+	s.Equal("", SpanContext{}.Traceparent(), "invalid")
+}
+
+func (s *TraceSuite) TestStart() {
+	root := Start("root", SpanContext{}, "plugin", "a", "odd")
+	s.True(root.Context.IsValid(), "a new trace")
+	s.True(root.Context.Sampled)
+	s.Empty(root.ParentID)
+	s.Equal(root.Context.GetTraceID(), root.TraceID)
+	s.Equal(root.Context.GetSpanID(), root.SpanID)
+	s.Equal(map[string]any{"plugin": "a"}, root.Attributes, "a key without a value is dropped")
+
+	child := Start("child", root.Context)
+	s.Equal(root.TraceID, child.TraceID)
+	s.Equal(root.SpanID, child.ParentID)
+	s.NotEqual(root.SpanID, child.SpanID)
+	s.Nil(child.Attributes)
+
+	parent, err := ParseTraceparent("00-" + traceID + "-" + spanID + "-00")
+	s.Require().False(err.IsError(), err.String())
+	remote := Start("remote", parent)
+	s.Equal(traceID, remote.TraceID)
+	s.Equal(spanID, remote.ParentID)
+	s.False(remote.Context.Sampled, "sampling follows the parent")
+}
+
+func (s *TraceSuite) TestEnd() {
+	span := Start("hook", SpanContext{})
+	s.True(span.EndTime.IsZero())
+	span.End(Return.NewError("failed"))
+	s.False(span.EndTime.IsZero())
+	s.Equal("failed", span.Error)
+	s.Equal(span.EndTime.Sub(span.StartTime), span.GetDuration())
+
+	end := span.EndTime
+	span.End(Return.Ok)
+	s.Equal(end, span.EndTime, "only ended once")
+	s.Equal("failed", span.Error)
+	s.Equal([]*Span{span}, s.spans.spans, "exported once")
+
+	ok := Start("ok", span.Context)
+	ok.End(Return.Ok)
+	s.Empty(ok.Error)
+
+	parent, err := ParseTraceparent("00-" + traceID + "-" + spanID + "-00")
+	s.Require().False(err.IsError(), err.String())
+	Start("not sampled", parent).End(Return.Ok)
+	s.Equal([]*Span{span, ok}, s.spans.spans, "spans that aren't sampled aren't exported")
+}
+
+func TestTraceSuite(t *testing.T) {
+	suite.Run(t, new(TraceSuite))
+}